@@ -4,10 +4,15 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"os"
+	"time"
 
+	gotfe "github.com/hashicorp/go-tfe"
 	"github.com/hashicorp/tfci/internal/cloud"
+	"github.com/hashicorp/tfci/internal/environment"
 	"github.com/hashicorp/tfci/internal/logging"
 	"github.com/hashicorp/tfci/internal/writer"
 	"github.com/hashicorp/tfci/version"
@@ -19,9 +24,43 @@ import (
 var (
 	hostnameFlag     = flag.String("hostname", "", "The hostname of a Terraform Enterprise installation, if using Terraform Enterprise. Defaults to HCP Terraform (app.terraform.io)")
 	tokenFlag        = flag.String("token", "", "The token used to authenticate with HCP Terraform. Defaults to reading `TF_API_TOKEN` environment variable")
+	tokenFileFlag    = flag.String("token-file", "", "Path to a file containing the token used to authenticate with HCP Terraform. Defaults to `TF_API_TOKEN_FILE`")
 	organizationFlag = flag.String("organization", "", "HCP Terraform Organization Name")
+	pollIntervalFlag = flag.Duration("poll-interval", 0, "How often to poll the status of a run or upload while waiting. Defaults to a backoff from 2s up to 7s")
+	runTimeoutFlag   = flag.Duration("run-timeout", 0, "Max duration to wait on a run or upload. Defaults to `TF_MAX_TIMEOUT` or 1h")
+	logFileFlag      = flag.String("log-file", "", "Path to a file to additionally write logs to")
+	logFileLevelFlag = flag.String("log-file-level", "DEBUG", "Log level for the log file, independent of `TF_LOG`")
+	outputFormatFlag = flag.String("output-format", "text", "Format of the command result written to stdout: text, json")
+	onelineJsonFlag  = flag.Bool("oneline-json", false, "Write a compact single line json summary of the command result to stdout")
+	teeLogsFlag      = flag.Bool("tee-logs-to-summary", false, "Append the trailing plan and apply logs to the GitHub job summary")
+	timeoutFlag      = flag.Duration("timeout", 0, "Max duration of the whole command, including API requests and waiting on runs. Defaults to `TFCI_TIMEOUT`, or no limit")
+	noColorFlag      = flag.Bool("no-color", false, "Disable colored output and logs. Also disabled when `NO_COLOR` is set")
+	dryRunFlag       = flag.Bool("dry-run", false, "Log the API requests a command would make instead of sending them to HCP Terraform")
+	caCertFlag       = flag.String("ca-cert", "", "Path to a PEM encoded root CA to trust in addition to the system roots. Defaults to `TFCI_CA_CERT`")
+	skipVerifyFlag   = flag.Bool("tls-skip-verify", false, "Disable TLS certificate verification of HCP Terraform, for exceptional use only")
+	quietFlag        = flag.Bool("quiet", false, "Suppress progress messages and platform output echoes on stdout, outputs and errors are still written")
+	profileFlag      = flag.String("profile", "", "Name of a profile in `TFCI_PROFILE_FILE` or ~/.tfci.json to read the hostname, organization and token from")
 )
 
+const envTimeout = "TFCI_TIMEOUT"
+
+// the deadline of the whole command from -timeout or TFCI_TIMEOUT, separate from the -run-timeout
+// of each wait, zero means no deadline
+func commandTimeout() (time.Duration, error) {
+	if *timeoutFlag > 0 {
+		return *timeoutFlag, nil
+	}
+	v := os.Getenv(envTimeout)
+	if v == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(v)
+	if err != nil || timeout < 0 {
+		return 0, fmt.Errorf("invalid %s %q, expected a duration such as 30m", envTimeout, v)
+	}
+	return timeout, nil
+}
+
 func newCliRunner() (*cli.CLI, error) {
 	args := os.Args[1:]
 	logging.Debug("Processing command arguments", "count", len(args))
@@ -31,12 +70,61 @@ func newCliRunner() (*cli.CLI, error) {
 		return nil, err
 	}
 
+	// decided once for both the Ui and the logger
+	noColor := *noColorFlag || noColorEnv()
+	if noColor {
+		Ui = newUi(noColor)
+	}
+
+	// reinitialize logger to additionally write to a log file, or without color
+	if *logFileFlag != "" || noColor {
+		if err := logging.SetupLogger(&logging.LoggerOptions{
+			PlatformType: string(env.PlatformType),
+			LogFile:      *logFileFlag,
+			LogFileLevel: *logFileLevelFlag,
+			NoColor:      noColor,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
 	newArgs := flag.CommandLine.Args()
 
 	cliRunner := cli.NewCLI("tfc", version.GetVersion())
 	cliRunner.Args = newArgs
 
-	writer := writer.NewWriter(Ui)
+	outputFormat, err := writer.ParseOutputFormat(*outputFormatFlag)
+	if err != nil {
+		return nil, err
+	}
+	if *onelineJsonFlag {
+		if outputFormat != writer.FormatText {
+			return nil, fmt.Errorf("-oneline-json cannot be combined with -output-format=%s", outputFormat)
+		}
+		outputFormat = writer.FormatOneLineJSON
+	}
+
+	resultWriter = writer.NewWriter(Ui, writer.WithOutputFormat(outputFormat), writer.WithQuiet(*quietFlag))
+	// keep stdout valid json by preventing platform echoes, outputs are still written to the platform
+	env.SetQuiet(resultWriter.Structured() || *quietFlag)
+
+	timeout, err := commandTimeout()
+	if err != nil {
+		return nil, err
+	}
+	if timeout > 0 {
+		appCtx, stopTimeout = context.WithTimeoutCause(appCtx, timeout,
+			fmt.Errorf("operation timed out after %s, see -timeout or %s", timeout, envTimeout))
+	}
+
+	// flags and environment variables override the profile's settings
+	if *profileFlag != "" {
+		if err := applyProfile(*profileFlag); err != nil {
+			logging.Error("Failed to load profile", "profile", *profileFlag, "error", err)
+			return nil, err
+		}
+	}
+
 	orgEnv := os.Getenv("TF_CLOUD_ORGANIZATION")
 
 	if *organizationFlag == "" && orgEnv != "" {
@@ -46,21 +134,60 @@ func newCliRunner() (*cli.CLI, error) {
 		"arg_count", len(newArgs), 
 		"organization", orgEnv)
 
-	tfe, err := cloud.NewTfeClient(*hostnameFlag, *tokenFlag, string(env.PlatformType))
+	// proxies and the custom CA apply to every request, including OIDC token exchanges and doctor's ping
+	transport, err := cloud.NewHTTPTransport(cloud.TLSOptions{CACert: *caCertFlag, SkipVerify: *skipVerifyFlag})
 	if err != nil {
-		logging.Error("Failed to initialize HCP Terraform client", "error", err)
+		logging.Error("Failed to configure the HTTP transport", "error", err)
 		return nil, err
 	}
+	// counts the requests of the command for the api_call_count output
+	apiCalls := cloud.NewCountingTransport(transport)
 
-	cloudService := cloud.NewCloud(tfe, writer)
+	// a dry run never sends requests, so no token is needed
+	tfe := &gotfe.Client{}
+	var clientErr error
+	if *dryRunFlag {
+		logging.Info("Dry run, API requests are logged instead of sent to HCP Terraform")
+	} else {
+		// outside of CI the ID only names the local process, which is not worth tagging requests with
+		ciRunID := ""
+		if env.PlatformType != environment.Other {
+			ciRunID = env.Context.ID()
+		}
+		tfe, err = cloud.NewTfeClient(*hostnameFlag, *tokenFlag, *tokenFileFlag, string(env.PlatformType), ciRunID, apiCalls)
+		if err != nil {
+			// doctor diagnoses why the client cannot be created, every other command fails
+			if len(newArgs) == 0 || newArgs[0] != "doctor" {
+				logging.Error("Failed to initialize HCP Terraform client", "error", err)
+				return nil, err
+			}
+			tfe, clientErr = &gotfe.Client{}, err
+		}
+	}
+
+	// job summaries are only supported by GitHub, the option is a no-op elsewhere
+	var logTee cloud.LogTee
+	if *teeLogsFlag && env.PlatformType == environment.GitHub {
+		logTee = environment.NewLogSummary(env, environment.SummaryLogMaxLines)
+	}
+
+	cloudService := cloud.NewCloud(tfe, resultWriter,
+		cloud.WithPollInterval(*pollIntervalFlag),
+		cloud.WithTimeout(*runTimeoutFlag),
+		cloud.WithLogTee(logTee),
+		cloud.WithDryRun(*dryRunFlag),
+		cloud.WithTransport(apiCalls),
+	)
 
 	meta := cmd.NewMetaOpts(
 		appCtx,
 		cloudService,
 		env,
 		cmd.WithOrg(*organizationFlag),
-		cmd.WithWriter(writer),
+		cmd.WithWriter(resultWriter),
+		cmd.WithAPICallCounter(apiCalls),
 	)
+	commandMeta = meta
 
 	cliRunner.Commands = map[string]cli.CommandFactory{
 		"upload": func() (cli.Command, error) {
@@ -75,18 +202,69 @@ func newCliRunner() (*cli.CLI, error) {
 		"run show": func() (cli.Command, error) {
 			return &cmd.ShowRunCommand{Meta: meta}, nil
 		},
+		"run list": func() (cli.Command, error) {
+			return &cmd.ListRunCommand{Meta: meta}, nil
+		},
+		"run wait": func() (cli.Command, error) {
+			return &cmd.WaitRunCommand{Meta: meta}, nil
+		},
 		"run discard": func() (cli.Command, error) {
 			return &cmd.DiscardRunCommand{Meta: meta}, nil
 		},
 		"run cancel": func() (cli.Command, error) {
 			return &cmd.CancelRunCommand{Meta: meta}, nil
 		},
+		"run logs": func() (cli.Command, error) {
+			return &cmd.LogsRunCommand{Meta: meta}, nil
+		},
+		"configuration show": func() (cli.Command, error) {
+			return &cmd.ShowConfigurationCommand{Meta: meta}, nil
+		},
 		"plan output": func() (cli.Command, error) {
 			return &cmd.OutputPlanCommand{Meta: meta}, nil
 		},
+		"tf-version list": func() (cli.Command, error) {
+			return &cmd.ListTerraformVersionsCommand{Meta: meta}, nil
+		},
+		"state show": func() (cli.Command, error) {
+			return &cmd.ShowStateCommand{Meta: meta}, nil
+		},
+		"workspace show": func() (cli.Command, error) {
+			return &cmd.ShowWorkspaceCommand{Meta: meta}, nil
+		},
+		"workspace create": func() (cli.Command, error) {
+			return &cmd.CreateWorkspaceCommand{Meta: meta}, nil
+		},
+		"workspace cleanup": func() (cli.Command, error) {
+			return &cmd.CleanupWorkspaceCommand{Meta: meta}, nil
+		},
+		"workspace drift": func() (cli.Command, error) {
+			return &cmd.WorkspaceDriftCommand{Meta: meta}, nil
+		},
+		"workspace lock": func() (cli.Command, error) {
+			return &cmd.LockWorkspaceCommand{Meta: meta}, nil
+		},
+		"workspace unlock": func() (cli.Command, error) {
+			return &cmd.UnlockWorkspaceCommand{Meta: meta}, nil
+		},
 		"workspace output list": func() (cli.Command, error) {
 			return &cmd.WorkspaceOutputCommand{Meta: meta}, nil
 		},
+		"variable set": func() (cli.Command, error) {
+			return &cmd.SetVariableCommand{Meta: meta}, nil
+		},
+		"variable-set apply": func() (cli.Command, error) {
+			return &cmd.ApplyVariableSetCommand{Meta: meta}, nil
+		},
+		"variable-set remove": func() (cli.Command, error) {
+			return &cmd.RemoveVariableSetCommand{Meta: meta}, nil
+		},
+		"context": func() (cli.Command, error) {
+			return &cmd.ContextCommand{Meta: meta}, nil
+		},
+		"doctor": func() (cli.Command, error) {
+			return &cmd.DoctorCommand{Meta: meta, Hostname: *hostnameFlag, ClientErr: clientErr}, nil
+		},
 	}
 
 	return cliRunner, nil
@@ -0,0 +1,126 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+const (
+	// the unauthenticated no-op endpoint go-tfe reads the API version from
+	pingPath    = "/api/v2/ping"
+	pingTimeout = 30 * time.Second
+)
+
+// read only requests verifying the host, token and organization are usable, eg. for `doctor`
+type AccountService interface {
+	// returns the API version of the host's HCP Terraform API, address is the scheme and host, eg. https://app.terraform.io
+	PingAPI(ctx context.Context, address string) (string, error)
+	// returns the user the token authenticates as, a service account user for team tokens
+	ReadCurrentUser(context.Context) (*tfe.User, error)
+	ReadOrganization(ctx context.Context, organization string) (*tfe.Organization, error)
+	// returns every organization the token can access
+	ListOrganizations(context.Context) ([]*tfe.Organization, error)
+}
+
+type accountService struct {
+	*cloudMeta
+
+	// sends the ping without the go-tfe client, which cannot be created when the host is unreachable
+	httpClient *http.Client
+}
+
+func (s *accountService) PingAPI(ctx context.Context, address string) (string, error) {
+	if err := s.skipDryRun("ping API", "address", address); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, address+pingPath, nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid API address %q: %w", address, err)
+	}
+	req.Header.Set("Accept", "application/vnd.api+json")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		log.Printf("[ERROR] error pinging API: %q, error: %s", address, err)
+		return "", fmt.Errorf("failed to reach the API at %q: %w", address, err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("unexpected response from the API at %q: %s", address, resp.Status)
+	}
+	version := resp.Header.Get("TFP-API-Version")
+	if version == "" {
+		return "", fmt.Errorf("%q responded without an API version, it is not HCP Terraform or a Terraform Enterprise installation", address)
+	}
+	return version, nil
+}
+
+func (s *accountService) ReadCurrentUser(ctx context.Context) (*tfe.User, error) {
+	if err := s.skipDryRun("read current user"); err != nil {
+		return nil, err
+	}
+
+	user, err := s.tfe.Users.ReadCurrent(ctx)
+	if err != nil {
+		log.Printf("[ERROR] error reading the authenticated user, error: %s", err)
+		return nil, fmt.Errorf("failed to read the authenticated user: %w", err)
+	}
+	return user, nil
+}
+
+func (s *accountService) ReadOrganization(ctx context.Context, organization string) (*tfe.Organization, error) {
+	if err := s.skipDryRun("read organization", "organization", organization); err != nil {
+		return nil, err
+	}
+
+	org, err := s.tfe.Organizations.Read(ctx, organization)
+	if err != nil {
+		log.Printf("[ERROR] error reading organization: %q, error: %s", organization, err)
+		return nil, fmt.Errorf("failed to read organization %q: %w", organization, err)
+	}
+	return org, nil
+}
+
+func (s *accountService) ListOrganizations(ctx context.Context) ([]*tfe.Organization, error) {
+	if err := s.skipDryRun("list organizations"); err != nil {
+		return nil, err
+	}
+
+	organizations := []*tfe.Organization{}
+	listOpts := &tfe.OrganizationListOptions{
+		ListOptions: tfe.ListOptions{PageNumber: 1, PageSize: maxPageSize},
+	}
+	for {
+		list, err := s.tfe.Organizations.List(ctx, listOpts)
+		if err != nil {
+			log.Printf("[ERROR] error listing organizations, error: %s", err)
+			return nil, fmt.Errorf("failed to list organizations: %w", err)
+		}
+		organizations = append(organizations, list.Items...)
+
+		if list.Pagination == nil || list.NextPage == 0 {
+			return organizations, nil
+		}
+		listOpts.PageNumber = list.NextPage
+	}
+}
+
+func NewAccountService(meta *cloudMeta) *accountService {
+	transport := meta.transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &accountService{
+		cloudMeta:  meta,
+		httpClient: &http.Client{Timeout: pingTimeout, Transport: newTraceTransport(transport)},
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cloud
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/go-tfe/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAccountService_PingAPI(t *testing.T) {
+	testCases := []struct {
+		name      string
+		handler   http.HandlerFunc
+		expected  string
+		expectErr bool
+	}{
+		{
+			name: "hcp-terraform",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/v2/ping" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.Header().Set("TFP-API-Version", "2.6")
+				w.WriteHeader(http.StatusNoContent)
+			},
+			expected: "2.6",
+		},
+		{
+			name: "not-terraform",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			},
+			expectErr: true,
+		},
+		{
+			name: "server-error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("TFP-API-Version", "2.6")
+				w.WriteHeader(http.StatusBadGateway)
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewTLSServer(tc.handler)
+			defer server.Close()
+
+			client := NewAccountService(&cloudMeta{writer: &defaultWriter{}})
+			client.httpClient = server.Client()
+			version, err := client.PingAPI(context.Background(), server.URL)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error: %t but received: %v", tc.expectErr, err)
+			}
+			if version != tc.expected {
+				t.Errorf("expected API version %q but received %q", tc.expected, version)
+			}
+		})
+	}
+}
+
+func TestAccountService_ListOrganizations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	orgMock := mocks.NewMockOrganizations(ctrl)
+	gomock.InOrder(
+		orgMock.EXPECT().List(ctx, &tfe.OrganizationListOptions{ListOptions: tfe.ListOptions{PageNumber: 1, PageSize: maxPageSize}}).
+			Return(&tfe.OrganizationList{
+				Items:      []*tfe.Organization{{Name: "hashicorp"}},
+				Pagination: &tfe.Pagination{CurrentPage: 1, NextPage: 2},
+			}, nil),
+		orgMock.EXPECT().List(ctx, &tfe.OrganizationListOptions{ListOptions: tfe.ListOptions{PageNumber: 2, PageSize: maxPageSize}}).
+			Return(&tfe.OrganizationList{
+				Items:      []*tfe.Organization{{Name: "tfci"}},
+				Pagination: &tfe.Pagination{CurrentPage: 2},
+			}, nil),
+	)
+
+	client := NewAccountService(&cloudMeta{tfe: &tfe.Client{Organizations: orgMock}, writer: &defaultWriter{}})
+	organizations, err := client.ListOrganizations(ctx)
+	if err != nil {
+		t.Fatalf("expected no error but received %s", err)
+	}
+	if len(organizations) != 2 || organizations[0].Name != "hashicorp" || organizations[1].Name != "tfci" {
+		t.Errorf("expected organizations hashicorp and tfci but received %v", organizations)
+	}
+}
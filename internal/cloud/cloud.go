@@ -4,7 +4,12 @@
 package cloud
 
 import (
+	"fmt"
+	"net/http"
+	"time"
+
 	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/logging"
 )
 
 type Writer interface {
@@ -22,6 +27,14 @@ func (d *defaultWriter) Error(msg string)  {}
 // compile time check
 var _ Writer = (*defaultWriter)(nil)
 
+// receives the trailing lines of each plan or apply log, eg. to append them to the GitHub step summary
+type LogTee interface {
+	// max number of trailing lines to keep for each log
+	MaxLines() int
+	// called once the log completes, omitted is the number of leading lines dropped to fit MaxLines()
+	TeeLog(label string, lines []string, omitted int)
+}
+
 type Cloud struct {
 	*cloudMeta
 
@@ -29,6 +42,9 @@ type Cloud struct {
 	RunService
 	PlanService
 	WorkspaceService
+	TerraformVersionService
+	VariableService
+	AccountService
 }
 
 func (c *Cloud) UseJson(json bool) {
@@ -39,19 +55,105 @@ func (c *Cloud) UseJson(json bool) {
 type cloudMeta struct {
 	tfe    *tfe.Client
 	writer Writer
+	// how often to poll while waiting on a run or upload
+	pollInterval time.Duration
+	// max duration to wait on a run or upload
+	timeout time.Duration
+	// optional copy of the plan and apply logs
+	logTee LogTee
+	// log the requests instead of sending them to HCP Terraform
+	dryRun bool
+	// base transport of requests sent without the go-tfe client, eg. the proxy and custom CA of NewHTTPTransport
+	transport http.RoundTripper
+}
+
+func WithPollInterval(interval time.Duration) func(*cloudMeta) {
+	return func(m *cloudMeta) {
+		m.pollInterval = interval
+	}
+}
+
+func WithTimeout(timeout time.Duration) func(*cloudMeta) {
+	return func(m *cloudMeta) {
+		m.timeout = timeout
+	}
 }
 
-func NewCloud(c *tfe.Client, w Writer) *Cloud {
+func WithLogTee(tee LogTee) func(*cloudMeta) {
+	return func(m *cloudMeta) {
+		m.logTee = tee
+	}
+}
+
+func WithTransport(transport http.RoundTripper) func(*cloudMeta) {
+	return func(m *cloudMeta) {
+		m.transport = transport
+	}
+}
+
+// requests are logged instead of sent, changes return a DryRunError and the reads a command needs before making a
+// change return placeholders
+func WithDryRun(dryRun bool) func(*cloudMeta) {
+	return func(m *cloudMeta) {
+		m.dryRun = dryRun
+	}
+}
+
+// returned by service methods when using -dry-run, instead of sending the request
+type DryRunError struct {
+	Operation string
+}
+
+func (e *DryRunError) Error() string {
+	return fmt.Sprintf("dry run, %s was not sent to HCP Terraform", e.Operation)
+}
+
+// logs the operation with its target and options when using -dry-run, returning a DryRunError
+// the caller returns instead of sending the request
+func (m *cloudMeta) skipDryRun(operation string, keysAndValues ...interface{}) error {
+	if !m.dryRun {
+		return nil
+	}
+	logging.Info(fmt.Sprintf("Dry run: %s", operation), keysAndValues...)
+	return &DryRunError{Operation: operation}
+}
+
+// logs the read when using -dry-run, returning true when the caller returns a placeholder instead of sending the
+// request, so the command reaches the change it would make and logs its options
+func (m *cloudMeta) placeholderDryRun(operation string, keysAndValues ...interface{}) bool {
+	if !m.dryRun {
+		return false
+	}
+	logging.Debug(fmt.Sprintf("Dry run: %s, using a placeholder", operation), keysAndValues...)
+	return true
+}
+
+// nil safe run id for logging, eg. when a dry run never read the run
+func runID(run *tfe.Run) string {
+	if run == nil {
+		return ""
+	}
+	return run.ID
+}
+
+func NewCloud(c *tfe.Client, w Writer, setters ...func(*cloudMeta)) *Cloud {
 	meta := &cloudMeta{
 		tfe:    c,
 		writer: w,
 	}
 
+	for _, setter := range setters {
+		setter(meta)
+	}
+
 	return &Cloud{
-		cloudMeta:            meta,
-		ConfigVersionService: NewConfigVersionService(meta),
-		RunService:           NewRunService(meta),
-		PlanService:          NewPlanService(meta),
-		WorkspaceService:     NewWorkspaceService(meta),
+		cloudMeta:               meta,
+		ConfigVersionService:    NewConfigVersionService(meta),
+		RunService:              NewRunService(meta),
+		PlanService:             NewPlanService(meta),
+		WorkspaceService:        NewWorkspaceService(meta),
+		TerraformVersionService: NewTerraformVersionService(meta),
+		VariableService:         NewVariableService(meta),
+		AccountService:          NewAccountService(meta),
 	}
 }
@@ -4,11 +4,18 @@
 package cloud
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
 
 	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/logging"
 	"github.com/sethvargo/go-retry"
 )
 
@@ -18,10 +25,75 @@ type UploadOptions struct {
 	ConfigurationDirectory string
 	Speculative            bool
 	Provisional            bool
+	// path of a pre-built gzip compressed tar uploaded as is, instead of packing ConfigurationDirectory
+	ConfigurationArchive string
+	// glob patterns of paths relative to ConfigurationDirectory to leave out, in addition to .terraformignore
+	Excludes []string
+	// pending configuration version to resume a failed upload into, instead of creating a new one
+	ConfigurationVersionID string
+}
+
+const (
+	envUploadRetries     = "TFCI_UPLOAD_RETRIES"
+	defaultUploadRetries = 3
+)
+
+// returned when the configuration archive could not be uploaded to the object store,
+// the signed upload url is never included as it grants write access
+type UploadError struct {
+	Host     string
+	Attempts int
+	// bytes of the archive sent by the last attempt
+	BytesSent int64
+	Size      int64
+
+	err error
+}
+
+func (e *UploadError) Error() string {
+	return fmt.Sprintf("failed to upload configuration to %s after %d attempts, %d of %d bytes sent: %s",
+		e.Host, e.Attempts, e.BytesSent, e.Size, withoutURL(e.err))
+}
+
+func (e *UploadError) Unwrap() error {
+	return e.err
+}
+
+// counts the bytes of the archive read by the http client as it is sent. It is a seeker with a length, so go-tfe
+// streams it rather than reading it in before the request
+type countingReader struct {
+	*bytes.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+func (r *countingReader) Seek(offset int64, whence int) (int64, error) {
+	pos, err := r.Reader.Seek(offset, whence)
+	r.n = pos
+	return pos, err
 }
 
 type ConfigVersionService interface {
 	UploadConfig(ctx context.Context, options UploadOptions) (*tfe.ConfigurationVersion, error)
+	GetIngressAttributes(ctx context.Context, configVersionID string) (*tfe.IngressAttributes, error)
+	GetConfigurationVersion(ctx context.Context, configVersionID string) (*tfe.ConfigurationVersion, error)
+	GetConfigurationPromotion(ctx context.Context, run *tfe.Run) (*ConfigurationPromotion, error)
+}
+
+// whether the configuration version of a run became the workspace's current configuration version, eg. a
+// provisional configuration version is only promoted once a run using it is applied
+type ConfigurationPromotion struct {
+	ConfigurationVersion *tfe.ConfigurationVersion
+	Promoted             bool
+	// the workspace's current configuration version ID, empty when the workspace has none
+	CurrentConfigurationVersionID string
+	// why the configuration version was not promoted, empty when it was
+	Reason string
 }
 
 type configVersionService struct {
@@ -29,27 +101,51 @@ type configVersionService struct {
 }
 
 func (service *configVersionService) UploadConfig(ctx context.Context, options UploadOptions) (*tfe.ConfigurationVersion, error) {
-	workspace, wErr := service.tfe.Workspaces.Read(ctx, options.Organization, options.Workspace)
+	if err := service.skipDryRun("upload configuration", "organization", options.Organization, "workspace", options.Workspace, "options", options); err != nil {
+		return nil, err
+	}
 
-	if wErr != nil {
-		log.Printf("[ERROR] error reading workspace: %q organization: %q error: %s", options.Workspace, options.Organization, wErr)
-		return nil, wErr
+	// a pre-built archive and the exclude patterns are validated before the configuration version is created
+	excludes, excludeErr := compileExcludeRules(options.Excludes)
+	if excludeErr != nil {
+		return nil, excludeErr
 	}
 
-	configVersion, cvErr := service.tfe.ConfigurationVersions.Create(ctx, workspace.ID, tfe.ConfigurationVersionCreateOptions{
-		Speculative:   &options.Speculative,
-		Provisional:   &options.Provisional,
-		AutoQueueRuns: tfe.Bool(false),
-	})
+	var archive []byte
+	if options.ConfigurationArchive != "" {
+		data, slug, readErr := readArchive(options.ConfigurationArchive)
+		if readErr != nil {
+			log.Printf("[ERROR] error reading configuration archive: %q error: %s", options.ConfigurationArchive, readErr)
+			return nil, readErr
+		}
+		logging.Debug("Read configuration archive",
+			"archive", options.ConfigurationArchive,
+			"files_included", slug.Included,
+			"upload_size_bytes", slug.Size)
+		archive = data
+	}
 
+	configVersion, cvErr := service.createOrResumeConfigVersion(ctx, options)
 	if cvErr != nil {
-		log.Printf("[ERROR] error creating configuration version: %s", cvErr)
 		return configVersion, cvErr
 	}
 
-	service.writer.Output(fmt.Sprintf("Configuration Version has been created: %s", configVersion.ID))
+	if archive == nil {
+		packed := &bytes.Buffer{}
+		slug, packErr := packConfiguration(options.ConfigurationDirectory, excludes, packed)
+		if packErr != nil {
+			log.Printf("[ERROR] error packing configuration directory: %q error: %s", options.ConfigurationDirectory, packErr)
+			return configVersion, packErr
+		}
+		logging.Debug("Packed configuration directory",
+			"directory", options.ConfigurationDirectory,
+			"files_included", slug.Included,
+			"paths_skipped", slug.Skipped,
+			"upload_size_bytes", slug.Size)
+		archive = packed.Bytes()
+	}
 
-	err := service.tfe.ConfigurationVersions.Upload(ctx, configVersion.UploadURL, options.ConfigurationDirectory)
+	err := service.uploadArchive(ctx, configVersion.UploadURL, archive)
 
 	if err != nil {
 		log.Printf("[ERROR] error uploading configuration version: %s", err)
@@ -58,7 +154,7 @@ func (service *configVersionService) UploadConfig(ctx context.Context, options U
 
 	service.writer.Output("Uploading configuration...")
 
-	retryErr := retry.Do(ctx, defaultBackoff(), func(ctx context.Context) error {
+	retryErr := retry.Do(ctx, service.backoff(), func(ctx context.Context) error {
 		log.Printf("[DEBUG] Monitoring Upload Status...")
 		cv, err := service.tfe.ConfigurationVersions.Read(ctx, configVersion.ID)
 		if err != nil {
@@ -81,6 +177,232 @@ func (service *configVersionService) UploadConfig(ctx context.Context, options U
 	return configVersion, err
 }
 
+// creates a new configuration version, or reads the configuration version to resume an upload into. Only a pending
+// configuration version accepts an upload, eg. one left behind when the archive upload of a previous attempt failed
+func (service *configVersionService) createOrResumeConfigVersion(ctx context.Context, options UploadOptions) (*tfe.ConfigurationVersion, error) {
+	if options.ConfigurationVersionID != "" {
+		configVersion, err := service.tfe.ConfigurationVersions.Read(ctx, options.ConfigurationVersionID)
+		if err != nil {
+			log.Printf("[ERROR] error reading configuration version: %q error: %s", options.ConfigurationVersionID, err)
+			return nil, fmt.Errorf("failed to read configuration version %q to resume the upload: %w", options.ConfigurationVersionID, err)
+		}
+		if configVersion.Status != tfe.ConfigurationPending {
+			return nil, fmt.Errorf("configuration version %q is %s, only a %s configuration version can be resumed", configVersion.ID, configVersion.Status, tfe.ConfigurationPending)
+		}
+		// the upload url is only returned while the version waits for its upload, and expires an hour after creation
+		if configVersion.UploadURL == "" {
+			return nil, fmt.Errorf("configuration version %q has no upload url to resume the upload, its signed url may have expired, upload without -configuration-version to create a new configuration version", configVersion.ID)
+		}
+		workspace, wErr := service.resolveWorkspace(ctx, options.Organization, options.Workspace)
+		if wErr != nil {
+			return nil, wErr
+		}
+		if err := service.checkWorkspaceConfigVersion(ctx, workspace, configVersion.ID); err != nil {
+			return nil, err
+		}
+		service.writer.Output(fmt.Sprintf("Resuming upload to Configuration Version: %s", configVersion.ID))
+		return configVersion, nil
+	}
+
+	workspace, wErr := service.resolveWorkspace(ctx, options.Organization, options.Workspace)
+	if wErr != nil {
+		return nil, wErr
+	}
+
+	configVersion, cvErr := service.tfe.ConfigurationVersions.Create(ctx, workspace.ID, tfe.ConfigurationVersionCreateOptions{
+		Speculative:   &options.Speculative,
+		Provisional:   &options.Provisional,
+		AutoQueueRuns: tfe.Bool(false),
+	})
+	if cvErr != nil {
+		log.Printf("[ERROR] error creating configuration version: %s", cvErr)
+		return configVersion, cvErr
+	}
+
+	service.writer.Output(fmt.Sprintf("Configuration Version has been created: %s", configVersion.ID))
+	return configVersion, nil
+}
+
+// returns an error when the configuration version was not uploaded to the workspace, eg. the configuration_version_id
+// output of an `upload` to another workspace. Versions are listed newest first, so a recent upload is found early
+func (m *cloudMeta) checkWorkspaceConfigVersion(ctx context.Context, w *tfe.Workspace, configVersionID string) error {
+	listOpts := &tfe.ConfigurationVersionListOptions{
+		ListOptions: tfe.ListOptions{PageNumber: 1, PageSize: maxPageSize},
+	}
+	for {
+		list, err := m.tfe.ConfigurationVersions.List(ctx, w.ID, listOpts)
+		if err != nil {
+			log.Printf("[ERROR] error listing configuration versions of workspace: %q error: %s", w.Name, err)
+			return fmt.Errorf("failed to list configuration versions of workspace %q: %w", w.Name, err)
+		}
+		for _, cv := range list.Items {
+			if cv.ID == configVersionID {
+				return nil
+			}
+		}
+
+		if list.Pagination == nil || list.NextPage == 0 {
+			return fmt.Errorf("configuration version %q does not belong to workspace %q, upload the configuration to the workspace and use its configuration_version_id", configVersionID, w.Name)
+		}
+		listOpts.PageNumber = list.NextPage
+	}
+}
+
+// uploads the archive to the object store, retrying the whole upload up to TFCI_UPLOAD_RETRIES times on transient
+// failures such as connection resets and server errors. The upload goes to a separate object store url, so it is
+// sent once by the API client's retryTransport. Other failures, such as an expired signed upload url, are not retried
+func (service *configVersionService) uploadArchive(ctx context.Context, uploadURL string, archive []byte) error {
+	maxRetries, baseDelay := uploadRetryConfig()
+	backoff := retry.WithMaxRetries(uint64(maxRetries), retry.NewExponential(baseDelay))
+
+	host := uploadHost(uploadURL)
+	attempts := 0
+	var sent int64
+	err := retry.Do(ctx, backoff, func(ctx context.Context) error {
+		attempts++
+		body := &countingReader{Reader: bytes.NewReader(archive)}
+		uploadErr := service.tfe.ConfigurationVersions.UploadTarGzip(withoutRetries(ctx), uploadURL, body)
+		sent = body.n
+		if uploadErr == nil || ctx.Err() != nil || !isTransientUploadError(uploadErr) {
+			return uploadErr
+		}
+		logging.Debug("Retrying configuration upload",
+			"host", host,
+			"attempt", attempts,
+			"max_retries", maxRetries,
+			"bytes_sent", sent,
+			"error", withoutURL(uploadErr))
+		return retry.RetryableError(uploadErr)
+	})
+
+	if err != nil {
+		return &UploadError{
+			Host:      host,
+			Attempts:  attempts,
+			BytesSent: sent,
+			Size:      int64(len(archive)),
+			err:       err,
+		}
+	}
+	if attempts > 1 {
+		logging.Info("Configuration upload succeeded after retrying", "host", host, "attempts", attempts)
+	}
+	return nil
+}
+
+// a failed connection, eg. reset part way through the upload, or a server error (5xx) or rate limited response of
+// the object store. Any other response, eg. 403 for an expired signed upload url, fails the same way when retried
+func isTransientUploadError(err error) bool {
+	var urlErr *url.Error
+	var serverErr *ServerError
+	return errors.As(err, &urlErr) || errors.As(err, &serverErr) || errors.Is(err, ErrRateLimited)
+}
+
+func (service *configVersionService) GetConfigurationVersion(ctx context.Context, configVersionID string) (*tfe.ConfigurationVersion, error) {
+	if err := service.skipDryRun("read configuration version", "configuration_version_id", configVersionID); err != nil {
+		return nil, err
+	}
+
+	configVersion, err := service.tfe.ConfigurationVersions.Read(ctx, configVersionID)
+	if err != nil {
+		log.Printf("[ERROR] error reading configuration version: %q error: %s", configVersionID, err)
+		return nil, err
+	}
+	return configVersion, nil
+}
+
+// reads the run's configuration version and the workspace's current configuration version to determine whether the
+// run's configuration version was promoted
+func (service *configVersionService) GetConfigurationPromotion(ctx context.Context, run *tfe.Run) (*ConfigurationPromotion, error) {
+	if err := service.skipDryRun("read configuration promotion", "run_id", run.ID); err != nil {
+		return nil, err
+	}
+	if run.ConfigurationVersion == nil || run.Workspace == nil {
+		return nil, fmt.Errorf("run %q has no configuration version or workspace", run.ID)
+	}
+
+	configVersion, err := service.tfe.ConfigurationVersions.Read(ctx, run.ConfigurationVersion.ID)
+	if err != nil {
+		log.Printf("[ERROR] error reading configuration version: %q error: %s", run.ConfigurationVersion.ID, err)
+		return nil, fmt.Errorf("failed to read configuration version %q: %w", run.ConfigurationVersion.ID, err)
+	}
+	w, err := service.tfe.Workspaces.ReadByID(ctx, run.Workspace.ID)
+	if err != nil {
+		log.Printf("[ERROR] error reading workspace: %q error: %s", run.Workspace.ID, err)
+		return nil, fmt.Errorf("failed to read workspace %q: %w", run.Workspace.ID, err)
+	}
+
+	promotion := &ConfigurationPromotion{ConfigurationVersion: configVersion}
+	if w.CurrentConfigurationVersion != nil {
+		promotion.CurrentConfigurationVersionID = w.CurrentConfigurationVersion.ID
+	}
+	switch {
+	// a non-provisional version is current once uploaded, only an applied run promotes it
+	case run.Status != tfe.RunApplied:
+		promotion.Reason = fmt.Sprintf("run %s did not apply, its status is %s", run.ID, run.Status)
+	case promotion.CurrentConfigurationVersionID == configVersion.ID:
+		promotion.Promoted = true
+	default:
+		// eg. a later upload or applied run replaced it
+		promotion.Reason = fmt.Sprintf("the workspace's current configuration version is %s, it was replaced after run %s applied", promotion.CurrentConfigurationVersionID, run.ID)
+	}
+	return promotion, nil
+}
+
+// returns the VCS commit details of the configuration version, nil when the configuration was not sourced from VCS
+func (service *configVersionService) GetIngressAttributes(ctx context.Context, configVersionID string) (*tfe.IngressAttributes, error) {
+	if err := service.skipDryRun("read ingress attributes", "configuration_version_id", configVersionID); err != nil {
+		return nil, err
+	}
+
+	configVersion, err := service.tfe.ConfigurationVersions.ReadWithOptions(ctx, configVersionID, &tfe.ConfigurationVersionReadOptions{
+		Include: []tfe.ConfigVerIncludeOpt{tfe.ConfigVerIngressAttributes},
+	})
+	if err != nil {
+		log.Printf("[ERROR] error reading configuration version: %q error: %s", configVersionID, err)
+		return nil, err
+	}
+	return configVersion.IngressAttributes, nil
+}
+
+// the max number of upload retries and the base delay for their exponential backoff
+func uploadRetryConfig() (int, time.Duration) {
+	maxRetries := defaultUploadRetries
+	if v := os.Getenv(envUploadRetries); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			maxRetries = n
+		} else {
+			logging.Warn("Invalid upload retries, using default", "value", v, "default", defaultUploadRetries)
+		}
+	}
+
+	baseDelay := defaultRetryBaseDelay
+	if v := os.Getenv(envRetryBaseDelay); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			baseDelay = d
+		}
+	}
+	return maxRetries, baseDelay
+}
+
+// the host of the upload url, without the signed query string
+func uploadHost(uploadURL string) string {
+	u, err := url.Parse(uploadURL)
+	if err != nil || u.Host == "" {
+		return "unknown host"
+	}
+	return u.Host
+}
+
+// the underlying error without the url, which the http client includes in its errors
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
 func NewConfigVersionService(meta *cloudMeta) ConfigVersionService {
 	return &configVersionService{meta}
 }
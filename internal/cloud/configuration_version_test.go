@@ -6,8 +6,14 @@ package cloud
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/go-tfe"
 	"github.com/hashicorp/go-tfe/mocks"
@@ -32,6 +38,7 @@ func TestUpload(t *testing.T) {
 	}
 
 	writer := &defaultWriter{}
+	configDir := t.TempDir()
 
 	tests := []struct {
 		name        string
@@ -61,7 +68,7 @@ func TestUpload(t *testing.T) {
 				options: UploadOptions{
 					Organization:           "my-org",
 					Workspace:              "my-ws",
-					ConfigurationDirectory: "dir/",
+					ConfigurationDirectory: configDir,
 					Speculative:            false,
 					Provisional:            false,
 				},
@@ -91,7 +98,7 @@ func TestUpload(t *testing.T) {
 				options: UploadOptions{
 					Organization:           "my-org",
 					Workspace:              "my-ws",
-					ConfigurationDirectory: "dir/",
+					ConfigurationDirectory: configDir,
 					Speculative:            false,
 					Provisional:            false,
 				},
@@ -127,7 +134,7 @@ func TestUpload(t *testing.T) {
 			}
 
 			if tt.cvUpload {
-				mockCv.EXPECT().Upload(tt.args.ctx, tt.cv.UploadURL, tt.args.options.ConfigurationDirectory).Return(tt.cvUploadErr)
+				mockCv.EXPECT().UploadTarGzip(gomock.Any(), tt.cv.UploadURL, gomock.Any()).Return(tt.cvUploadErr)
 
 			}
 			if tt.cvRead {
@@ -153,3 +160,332 @@ func TestUpload(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigVersionService_ResumeUpload(t *testing.T) {
+	testCases := []struct {
+		name      string
+		status    tfe.ConfigurationStatus
+		uploadURL string
+		// configuration versions of the workspace
+		listed    []*tfe.ConfigurationVersion
+		expectErr string
+	}{
+		{
+			name:      "pending",
+			status:    tfe.ConfigurationPending,
+			uploadURL: "cv.com",
+			listed:    []*tfe.ConfigurationVersion{{ID: "cv-newer"}, {ID: "cv-pending"}},
+		},
+		{
+			name:      "already-uploaded",
+			status:    tfe.ConfigurationUploaded,
+			uploadURL: "cv.com",
+			expectErr: "only a pending configuration version can be resumed",
+		},
+		{
+			name:      "no-upload-url",
+			status:    tfe.ConfigurationPending,
+			expectErr: "has no upload url to resume the upload",
+		},
+		{
+			name:      "other-workspace",
+			status:    tfe.ConfigurationPending,
+			uploadURL: "cv.com",
+			listed:    []*tfe.ConfigurationVersion{{ID: "cv-newer"}},
+			expectErr: "does not belong to workspace \"my-ws\"",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx := context.Background()
+			// no configuration version is created when resuming
+			mockCv := mocks.NewMockConfigurationVersions(ctrl)
+			mockWorkspaces := mocks.NewMockWorkspaces(ctrl)
+			existing := &tfe.ConfigurationVersion{ID: "cv-pending", UploadURL: tc.uploadURL, Status: tc.status}
+			uploaded := &tfe.ConfigurationVersion{ID: "cv-pending", Status: tfe.ConfigurationUploaded}
+			mockCv.EXPECT().Read(ctx, "cv-pending").Return(existing, nil)
+			if tc.listed != nil {
+				workspace := &tfe.Workspace{ID: "ws-1", Name: "my-ws"}
+				mockWorkspaces.EXPECT().Read(ctx, "my-org", "my-ws").Return(workspace, nil)
+				mockCv.EXPECT().List(ctx, "ws-1", gomock.Any()).Return(&tfe.ConfigurationVersionList{Items: tc.listed, Pagination: &tfe.Pagination{}}, nil)
+			}
+			if tc.expectErr == "" {
+				gomock.InOrder(
+					mockCv.EXPECT().UploadTarGzip(gomock.Any(), "cv.com", gomock.Any()).Return(nil),
+					mockCv.EXPECT().Read(ctx, "cv-pending").Return(uploaded, nil),
+				)
+			}
+
+			client := NewConfigVersionService(&cloudMeta{
+				tfe:    &tfe.Client{Workspaces: mockWorkspaces, ConfigurationVersions: mockCv},
+				writer: &defaultWriter{},
+			})
+			cv, err := client.UploadConfig(ctx, UploadOptions{
+				Organization:           "my-org",
+				Workspace:              "my-ws",
+				ConfigurationDirectory: t.TempDir(),
+				ConfigurationVersionID: "cv-pending",
+			})
+			if tc.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectErr) {
+					t.Fatalf("expected error containing %q but received %v", tc.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but received %s", err)
+			}
+			if cv.Status != tfe.ConfigurationUploaded {
+				t.Errorf("expected status %q but received %q", tfe.ConfigurationUploaded, cv.Status)
+			}
+		})
+	}
+}
+
+func TestConfigVersionService_GetIngressAttributes(t *testing.T) {
+	testCases := []struct {
+		name          string
+		configVersion *tfe.ConfigurationVersion
+		expected      *tfe.IngressAttributes
+	}{
+		{
+			name: "vcs-sourced",
+			configVersion: &tfe.ConfigurationVersion{
+				ID:     "cv-1",
+				Source: tfe.ConfigurationSourceGithub,
+				IngressAttributes: &tfe.IngressAttributes{
+					CommitMessage:  "fix: bump instance size",
+					CommitSHA:      "abc123",
+					SenderUsername: "octocat",
+				},
+			},
+			expected: &tfe.IngressAttributes{
+				CommitMessage:  "fix: bump instance size",
+				CommitSHA:      "abc123",
+				SenderUsername: "octocat",
+			},
+		},
+		{
+			name: "api-sourced",
+			configVersion: &tfe.ConfigurationVersion{
+				ID:     "cv-1",
+				Source: tfe.ConfigurationSourceAPI,
+			},
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx := context.Background()
+			cvMock := mocks.NewMockConfigurationVersions(ctrl)
+			cvMock.EXPECT().ReadWithOptions(ctx, "cv-1", &tfe.ConfigurationVersionReadOptions{
+				Include: []tfe.ConfigVerIncludeOpt{tfe.ConfigVerIngressAttributes},
+			}).Return(tc.configVersion, nil)
+
+			client := NewConfigVersionService(&cloudMeta{
+				tfe:    &tfe.Client{ConfigurationVersions: cvMock},
+				writer: &defaultWriter{},
+			})
+
+			ingress, err := client.GetIngressAttributes(ctx, "cv-1")
+			if err != nil {
+				t.Fatalf("expected %v but received %s", nil, err)
+			}
+			if !reflect.DeepEqual(ingress, tc.expected) {
+				t.Errorf("expected %v but received %v", tc.expected, ingress)
+			}
+		})
+	}
+}
+
+func TestConfigVersionService_GetConfigurationPromotion(t *testing.T) {
+	testCases := []struct {
+		name             string
+		status           tfe.RunStatus
+		currentID        string
+		expectedPromoted bool
+		expectedReason   string
+	}{
+		{
+			name:             "promoted",
+			status:           tfe.RunApplied,
+			currentID:        "cv-1",
+			expectedPromoted: true,
+		},
+		{
+			name:           "not-applied",
+			status:         tfe.RunErrored,
+			currentID:      "cv-0",
+			expectedReason: "run run-1 did not apply, its status is errored",
+		},
+		{
+			name:           "current-but-not-applied",
+			status:         tfe.RunPlanned,
+			currentID:      "cv-1",
+			expectedReason: "run run-1 did not apply, its status is planned",
+		},
+		{
+			name:           "replaced",
+			status:         tfe.RunApplied,
+			currentID:      "cv-2",
+			expectedReason: "the workspace's current configuration version is cv-2, it was replaced after run run-1 applied",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx := context.Background()
+			cvMock := mocks.NewMockConfigurationVersions(ctrl)
+			cvMock.EXPECT().Read(ctx, "cv-1").Return(&tfe.ConfigurationVersion{ID: "cv-1", Provisional: true}, nil)
+			wsMock := mocks.NewMockWorkspaces(ctrl)
+			wsMock.EXPECT().ReadByID(ctx, "ws-1").Return(&tfe.Workspace{
+				ID:                          "ws-1",
+				CurrentConfigurationVersion: &tfe.ConfigurationVersion{ID: tc.currentID},
+			}, nil)
+
+			client := NewConfigVersionService(&cloudMeta{
+				tfe:    &tfe.Client{ConfigurationVersions: cvMock, Workspaces: wsMock},
+				writer: &defaultWriter{},
+			})
+
+			promotion, err := client.GetConfigurationPromotion(ctx, &tfe.Run{
+				ID:                   "run-1",
+				Status:               tc.status,
+				ConfigurationVersion: &tfe.ConfigurationVersion{ID: "cv-1"},
+				Workspace:            &tfe.Workspace{ID: "ws-1"},
+			})
+			if err != nil {
+				t.Fatalf("expected no error but received %s", err)
+			}
+			if promotion.Promoted != tc.expectedPromoted {
+				t.Errorf("expected promoted %t but received %t", tc.expectedPromoted, promotion.Promoted)
+			}
+			if promotion.CurrentConfigurationVersionID != tc.currentID {
+				t.Errorf("expected current configuration version %q but received %q", tc.currentID, promotion.CurrentConfigurationVersionID)
+			}
+			if promotion.Reason != tc.expectedReason {
+				t.Errorf("expected reason %q but received %q", tc.expectedReason, promotion.Reason)
+			}
+		})
+	}
+}
+
+func TestConfigVersionService_UploadArchive(t *testing.T) {
+	t.Setenv(envUploadRetries, "2")
+	t.Setenv(envRetryBaseDelay, "1ms")
+	archive := []byte(strings.Repeat("configuration", 100))
+
+	testCases := []struct {
+		name           string
+		failures       int
+		status         int
+		expectAttempts int
+		expectErr      bool
+	}{
+		{
+			name:           "connection-reset-then-success",
+			failures:       1,
+			expectAttempts: 2,
+		},
+		{
+			name:           "persistent-failure",
+			failures:       3,
+			expectAttempts: 3,
+			expectErr:      true,
+		},
+		{
+			name:           "server-error",
+			status:         http.StatusServiceUnavailable,
+			expectAttempts: 3,
+			expectErr:      true,
+		},
+		{
+			// an expired signed upload url fails on every attempt
+			name:           "forbidden-not-retried",
+			status:         http.StatusForbidden,
+			expectAttempts: 1,
+			expectErr:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/api/v2/ping" {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				attempts++
+				if attempts <= tc.failures {
+					// drop the connection part way through the upload
+					io.CopyN(io.Discard, r.Body, 10)
+					conn, _, err := w.(http.Hijacker).Hijack()
+					if err != nil {
+						t.Fatal(err)
+					}
+					conn.Close()
+					return
+				}
+				if tc.status != 0 {
+					w.WriteHeader(tc.status)
+					return
+				}
+				body, _ := io.ReadAll(r.Body)
+				if len(body) != len(archive) {
+					t.Errorf("expected %d bytes but received %d", len(archive), len(body))
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			config := tfe.DefaultConfig()
+			config.Address = server.URL
+			config.Token = "token"
+			// as installed by NewTfeClient, it sends the upload once so retries are not multiplied
+			config.HTTPClient.Transport = &retryTransport{next: http.DefaultTransport, maxRetries: 5, baseDelay: time.Millisecond}
+			tfeClient, err := tfe.NewClient(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			client := &configVersionService{&cloudMeta{tfe: tfeClient, writer: &defaultWriter{}}}
+			uploadURL := server.URL + "/upload?signature=secret"
+			err = client.uploadArchive(context.Background(), uploadURL, archive)
+
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error: %t but received: %v", tc.expectErr, err)
+			}
+			if attempts != tc.expectAttempts {
+				t.Errorf("expected %d attempts but received %d", tc.expectAttempts, attempts)
+			}
+			if !tc.expectErr {
+				return
+			}
+
+			var uploadErr *UploadError
+			if !errors.As(err, &uploadErr) {
+				t.Fatalf("expected upload error but received %T", err)
+			}
+			if strings.Contains(err.Error(), "signature") {
+				t.Errorf("expected error not to include the signed upload url, received: %s", err)
+			}
+			for _, expected := range []string{strings.TrimPrefix(server.URL, "http://"), fmt.Sprintf("after %d attempts", tc.expectAttempts), fmt.Sprintf("of %d bytes sent", len(archive))} {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("expected error to contain %q, received: %s", expected, err)
+				}
+			}
+		})
+	}
+}
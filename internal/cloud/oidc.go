@@ -0,0 +1,160 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	envOIDCAudience        = "TFCI_OIDC_AUDIENCE"
+	envOIDCTokenURL        = "TFCI_OIDC_TOKEN_URL"
+	envIDTokenRequestURL   = "ACTIONS_ID_TOKEN_REQUEST_URL"
+	envIDTokenRequestToken = "ACTIONS_ID_TOKEN_REQUEST_TOKEN"
+
+	// RFC 8693 token exchange parameters
+	tokenExchangeGrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+	jwtTokenType           = "urn:ietf:params:oauth:token-type:jwt"
+
+	oidcRequestTimeout = 30 * time.Second
+	// bounds the size of a token response read into memory
+	maxTokenResponseBytes = 1 << 20
+)
+
+// OIDCConfig configures exchanging a GitHub Actions workload identity token for a short-lived HCP Terraform token
+type OIDCConfig struct {
+	// audience requested for the workload identity token
+	Audience string
+	// token exchange endpoint, which returns an HCP Terraform token for the workload identity token
+	TokenURL string
+	// GitHub Actions workload identity token request url and bearer token,
+	// only available to jobs with the `id-token: write` permission
+	RequestURL   string
+	RequestToken string
+}
+
+// reads the OIDC configuration from the environment
+func oidcConfigFromEnv() *OIDCConfig {
+	return &OIDCConfig{
+		Audience:     os.Getenv(envOIDCAudience),
+		TokenURL:     os.Getenv(envOIDCTokenURL),
+		RequestURL:   os.Getenv(envIDTokenRequestURL),
+		RequestToken: os.Getenv(envIDTokenRequestToken),
+	}
+}
+
+// OIDC is only attempted once an audience has been configured
+func (o *OIDCConfig) enabled() bool {
+	return o != nil && o.Audience != ""
+}
+
+func (o *OIDCConfig) validate() error {
+	missing := []string{}
+	if o.TokenURL == "" {
+		missing = append(missing, envOIDCTokenURL)
+	}
+	if o.RequestURL == "" {
+		missing = append(missing, envIDTokenRequestURL)
+	}
+	if o.RequestToken == "" {
+		missing = append(missing, envIDTokenRequestToken)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("OIDC authentication requires %s to be set, GitHub Actions jobs need the `id-token: write` permission", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// ExchangeOIDCToken requests a workload identity token from GitHub Actions and exchanges it
+// for a short-lived HCP Terraform token using an RFC 8693 token exchange request
+func ExchangeOIDCToken(ctx context.Context, client *http.Client, config *OIDCConfig) (string, error) {
+	if err := config.validate(); err != nil {
+		return "", err
+	}
+
+	idToken, err := requestIDToken(ctx, client, config)
+	if err != nil {
+		return "", fmt.Errorf("failed to request OIDC token: %w", err)
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", tokenExchangeGrantType)
+	form.Set("subject_token", idToken)
+	form.Set("subject_token_type", jwtTokenType)
+	form.Set("audience", config.Audience)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, config.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var exchange struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := doTokenRequest(client, req, &exchange); err != nil {
+		return "", fmt.Errorf("failed to exchange OIDC token: %w", err)
+	}
+	if exchange.AccessToken == "" {
+		return "", fmt.Errorf("failed to exchange OIDC token: response did not include an access_token")
+	}
+	return exchange.AccessToken, nil
+}
+
+// requests the workload identity token for the configured audience
+// https://docs.github.com/en/actions/security-for-github-actions/security-hardening-your-deployments/about-security-hardening-with-openid-connect
+func requestIDToken(ctx context.Context, client *http.Client, config *OIDCConfig) (string, error) {
+	requestURL, err := url.Parse(config.RequestURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s: %w", envIDTokenRequestURL, err)
+	}
+	query := requestURL.Query()
+	query.Set("audience", config.Audience)
+	requestURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+config.RequestToken)
+	req.Header.Set("Accept", "application/json")
+
+	var idToken struct {
+		Value string `json:"value"`
+	}
+	if err := doTokenRequest(client, req, &idToken); err != nil {
+		return "", err
+	}
+	if idToken.Value == "" {
+		return "", fmt.Errorf("response did not include a token value")
+	}
+	return idToken.Value, nil
+}
+
+// sends a token request and decodes the json response body into v, response bodies are
+// never included in errors as they may contain credentials
+func doTokenRequest(client *http.Client, req *http.Request, v any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %q from %s", resp.Status, req.URL.Host)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxTokenResponseBytes)).Decode(v); err != nil {
+		return fmt.Errorf("invalid response from %s: %w", req.URL.Host, err)
+	}
+	return nil
+}
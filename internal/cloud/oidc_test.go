@@ -0,0 +1,149 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExchangeOIDCToken(t *testing.T) {
+	testCases := []struct {
+		name           string
+		idTokenStatus  int
+		idTokenBody    string
+		exchangeStatus int
+		exchangeBody   string
+		expectToken    string
+		expectErr      string
+	}{
+		{
+			name:           "success",
+			idTokenStatus:  http.StatusOK,
+			idTokenBody:    `{"value":"gh-jwt"}`,
+			exchangeStatus: http.StatusOK,
+			exchangeBody:   `{"access_token":"tfc-token","token_type":"Bearer"}`,
+			expectToken:    "tfc-token",
+		},
+		{
+			name:          "id-token-request-denied",
+			idTokenStatus: http.StatusForbidden,
+			expectErr:     "failed to request OIDC token: unexpected status \"403 Forbidden\"",
+		},
+		{
+			name:          "id-token-missing-value",
+			idTokenStatus: http.StatusOK,
+			idTokenBody:   `{}`,
+			expectErr:     "failed to request OIDC token: response did not include a token value",
+		},
+		{
+			name:           "exchange-rejected",
+			idTokenStatus:  http.StatusOK,
+			idTokenBody:    `{"value":"gh-jwt"}`,
+			exchangeStatus: http.StatusUnauthorized,
+			exchangeBody:   `{"error":"invalid_grant"}`,
+			expectErr:      "failed to exchange OIDC token: unexpected status \"401 Unauthorized\"",
+		},
+		{
+			name:           "exchange-invalid-json",
+			idTokenStatus:  http.StatusOK,
+			idTokenBody:    `{"value":"gh-jwt"}`,
+			exchangeStatus: http.StatusOK,
+			exchangeBody:   `not json`,
+			expectErr:      "failed to exchange OIDC token: invalid response",
+		},
+		{
+			name:           "exchange-missing-access-token",
+			idTokenStatus:  http.StatusOK,
+			idTokenBody:    `{"value":"gh-jwt"}`,
+			exchangeStatus: http.StatusOK,
+			exchangeBody:   `{}`,
+			expectErr:      "failed to exchange OIDC token: response did not include an access_token",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/id-token", func(w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get("Authorization"); got != "Bearer request-token" {
+					t.Errorf("expected bearer request token but received %q", got)
+				}
+				if got := r.URL.Query().Get("api-version"); got != "2.0" {
+					t.Errorf("expected existing query to be preserved but received api-version %q", got)
+				}
+				if got := r.URL.Query().Get("audience"); got != "tfc.example" {
+					t.Errorf("expected audience %q but received %q", "tfc.example", got)
+				}
+				w.WriteHeader(tc.idTokenStatus)
+				fmt.Fprint(w, tc.idTokenBody)
+			})
+			mux.HandleFunc("/exchange", func(w http.ResponseWriter, r *http.Request) {
+				if err := r.ParseForm(); err != nil {
+					t.Fatal(err)
+				}
+				expectForm := map[string]string{
+					"grant_type":         tokenExchangeGrantType,
+					"subject_token":      "gh-jwt",
+					"subject_token_type": jwtTokenType,
+					"audience":           "tfc.example",
+				}
+				for k, v := range expectForm {
+					if got := r.PostForm.Get(k); got != v {
+						t.Errorf("expected form %s=%q but received %q", k, v, got)
+					}
+				}
+				w.WriteHeader(tc.exchangeStatus)
+				fmt.Fprint(w, tc.exchangeBody)
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			token, err := ExchangeOIDCToken(context.Background(), server.Client(), &OIDCConfig{
+				Audience:     "tfc.example",
+				TokenURL:     server.URL + "/exchange",
+				RequestURL:   server.URL + "/id-token?api-version=2.0",
+				RequestToken: "request-token",
+			})
+
+			if tc.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectErr) {
+					t.Fatalf("expected error containing %q but received %v", tc.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but received %s", err)
+			}
+			if token != tc.expectToken {
+				t.Errorf("expected token %q but received %q", tc.expectToken, token)
+			}
+		})
+	}
+}
+
+func TestExchangeOIDCToken_MissingConfig(t *testing.T) {
+	_, err := ExchangeOIDCToken(context.Background(), http.DefaultClient, &OIDCConfig{Audience: "tfc.example"})
+	expected := "OIDC authentication requires TFCI_OIDC_TOKEN_URL, ACTIONS_ID_TOKEN_REQUEST_URL, ACTIONS_ID_TOKEN_REQUEST_TOKEN to be set"
+	if err == nil || !strings.Contains(err.Error(), expected) {
+		t.Fatalf("expected error containing %q but received %v", expected, err)
+	}
+}
+
+func TestNewTfeClient_NoCredentials(t *testing.T) {
+	t.Setenv("TF_API_TOKEN", "")
+	t.Setenv("TF_API_TOKEN_FILE", "")
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(envOIDCAudience, "")
+
+	_, err := NewTfeClient("", "", "", "other", "", nil)
+	expected := "HCP Terraform API token is not set"
+	if err == nil || !strings.Contains(err.Error(), expected) {
+		t.Fatalf("expected error containing %q but received %v", expected, err)
+	}
+}
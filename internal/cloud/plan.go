@@ -4,14 +4,60 @@
 package cloud
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
 	"log"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
 
 	"github.com/hashicorp/go-tfe"
+	"github.com/sethvargo/go-retry"
 )
 
+// a resource the plan changes, as listed by `resource_changes_payload`
+type ResourceChange struct {
+	Address string `json:"address"`
+	// create, update, delete or replace
+	Action       string `json:"action"`
+	ResourceType string `json:"resource_type"`
+}
+
+// a root module output as planned, as listed by `planned_outputs`
+type PlannedOutput struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+	// the value is only known after apply, Value is nil
+	Unknown   bool `json:"unknown,omitempty"`
+	Sensitive bool `json:"-"`
+}
+
+// subset of the JSON execution plan describing resource and output changes
+// https://developer.hashicorp.com/terraform/internals/json-format#plan-representation
+type executionPlan struct {
+	ResourceChanges []struct {
+		Address string `json:"address"`
+		Type    string `json:"type"`
+		Change  struct {
+			Actions []string `json:"actions"`
+		} `json:"change"`
+	} `json:"resource_changes"`
+	OutputChanges map[string]struct {
+		Actions []string    `json:"actions"`
+		After   interface{} `json:"after"`
+		// true, or a structure matching the value, when any part of it is unknown or sensitive
+		AfterUnknown   interface{} `json:"after_unknown"`
+		AfterSensitive interface{} `json:"after_sensitive"`
+	} `json:"output_changes"`
+}
+
 type PlanService interface {
 	GetPlan(context.Context, string) (*tfe.Plan, error)
+	ReadPlanJSON(context.Context, string) ([]byte, error)
+	ReadRedactedPlanJSON(context.Context, string) ([]byte, error)
 }
 
 type planService struct {
@@ -19,6 +65,10 @@ type planService struct {
 }
 
 func (service *planService) GetPlan(ctx context.Context, planID string) (*tfe.Plan, error) {
+	if err := service.skipDryRun("read plan", "plan_id", planID); err != nil {
+		return nil, err
+	}
+
 	data, err := service.tfe.Plans.Read(ctx, planID)
 	if err != nil {
 		log.Printf("[ERROR] error reading plan: '%s', with: '%s'", planID, err.Error())
@@ -27,6 +77,147 @@ func (service *planService) GetPlan(ctx context.Context, planID string) (*tfe.Pl
 	return data, nil
 }
 
+// waits for the plan to finish and returns its JSON execution plan, the `terraform show -json` format
+func (service *planService) ReadPlanJSON(ctx context.Context, planID string) ([]byte, error) {
+	if err := service.skipDryRun("read plan json", "plan_id", planID); err != nil {
+		return nil, err
+	}
+	return service.readPlanJSON(ctx, planID, service.tfe.Plans.ReadJSONOutput)
+}
+
+// waits for the plan to finish and returns its JSON execution plan with sensitive values redacted, the format
+// HCP Terraform renders plans from
+func (service *planService) ReadRedactedPlanJSON(ctx context.Context, planID string) ([]byte, error) {
+	if err := service.skipDryRun("read redacted plan json", "plan_id", planID); err != nil {
+		return nil, err
+	}
+	return service.readPlanJSON(ctx, planID, service.readRedactedJSONOutput)
+}
+
+func (service *planService) readPlanJSON(ctx context.Context, planID string, readJSON func(context.Context, string) ([]byte, error)) ([]byte, error) {
+	var planJSON []byte
+	retryErr := retry.Do(ctx, service.backoff(), func(ctx context.Context) error {
+		plan, err := service.tfe.Plans.Read(ctx, planID)
+		// return non-retryable error
+		if err != nil {
+			return err
+		}
+
+		switch plan.Status {
+		case tfe.PlanFinished:
+		case tfe.PlanErrored, tfe.PlanCanceled, tfe.PlanUnreachable:
+			return fmt.Errorf("plan %q is %s, its JSON execution plan is not available", planID, plan.Status)
+		default:
+			service.writer.Output(fmt.Sprintf("Waiting for plan to finish, plan status: %s", plan.Status))
+			return retryableTimeoutError("wait for plan to finish")
+		}
+
+		data, err := readJSON(ctx, planID)
+		if err != nil {
+			return err
+		}
+		// the JSON execution plan is generated shortly after the plan finishes
+		if len(data) == 0 {
+			return retryableTimeoutError("wait for JSON execution plan")
+		}
+		planJSON = data
+		return nil
+	})
+
+	if retryErr != nil {
+		log.Printf("[ERROR] error reading JSON execution plan: '%s', with: '%s'", planID, retryErr.Error())
+		return nil, retryErr
+	}
+	return planJSON, nil
+}
+
+// go-tfe only reads the unredacted JSON execution plan, which requires admin access to the workspace
+func (service *planService) readRedactedJSONOutput(ctx context.Context, planID string) ([]byte, error) {
+	req, err := service.tfe.NewRequest("GET", fmt.Sprintf("plans/%s/json-output-redacted", url.PathEscape(planID)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := req.Do(ctx, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// returns the resources changed by the JSON execution plan in plan order, unchanged resources and data sources
+// which are only read are omitted. The list is empty, not nil, when the plan has no changes
+func ParseResourceChanges(planJSON []byte) ([]*ResourceChange, error) {
+	plan := &executionPlan{}
+	if err := json.Unmarshal(planJSON, plan); err != nil {
+		return nil, fmt.Errorf("invalid JSON execution plan: %w", err)
+	}
+
+	changes := make([]*ResourceChange, 0, len(plan.ResourceChanges))
+	for _, rc := range plan.ResourceChanges {
+		action := changeAction(rc.Change.Actions)
+		if action == "no-op" || action == "read" {
+			continue
+		}
+		changes = append(changes, &ResourceChange{
+			Address:      rc.Address,
+			Action:       action,
+			ResourceType: rc.Type,
+		})
+	}
+	return changes, nil
+}
+
+// returns the root module outputs the JSON execution plan leaves in state, sorted by name. Outputs the plan
+// deletes are omitted. The list is empty, not nil, when the plan has no outputs
+func ParsePlannedOutputs(planJSON []byte) ([]*PlannedOutput, error) {
+	plan := &executionPlan{}
+	if err := json.Unmarshal(planJSON, plan); err != nil {
+		return nil, fmt.Errorf("invalid JSON execution plan: %w", err)
+	}
+
+	outputs := make([]*PlannedOutput, 0, len(plan.OutputChanges))
+	for _, name := range slices.Sorted(maps.Keys(plan.OutputChanges)) {
+		change := plan.OutputChanges[name]
+		if changeAction(change.Actions) == "delete" {
+			continue
+		}
+		outputs = append(outputs, &PlannedOutput{
+			Name:      name,
+			Value:     change.After,
+			Unknown:   change.AfterUnknown == true,
+			Sensitive: containsTrue(change.AfterSensitive),
+		})
+	}
+	return outputs, nil
+}
+
+// whether an after_unknown or after_sensitive structure marks any part of the value, eg. a single sensitive
+// attribute of an object makes the whole output sensitive
+func containsTrue(marks interface{}) bool {
+	switch m := marks.(type) {
+	case bool:
+		return m
+	case []interface{}:
+		return slices.ContainsFunc(m, containsTrue)
+	case map[string]interface{}:
+		for _, v := range m {
+			if containsTrue(v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// collapses the plan's actions to a single action, a delete and create in either order is a replace
+func changeAction(actions []string) string {
+	if len(actions) == 2 && slices.Contains(actions, "create") && slices.Contains(actions, "delete") {
+		return "replace"
+	}
+	return strings.Join(actions, ",")
+}
+
 func NewPlanService(meta *cloudMeta) *planService {
 	return &planService{meta}
 }
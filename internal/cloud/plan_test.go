@@ -0,0 +1,193 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cloud
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/go-tfe/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPlanService_ReadPlanJSON(t *testing.T) {
+	testCases := []struct {
+		name      string
+		statuses  []tfe.PlanStatus
+		outputs   [][]byte
+		expectErr bool
+	}{
+		{
+			name:     "waits-for-plan",
+			statuses: []tfe.PlanStatus{tfe.PlanRunning, tfe.PlanFinished},
+			outputs:  [][]byte{[]byte(`{"format_version":"1.2"}`)},
+		},
+		{
+			name:     "waits-for-json-output",
+			statuses: []tfe.PlanStatus{tfe.PlanFinished, tfe.PlanFinished},
+			outputs:  [][]byte{{}, []byte(`{"format_version":"1.2"}`)},
+		},
+		{
+			name:      "plan-errored",
+			statuses:  []tfe.PlanStatus{tfe.PlanErrored},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx, planID := context.Background(), "plan-***"
+			mPlans := mocks.NewMockPlans(ctrl)
+			for _, status := range tc.statuses {
+				mPlans.EXPECT().Read(ctx, planID).Return(&tfe.Plan{ID: planID, Status: status}, nil)
+			}
+			for _, output := range tc.outputs {
+				mPlans.EXPECT().ReadJSONOutput(ctx, planID).Return(output, nil)
+			}
+
+			client := NewPlanService(&cloudMeta{
+				tfe:          &tfe.Client{Plans: mPlans},
+				writer:       &defaultWriter{},
+				pollInterval: time.Millisecond,
+			})
+			planJSON, err := client.ReadPlanJSON(ctx, planID)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error: %t but received: %v", tc.expectErr, err)
+			}
+			if !tc.expectErr && string(planJSON) != `{"format_version":"1.2"}` {
+				t.Errorf("expected plan JSON but received %q", planJSON)
+			}
+		})
+	}
+}
+
+func TestPlanService_ReadRedactedPlanJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/ping":
+			w.WriteHeader(http.StatusNoContent)
+		case "/api/v2/plans/plan-***":
+			w.Header().Set("Content-Type", "application/vnd.api+json")
+			w.Write([]byte(`{"data":{"id":"plan-***","type":"plans","attributes":{"status":"finished"}}}`))
+		case "/api/v2/plans/plan-***/json-output-redacted":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"format_version":"1.2"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	config := tfe.DefaultConfig()
+	config.Address = server.URL
+	config.Token = "token"
+	tfeClient, err := tfe.NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewPlanService(&cloudMeta{tfe: tfeClient, writer: &defaultWriter{}, pollInterval: time.Millisecond})
+	planJSON, err := client.ReadRedactedPlanJSON(context.Background(), "plan-***")
+	if err != nil {
+		t.Fatalf("expected no error but received %s", err)
+	}
+	if string(planJSON) != `{"format_version":"1.2"}` {
+		t.Errorf("expected redacted plan JSON but received %q", planJSON)
+	}
+}
+
+func TestParseResourceChanges(t *testing.T) {
+	planJSON := `{"format_version":"1.2","resource_changes":[
+		{"address":"aws_instance.web","type":"aws_instance","change":{"actions":["create"]}},
+		{"address":"aws_s3_bucket.logs","type":"aws_s3_bucket","change":{"actions":["no-op"]}},
+		{"address":"data.aws_ami.ubuntu","type":"aws_ami","change":{"actions":["read"]}},
+		{"address":"aws_security_group.web","type":"aws_security_group","change":{"actions":["update"]}},
+		{"address":"aws_eip.web","type":"aws_eip","change":{"actions":["delete","create"]}},
+		{"address":"aws_lb.web","type":"aws_lb","change":{"actions":["create","delete"]}},
+		{"address":"module.db.aws_db_instance.main","type":"aws_db_instance","change":{"actions":["delete"]}}
+	]}`
+
+	changes, err := ParseResourceChanges([]byte(planJSON))
+	if err != nil {
+		t.Fatalf("expected no error but received %s", err)
+	}
+	expected := []ResourceChange{
+		{Address: "aws_instance.web", Action: "create", ResourceType: "aws_instance"},
+		{Address: "aws_security_group.web", Action: "update", ResourceType: "aws_security_group"},
+		{Address: "aws_eip.web", Action: "replace", ResourceType: "aws_eip"},
+		{Address: "aws_lb.web", Action: "replace", ResourceType: "aws_lb"},
+		{Address: "module.db.aws_db_instance.main", Action: "delete", ResourceType: "aws_db_instance"},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d resource changes but received %d", len(expected), len(changes))
+	}
+	for i, change := range changes {
+		if *change != expected[i] {
+			t.Errorf("expected resource change %+v but received %+v", expected[i], *change)
+		}
+	}
+
+	noChanges, err := ParseResourceChanges([]byte(`{"format_version":"1.2"}`))
+	if err != nil {
+		t.Fatalf("expected no error but received %s", err)
+	}
+	if noChanges == nil || len(noChanges) != 0 {
+		t.Errorf("expected an empty list of resource changes but received %v", noChanges)
+	}
+
+	if _, err := ParseResourceChanges([]byte("not json")); err == nil {
+		t.Error("expected an error for an invalid JSON execution plan")
+	}
+}
+
+func TestParsePlannedOutputs(t *testing.T) {
+	planJSON := `{"format_version":"1.2","output_changes":{
+		"vpc_id":{"actions":["no-op"],"after":"vpc-123","after_unknown":false,"after_sensitive":false},
+		"db_password":{"actions":["create"],"after":"secret","after_unknown":false,"after_sensitive":true},
+		"endpoint":{"actions":["create"],"after_unknown":true,"after_sensitive":false},
+		"db":{"actions":["update"],"after":{"host":"db","password":"secret"},"after_unknown":false,"after_sensitive":{"password":true}},
+		"legacy":{"actions":["delete"],"before":"old","after_unknown":false,"after_sensitive":false}
+	}}`
+
+	outputs, err := ParsePlannedOutputs([]byte(planJSON))
+	if err != nil {
+		t.Fatalf("expected no error but received %s", err)
+	}
+	expected := []struct {
+		name      string
+		unknown   bool
+		sensitive bool
+	}{
+		{name: "db", sensitive: true},
+		{name: "db_password", sensitive: true},
+		{name: "endpoint", unknown: true},
+		{name: "vpc_id"},
+	}
+	if len(outputs) != len(expected) {
+		t.Fatalf("expected %d planned outputs but received %d", len(expected), len(outputs))
+	}
+	for i, output := range outputs {
+		if output.Name != expected[i].name || output.Unknown != expected[i].unknown || output.Sensitive != expected[i].sensitive {
+			t.Errorf("expected planned output %+v but received %+v", expected[i], *output)
+		}
+	}
+	if outputs[3].Value != "vpc-123" {
+		t.Errorf("expected value %q but received %v", "vpc-123", outputs[3].Value)
+	}
+
+	noOutputs, err := ParsePlannedOutputs([]byte(`{"format_version":"1.2"}`))
+	if err != nil {
+		t.Fatalf("expected no error but received %s", err)
+	}
+	if noOutputs == nil || len(noOutputs) != 0 {
+		t.Errorf("expected an empty list of planned outputs but received %v", noOutputs)
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+// aggregated status of a run's policy checks, in increasing order of severity
+const (
+	PolicyStatusPassed     = "passed"
+	PolicyStatusOverridden = "overridden"
+	PolicyStatusPending    = "pending"
+	PolicyStatusSoftFailed = "soft_failed"
+	PolicyStatusHardFailed = "hard_failed"
+	PolicyStatusErrored    = "errored"
+)
+
+var policyStatusSeverity = map[string]int{
+	PolicyStatusPassed:     0,
+	PolicyStatusOverridden: 1,
+	PolicyStatusPending:    2,
+	PolicyStatusSoftFailed: 3,
+	PolicyStatusHardFailed: 4,
+	PolicyStatusErrored:    5,
+}
+
+// PolicyResults summarizes the Sentinel policy checks and OPA policy evaluations of a run
+type PolicyResults struct {
+	// most severe status of all policies, eg. `hard_failed` when any mandatory policy failed
+	Status string
+	// a soft-mandatory policy failed, whether or not the failure was overridden
+	SoftFailed bool
+	// number of failed advisory policies, which never block the run
+	AdvisoryFailed int
+	Outcomes       []*PolicyOutcome
+}
+
+// PolicyOutcome is the result of a single OPA policy, or of a Sentinel policy check which only reports counts
+type PolicyOutcome struct {
+	Kind             string               `json:"kind"`
+	ID               string               `json:"id"`
+	PolicySet        string               `json:"policy_set,omitempty"`
+	Policy           string               `json:"policy,omitempty"`
+	EnforcementLevel string               `json:"enforcement_level,omitempty"`
+	Status           string               `json:"status"`
+	Counts           *PolicyOutcomeCounts `json:"counts,omitempty"`
+}
+
+type PolicyOutcomeCounts struct {
+	Passed         int `json:"passed"`
+	AdvisoryFailed int `json:"advisory_failed"`
+	SoftFailed     int `json:"soft_failed"`
+	HardFailed     int `json:"hard_failed"`
+}
+
+func (r *PolicyResults) add(outcome *PolicyOutcome, status string) {
+	r.Outcomes = append(r.Outcomes, outcome)
+	if status == PolicyStatusSoftFailed || status == PolicyStatusOverridden {
+		r.SoftFailed = true
+	}
+	if r.Status == "" || policyStatusSeverity[status] > policyStatusSeverity[r.Status] {
+		r.Status = status
+	}
+}
+
+// GetPolicyResults reads the run's Sentinel policy checks and OPA policy evaluations,
+// returning empty results when no policies apply to the run
+func (s *runService) GetPolicyResults(ctx context.Context, run *tfe.Run) (*PolicyResults, error) {
+	results := &PolicyResults{Outcomes: []*PolicyOutcome{}}
+	if s.placeholderDryRun("read policy results", "run_id", runID(run)) || run == nil {
+		return results, nil
+	}
+
+	if hasPolicyChecks(run) {
+		if err := s.addSentinelResults(ctx, run, results); err != nil {
+			log.Printf("[ERROR] error reading policy checks for run: %q error: %s", run.ID, err)
+			return nil, err
+		}
+	}
+	if len(run.TaskStages) > 0 {
+		if err := s.addOPAResults(ctx, run, results); err != nil {
+			log.Printf("[ERROR] error reading policy evaluations for run: %q error: %s", run.ID, err)
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+func (s *runService) addSentinelResults(ctx context.Context, run *tfe.Run, results *PolicyResults) error {
+	policyChecks := []*tfe.PolicyCheck{}
+	listOpts := &tfe.PolicyCheckListOptions{
+		ListOptions: tfe.ListOptions{PageNumber: 1, PageSize: maxPageSize},
+	}
+	for {
+		list, err := s.tfe.PolicyChecks.List(ctx, run.ID, listOpts)
+		if err != nil {
+			return err
+		}
+		policyChecks = append(policyChecks, list.Items...)
+
+		if list.Pagination == nil || list.NextPage == 0 {
+			break
+		}
+		listOpts.PageNumber = list.NextPage
+	}
+
+	for _, pcheck := range policyChecks {
+		status := sentinelPolicyStatus(pcheck.Status)
+		if status == "" {
+			continue
+		}
+		outcome := &PolicyOutcome{
+			Kind:   string(tfe.Sentinel),
+			ID:     pcheck.ID,
+			Status: status,
+		}
+		if pcheck.Result != nil {
+			outcome.Counts = &PolicyOutcomeCounts{
+				Passed:         pcheck.Result.Passed,
+				AdvisoryFailed: pcheck.Result.AdvisoryFailed,
+				SoftFailed:     pcheck.Result.SoftFailed,
+				HardFailed:     pcheck.Result.HardFailed,
+			}
+			results.AdvisoryFailed += pcheck.Result.AdvisoryFailed
+		}
+		results.add(outcome, status)
+	}
+	return nil
+}
+
+// maps a Sentinel policy check status, canceled and unreachable checks did no work and are skipped
+func sentinelPolicyStatus(status tfe.PolicyStatus) string {
+	switch status {
+	case tfe.PolicyPasses:
+		return PolicyStatusPassed
+	case tfe.PolicyOverridden:
+		return PolicyStatusOverridden
+	case tfe.PolicyPending, tfe.PolicyQueued:
+		return PolicyStatusPending
+	case tfe.PolicySoftFailed:
+		return PolicyStatusSoftFailed
+	case tfe.PolicyHardFailed:
+		return PolicyStatusHardFailed
+	case tfe.PolicyErrored:
+		return PolicyStatusErrored
+	default:
+		return ""
+	}
+}
+
+func (s *runService) addOPAResults(ctx context.Context, run *tfe.Run, results *PolicyResults) error {
+	taskStages, err := s.tfe.TaskStages.List(ctx, run.ID, &tfe.TaskStageListOptions{})
+	if err != nil {
+		return err
+	}
+
+	for _, stage := range taskStages.Items {
+		evaluations, err := s.tfe.PolicyEvaluations.List(ctx, stage.ID, &tfe.PolicyEvaluationListOptions{})
+		if err != nil {
+			return err
+		}
+		for _, evaluation := range evaluations.Items {
+			setOutcomes, err := s.tfe.PolicySetOutcomes.List(ctx, evaluation.ID, &tfe.PolicySetOutcomeListOptions{})
+			if err != nil {
+				return fmt.Errorf("error reading policy set outcomes for policy evaluation %q: %w", evaluation.ID, err)
+			}
+			for _, setOutcome := range setOutcomes.Items {
+				overridable := setOutcome.Overridable != nil && *setOutcome.Overridable
+				for _, o := range setOutcome.Outcomes {
+					status := opaPolicyStatus(evaluation.Status, o, overridable)
+					if o.Status == "failed" && o.EnforcementLevel == tfe.EnforcementAdvisory {
+						results.AdvisoryFailed++
+					}
+					results.add(&PolicyOutcome{
+						Kind:             string(tfe.OPA),
+						ID:               evaluation.ID,
+						PolicySet:        setOutcome.PolicySetName,
+						Policy:           o.PolicyName,
+						EnforcementLevel: string(o.EnforcementLevel),
+						Status:           status,
+					}, status)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// maps an OPA policy outcome, a failed mandatory policy is a soft failure when it can be overridden
+func opaPolicyStatus(evaluationStatus tfe.PolicyEvaluationStatus, o tfe.Outcome, overridable bool) string {
+	switch {
+	case o.Status == "errored":
+		return PolicyStatusErrored
+	case o.Status != "failed" || o.EnforcementLevel == tfe.EnforcementAdvisory:
+		if evaluationStatus == tfe.PolicyEvaluationPending || evaluationStatus == tfe.PolicyEvaluationRunning {
+			return PolicyStatusPending
+		}
+		return PolicyStatusPassed
+	case evaluationStatus == tfe.PolicyEvaluationOverridden:
+		return PolicyStatusOverridden
+	case overridable:
+		return PolicyStatusSoftFailed
+	default:
+		return PolicyStatusHardFailed
+	}
+}
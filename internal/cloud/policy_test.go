@@ -0,0 +1,131 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cloud
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/go-tfe/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRunService_GetPolicyResults(t *testing.T) {
+	overridable, notOverridable := true, false
+
+	testCases := []struct {
+		name           string
+		policyChecks   []*tfe.PolicyCheck
+		setOutcomes    []*tfe.PolicySetOutcome
+		expectStatus   string
+		expectSoft     bool
+		expectAdvisory int
+		expectOutcomes int
+	}{
+		{
+			name: "sentinel-passed-with-advisory-failure",
+			policyChecks: []*tfe.PolicyCheck{
+				{ID: "polchk-1", Status: tfe.PolicyPasses, Result: &tfe.PolicyResult{Passed: 2, AdvisoryFailed: 1}},
+			},
+			expectStatus:   PolicyStatusPassed,
+			expectAdvisory: 1,
+			expectOutcomes: 1,
+		},
+		{
+			name: "sentinel-hard-failed",
+			policyChecks: []*tfe.PolicyCheck{
+				{ID: "polchk-1", Status: tfe.PolicyHardFailed, Result: &tfe.PolicyResult{HardFailed: 1}},
+				{ID: "polchk-2", Status: tfe.PolicyUnreachable},
+			},
+			expectStatus:   PolicyStatusHardFailed,
+			expectOutcomes: 1,
+		},
+		{
+			name: "opa-overridable-mandatory-failure",
+			setOutcomes: []*tfe.PolicySetOutcome{
+				{
+					PolicySetName: "baseline",
+					Overridable:   &overridable,
+					Outcomes: []tfe.Outcome{
+						{PolicyName: "tags", EnforcementLevel: tfe.EnforcementMandatory, Status: "failed"},
+						{PolicyName: "regions", EnforcementLevel: tfe.EnforcementAdvisory, Status: "failed"},
+						{PolicyName: "encryption", EnforcementLevel: tfe.EnforcementMandatory, Status: "passed"},
+					},
+				},
+			},
+			expectStatus:   PolicyStatusSoftFailed,
+			expectSoft:     true,
+			expectAdvisory: 1,
+			expectOutcomes: 3,
+		},
+		{
+			name: "opa-mandatory-failure",
+			setOutcomes: []*tfe.PolicySetOutcome{
+				{
+					PolicySetName: "baseline",
+					Overridable:   &notOverridable,
+					Outcomes: []tfe.Outcome{
+						{PolicyName: "tags", EnforcementLevel: tfe.EnforcementMandatory, Status: "failed"},
+					},
+				},
+			},
+			expectStatus:   PolicyStatusHardFailed,
+			expectOutcomes: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx := context.Background()
+			run := &tfe.Run{ID: "run-***"}
+			client := &tfe.Client{}
+
+			if tc.policyChecks != nil {
+				run.PolicyChecks = tc.policyChecks
+				policyChecksMock := mocks.NewMockPolicyChecks(ctrl)
+				policyChecksMock.EXPECT().List(ctx, run.ID, &tfe.PolicyCheckListOptions{ListOptions: tfe.ListOptions{PageNumber: 1, PageSize: maxPageSize}}).Return(&tfe.PolicyCheckList{Items: tc.policyChecks}, nil)
+				client.PolicyChecks = policyChecksMock
+			}
+			if tc.setOutcomes != nil {
+				stage := &tfe.TaskStage{ID: "ts-1", Stage: tfe.PostPlan}
+				run.TaskStages = []*tfe.TaskStage{stage}
+
+				taskStagesMock := mocks.NewMockTaskStages(ctrl)
+				taskStagesMock.EXPECT().List(ctx, run.ID, &tfe.TaskStageListOptions{}).Return(&tfe.TaskStageList{Items: []*tfe.TaskStage{stage}}, nil)
+				evaluationsMock := mocks.NewMockPolicyEvaluations(ctrl)
+				evaluationsMock.EXPECT().List(ctx, stage.ID, &tfe.PolicyEvaluationListOptions{}).Return(&tfe.PolicyEvaluationList{
+					Items: []*tfe.PolicyEvaluation{{ID: "poleval-1", Status: tfe.PolicyEvaluationFailed, PolicyKind: tfe.OPA}},
+				}, nil)
+				setOutcomesMock := mocks.NewMockPolicySetOutcomes(ctrl)
+				setOutcomesMock.EXPECT().List(ctx, "poleval-1", &tfe.PolicySetOutcomeListOptions{}).Return(&tfe.PolicySetOutcomeList{Items: tc.setOutcomes}, nil)
+
+				client.TaskStages = taskStagesMock
+				client.PolicyEvaluations = evaluationsMock
+				client.PolicySetOutcomes = setOutcomesMock
+			}
+
+			service := NewRunService(&cloudMeta{tfe: client, writer: &defaultWriter{}})
+			results, err := service.GetPolicyResults(ctx, run)
+			if err != nil {
+				t.Fatalf("expected no error but received %s", err)
+			}
+			if results.Status != tc.expectStatus {
+				t.Errorf("expected status %q but received %q", tc.expectStatus, results.Status)
+			}
+			if results.SoftFailed != tc.expectSoft {
+				t.Errorf("expected soft failed %t but received %t", tc.expectSoft, results.SoftFailed)
+			}
+			if results.AdvisoryFailed != tc.expectAdvisory {
+				t.Errorf("expected %d advisory failures but received %d", tc.expectAdvisory, results.AdvisoryFailed)
+			}
+			if len(results.Outcomes) != tc.expectOutcomes {
+				t.Errorf("expected %d outcomes but received %d", tc.expectOutcomes, len(results.Outcomes))
+			}
+		})
+	}
+}
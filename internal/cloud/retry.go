@@ -46,6 +46,28 @@ func defaultBackoff() retry.Backoff {
 	return backoff
 }
 
+// backoff used while waiting on runs and uploads to reach a desired status
+// uses configured poll interval and timeout, otherwise falls back to defaultBackoff()
+func (m *cloudMeta) backoff() retry.Backoff {
+	if m.pollInterval <= 0 && m.timeout <= 0 {
+		return defaultBackoff()
+	}
+
+	timeout := m.timeout
+	if timeout <= 0 {
+		timeout = Timeout()
+	}
+
+	var backoff retry.Backoff
+	if m.pollInterval > 0 {
+		backoff = retry.NewConstant(m.pollInterval)
+	} else {
+		backoff = retry.NewFibonacci(2 * time.Second)
+		backoff = retry.WithCappedDuration(7*time.Second, backoff)
+	}
+	return retry.WithMaxDuration(timeout, backoff)
+}
+
 func Timeout() time.Duration {
 	timeout := defaultTimeoutDuration
 	once.Do(func() {
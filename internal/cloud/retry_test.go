@@ -37,3 +37,28 @@ func TestTimeout(t *testing.T) {
 		})
 	}
 }
+
+func TestCloudMetaBackoff(t *testing.T) {
+	t.Run("poll interval is used between attempts", func(t *testing.T) {
+		m := &cloudMeta{pollInterval: 3 * time.Second, timeout: time.Minute}
+		backoff := m.backoff()
+		for i := 0; i < 3; i++ {
+			next, stop := backoff.Next()
+			if stop {
+				t.Fatalf("expected backoff to continue")
+			}
+			if next != 3*time.Second {
+				t.Errorf("expected %v but received %v", 3*time.Second, next)
+			}
+		}
+	})
+
+	t.Run("timeout stops attempts", func(t *testing.T) {
+		m := &cloudMeta{pollInterval: time.Second, timeout: time.Nanosecond}
+		backoff := m.backoff()
+		time.Sleep(time.Millisecond)
+		if _, stop := backoff.Next(); !stop {
+			t.Errorf("expected backoff to stop after timeout")
+		}
+	})
+}
@@ -10,6 +10,11 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/go-tfe"
@@ -18,6 +23,12 @@ import (
 
 const LogTimeout = time.Second * 10
 
+// maximum page size supported by the HCP Terraform API
+const maxPageSize = 100
+
+// runs created within the window are checked for a duplicate of a new run, eg. created by a retried command
+const duplicateRunWindow = 10 * time.Minute
+
 var (
 	ForceCancel              = tfe.RunStatus("force_canceled")
 	PrePlanAwaitingDecision  = tfe.RunStatus("pre_apply_awaiting_decision")
@@ -52,6 +63,56 @@ var NoopStatus = []tfe.RunStatus{
 	PreApplyAwaitingDecision,
 }
 
+// statuses a run never leaves, `run show -watch` stops once reached
+var FinalRunStatus = []tfe.RunStatus{
+	tfe.RunApplied,
+	tfe.RunPlannedAndFinished,
+	tfe.RunPlannedAndSaved,
+	tfe.RunErrored,
+	tfe.RunCanceled,
+	tfe.RunDiscarded,
+	ForceCancel,
+}
+
+// statuses of runs which hold the workspace's run queue or wait in it. Speculative runs and saved plans do not queue
+var ActiveRunStatus = []tfe.RunStatus{
+	tfe.RunPending,
+	tfe.RunFetching,
+	tfe.RunFetchingCompleted,
+	tfe.RunPrePlanRunning,
+	tfe.RunPrePlanCompleted,
+	PrePlanAwaitingDecision,
+	tfe.RunQueuing,
+	tfe.RunPlanQueued,
+	tfe.RunPlanning,
+	tfe.RunPlanned,
+	tfe.RunCostEstimating,
+	tfe.RunCostEstimated,
+	tfe.RunPolicyChecking,
+	tfe.RunPolicyOverride,
+	tfe.RunPolicySoftFailed,
+	tfe.RunPolicyChecked,
+	tfe.RunPostPlanRunning,
+	tfe.RunPostPlanCompleted,
+	PostPlanAwaitingDecision,
+	tfe.RunConfirmed,
+	tfe.RunQueuingApply,
+	tfe.RunApplyQueued,
+	tfe.RunPreApplyRunning,
+	tfe.RunPreApplyCompleted,
+	PreApplyAwaitingDecision,
+	tfe.RunApplying,
+}
+
+// the workspace's active runs which a new run queues behind
+type RunQueue struct {
+	// the workspace's current run, or the oldest active run when the current run has completed. nil when the
+	// queue is empty
+	BlockingRun *tfe.Run
+	// number of active runs ahead of a new run, 0 when a new run starts immediately
+	Position int
+}
+
 type CreateRunOptions struct {
 	Organization           string
 	Workspace              string
@@ -63,6 +124,19 @@ type CreateRunOptions struct {
 	AsyncNoLog             bool
 	RunVariables           []*tfe.RunVariable
 	TargetAddrs            []string
+	ReplaceAddrs           []string
+	// overrides the workspace's auto-apply setting, nil uses the workspace's setting
+	AutoApply *bool
+	// Terraform version of the run, only allowed for plan-only runs. Empty uses the workspace's version
+	TerraformVersion string
+	// updates the state to match the real infrastructure, without proposing configuration changes
+	RefreshOnly bool
+	// whether resources are refreshed before planning, nil uses the default of refreshing
+	Refresh *bool
+	// allows the run to be applied even when the plan has no changes
+	AllowEmptyApply bool
+	// identifies the run across retries of the command, it is appended to the run message
+	IdempotencyKey string
 }
 
 type ApplyRunOptions struct {
@@ -74,6 +148,22 @@ type GetRunOptions struct {
 	RunID string
 }
 
+type WaitRunOptions struct {
+	RunID string
+}
+
+type WatchRunOptions struct {
+	RunID string
+}
+
+type ListRunsOptions struct {
+	Organization string
+	Workspace    string
+	// comma-separated list of run statuses to filter by
+	Status   string
+	MaxItems int
+}
+
 type DiscardRunOptions struct {
 	RunID   string
 	Comment string
@@ -85,16 +175,37 @@ type CancelRunOptions struct {
 	ForceCancel bool
 }
 
+// run phases with a log
+const (
+	LogPhasePlan  = "plan"
+	LogPhaseApply = "apply"
+)
+
+type StreamLogOptions struct {
+	// skips output written before attaching, only new output is streamed
+	Tail bool
+	// the phase to stream the log of, LogPhasePlan or LogPhaseApply. Defaults to the run's current phase
+	Phase string
+}
+
 type RunService interface {
 	RunLink(context.Context, string, *tfe.Run) (string, error)
 	GetRun(context.Context, GetRunOptions) (*tfe.Run, error)
+	ListRuns(context.Context, ListRunsOptions) ([]*tfe.Run, error)
 	CreateRun(context.Context, CreateRunOptions) (*tfe.Run, error)
+	FindDuplicateRun(context.Context, CreateRunOptions) (*tfe.Run, error)
+	GetRunQueue(context.Context, string, string) (*RunQueue, error)
 	ApplyRun(context.Context, ApplyRunOptions) (*tfe.Run, error)
 	DiscardRun(context.Context, DiscardRunOptions) (*tfe.Run, error)
 	CancelRun(context.Context, CancelRunOptions) (*tfe.Run, error)
+	WaitRun(context.Context, WaitRunOptions) (*tfe.Run, error)
+	WatchRun(context.Context, WatchRunOptions) (*tfe.Run, error)
 	GetPlanLogs(context.Context, string) error
 	GetApplyLogs(context.Context, string) error
+	ReadRunLog(context.Context, *tfe.Run) (string, error)
+	StreamRunLogs(context.Context, *tfe.Run, StreamLogOptions) error
 	GetPolicyCheckLogs(context.Context, *tfe.Run) error
+	GetPolicyResults(context.Context, *tfe.Run) (*PolicyResults, error)
 	LogCostEstimation(context.Context, *tfe.Run)
 	LogTaskStage(context.Context, *tfe.Run, tfe.Stage) error
 }
@@ -103,23 +214,64 @@ type runService struct {
 	*cloudMeta
 }
 
+// returns an empty link when the run's workspace is unknown, rather than a broken url
 func (service *runService) RunLink(ctx context.Context, organization string, run *tfe.Run) (string, error) {
-	wId := run.Workspace.ID
-	tfWorkspace, err := service.tfe.Workspaces.ReadByID(ctx, wId)
-	if err != nil {
-		log.Printf("[ERROR] problem generating run link while fetching run by id: %s", wId)
+	if err := service.skipDryRun("build run link", "organization", organization, "run_id", runID(run)); err != nil {
 		return "", err
 	}
-	url := service.tfe.BaseURL()
-	link := fmt.Sprintf("%s://%s/app/%s/workspaces/%s/runs/%s", url.Scheme, url.Host, organization, tfWorkspace.Name, run.ID)
-	service.writer.Output(fmt.Sprintf("View Run in HCP Terraform: %s", link))
 
+	if run == nil || run.Workspace == nil {
+		log.Printf("[DEBUG] unable to generate run link, the run's workspace is unknown")
+		return "", nil
+	}
+
+	wName := run.Workspace.Name
+	if wName == "" {
+		tfWorkspace, err := service.tfe.Workspaces.ReadByID(ctx, run.Workspace.ID)
+		if err != nil {
+			log.Printf("[ERROR] problem generating run link while fetching run by id: %s", run.Workspace.ID)
+			return "", err
+		}
+		wName = tfWorkspace.Name
+	}
+
+	link := buildRunLink(service.tfe.BaseURL(), organization, wName, run.ID)
+	if link != "" {
+		service.writer.Output(fmt.Sprintf("View Run in HCP Terraform: %s", link))
+	}
 	return link, nil
 }
 
+// builds the run's url in the UI of the configured host, empty when any component is missing
+func buildRunLink(baseURL url.URL, organization string, workspace string, runID string) string {
+	if baseURL.Host == "" || organization == "" || workspace == "" || runID == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s://%s/app/%s/workspaces/%s/runs/%s", baseURL.Scheme, baseURL.Host,
+		url.PathEscape(organization), url.PathEscape(workspace), url.PathEscape(runID))
+}
+
+// stands in for the run read by -dry-run, every action is available so the command logs the change it would make
+func placeholderRun(runID string) *tfe.Run {
+	return &tfe.Run{
+		ID:     runID,
+		Status: tfe.RunPlanned,
+		Actions: &tfe.RunActions{
+			IsCancelable:      true,
+			IsConfirmable:     true,
+			IsDiscardable:     true,
+			IsForceCancelable: true,
+		},
+	}
+}
+
 func (service *runService) GetRun(ctx context.Context, options GetRunOptions) (*tfe.Run, error) {
+	if service.placeholderDryRun("read run", "run_id", options.RunID) {
+		return placeholderRun(options.RunID), nil
+	}
+
 	run, err := service.tfe.Runs.ReadWithOptions(ctx, options.RunID, &tfe.RunReadOptions{
-		Include: []tfe.RunIncludeOpt{"cost_estimate", "plan"},
+		Include: []tfe.RunIncludeOpt{"cost_estimate", "plan", "created_by", "configuration_version.ingress_attributes"},
 	})
 	if err != nil {
 		log.Printf("[ERROR] error reading run: %q error: %s", options.RunID, err)
@@ -128,13 +280,57 @@ func (service *runService) GetRun(ctx context.Context, options GetRunOptions) (*
 	return run, nil
 }
 
+func (service *runService) ListRuns(ctx context.Context, options ListRunsOptions) ([]*tfe.Run, error) {
+	if err := service.skipDryRun("list runs", "organization", options.Organization, "workspace", options.Workspace, "options", options); err != nil {
+		return nil, err
+	}
+
+	w, err := service.resolveWorkspace(ctx, options.Organization, options.Workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	pageSize := maxPageSize
+	if options.MaxItems > 0 && options.MaxItems < pageSize {
+		pageSize = options.MaxItems
+	}
+
+	runs := []*tfe.Run{}
+	listOpts := &tfe.RunListOptions{
+		ListOptions: tfe.ListOptions{PageNumber: 1, PageSize: pageSize},
+		Status:      options.Status,
+	}
+	for {
+		runList, listErr := service.tfe.Runs.List(ctx, w.ID, listOpts)
+		if listErr != nil {
+			log.Printf("[ERROR] error listing runs for workspace: %q error: %s", options.Workspace, listErr)
+			return nil, listErr
+		}
+
+		for _, run := range runList.Items {
+			runs = append(runs, run)
+			if options.MaxItems > 0 && len(runs) >= options.MaxItems {
+				return runs, nil
+			}
+		}
+
+		if runList.Pagination == nil || runList.NextPage == 0 {
+			return runs, nil
+		}
+		listOpts.PageNumber = runList.NextPage
+	}
+}
+
 func (service *runService) CreateRun(ctx context.Context, options CreateRunOptions) (*tfe.Run, error) {
+	if err := service.skipDryRun("create run", "organization", options.Organization, "workspace", options.Workspace, "options", options); err != nil {
+		return nil, err
+	}
+
 	var createOpts tfe.RunCreateOptions
 	var cv *tfe.ConfigurationVersion
 	// read workspace
-	w, err := service.tfe.Workspaces.Read(ctx, options.Organization, options.Workspace)
+	w, err := service.resolveWorkspace(ctx, options.Organization, options.Workspace)
 	if err != nil {
-		log.Printf("[ERROR] error reading workspace: %q organization: %q error: %s", options.Workspace, options.Organization, err)
 		return nil, err
 	}
 
@@ -150,6 +346,11 @@ func (service *runService) CreateRun(ctx context.Context, options CreateRunOptio
 		}
 		createOpts.ConfigurationVersion = cv
 
+		// HCP Terraform's rejection of another workspace's configuration version does not name the cause
+		if err := service.checkWorkspaceConfigVersion(ctx, w, cv.ID); err != nil {
+			return nil, err
+		}
+
 		// if previously specified config version as speculative only and attempting to create a run
 		// that is not, then return validation error
 		if cv.Speculative && !options.PlanOnly {
@@ -157,19 +358,50 @@ func (service *runService) CreateRun(ctx context.Context, options CreateRunOptio
 		}
 	}
 
+	// runs of VCS-backed workspaces are queued off the latest commit of the workspace's branch, which HCP Terraform
+	// ingresses when the run is created without a configuration version. A speculative plan of an uploaded
+	// configuration version is allowed, eg. to plan a pull request's changes
+	if w.VCSRepo != nil {
+		if cv != nil && !cv.Speculative {
+			return nil, fmt.Errorf("workspace %q is connected to the VCS repository %q, its runs use the latest commit of branch %q instead of an uploaded configuration version, create the run without a configuration version or plan a speculative configuration version with -plan-only",
+				w.Name, w.VCSRepo.Identifier, vcsBranch(w.VCSRepo))
+		}
+		if cv == nil {
+			log.Printf("[DEBUG] workspace: %q is connected to the VCS repository: %q, queuing the run off the latest commit of branch: %q",
+				w.Name, w.VCSRepo.Identifier, vcsBranch(w.VCSRepo))
+		}
+	}
+
 	createOpts.Workspace = w
-	createOpts.Message = &options.Message
+	createOpts.Message = tfe.String(runMessage(options))
 	createOpts.PlanOnly = tfe.Bool(options.PlanOnly)
 	createOpts.IsDestroy = tfe.Bool(options.IsDestroy)
 	createOpts.SavePlan = tfe.Bool(options.SavePlan)
 	createOpts.Variables = options.RunVariables
 	createOpts.TargetAddrs = options.TargetAddrs
+	createOpts.ReplaceAddrs = options.ReplaceAddrs
+	createOpts.AutoApply = options.AutoApply
+	if options.TerraformVersion != "" {
+		createOpts.TerraformVersion = tfe.String(options.TerraformVersion)
+	}
+	// only sent when set, so HCP Terraform's defaults apply otherwise
+	if options.RefreshOnly {
+		createOpts.RefreshOnly = tfe.Bool(true)
+	}
+	createOpts.Refresh = options.Refresh
+	if options.AllowEmptyApply {
+		createOpts.AllowEmptyApply = tfe.Bool(true)
+	}
 
 	// create the run
 	run, err := service.tfe.Runs.Create(ctx, createOpts)
 
 	if err != nil {
 		log.Printf("[ERROR] error creating run in HCP Terraform: %s", err)
+		// invalid resource addresses are only detected by HCP Terraform, include them to help diagnose the rejection
+		if len(options.TargetAddrs) > 0 || len(options.ReplaceAddrs) > 0 {
+			return nil, fmt.Errorf("run with resource addresses (target: %q, replace: %q) was rejected: %w", options.TargetAddrs, options.ReplaceAddrs, err)
+		}
 		return nil, err
 	}
 
@@ -185,7 +417,7 @@ func (service *runService) CreateRun(ctx context.Context, options CreateRunOptio
 		return run, nil
 	}
 
-	retryErr := retry.Do(ctx, defaultBackoff(), func(ctx context.Context) error {
+	retryErr := retry.Do(ctx, service.backoff(), func(ctx context.Context) error {
 		log.Printf("[DEBUG] Monitoring run status...")
 		r, err := service.GetRun(ctx, GetRunOptions{
 			RunID: run.ID,
@@ -218,16 +450,132 @@ func (service *runService) CreateRun(ctx context.Context, options CreateRunOptio
 	return run, nil
 }
 
+// the run message, including the idempotency key when there is one
+func runMessage(options CreateRunOptions) string {
+	if options.IdempotencyKey == "" {
+		return options.Message
+	}
+	return fmt.Sprintf("%s [idempotency-key: %s]", options.Message, options.IdempotencyKey)
+}
+
+// returns the run created within duplicateRunWindow whose message carries the idempotency key, eg. when a retried
+// command already created the run but its response was lost, or nil. Canceled, discarded and errored runs are not
+// returned, so retrying a failed run creates a new run. Without a key nil is returned, as a run cannot be told apart
+// from an intentional new run, eg. another step creating a run with different targets
+func (service *runService) FindDuplicateRun(ctx context.Context, options CreateRunOptions) (*tfe.Run, error) {
+	if options.IdempotencyKey == "" {
+		return nil, nil
+	}
+	if service.placeholderDryRun("find duplicate run", "organization", options.Organization, "workspace", options.Workspace,
+		"idempotency_key", options.IdempotencyKey) {
+		return nil, nil
+	}
+
+	w, err := service.resolveWorkspace(ctx, options.Organization, options.Workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	// runs are listed newest first, the first page covers the window of all but the busiest workspaces
+	runList, err := service.tfe.Runs.List(ctx, w.ID, &tfe.RunListOptions{
+		ListOptions: tfe.ListOptions{PageNumber: 1, PageSize: 20},
+	})
+	if err != nil {
+		log.Printf("[ERROR] error listing runs for workspace: %q error: %s", options.Workspace, err)
+		return nil, fmt.Errorf("failed to list runs of workspace %q: %w", options.Workspace, err)
+	}
+
+	message := runMessage(options)
+	for _, run := range runList.Items {
+		if time.Since(run.CreatedAt) > duplicateRunWindow {
+			return nil, nil
+		}
+		if run.PlanOnly != options.PlanOnly || run.IsDestroy != options.IsDestroy {
+			continue
+		}
+		if run.Message == message && !slices.Contains([]tfe.RunStatus{tfe.RunCanceled, tfe.RunDiscarded, tfe.RunErrored}, run.Status) {
+			return run, nil
+		}
+	}
+	return nil, nil
+}
+
+// an empty branch is the repository's default branch
+func vcsBranch(repo *tfe.VCSRepo) string {
+	if repo.Branch == "" {
+		return "(default)"
+	}
+	return repo.Branch
+}
+
+// lists the workspace's active runs, speculative runs are excluded as they never wait for the queue
+func (service *runService) GetRunQueue(ctx context.Context, organization string, workspace string) (*RunQueue, error) {
+	if service.placeholderDryRun("read run queue", "organization", organization, "workspace", workspace) {
+		return &RunQueue{}, nil
+	}
+
+	w, err := service.resolveWorkspace(ctx, organization, workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]string, 0, len(ActiveRunStatus))
+	for _, status := range ActiveRunStatus {
+		statuses = append(statuses, string(status))
+	}
+
+	queue := &RunQueue{}
+	listOpts := &tfe.RunListOptions{
+		ListOptions: tfe.ListOptions{PageNumber: 1, PageSize: maxPageSize},
+		Status:      strings.Join(statuses, ","),
+	}
+	for {
+		runList, listErr := service.tfe.Runs.List(ctx, w.ID, listOpts)
+		if listErr != nil {
+			log.Printf("[ERROR] error listing active runs for workspace: %q error: %s", workspace, listErr)
+			return nil, fmt.Errorf("failed to list active runs of workspace %q: %w", workspace, listErr)
+		}
+
+		// runs are listed newest first, so the last active run is the oldest
+		for _, run := range runList.Items {
+			if run.PlanOnly {
+				continue
+			}
+			queue.Position++
+			if queue.BlockingRun == nil || queue.BlockingRun.ID != runID(w.CurrentRun) {
+				queue.BlockingRun = run
+			}
+		}
+
+		if runList.Pagination == nil || runList.NextPage == 0 {
+			return queue, nil
+		}
+		listOpts.PageNumber = runList.NextPage
+	}
+}
+
+// omits an empty comment from run actions, so the run history only records comments that were provided
+func optionalComment(comment string) *string {
+	if comment == "" {
+		return nil
+	}
+	return tfe.String(comment)
+}
+
 func (service *runService) ApplyRun(ctx context.Context, options ApplyRunOptions) (*tfe.Run, error) {
+	if err := service.skipDryRun("apply run", "run_id", options.RunID, "options", options); err != nil {
+		return nil, err
+	}
+
 	var applyRun *tfe.Run
 	if err := service.tfe.Runs.Apply(ctx, options.RunID, tfe.RunApplyOptions{
-		Comment: tfe.String(options.Comment),
+		Comment: optionalComment(options.Comment),
 	}); err != nil {
 		log.Printf("[ERROR] error applying run: %q error: %s", options.RunID, err)
 		return applyRun, err
 	}
 
-	if retryErr := retry.Do(ctx, defaultBackoff(), func(ctx context.Context) error {
+	if retryErr := retry.Do(ctx, service.backoff(), func(ctx context.Context) error {
 		log.Printf("[DEBUG] Monitoring apply run status...")
 
 		run, runErr := service.GetRun(ctx, GetRunOptions{
@@ -259,15 +607,19 @@ func (service *runService) ApplyRun(ctx context.Context, options ApplyRunOptions
 }
 
 func (service *runService) DiscardRun(ctx context.Context, options DiscardRunOptions) (*tfe.Run, error) {
+	if err := service.skipDryRun("discard run", "run_id", options.RunID, "options", options); err != nil {
+		return nil, err
+	}
+
 	var discardRun *tfe.Run
 	if err := service.tfe.Runs.Discard(ctx, options.RunID, tfe.RunDiscardOptions{
-		Comment: &options.Comment,
+		Comment: optionalComment(options.Comment),
 	}); err != nil {
 		log.Printf("[ERROR] error discarding run: %q error: %s", options.RunID, err.Error())
 		return discardRun, err
 	}
 
-	if retryErr := retry.Do(ctx, defaultBackoff(), func(context context.Context) error {
+	if retryErr := retry.Do(ctx, service.backoff(), func(context context.Context) error {
 		log.Printf("[DEBUG] Monitoring discard run status...")
 		run, runErr := service.GetRun(ctx, GetRunOptions{
 			RunID: options.RunID,
@@ -298,15 +650,19 @@ func (service *runService) DiscardRun(ctx context.Context, options DiscardRunOpt
 }
 
 func (service *runService) CancelRun(ctx context.Context, options CancelRunOptions) (*tfe.Run, error) {
+	if err := service.skipDryRun("cancel run", "run_id", options.RunID, "options", options); err != nil {
+		return nil, err
+	}
+
 	var cancelRun *tfe.Run
 	var err error
 	if options.ForceCancel {
 		err = service.tfe.Runs.ForceCancel(ctx, options.RunID, tfe.RunForceCancelOptions{
-			Comment: &options.Comment,
+			Comment: optionalComment(options.Comment),
 		})
 	} else {
 		err = service.tfe.Runs.Cancel(ctx, options.RunID, tfe.RunCancelOptions{
-			Comment: &options.Comment,
+			Comment: optionalComment(options.Comment),
 		})
 	}
 
@@ -315,7 +671,7 @@ func (service *runService) CancelRun(ctx context.Context, options CancelRunOptio
 		return cancelRun, err
 	}
 
-	retryErr := retry.Do(ctx, defaultBackoff(), func(context context.Context) error {
+	retryErr := retry.Do(ctx, service.backoff(), func(context context.Context) error {
 		log.Printf("[DEBUG] Monitoring cancel run status...")
 		run, runErr := service.GetRun(ctx, GetRunOptions{
 			RunID: options.RunID,
@@ -346,7 +702,92 @@ func (service *runService) CancelRun(ctx context.Context, options CancelRunOptio
 	return cancelRun, nil
 }
 
+// polls an existing run until it reaches the same status `run create` would wait for,
+// returning an error if the run errored, was canceled or discarded
+func (service *runService) WaitRun(ctx context.Context, options WaitRunOptions) (*tfe.Run, error) {
+	if err := service.skipDryRun("wait for run", "run_id", options.RunID); err != nil {
+		return nil, err
+	}
+
+	waitRun, err := service.GetRun(ctx, GetRunOptions{
+		RunID: options.RunID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	desiredStatus := getDesiredRunStatus(waitRun, hasPolicyChecks(waitRun), hasCostEstimate(waitRun))
+
+	retryErr := retry.Do(ctx, service.backoff(), func(ctx context.Context) error {
+		log.Printf("[DEBUG] Monitoring run status...")
+		run, runErr := service.GetRun(ctx, GetRunOptions{
+			RunID: options.RunID,
+		})
+		if runErr != nil {
+			return runErr
+		}
+
+		waitRun = run
+		service.writer.Output(fmt.Sprintf("Run Status: %q", run.Status))
+
+		done, err := isRunComplete(run, desiredStatus, NoopStatus)
+		if err != nil {
+			return err
+		}
+
+		if done {
+			return nil
+		}
+		return retryableTimeoutError("wait run")
+	})
+	if retryErr != nil {
+		return waitRun, retryErr
+	}
+
+	return waitRun, nil
+}
+
+// polls the run until it reaches a final status, writing each status transition with the time it was observed.
+// Unlike WaitRun, a run which errored or was canceled is not an error, and a run paused for confirmation is
+// watched until it is confirmed or discarded
+func (service *runService) WatchRun(ctx context.Context, options WatchRunOptions) (*tfe.Run, error) {
+	if err := service.skipDryRun("watch run", "run_id", options.RunID); err != nil {
+		return nil, err
+	}
+
+	var watchRun *tfe.Run
+	retryErr := retry.Do(ctx, service.backoff(), func(ctx context.Context) error {
+		run, runErr := service.GetRun(ctx, GetRunOptions{
+			RunID: options.RunID,
+		})
+		if runErr != nil {
+			return runErr
+		}
+
+		if watchRun == nil {
+			service.writer.Output(fmt.Sprintf("%s Run Status: %q", time.Now().Format(time.RFC3339), run.Status))
+		} else if run.Status != watchRun.Status {
+			service.writer.Output(fmt.Sprintf("%s Run Status: %q -> %q", time.Now().Format(time.RFC3339), watchRun.Status, run.Status))
+		}
+		watchRun = run
+
+		if slices.Contains(FinalRunStatus, run.Status) {
+			return nil
+		}
+		return retryableTimeoutError("watch run")
+	})
+	if retryErr != nil {
+		return watchRun, retryErr
+	}
+
+	return watchRun, nil
+}
+
 func (service *runService) GetPlanLogs(ctx context.Context, planID string) error {
+	if err := service.skipDryRun("read plan logs", "plan_id", planID); err != nil {
+		return err
+	}
+
 	ctxTimeout, cancel := context.WithTimeout(ctx, LogTimeout)
 	defer cancel()
 
@@ -358,15 +799,21 @@ func (service *runService) GetPlanLogs(ctx context.Context, planID string) error
 	}
 
 	service.writer.Output(fmt.Sprintf("-------------- %s --------------", "Plan Log"))
-	err = outputRunLogLines(logReader, service.writer)
+	logWriter, closeTee := service.teeLog("Plan Log")
+	defer closeTee()
+	err = outputRunLogLines(logReader, logWriter)
 	if err != nil {
 		return err
 	}
-	fmt.Println()
+	service.writer.Output("")
 	return nil
 }
 
 func (service *runService) GetApplyLogs(ctx context.Context, applyID string) error {
+	if err := service.skipDryRun("read apply logs", "apply_id", applyID); err != nil {
+		return err
+	}
+
 	ctxTimeout, cancel := context.WithTimeout(ctx, LogTimeout)
 	defer cancel()
 
@@ -378,15 +825,194 @@ func (service *runService) GetApplyLogs(ctx context.Context, applyID string) err
 	}
 
 	service.writer.Output(fmt.Sprintf("-------------- %s --------------", "Apply Log"))
-	err = outputRunLogLines(logReader, service.writer)
+	logWriter, closeTee := service.teeLog("Apply Log")
+	defer closeTee()
+	err = outputRunLogLines(logReader, logWriter)
 	if err != nil {
 		return err
 	}
-	fmt.Println()
+	service.writer.Output("")
 	return nil
 }
 
+// bounds the size of a log read into memory, the end of the log containing any errors is kept
+const maxRunLogBytes = 1 << 20
+
+// reads the log of the run's last phase, the apply log when the apply errored and otherwise the plan log
+func (service *runService) ReadRunLog(ctx context.Context, run *tfe.Run) (string, error) {
+	if err := service.skipDryRun("read run log", "run_id", runID(run)); err != nil {
+		return "", err
+	}
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, LogTimeout)
+	defer cancel()
+
+	applyErrored := false
+	if run.Apply != nil && run.Apply.ID != "" {
+		apply, err := service.tfe.Applies.Read(ctxTimeout, run.Apply.ID)
+		if err != nil {
+			return "", err
+		}
+		applyErrored = apply.Status == tfe.ApplyErrored
+	}
+
+	var logReader io.Reader
+	var err error
+	switch {
+	case applyErrored:
+		logReader, err = service.tfe.Applies.Logs(ctxTimeout, run.Apply.ID)
+	case run.Plan != nil:
+		logReader, err = service.tfe.Plans.Logs(ctxTimeout, run.Plan.ID)
+	default:
+		return "", fmt.Errorf("run %s does not have a plan or apply to read logs from", run.ID)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	content, err := io.ReadAll(logReader)
+	if err != nil {
+		return "", err
+	}
+	if len(content) > maxRunLogBytes {
+		content = content[len(content)-maxRunLogBytes:]
+	}
+	return string(content), nil
+}
+
+// streams the log of the run's current or selected phase until it completes, eg. when attaching to an in-progress run.
+// The log is written line by line as it is read, it is never buffered in memory as a whole.
+func (service *runService) StreamRunLogs(ctx context.Context, run *tfe.Run, options StreamLogOptions) error {
+	if err := service.skipDryRun("stream run logs", "run_id", runID(run), "options", options); err != nil {
+		return err
+	}
+
+	timeout := service.timeout
+	if timeout <= 0 {
+		timeout = Timeout()
+	}
+	ctxTimeout, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	phase := options.Phase
+	if phase == "" && run.Apply != nil && isApplyPhase(run.Status) {
+		phase = LogPhaseApply
+	}
+
+	label, logURL := "Plan Log", ""
+	var logs func(context.Context) (io.Reader, error)
+	switch {
+	case phase == LogPhaseApply && run.Apply == nil:
+		return fmt.Errorf("run %s does not have an apply to stream logs from", run.ID)
+	case phase == LogPhaseApply:
+		apply, err := service.tfe.Applies.Read(ctxTimeout, run.Apply.ID)
+		if err != nil {
+			return err
+		}
+		label, logURL = "Apply Log", apply.LogReadURL
+		logs = func(ctx context.Context) (io.Reader, error) { return service.tfe.Applies.Logs(ctx, apply.ID) }
+	case run.Plan != nil:
+		plan, err := service.tfe.Plans.Read(ctxTimeout, run.Plan.ID)
+		if err != nil {
+			return err
+		}
+		logURL = plan.LogReadURL
+		logs = func(ctx context.Context) (io.Reader, error) { return service.tfe.Plans.Logs(ctx, plan.ID) }
+	default:
+		return fmt.Errorf("run %s does not have a plan or apply to stream logs from", run.ID)
+	}
+
+	var offset int64
+	if options.Tail {
+		size, err := service.currentLogSize(ctxTimeout, logURL)
+		if err != nil {
+			return err
+		}
+		offset = size
+		log.Printf("[DEBUG] tailing %s from offset: %d", label, offset)
+	}
+
+	logReader, err := logs(ctxTimeout)
+	if err != nil {
+		return err
+	}
+	// the log reader tracks its own offset between polls, historical output is discarded up front
+	if offset > 0 {
+		if _, err := io.CopyN(io.Discard, logReader, offset); err != nil && err != io.EOF {
+			return err
+		}
+	}
+
+	service.writer.Output(fmt.Sprintf("-------------- %s --------------", label))
+	logWriter, closeTee := service.teeLog(label)
+	defer closeTee()
+	if err := outputRunLogLines(logReader, logWriter); err != nil {
+		return err
+	}
+	service.writer.Output("")
+	return nil
+}
+
+func isApplyPhase(status tfe.RunStatus) bool {
+	switch status {
+	case tfe.RunApplyQueued, tfe.RunApplying, tfe.RunApplied:
+		return true
+	default:
+		return false
+	}
+}
+
+// returns the length of the log output written so far, excluding the start of text marker which is
+// stripped by the go-tfe log reader. Only the first byte is requested, the total length is read from
+// the Content-Range of the partial response, so the log is never downloaded to measure it. Output
+// written after the length is read is streamed as new output.
+func (service *runService) currentLogSize(ctx context.Context, logURL string) (int64, error) {
+	if logURL == "" {
+		return 0, errors.New("log url is not available")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, logURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Range", "bytes=0-0")
+
+	transport := service.transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := (&http.Client{Transport: newTraceTransport(transport)}).Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusRequestedRangeNotSatisfiable:
+		// nothing has been written yet
+		return 0, nil
+	case http.StatusPartialContent:
+	default:
+		return 0, fmt.Errorf("unexpected status reading log size: %s", resp.Status)
+	}
+
+	// eg. `bytes 0-0/1024`, the total is `*` when the server does not know it
+	contentRange := resp.Header.Get("Content-Range")
+	total, err := strconv.ParseInt(contentRange[strings.LastIndex(contentRange, "/")+1:], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected content range reading log size: %q", contentRange)
+	}
+	first := make([]byte, 1)
+	if n, _ := io.ReadFull(resp.Body, first); n == 1 && first[0] == 2 {
+		total--
+	}
+	return total, nil
+}
+
 func (s *runService) GetPolicyCheckLogs(ctx context.Context, run *tfe.Run) error {
+	if err := s.skipDryRun("read policy check logs", "run_id", runID(run)); err != nil {
+		return err
+	}
+
 	if !(len(run.PolicyChecks) > 0) {
 		return nil
 	}
@@ -397,7 +1023,7 @@ func (s *runService) GetPolicyCheckLogs(ctx context.Context, run *tfe.Run) error
 	}
 
 	logStart := true
-	fmt.Println()
+	s.writer.Output("")
 	for _, pcheck := range policyChecks.Items {
 		ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*10)
 		defer cancel()
@@ -424,13 +1050,17 @@ func (s *runService) GetPolicyCheckLogs(ctx context.Context, run *tfe.Run) error
 		if err != nil {
 			return err
 		}
-		fmt.Println()
+		s.writer.Output("")
 	}
 
 	return nil
 }
 
 func (s *runService) LogTaskStage(ctx context.Context, run *tfe.Run, stage tfe.Stage) error {
+	if err := s.skipDryRun("read task stage", "run_id", runID(run)); err != nil {
+		return err
+	}
+
 	taskStages, err := s.tfe.TaskStages.List(ctx, run.ID, &tfe.TaskStageListOptions{})
 	if err != nil {
 		return err
@@ -445,7 +1075,7 @@ func (s *runService) LogTaskStage(ctx context.Context, run *tfe.Run, stage tfe.S
 		"pre_apply": "Pre Apply",
 	}
 
-	fmt.Println()
+	s.writer.Output("")
 	for _, task := range taskStages.Items {
 		if task.Stage == stage {
 			s.writer.Output(fmt.Sprintf("-------------- %s --------------", labelMap[string(stage)]))
@@ -465,13 +1095,17 @@ func (s *runService) LogTaskStage(ctx context.Context, run *tfe.Run, stage tfe.S
 				s.writer.Output(fmt.Sprintf("- PolicyEvalutation (%s), Status: '%s', PolicyKind: '%s'", p.ID, p.Status, p.PolicyKind))
 				s.writer.Output(fmt.Sprintf("  Passed: (%d), AdvisoryFailed: (%d), MandatoryFailed: (%d), Failed: (%d)", p.ResultCount.Passed, p.ResultCount.AdvisoryFailed, p.ResultCount.MandatoryFailed, p.ResultCount.Errored))
 			}
-			fmt.Println()
+			s.writer.Output("")
 		}
 	}
 	return nil
 }
 
 func (s *runService) LogCostEstimation(ctx context.Context, run *tfe.Run) {
+	if err := s.skipDryRun("read cost estimation", "run_id", runID(run)); err != nil {
+		return
+	}
+
 	if run.CostEstimate == nil || run.CostEstimate.Status == tfe.CostEstimateStatus("unreachable") || run.CostEstimate.Status == tfe.CostEstimatePending {
 		return
 	}
@@ -479,7 +1113,7 @@ func (s *runService) LogCostEstimation(ctx context.Context, run *tfe.Run) {
 	s.writer.Output(fmt.Sprintf("-------------- CostEstimation (%s) --------------", run.CostEstimate.ID))
 	s.writer.Output(fmt.Sprintf("Status: %q, ErrorMessage: %q", run.CostEstimate.Status, run.CostEstimate.ErrorMessage))
 	s.writer.Output(fmt.Sprintf("PriorMonthlyCost: (%s), ProposedMonthlyCost: (%s), Delta: (%s)", run.CostEstimate.PriorMonthlyCost, run.CostEstimate.ProposedMonthlyCost, run.CostEstimate.DeltaMonthlyCost))
-	fmt.Println()
+	s.writer.Output("")
 }
 
 func outputRunLogLines(logs io.Reader, writer Writer) error {
@@ -506,6 +1140,36 @@ func outputRunLogLines(logs io.Reader, writer Writer) error {
 	return nil
 }
 
+// returns a writer which additionally keeps the trailing log lines for the log tee, if configured.
+// The returned func passes the kept lines to the log tee and must be called once the log completes.
+func (m *cloudMeta) teeLog(label string) (Writer, func()) {
+	if m.logTee == nil {
+		return m.writer, func() {}
+	}
+
+	tail := &logTail{Writer: m.writer, max: m.logTee.MaxLines()}
+	return tail, func() {
+		m.logTee.TeeLog(label, tail.lines, tail.omitted)
+	}
+}
+
+// keeps the last max lines written to the log
+type logTail struct {
+	Writer
+	max     int
+	lines   []string
+	omitted int
+}
+
+func (t *logTail) Output(msg string) {
+	t.Writer.Output(msg)
+	t.lines = append(t.lines, msg)
+	if t.max > 0 && len(t.lines) > t.max {
+		t.lines = t.lines[1:]
+		t.omitted++
+	}
+}
+
 func NewRunService(meta *cloudMeta) RunService {
 	return &runService{meta}
 }
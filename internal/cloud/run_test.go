@@ -5,10 +5,18 @@ package cloud
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/go-tfe"
 	"github.com/hashicorp/go-tfe/mocks"
+	"github.com/hashicorp/tfci/internal/writer"
+	"github.com/mitchellh/cli"
 	"go.uber.org/mock/gomock"
 )
 
@@ -37,6 +45,9 @@ func testGenerateServiceMocks(t *testing.T, ctrl *gomock.Controller, tc createRu
 		tc.tfeConfigVersion,
 		nil,
 	)
+	configVersionMock.EXPECT().List(tc.ctx, tc.tfeWorkspace.ID, gomock.Any()).Return(&tfe.ConfigurationVersionList{
+		Items: []*tfe.ConfigurationVersion{tc.tfeConfigVersion},
+	}, nil)
 
 	runsMock := mocks.NewMockRuns(ctrl)
 	runsMock.EXPECT().Create(tc.ctx, tfe.RunCreateOptions{
@@ -192,6 +203,8 @@ func TestRunService_CreateRun(t *testing.T) {
 				Include: []tfe.RunIncludeOpt{
 					"cost_estimate",
 					"plan",
+					"created_by",
+					"configuration_version.ingress_attributes",
 				},
 			}
 
@@ -242,3 +255,847 @@ func TestRunService_CreateRun(t *testing.T) {
 		})
 	}
 }
+
+func TestRunService_CreateRun_ConfigVersionOfOtherWorkspace(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	listOpts := func(page int) *tfe.ConfigurationVersionListOptions {
+		return &tfe.ConfigurationVersionListOptions{ListOptions: tfe.ListOptions{PageNumber: page, PageSize: maxPageSize}}
+	}
+
+	workspaceMock := mocks.NewMockWorkspaces(ctrl)
+	workspaceMock.EXPECT().Read(ctx, "test", "my-workspace").Return(&tfe.Workspace{ID: "ws-***", Name: "my-workspace"}, nil)
+	configVersionMock := mocks.NewMockConfigurationVersions(ctrl)
+	configVersionMock.EXPECT().Read(ctx, "cv-other").Return(&tfe.ConfigurationVersion{ID: "cv-other"}, nil)
+	gomock.InOrder(
+		configVersionMock.EXPECT().List(ctx, "ws-***", listOpts(1)).Return(&tfe.ConfigurationVersionList{
+			Items:      []*tfe.ConfigurationVersion{{ID: "cv-1"}},
+			Pagination: &tfe.Pagination{CurrentPage: 1, NextPage: 2},
+		}, nil),
+		configVersionMock.EXPECT().List(ctx, "ws-***", listOpts(2)).Return(&tfe.ConfigurationVersionList{
+			Items:      []*tfe.ConfigurationVersion{{ID: "cv-2"}},
+			Pagination: &tfe.Pagination{CurrentPage: 2},
+		}, nil),
+	)
+	// no run is created
+	runsMock := mocks.NewMockRuns(ctrl)
+
+	client := NewRunService(&cloudMeta{
+		tfe:    &tfe.Client{Workspaces: workspaceMock, ConfigurationVersions: configVersionMock, Runs: runsMock},
+		writer: &defaultWriter{},
+	})
+	_, err := client.CreateRun(ctx, CreateRunOptions{
+		Organization:           "test",
+		Workspace:              "my-workspace",
+		ConfigurationVersionID: "cv-other",
+	})
+	if err == nil || !strings.Contains(err.Error(), `configuration version "cv-other" does not belong to workspace "my-workspace"`) {
+		t.Errorf("expected an error naming the workspace but received %v", err)
+	}
+}
+
+func TestRunService_CreateRun_VCSWorkspace(t *testing.T) {
+	ctx := context.Background()
+	vcsWorkspace := &tfe.Workspace{
+		ID:      "ws-***",
+		Name:    "my-workspace",
+		VCSRepo: &tfe.VCSRepo{Identifier: "hashicorp/infra", Branch: "main"},
+	}
+
+	t.Run("latest-commit", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		workspaceMock := mocks.NewMockWorkspaces(ctrl)
+		workspaceMock.EXPECT().Read(ctx, "test", "my-workspace").Return(vcsWorkspace, nil)
+		// no configuration version is read or uploaded
+		configVersionMock := mocks.NewMockConfigurationVersions(ctrl)
+		runsMock := mocks.NewMockRuns(ctrl)
+		runsMock.EXPECT().Create(ctx, tfe.RunCreateOptions{
+			Workspace: vcsWorkspace,
+			Message:   tfe.String(""),
+			PlanOnly:  tfe.Bool(false),
+			IsDestroy: tfe.Bool(false),
+			SavePlan:  tfe.Bool(false),
+		}).Return(&tfe.Run{ID: "run-***", Status: tfe.RunPending}, nil)
+
+		client := NewRunService(&cloudMeta{
+			tfe:    &tfe.Client{Workspaces: workspaceMock, ConfigurationVersions: configVersionMock, Runs: runsMock},
+			writer: &defaultWriter{},
+		})
+		run, err := client.CreateRun(ctx, CreateRunOptions{Organization: "test", Workspace: "my-workspace", AsyncNoLog: true})
+		if err != nil {
+			t.Fatalf("expected no error but received %s", err)
+		}
+		if run.ID != "run-***" {
+			t.Errorf("expected run %q but received %q", "run-***", run.ID)
+		}
+	})
+
+	t.Run("configuration-version", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		workspaceMock := mocks.NewMockWorkspaces(ctrl)
+		workspaceMock.EXPECT().Read(ctx, "test", "my-workspace").Return(vcsWorkspace, nil)
+		configVersionMock := mocks.NewMockConfigurationVersions(ctrl)
+		configVersionMock.EXPECT().Read(ctx, "cv-***").Return(&tfe.ConfigurationVersion{ID: "cv-***"}, nil)
+		configVersionMock.EXPECT().List(ctx, "ws-***", gomock.Any()).
+			Return(&tfe.ConfigurationVersionList{Items: []*tfe.ConfigurationVersion{{ID: "cv-***"}}}, nil)
+		// no run is created
+		client := NewRunService(&cloudMeta{
+			tfe: &tfe.Client{
+				Workspaces:            workspaceMock,
+				ConfigurationVersions: configVersionMock,
+				Runs:                  mocks.NewMockRuns(ctrl),
+			},
+			writer: &defaultWriter{},
+		})
+		_, err := client.CreateRun(ctx, CreateRunOptions{Organization: "test", Workspace: "my-workspace", ConfigurationVersionID: "cv-***"})
+		if err == nil || !strings.Contains(err.Error(), `workspace "my-workspace" is connected to the VCS repository "hashicorp/infra"`) {
+			t.Errorf("expected an error naming the repository but received %v", err)
+		}
+	})
+
+	t.Run("speculative-configuration-version", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		workspaceMock := mocks.NewMockWorkspaces(ctrl)
+		workspaceMock.EXPECT().Read(ctx, "test", "my-workspace").Return(vcsWorkspace, nil)
+		speculative := &tfe.ConfigurationVersion{ID: "cv-***", Speculative: true}
+		configVersionMock := mocks.NewMockConfigurationVersions(ctrl)
+		configVersionMock.EXPECT().Read(ctx, "cv-***").Return(speculative, nil)
+		configVersionMock.EXPECT().List(ctx, "ws-***", gomock.Any()).
+			Return(&tfe.ConfigurationVersionList{Items: []*tfe.ConfigurationVersion{speculative}}, nil)
+		runsMock := mocks.NewMockRuns(ctrl)
+		runsMock.EXPECT().Create(ctx, gomock.Any()).
+			DoAndReturn(func(_ context.Context, options tfe.RunCreateOptions) (*tfe.Run, error) {
+				if options.ConfigurationVersion != speculative || !*options.PlanOnly {
+					t.Errorf("expected a plan-only run of the speculative configuration version but received %+v", options)
+				}
+				return &tfe.Run{ID: "run-***", Status: tfe.RunPending}, nil
+			})
+
+		client := NewRunService(&cloudMeta{
+			tfe:    &tfe.Client{Workspaces: workspaceMock, ConfigurationVersions: configVersionMock, Runs: runsMock},
+			writer: &defaultWriter{},
+		})
+		run, err := client.CreateRun(ctx, CreateRunOptions{Organization: "test", Workspace: "my-workspace", ConfigurationVersionID: "cv-***",
+			PlanOnly: true, AsyncNoLog: true})
+		if err != nil {
+			t.Fatalf("expected no error but received %s", err)
+		}
+		if run.ID != "run-***" {
+			t.Errorf("expected run %q but received %q", "run-***", run.ID)
+		}
+	})
+}
+
+func TestRunService_DryRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// no calls are expected, any request fails the test
+	m := &cloudMeta{
+		tfe: &tfe.Client{
+			Workspaces: mocks.NewMockWorkspaces(ctrl),
+			Runs:       mocks.NewMockRuns(ctrl),
+		},
+		writer: &defaultWriter{},
+		dryRun: true,
+	}
+	client := NewRunService(m)
+
+	run, err := client.CreateRun(context.Background(), CreateRunOptions{
+		Organization: "test",
+		Workspace:    "my-workspace",
+	})
+	if run != nil {
+		t.Errorf("expected no run but received %v", run)
+	}
+	var dryRunErr *DryRunError
+	if !errors.As(err, &dryRunErr) {
+		t.Fatalf("expected a dry run error but received %v", err)
+	}
+	if dryRunErr.Operation != "create run" {
+		t.Errorf("expected operation %q but received %q", "create run", dryRunErr.Operation)
+	}
+
+	// reads return a placeholder, so commands reach the change they would make
+	read, err := client.GetRun(context.Background(), GetRunOptions{RunID: "run-123"})
+	if err != nil {
+		t.Fatalf("expected no error reading the run but received %v", err)
+	}
+	if read.ID != "run-123" || !read.Actions.IsConfirmable || !read.Actions.IsDiscardable || !read.Actions.IsCancelable {
+		t.Errorf("expected a placeholder run allowing every action but received %+v", read)
+	}
+	queue, err := client.GetRunQueue(context.Background(), "test", "my-workspace")
+	if err != nil || queue.BlockingRun != nil {
+		t.Errorf("expected an empty placeholder queue but received %v, %v", queue, err)
+	}
+	policies, err := client.GetPolicyResults(context.Background(), read)
+	if err != nil || len(policies.Outcomes) != 0 {
+		t.Errorf("expected empty placeholder policy results but received %v, %v", policies, err)
+	}
+}
+
+func TestRunService_RunLink(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// a Terraform Enterprise host, the link must use the configured host
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	config := tfe.DefaultConfig()
+	config.Address = server.URL
+	config.Token = "token"
+	tfeClient, err := tfe.NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name         string
+		organization string
+		run          *tfe.Run
+		readByID     bool
+		expected     string
+	}{
+		{
+			name:         "workspace-read-by-id",
+			organization: "my-org",
+			run:          &tfe.Run{ID: "run-CZcmD7eagjhyX0vN", Workspace: &tfe.Workspace{ID: "ws-***"}},
+			readByID:     true,
+			expected:     server.URL + "/app/my-org/workspaces/my-workspace/runs/run-CZcmD7eagjhyX0vN",
+		},
+		{
+			name:         "workspace-name-included",
+			organization: "my-org",
+			run:          &tfe.Run{ID: "run-CZcmD7eagjhyX0vN", Workspace: &tfe.Workspace{ID: "ws-***", Name: "other-workspace"}},
+			expected:     server.URL + "/app/my-org/workspaces/other-workspace/runs/run-CZcmD7eagjhyX0vN",
+		},
+		{
+			name:         "missing-workspace",
+			organization: "my-org",
+			run:          &tfe.Run{ID: "run-CZcmD7eagjhyX0vN"},
+		},
+		{
+			name: "missing-organization",
+			run:  &tfe.Run{ID: "run-CZcmD7eagjhyX0vN", Workspace: &tfe.Workspace{ID: "ws-***", Name: "my-workspace"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mWorkspaces := mocks.NewMockWorkspaces(ctrl)
+			if tc.readByID {
+				mWorkspaces.EXPECT().ReadByID(gomock.Any(), "ws-***").Return(&tfe.Workspace{ID: "ws-***", Name: "my-workspace"}, nil)
+			}
+			tfeClient.Workspaces = mWorkspaces
+
+			service := NewRunService(&cloudMeta{tfe: tfeClient, writer: &defaultWriter{}})
+			link, err := service.RunLink(context.Background(), tc.organization, tc.run)
+			if err != nil {
+				t.Fatalf("expected no error but received %s", err)
+			}
+			if link != tc.expected {
+				t.Errorf("expected link %q but received %q", tc.expected, link)
+			}
+		})
+	}
+}
+
+func TestRunService_ListRuns(t *testing.T) {
+	testCases := []struct {
+		name     string
+		maxItems int
+		pages    [][]*tfe.Run
+		expected []string
+	}{
+		{
+			name:     "single-page",
+			maxItems: 20,
+			pages: [][]*tfe.Run{
+				{{ID: "run-1"}, {ID: "run-2"}},
+			},
+			expected: []string{"run-1", "run-2"},
+		},
+		{
+			name:     "multiple-pages",
+			maxItems: 0,
+			pages: [][]*tfe.Run{
+				{{ID: "run-1"}, {ID: "run-2"}},
+				{{ID: "run-3"}},
+			},
+			expected: []string{"run-1", "run-2", "run-3"},
+		},
+		{
+			name:     "max-items-cap",
+			maxItems: 3,
+			pages: [][]*tfe.Run{
+				{{ID: "run-1"}, {ID: "run-2"}},
+				{{ID: "run-3"}, {ID: "run-4"}},
+			},
+			expected: []string{"run-1", "run-2", "run-3"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx := context.Background()
+			workspaceMock := mocks.NewMockWorkspaces(ctrl)
+			workspaceMock.EXPECT().Read(ctx, "test", "my-workspace").Return(&tfe.Workspace{ID: "ws-***"}, nil)
+
+			runsMock := mocks.NewMockRuns(ctrl)
+			for i, page := range tc.pages {
+				nextPage := i + 2
+				if i == len(tc.pages)-1 {
+					nextPage = 0
+				}
+				runsMock.EXPECT().List(ctx, "ws-***", gomock.Any()).Return(&tfe.RunList{
+					Pagination: &tfe.Pagination{CurrentPage: i + 1, NextPage: nextPage},
+					Items:      page,
+				}, nil)
+			}
+
+			client := NewRunService(&cloudMeta{
+				tfe: &tfe.Client{
+					Workspaces: workspaceMock,
+					Runs:       runsMock,
+				},
+				writer: &defaultWriter{},
+			})
+
+			runs, err := client.ListRuns(ctx, ListRunsOptions{
+				Organization: "test",
+				Workspace:    "my-workspace",
+				MaxItems:     tc.maxItems,
+			})
+			if err != nil {
+				t.Fatalf("expected %v but received %s", nil, err)
+			}
+
+			actual := []string{}
+			for _, run := range runs {
+				actual = append(actual, run.ID)
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %v but received %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRunService_GetRunQueue(t *testing.T) {
+	testCases := []struct {
+		name             string
+		currentRun       *tfe.Run
+		pages            [][]*tfe.Run
+		expectedBlocking string
+		expectedPosition int
+	}{
+		{
+			name:  "idle",
+			pages: [][]*tfe.Run{{}},
+		},
+		{
+			name:       "current-run-and-pending",
+			currentRun: &tfe.Run{ID: "run-current"},
+			pages: [][]*tfe.Run{
+				{{ID: "run-pending-2"}, {ID: "run-pending-1"}},
+				{{ID: "run-current"}},
+			},
+			expectedBlocking: "run-current",
+			expectedPosition: 3,
+		},
+		{
+			// the current run completed, the oldest pending run is next
+			name:       "current-run-completed",
+			currentRun: &tfe.Run{ID: "run-applied"},
+			pages: [][]*tfe.Run{
+				{{ID: "run-pending-2"}, {ID: "run-pending-1"}, {ID: "run-speculative", PlanOnly: true}},
+			},
+			expectedBlocking: "run-pending-1",
+			expectedPosition: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx := context.Background()
+			workspaceMock := mocks.NewMockWorkspaces(ctrl)
+			workspaceMock.EXPECT().Read(ctx, "test", "my-workspace").Return(&tfe.Workspace{ID: "ws-***", CurrentRun: tc.currentRun}, nil)
+
+			runsMock := mocks.NewMockRuns(ctrl)
+			for i, page := range tc.pages {
+				nextPage := i + 2
+				if i == len(tc.pages)-1 {
+					nextPage = 0
+				}
+				runsMock.EXPECT().List(ctx, "ws-***", gomock.Any()).DoAndReturn(func(_ context.Context, _ string, options *tfe.RunListOptions) (*tfe.RunList, error) {
+					if !strings.Contains(options.Status, string(tfe.RunPending)) || strings.Contains(options.Status, string(tfe.RunApplied)) {
+						t.Errorf("expected only active run statuses but received %q", options.Status)
+					}
+					return &tfe.RunList{
+						Pagination: &tfe.Pagination{CurrentPage: i + 1, NextPage: nextPage},
+						Items:      page,
+					}, nil
+				})
+			}
+
+			client := NewRunService(&cloudMeta{
+				tfe:    &tfe.Client{Workspaces: workspaceMock, Runs: runsMock},
+				writer: &defaultWriter{},
+			})
+
+			queue, err := client.GetRunQueue(ctx, "test", "my-workspace")
+			if err != nil {
+				t.Fatalf("expected no error but received %s", err)
+			}
+			if blocking := runID(queue.BlockingRun); blocking != tc.expectedBlocking {
+				t.Errorf("expected blocking run %q but received %q", tc.expectedBlocking, blocking)
+			}
+			if queue.Position != tc.expectedPosition {
+				t.Errorf("expected position %d but received %d", tc.expectedPosition, queue.Position)
+			}
+		})
+	}
+}
+
+func TestRunService_FindDuplicateRun(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name           string
+		options        CreateRunOptions
+		runs           []*tfe.Run
+		expectedRunID  string
+		expectNoLookup bool
+	}{
+		{
+			name:    "outside-window",
+			options: CreateRunOptions{Message: "Triggered by tfci", IdempotencyKey: "job-123"},
+			runs: []*tfe.Run{
+				{ID: "run-old", Status: tfe.RunPending, Message: "Triggered by tfci [idempotency-key: job-123]", CreatedAt: now.Add(-time.Hour)},
+			},
+		},
+		{
+			name:    "idempotency-key",
+			options: CreateRunOptions{Message: "Triggered by tfci", IdempotencyKey: "job-123"},
+			runs: []*tfe.Run{
+				{ID: "run-errored", Status: tfe.RunErrored, Message: "Triggered by tfci [idempotency-key: job-123]", CreatedAt: now},
+				{ID: "run-other-key", Status: tfe.RunPlanned, Message: "Triggered by tfci [idempotency-key: job-456]", CreatedAt: now},
+				{ID: "run-duplicate", Status: tfe.RunPlannedAndFinished, Message: "Triggered by tfci [idempotency-key: job-123]", CreatedAt: now},
+			},
+			expectedRunID: "run-duplicate",
+		},
+		{
+			// the default message is shared by every run create step of a job, eg. with different targets
+			name:           "no-key",
+			options:        CreateRunOptions{ConfigurationVersionID: "cv-***", Message: "Triggered by tfci"},
+			expectNoLookup: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx := context.Background()
+			workspaceMock := mocks.NewMockWorkspaces(ctrl)
+			runsMock := mocks.NewMockRuns(ctrl)
+			if !tc.expectNoLookup {
+				workspaceMock.EXPECT().Read(ctx, "test", "my-workspace").Return(&tfe.Workspace{ID: "ws-***"}, nil)
+				runsMock.EXPECT().List(ctx, "ws-***", gomock.Any()).Return(&tfe.RunList{Items: tc.runs}, nil)
+			}
+
+			client := NewRunService(&cloudMeta{
+				tfe:    &tfe.Client{Workspaces: workspaceMock, Runs: runsMock},
+				writer: &defaultWriter{},
+			})
+
+			tc.options.Organization, tc.options.Workspace = "test", "my-workspace"
+			run, err := client.FindDuplicateRun(ctx, tc.options)
+			if err != nil {
+				t.Fatalf("expected no error but received %s", err)
+			}
+			if id := runID(run); id != tc.expectedRunID {
+				t.Errorf("expected run %q but received %q", tc.expectedRunID, id)
+			}
+		})
+	}
+}
+
+func TestRunService_StreamRunLogs(t *testing.T) {
+	historical := "historical line 1\nhistorical line 2\n"
+	newOutput := "new line 1\nnew line 2\n"
+
+	testCases := []struct {
+		name           string
+		tail           bool
+		expectLines    []string
+		unexpectedLine string
+	}{
+		{
+			name:        "full-log",
+			tail:        false,
+			expectLines: []string{"historical line 1", "new line 2"},
+		},
+		{
+			name:           "tail-skips-historical-output",
+			tail:           true,
+			expectLines:    []string{"new line 1", "new line 2"},
+			unexpectedLine: "historical line",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// the log as it was written at the point of attaching, including the start of text marker.
+			// Only the first byte is served, the size is read from the Content-Range
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Range") != "bytes=0-0" {
+					t.Errorf("expected the log size to be read with a range request, received range %q", r.Header.Get("Range"))
+				}
+				http.ServeContent(w, r, "", time.Time{}, strings.NewReader("\x02"+historical))
+			}))
+			defer server.Close()
+			// requests are sent through the configured transport, eg. with -ca-cert
+			transport := NewCountingTransport(http.DefaultTransport)
+
+			ctx := context.Background()
+			plan := &tfe.Plan{ID: "plan-***", LogReadURL: server.URL}
+
+			plansMock := mocks.NewMockPlans(ctrl)
+			plansMock.EXPECT().Read(gomock.Any(), plan.ID).Return(plan, nil)
+			plansMock.EXPECT().Logs(gomock.Any(), plan.ID).Return(strings.NewReader(historical+newOutput), nil)
+
+			ui := cli.NewMockUi()
+			client := NewRunService(&cloudMeta{
+				tfe:       &tfe.Client{Plans: plansMock},
+				writer:    writer.NewWriter(ui),
+				transport: transport,
+			})
+
+			err := client.StreamRunLogs(ctx, &tfe.Run{ID: "run-***", Status: tfe.RunPlanning, Plan: plan}, StreamLogOptions{Tail: tc.tail})
+			if err != nil {
+				t.Fatalf("expected %v but received %s", nil, err)
+			}
+
+			output := ui.OutputWriter.String()
+			for _, line := range tc.expectLines {
+				if !strings.Contains(output, line) {
+					t.Errorf("expected output to contain %q, received: %q", line, output)
+				}
+			}
+			if tc.unexpectedLine != "" && strings.Contains(output, tc.unexpectedLine) {
+				t.Errorf("expected output to skip %q, received: %q", tc.unexpectedLine, output)
+			}
+			if tc.tail && transport.Count() != 1 {
+				t.Errorf("expected the log size to be read through the transport, received %d requests", transport.Count())
+			}
+		})
+	}
+}
+
+func TestRunService_WaitRun(t *testing.T) {
+	testCases := []struct {
+		name          string
+		statusChanges []tfe.RunStatus
+		finalStatus   tfe.RunStatus
+		expectErr     bool
+	}{
+		{
+			name:          "confirmable-run-planned",
+			statusChanges: []tfe.RunStatus{tfe.RunPending, tfe.RunPlanning},
+			finalStatus:   tfe.RunPlanned,
+		},
+		{
+			name:          "run-errored",
+			statusChanges: []tfe.RunStatus{tfe.RunPlanning},
+			finalStatus:   tfe.RunErrored,
+			expectErr:     true,
+		},
+		{
+			name:          "run-canceled",
+			statusChanges: []tfe.RunStatus{tfe.RunPlanning},
+			finalStatus:   tfe.RunCanceled,
+			expectErr:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx, runID := context.Background(), "run-***"
+			readOptions := &tfe.RunReadOptions{
+				Include: []tfe.RunIncludeOpt{"cost_estimate", "plan", "created_by", "configuration_version.ingress_attributes"},
+			}
+
+			runsMock := mocks.NewMockRuns(ctrl)
+			goMockCalls := []any{
+				runsMock.EXPECT().ReadWithOptions(ctx, runID, readOptions).Return(&tfe.Run{ID: runID, Status: tfe.RunPending}, nil),
+			}
+			for _, status := range tc.statusChanges {
+				goMockCalls = append(goMockCalls, runsMock.EXPECT().ReadWithOptions(ctx, runID, readOptions).Return(&tfe.Run{
+					ID:     runID,
+					Status: status,
+				}, nil))
+			}
+			goMockCalls = append(goMockCalls, runsMock.EXPECT().ReadWithOptions(ctx, runID, readOptions).Return(&tfe.Run{
+				ID:     runID,
+				Status: tc.finalStatus,
+			}, nil))
+			gomock.InOrder(goMockCalls...)
+
+			client := NewRunService(&cloudMeta{
+				tfe:          &tfe.Client{Runs: runsMock},
+				writer:       &defaultWriter{},
+				pollInterval: time.Millisecond,
+			})
+
+			run, err := client.WaitRun(ctx, WaitRunOptions{RunID: runID})
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error: %t but received: %v", tc.expectErr, err)
+			}
+			if run.Status != tc.finalStatus {
+				t.Errorf("expected run status %q but received %q", tc.finalStatus, run.Status)
+			}
+		})
+	}
+}
+
+func TestRunService_DiscardRun_Comment(t *testing.T) {
+	testCases := []struct {
+		name          string
+		comment       string
+		expectComment *string
+	}{
+		{
+			name:          "with-comment",
+			comment:       "superseded by run-456",
+			expectComment: tfe.String("superseded by run-456"),
+		},
+		{
+			name: "without-comment",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx, runID := context.Background(), "run-***"
+			readOptions := &tfe.RunReadOptions{
+				Include: []tfe.RunIncludeOpt{"cost_estimate", "plan", "created_by", "configuration_version.ingress_attributes"},
+			}
+
+			runsMock := mocks.NewMockRuns(ctrl)
+			gomock.InOrder(
+				runsMock.EXPECT().Discard(ctx, runID, tfe.RunDiscardOptions{Comment: tc.expectComment}).Return(nil),
+				runsMock.EXPECT().ReadWithOptions(ctx, runID, readOptions).Return(&tfe.Run{ID: runID, Status: tfe.RunDiscarded}, nil),
+			)
+
+			client := NewRunService(&cloudMeta{
+				tfe:          &tfe.Client{Runs: runsMock},
+				writer:       &defaultWriter{},
+				pollInterval: time.Millisecond,
+			})
+
+			if _, err := client.DiscardRun(ctx, DiscardRunOptions{RunID: runID, Comment: tc.comment}); err != nil {
+				t.Fatalf("expected no error but received %s", err)
+			}
+		})
+	}
+}
+
+func TestRunService_ReadRunLog(t *testing.T) {
+	testCases := []struct {
+		name        string
+		run         *tfe.Run
+		applyStatus tfe.ApplyStatus
+		expectLog   string
+	}{
+		{
+			name:      "plan-errored",
+			run:       &tfe.Run{ID: "run-***", Plan: &tfe.Plan{ID: "plan-***"}},
+			expectLog: "plan log",
+		},
+		{
+			name:        "apply-errored",
+			run:         &tfe.Run{ID: "run-***", Plan: &tfe.Plan{ID: "plan-***"}, Apply: &tfe.Apply{ID: "apply-***"}},
+			applyStatus: tfe.ApplyErrored,
+			expectLog:   "apply log",
+		},
+		{
+			name:        "apply-unreachable",
+			run:         &tfe.Run{ID: "run-***", Plan: &tfe.Plan{ID: "plan-***"}, Apply: &tfe.Apply{ID: "apply-***"}},
+			applyStatus: tfe.ApplyUnreachable,
+			expectLog:   "plan log",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			plansMock := mocks.NewMockPlans(ctrl)
+			appliesMock := mocks.NewMockApplies(ctrl)
+			if tc.run.Apply != nil {
+				appliesMock.EXPECT().Read(gomock.Any(), "apply-***").Return(&tfe.Apply{ID: "apply-***", Status: tc.applyStatus}, nil)
+			}
+			if tc.applyStatus == tfe.ApplyErrored {
+				appliesMock.EXPECT().Logs(gomock.Any(), "apply-***").Return(strings.NewReader("apply log"), nil)
+			} else {
+				plansMock.EXPECT().Logs(gomock.Any(), "plan-***").Return(strings.NewReader("plan log"), nil)
+			}
+
+			client := NewRunService(&cloudMeta{
+				tfe:    &tfe.Client{Plans: plansMock, Applies: appliesMock},
+				writer: &defaultWriter{},
+			})
+
+			runLog, err := client.ReadRunLog(context.Background(), tc.run)
+			if err != nil {
+				t.Fatalf("expected no error but received %s", err)
+			}
+			if runLog != tc.expectLog {
+				t.Errorf("expected %q but received %q", tc.expectLog, runLog)
+			}
+		})
+	}
+}
+
+type testLogTee struct {
+	label   string
+	lines   []string
+	omitted int
+}
+
+func (l *testLogTee) MaxLines() int { return 2 }
+
+func (l *testLogTee) TeeLog(label string, lines []string, omitted int) {
+	l.label, l.lines, l.omitted = label, lines, omitted
+}
+
+func TestRunService_GetPlanLogs_LogTee(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	plansMock := mocks.NewMockPlans(ctrl)
+	plansMock.EXPECT().Logs(gomock.Any(), "plan-***").Return(strings.NewReader("line 1\nline 2\nline 3\n"), nil)
+
+	tee := &testLogTee{}
+	ui := cli.NewMockUi()
+	client := NewRunService(&cloudMeta{
+		tfe:    &tfe.Client{Plans: plansMock},
+		writer: writer.NewWriter(ui),
+		logTee: tee,
+	})
+
+	if err := client.GetPlanLogs(context.Background(), "plan-***"); err != nil {
+		t.Fatalf("expected %v but received %s", nil, err)
+	}
+
+	if expected := []string{"line 2", "line 3"}; tee.label != "Plan Log" || !reflect.DeepEqual(tee.lines, expected) || tee.omitted != 1 {
+		t.Errorf("expected Plan Log %v with %d omitted but received %s %v with %d omitted", expected, 1, tee.label, tee.lines, tee.omitted)
+	}
+	if !strings.Contains(ui.OutputWriter.String(), "line 1\n") {
+		t.Errorf("expected all lines to be written to the output, received: %q", ui.OutputWriter.String())
+	}
+}
+
+func TestRunService_StreamRunLogs_Phase(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	apply := &tfe.Apply{ID: "apply-***"}
+
+	appliesMock := mocks.NewMockApplies(ctrl)
+	appliesMock.EXPECT().Read(gomock.Any(), apply.ID).Return(apply, nil)
+	appliesMock.EXPECT().Logs(gomock.Any(), apply.ID).Return(strings.NewReader("Error: creating bucket\n"), nil)
+
+	ui := cli.NewMockUi()
+	client := NewRunService(&cloudMeta{
+		tfe:    &tfe.Client{Applies: appliesMock},
+		writer: writer.NewWriter(ui),
+	})
+
+	// an errored run is not in the apply phase, the apply log is only streamed when selected
+	run := &tfe.Run{ID: "run-***", Status: tfe.RunErrored, Plan: &tfe.Plan{ID: "plan-***"}, Apply: apply}
+	if err := client.StreamRunLogs(ctx, run, StreamLogOptions{Phase: LogPhaseApply}); err != nil {
+		t.Fatalf("expected %v but received %s", nil, err)
+	}
+	if output := ui.OutputWriter.String(); !strings.Contains(output, "Apply Log") || !strings.Contains(output, "Error: creating bucket") {
+		t.Errorf("expected the apply log but received: %q", output)
+	}
+
+	if err := client.StreamRunLogs(ctx, &tfe.Run{ID: "run-***", Plan: &tfe.Plan{ID: "plan-***"}}, StreamLogOptions{Phase: LogPhaseApply}); err == nil {
+		t.Errorf("expected an error streaming the apply log of a run without an apply")
+	}
+}
+
+func TestRunService_WatchRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, runID := context.Background(), "run-***"
+	readOptions := &tfe.RunReadOptions{
+		Include: []tfe.RunIncludeOpt{"cost_estimate", "plan", "created_by", "configuration_version.ingress_attributes"},
+	}
+
+	runsMock := mocks.NewMockRuns(ctrl)
+	calls := []any{}
+	// unchanged statuses are only written once, an errored run is not an error
+	for _, status := range []tfe.RunStatus{tfe.RunPending, tfe.RunPlanning, tfe.RunPlanning, tfe.RunErrored} {
+		calls = append(calls, runsMock.EXPECT().ReadWithOptions(ctx, runID, readOptions).Return(&tfe.Run{ID: runID, Status: status}, nil))
+	}
+	gomock.InOrder(calls...)
+
+	ui := cli.NewMockUi()
+	client := NewRunService(&cloudMeta{
+		tfe:          &tfe.Client{Runs: runsMock},
+		writer:       writer.NewWriter(ui),
+		pollInterval: time.Millisecond,
+	})
+
+	run, err := client.WatchRun(ctx, WatchRunOptions{RunID: runID})
+	if err != nil {
+		t.Fatalf("expected no error but received %s", err)
+	}
+	if run.Status != tfe.RunErrored {
+		t.Errorf("expected status %q but received %q", tfe.RunErrored, run.Status)
+	}
+
+	expected := []string{`Run Status: "pending"`, `Run Status: "pending" -> "planning"`, `Run Status: "planning" -> "errored"`}
+	messages := strings.Split(strings.TrimSpace(ui.OutputWriter.String()), "\n")
+	if len(messages) != len(expected) {
+		t.Fatalf("expected %d transitions but received %q", len(expected), messages)
+	}
+	for i, msg := range messages {
+		if !strings.HasSuffix(msg, expected[i]) {
+			t.Errorf("expected transition %q but received %q", expected[i], msg)
+		}
+	}
+}
@@ -0,0 +1,388 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cloud
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/scanner"
+
+	"github.com/hashicorp/go-slug"
+	"github.com/hashicorp/tfci/internal/logging"
+)
+
+// slugMeta describes the contents of a packed configuration archive
+type slugMeta struct {
+	Included int
+	// files and symlinks left out by .terraformignore, -exclude or for pointing outside of the directory
+	Skipped int
+	Size    int64
+}
+
+// excludeRule is a single compiled -exclude glob pattern
+type excludeRule struct {
+	val   string
+	regex *regexp.Regexp
+}
+
+// compileExcludeRules compiles the glob patterns of -exclude, which are matched against paths relative to the
+// configuration directory. Unlike .terraformignore a pattern is always anchored to the root, "**/" matches
+// at any depth, and patterns cannot be negated
+func compileExcludeRules(patterns []string) ([]*excludeRule, error) {
+	rules := make([]*excludeRule, 0, len(patterns))
+	for _, pattern := range patterns {
+		val := strings.TrimPrefix(strings.TrimSpace(pattern), "/")
+		if val == "" {
+			return nil, fmt.Errorf("invalid exclude pattern %q, patterns must not be empty", pattern)
+		}
+		// directories also match their descendants
+		if strings.HasSuffix(val, "/") {
+			val += "**"
+		}
+		rule := &excludeRule{val: val}
+		if err := rule.compile(); err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern %q: %w", pattern, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// compile converts the glob pattern to a regular expression, "**" matches any number of
+// directories, "*", "?" and "[...]" classes never match a path separator
+func (r *excludeRule) compile() error {
+	var scan scanner.Scanner
+	scan.Init(strings.NewReader(r.val))
+
+	expr := "^"
+	for scan.Peek() != scanner.EOF {
+		ch := scan.Next()
+		switch ch {
+		case '*':
+			if scan.Peek() != '*' {
+				expr += "[^/]*"
+				continue
+			}
+			scan.Next()
+			if scan.Peek() == '/' {
+				scan.Next()
+			}
+			if scan.Peek() == scanner.EOF {
+				expr += ".*"
+			} else {
+				expr += "(.*/)?"
+			}
+		case '?':
+			expr += "[^/]"
+		case '[':
+			class, err := compileClass(&scan)
+			if err != nil {
+				return err
+			}
+			expr += class
+		case '.', '$', '(', ')', '+', '|', '^', '{', '}':
+			expr += `\` + string(ch)
+		case '\\':
+			if scan.Peek() != scanner.EOF {
+				expr += `\` + string(scan.Next())
+			} else {
+				expr += `\\`
+			}
+		default:
+			expr += string(ch)
+		}
+	}
+	expr += "$"
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return err
+	}
+	r.regex = re
+	return nil
+}
+
+// converts a "[...]" character class, whose opening bracket was scanned, to a regular expression class. "[!" and
+// "[^" negate the class, and a path separator is removed from the class and its ranges
+func compileClass(scan *scanner.Scanner) (string, error) {
+	negate := false
+	if scan.Peek() == '!' || scan.Peek() == '^' {
+		scan.Next()
+		negate = true
+	}
+
+	var ranges [][2]rune
+	for first := true; ; first = false {
+		ch := scan.Next()
+		switch {
+		case ch == scanner.EOF:
+			return "", errors.New("unterminated character class")
+		// a leading "]" is part of the class
+		case ch == ']' && !first:
+			return classExpr(ranges, negate), nil
+		case ch == '\\' && scan.Peek() != scanner.EOF:
+			ch = scan.Next()
+		}
+
+		hi := ch
+		if scan.Peek() == '-' {
+			scan.Next()
+			if scan.Peek() == ']' {
+				// a trailing "-" is literal
+				ranges = append(ranges, [2]rune{ch, ch}, [2]rune{'-', '-'})
+				continue
+			}
+			if hi = scan.Next(); hi == '\\' && scan.Peek() != scanner.EOF {
+				hi = scan.Next()
+			}
+			if hi == scanner.EOF {
+				return "", errors.New("unterminated character class")
+			}
+			if hi < ch {
+				return "", fmt.Errorf("invalid character class range %q", string(ch)+"-"+string(hi))
+			}
+		}
+		ranges = append(ranges, [2]rune{ch, hi})
+	}
+}
+
+// returns the regular expression class of the ranges, which never matches a path separator
+func classExpr(ranges [][2]rune, negate bool) string {
+	expr := "["
+	if negate {
+		expr += "^/"
+	}
+	for _, r := range ranges {
+		// split a range around the path separator
+		if !negate && r[0] <= '/' && '/' <= r[1] {
+			if r[0] < '/' {
+				expr += fmt.Sprintf(`\x{%x}-\x{%x}`, r[0], '/'-1)
+			}
+			if '/' < r[1] {
+				expr += fmt.Sprintf(`\x{%x}-\x{%x}`, '/'+1, r[1])
+			}
+			continue
+		}
+		expr += fmt.Sprintf(`\x{%x}-\x{%x}`, r[0], r[1])
+	}
+	if expr == "[" {
+		// eg. "[/]", which never matches
+		return `[^\x00-\x{10FFFF}]`
+	}
+	return expr + "]"
+}
+
+// reports whether a rule matches the archive entry or one of its parent directories, directories are also
+// matched with a trailing slash so "dir/" patterns apply to them
+func isExcluded(name string, rules []*excludeRule) bool {
+	isDir := strings.HasSuffix(name, "/")
+	parts := strings.Split(strings.TrimSuffix(name, "/"), "/")
+	for i := range parts {
+		path := strings.Join(parts[:i+1], "/")
+		for _, rule := range rules {
+			if rule.regex.MatchString(path) {
+				return true
+			}
+			if (isDir || i < len(parts)-1) && rule.regex.MatchString(path+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// packConfiguration writes a gzipped tarball of the configuration directory to w with go-slug, which applies
+// .terraformignore the same way as the Terraform CLI, then leaves out the paths matched by the exclude rules.
+// Symlinks pointing outside of the directory are skipped rather than followed, eg. a shared module
+func packConfiguration(root string, excludes []*excludeRule, w io.Writer) (*slugMeta, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("configuration directory %q is not a directory", root)
+	}
+
+	files, external, err := scanConfiguration(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration directory %q: %w", root, err)
+	}
+	// go-slug either follows an external symlink or fails the whole pack, allowing the exact targets keeps them as
+	// links in the packed archive, which are then dropped below
+	options := []slug.PackerOption{slug.ApplyTerraformIgnore()}
+	for _, target := range external {
+		options = append(options, slug.AllowSymlinkTarget(target))
+	}
+	packer, err := slug.NewPacker(options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack configuration directory %q: %w", root, err)
+	}
+
+	packed := &bytes.Buffer{}
+	if _, err := packer.Pack(root, packed); err != nil {
+		return nil, fmt.Errorf("failed to pack configuration directory %q: %w", root, err)
+	}
+	if len(excludes) > 0 {
+		logging.Debug("Effective exclusion rules", "rules", describeRules(excludes))
+	}
+
+	gzipR, err := gzip.NewReader(packed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the packed configuration: %w", err)
+	}
+	tarR := tar.NewReader(gzipR)
+
+	counter := &countingWriter{w: w}
+	gzipW := gzip.NewWriter(counter)
+	tarW := tar.NewWriter(gzipW)
+	meta := &slugMeta{}
+	written := 0
+	for {
+		header, err := tarR.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the packed configuration: %w", err)
+		}
+		if _, ok := external[header.Name]; ok && header.Typeflag == tar.TypeSymlink {
+			logging.Debug("Skipping symlink pointing outside of the configuration directory", "path", header.Name, "target", header.Linkname)
+			continue
+		}
+		if isExcluded(header.Name, excludes) {
+			logging.Debug("Skipping excluded path", "path", header.Name)
+			continue
+		}
+		if err := tarW.WriteHeader(header); err != nil {
+			return nil, fmt.Errorf("failed writing archive header for %q: %w", header.Name, err)
+		}
+		if _, err := io.Copy(tarW, tarR); err != nil {
+			return nil, fmt.Errorf("failed copying file %q to archive: %w", header.Name, err)
+		}
+		if header.Typeflag != tar.TypeDir {
+			written++
+		}
+		if header.Typeflag == tar.TypeReg {
+			meta.Included++
+		}
+	}
+	meta.Skipped = files - written
+
+	if err := tarW.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close the archive: %w", err)
+	}
+	if err := gzipW.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close the archive: %w", err)
+	}
+	meta.Size = counter.n
+
+	return meta, nil
+}
+
+// counts the files and symlinks of the configuration directory, and returns the symlinks pointing outside of it by
+// their slash separated path with their absolute target. Targets are resolved lexically, the same as go-slug
+func scanConfiguration(root string) (int, map[string]string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return 0, nil, err
+	}
+	files := 0
+	external := make(map[string]string)
+	err = filepath.WalkDir(absRoot, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		files++
+		if d.Type()&fs.ModeSymlink == 0 {
+			return nil
+		}
+
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		target = filepath.Clean(target)
+		if rel, err := filepath.Rel(absRoot, target); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil
+		}
+		name, err := filepath.Rel(absRoot, path)
+		if err != nil {
+			return err
+		}
+		external[filepath.ToSlash(name)] = target
+		return nil
+	})
+	return files, external, err
+}
+
+// returns the glob pattern of each rule
+func describeRules(rules []*excludeRule) []string {
+	patterns := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		patterns = append(patterns, rule.val)
+	}
+	return patterns
+}
+
+// reads a pre-built configuration archive, verifying it is a gzip compressed tar with at least one entry
+// so a wrong file fails before a configuration version is created
+func readArchive(path string) ([]byte, *slugMeta, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read configuration archive: %w", err)
+	}
+
+	gzipR, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("configuration archive %q is not a gzip compressed tar: %w", path, err)
+	}
+	tarR := tar.NewReader(gzipR)
+	meta := &slugMeta{Size: int64(len(data))}
+	for {
+		header, err := tarR.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("configuration archive %q is not a gzip compressed tar: %w", path, err)
+		}
+		// read through the entries so a truncated archive fails its gzip checksum
+		if _, err := io.Copy(io.Discard, tarR); err != nil {
+			return nil, nil, fmt.Errorf("configuration archive %q is not a gzip compressed tar: %w", path, err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			meta.Included++
+		}
+	}
+	if meta.Included == 0 {
+		return nil, nil, fmt.Errorf("configuration archive %q does not contain any files", path)
+	}
+	return data, meta, nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
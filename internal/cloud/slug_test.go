@@ -0,0 +1,323 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cloud
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestPackConfiguration(t *testing.T) {
+	root := t.TempDir()
+
+	files := map[string]string{
+		".terraformignore":                "# comments are ignored\n*.tfstate\nlogs/\n!logs/keep.log\n/fixtures/\n",
+		"main.tf":                         "",
+		"terraform.tfstate":               "",
+		"modules/app/main.tf":             "",
+		"modules/app/nested.tfstate":      "",
+		"logs/debug.log":                  "",
+		"logs/keep.log":                   "",
+		"fixtures/data.json":              "",
+		"modules/fixtures/main.tf":        "",
+		".git/HEAD":                       "",
+		".terraform/providers/p":          "",
+		".terraform/modules/modules.json": "",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("main.tf", filepath.Join(root, "internal.tf")); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	meta, err := packConfiguration(root, nil, buf)
+	if err != nil {
+		t.Fatalf("expected %v but received %s", nil, err)
+	}
+
+	expected := []string{
+		".terraform/modules/modules.json",
+		".terraformignore",
+		"internal.tf",
+		"logs/keep.log",
+		"main.tf",
+		"modules/",
+		"modules/app/",
+		"modules/app/main.tf",
+		"modules/fixtures/",
+		"modules/fixtures/main.tf",
+	}
+	actual := archiveEntries(t, buf)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v but received %v", expected, actual)
+	}
+
+	if meta.Included != 6 {
+		t.Errorf("expected %d files included but received %d", 6, meta.Included)
+	}
+	// files left out by .terraformignore and the default rules count as skipped
+	if meta.Skipped != 6 {
+		t.Errorf("expected %d paths skipped but received %d", 6, meta.Skipped)
+	}
+	if meta.Size == 0 {
+		t.Errorf("expected upload size to be recorded")
+	}
+}
+
+func TestPackConfiguration_ExternalSymlink(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret"), filepath.Join(root, "external")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join("..", filepath.Base(outside)), filepath.Join(root, "shared")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "main.tf"), []byte(""), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// files outside of the directory are never uploaded, the symlinks are skipped without failing the upload
+	buf := &bytes.Buffer{}
+	meta, err := packConfiguration(root, nil, buf)
+	if err != nil {
+		t.Fatalf("expected %v but received %s", nil, err)
+	}
+	expected := []string{"main.tf"}
+	if actual := archiveEntries(t, buf); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v but received %v", expected, actual)
+	}
+	if meta.Skipped != 2 {
+		t.Errorf("expected %d paths skipped but received %d", 2, meta.Skipped)
+	}
+}
+
+func TestPackConfiguration_Excludes(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".terraformignore":         "!logs/keep.log\n",
+		"main.tf":                  "",
+		"dev.tfvars":               "",
+		"env/prod.tfvars":          "",
+		"logs/keep.log":            "",
+		"tests/main.tftest.hcl":    "",
+		"modules/app/main.tf":      "",
+		"modules/app/test/fixture": "",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// "*.tfvars" is anchored to the root, only "**/" matches at any depth
+	excludes, err := compileExcludeRules([]string{"*.tfvars", "tests/", "modules/**/test", "logs/*.log"})
+	if err != nil {
+		t.Fatalf("expected %v but received %s", nil, err)
+	}
+
+	buf := &bytes.Buffer{}
+	meta, err := packConfiguration(root, excludes, buf)
+	if err != nil {
+		t.Fatalf("expected %v but received %s", nil, err)
+	}
+
+	expected := []string{
+		".terraformignore",
+		"env/",
+		"env/prod.tfvars",
+		"logs/",
+		"main.tf",
+		"modules/",
+		"modules/app/",
+		"modules/app/main.tf",
+	}
+	actual := archiveEntries(t, buf)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v but received %v", expected, actual)
+	}
+	if meta.Included != 4 {
+		t.Errorf("expected %d files included but received %d", 4, meta.Included)
+	}
+	if meta.Skipped != 4 {
+		t.Errorf("expected %d paths skipped but received %d", 4, meta.Skipped)
+	}
+
+	if _, err := compileExcludeRules([]string{" "}); err == nil {
+		t.Errorf("expected an error compiling an empty pattern")
+	}
+}
+
+func TestIsExcluded(t *testing.T) {
+	testCases := []struct {
+		pattern  string
+		name     string
+		expected bool
+	}{
+		{pattern: "env/[ps]*.tfvars", name: "env/prod.tfvars", expected: true},
+		{pattern: "env/[ps]*.tfvars", name: "env/dev.tfvars", expected: false},
+		// a negated class, "[!" and "[^" are equivalent
+		{pattern: "env/[!d]*.tfvars", name: "env/prod.tfvars", expected: true},
+		{pattern: "env/[!d]*.tfvars", name: "env/dev.tfvars", expected: false},
+		{pattern: "env/[!d]*.tfvars", name: "env/!.tfvars", expected: true},
+		{pattern: "env/[^d]*.tfvars", name: "env/dev.tfvars", expected: false},
+		{pattern: "env/[a-z]?.tf", name: "env/ab.tf", expected: true},
+		// classes and wildcards never match a path separator
+		{pattern: "env[!a]prod.tfvars", name: "env/prod.tfvars", expected: false},
+		{pattern: "env[-0]prod.tfvars", name: "env/prod.tfvars", expected: false},
+		{pattern: "env?prod.tfvars", name: "env/prod.tfvars", expected: false},
+		{pattern: "env[/]prod.tfvars", name: "env/prod.tfvars", expected: false},
+		{pattern: "env/[]x]*", name: "env/]", expected: true},
+		{pattern: "env/[a-]*", name: "env/-", expected: true},
+		// a trailing slash only matches directories
+		{pattern: "tests/", name: "tests", expected: false},
+		{pattern: "tests/", name: "tests/", expected: true},
+		{pattern: "tests/", name: "tests/main.tftest.hcl", expected: true},
+		{pattern: `\[draft\].tf`, name: "[draft].tf", expected: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.pattern+" "+tc.name, func(t *testing.T) {
+			rules, err := compileExcludeRules([]string{tc.pattern})
+			if err != nil {
+				t.Fatalf("expected %v but received %s", nil, err)
+			}
+			if actual := isExcluded(tc.name, rules); actual != tc.expected {
+				t.Errorf("expected %t but received %t", tc.expected, actual)
+			}
+		})
+	}
+
+	if _, err := compileExcludeRules([]string{"env/[a-"}); err == nil {
+		t.Errorf("expected an error compiling an unterminated class")
+	}
+}
+
+func TestPackConfiguration_MissingDirectory(t *testing.T) {
+	if _, err := packConfiguration(filepath.Join(t.TempDir(), "missing"), nil, io.Discard); err == nil {
+		t.Errorf("expected an error packing a missing directory")
+	}
+}
+
+func TestReadArchive(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "config")
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "main.tf"), []byte("terraform {}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	packed := &bytes.Buffer{}
+	if _, err := packConfiguration(root, nil, packed); err != nil {
+		t.Fatal(err)
+	}
+
+	emptyTar := &bytes.Buffer{}
+	gzipW := gzip.NewWriter(emptyTar)
+	tar.NewWriter(gzipW).Close()
+	gzipW.Close()
+
+	testCases := []struct {
+		name      string
+		content   []byte
+		expectErr bool
+	}{
+		{
+			name:    "gzip-tar",
+			content: packed.Bytes(),
+		},
+		{
+			name:      "not-gzip",
+			content:   []byte("terraform {}"),
+			expectErr: true,
+		},
+		{
+			name:      "truncated",
+			content:   packed.Bytes()[:packed.Len()/2],
+			expectErr: true,
+		},
+		{
+			name:      "no-files",
+			content:   emptyTar.Bytes(),
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, tc.name+".tar.gz")
+			if err := os.WriteFile(path, tc.content, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			data, meta, err := readArchive(path)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected an error reading %s", tc.name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected %v but received %s", nil, err)
+			}
+			if !bytes.Equal(data, tc.content) {
+				t.Errorf("expected the archive to be uploaded as is")
+			}
+			if meta.Included != 1 || meta.Size != int64(len(tc.content)) {
+				t.Errorf("expected 1 file of %d bytes but received %d files of %d bytes", len(tc.content), meta.Included, meta.Size)
+			}
+		})
+	}
+
+	if _, _, err := readArchive(filepath.Join(dir, "missing.tar.gz")); err == nil {
+		t.Errorf("expected an error reading a missing archive")
+	}
+}
+
+func archiveEntries(t *testing.T, r io.Reader) []string {
+	t.Helper()
+
+	gzipR, err := gzip.NewReader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tarR := tar.NewReader(gzipR)
+
+	entries := []string{}
+	for {
+		header, err := tarR.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, header.Name)
+	}
+	sort.Strings(entries)
+	return entries
+}
@@ -0,0 +1,68 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+type TerraformVersionService interface {
+	ListTerraformVersions(context.Context) ([]*tfe.AdminTerraformVersion, error)
+}
+
+type terraformVersionService struct {
+	*cloudMeta
+}
+
+// returned when the token cannot read the admin Terraform versions, which requires site admin
+// access on Terraform Enterprise and is not available on HCP Terraform
+type AdminAccessRequiredError struct {
+	err error
+}
+
+func (e *AdminAccessRequiredError) Error() string {
+	return "listing Terraform versions requires a Terraform Enterprise site admin token, the admin API is not available on HCP Terraform"
+}
+
+func (e *AdminAccessRequiredError) Unwrap() error {
+	return e.err
+}
+
+// lists every Terraform version known to the instance, including disabled and beta versions
+func (s *terraformVersionService) ListTerraformVersions(ctx context.Context) ([]*tfe.AdminTerraformVersion, error) {
+	if err := s.skipDryRun("list terraform versions"); err != nil {
+		return nil, err
+	}
+
+	versions := []*tfe.AdminTerraformVersion{}
+	listOpts := &tfe.AdminTerraformVersionsListOptions{
+		ListOptions: tfe.ListOptions{PageNumber: 1, PageSize: maxPageSize},
+	}
+	for {
+		list, err := s.tfe.Admin.TerraformVersions.List(ctx, listOpts)
+		if err != nil {
+			log.Printf("[ERROR] error listing Terraform versions: %s", err)
+			// admin endpoints respond with not found to tokens without site admin access
+			if errors.Is(err, tfe.ErrResourceNotFound) {
+				return nil, &AdminAccessRequiredError{err: err}
+			}
+			return nil, fmt.Errorf("failed to list Terraform versions: %w", err)
+		}
+		versions = append(versions, list.Items...)
+
+		if list.Pagination == nil || list.NextPage == 0 {
+			return versions, nil
+		}
+		listOpts.PageNumber = list.NextPage
+	}
+}
+
+func NewTerraformVersionService(meta *cloudMeta) *terraformVersionService {
+	return &terraformVersionService{meta}
+}
@@ -0,0 +1,60 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cloud
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/go-tfe/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestTerraformVersionService_ListTerraformVersions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mVersions := mocks.NewMockAdminTerraformVersions(ctrl)
+	gomock.InOrder(
+		mVersions.EXPECT().List(ctx, &tfe.AdminTerraformVersionsListOptions{ListOptions: tfe.ListOptions{PageNumber: 1, PageSize: maxPageSize}}).
+			Return(&tfe.AdminTerraformVersionsList{
+				Items:      []*tfe.AdminTerraformVersion{{Version: "1.9.5"}},
+				Pagination: &tfe.Pagination{NextPage: 2},
+			}, nil),
+		mVersions.EXPECT().List(ctx, &tfe.AdminTerraformVersionsListOptions{ListOptions: tfe.ListOptions{PageNumber: 2, PageSize: maxPageSize}}).
+			Return(&tfe.AdminTerraformVersionsList{
+				Items:      []*tfe.AdminTerraformVersion{{Version: "1.10.0"}},
+				Pagination: &tfe.Pagination{},
+			}, nil),
+	)
+
+	client := NewTerraformVersionService(&cloudMeta{tfe: &tfe.Client{Admin: tfe.Admin{TerraformVersions: mVersions}}, writer: &defaultWriter{}})
+	versions, err := client.ListTerraformVersions(ctx)
+	if err != nil {
+		t.Fatalf("expected no error but received %s", err)
+	}
+	if len(versions) != 2 || versions[1].Version != "1.10.0" {
+		t.Errorf("expected versions from every page but received %+v", versions)
+	}
+}
+
+func TestTerraformVersionService_ListTerraformVersions_AdminRequired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mVersions := mocks.NewMockAdminTerraformVersions(ctrl)
+	mVersions.EXPECT().List(ctx, gomock.Any()).Return(nil, tfe.ErrResourceNotFound)
+
+	client := NewTerraformVersionService(&cloudMeta{tfe: &tfe.Client{Admin: tfe.Admin{TerraformVersions: mVersions}}, writer: &defaultWriter{}})
+	_, err := client.ListTerraformVersions(ctx)
+
+	var adminErr *AdminAccessRequiredError
+	if !errors.As(err, &adminErr) {
+		t.Fatalf("expected admin access required error but received %v", err)
+	}
+}
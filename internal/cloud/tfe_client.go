@@ -4,12 +4,19 @@
 package cloud
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
+	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/logging"
 	"github.com/hashicorp/tfci/version"
 )
 
@@ -17,6 +24,14 @@ const (
 	defaultHostname = "app.terraform.io"
 	baseUserAgent   = "tfci"
 	unknownPlatform = "other"
+
+	envToken     = "TF_API_TOKEN"
+	envTokenFile = "TF_API_TOKEN_FILE"
+	// appended to the User-Agent, eg. to name the pipeline in Terraform Enterprise audit logs
+	envUserAgentSuffix = "TFCI_USER_AGENT_SUFFIX"
+
+	// identifies the CI job which sent the request, eg. when tracing requests of a support ticket
+	ciRunIDHeader = "X-TFCI-CI-Run-ID"
 )
 
 func getUserAgent(platform string) string {
@@ -28,48 +43,150 @@ func getUserAgent(platform string) string {
 	} else {
 		agent = fmt.Sprintf("%s/%s", baseUserAgent, version)
 	}
+	if suffix := strings.TrimSpace(os.Getenv(envUserAgentSuffix)); suffix != "" {
+		agent = fmt.Sprintf("%s %s", agent, suffix)
+	}
 	return agent
 }
 
-func NewTfeClient(hostFlag string, tokenFlag string, platform string) (*tfe.Client, error) {
-	tfeConfig := tfe.DefaultConfig()
+// resolves the static api token, in order of precedence: -token, -token-file or TF_API_TOKEN_FILE, then TF_API_TOKEN.
+// Returns the source of the token for logging, the token itself must never be logged.
+func resolveToken(tokenFlag string, tokenFileFlag string) (token string, source string, err error) {
+	if tokenFlag != "" {
+		return tokenFlag, "-token", nil
+	}
 
-	host := hostFlag
-	if hostFlag == "" {
-		hostEnv := os.Getenv("TF_HOSTNAME")
-		if hostEnv != "" {
-			host = hostEnv
-		} else {
-			host = defaultHostname
+	tokenFile, source := tokenFileFlag, "-token-file"
+	if tokenFile == "" {
+		tokenFile, source = os.Getenv(envTokenFile), envTokenFile
+	}
+	if tokenFile != "" {
+		raw, readErr := os.ReadFile(tokenFile)
+		if readErr != nil {
+			return "", "", fmt.Errorf("unable to read API token from %s: %w", source, readErr)
+		}
+		token = strings.TrimSpace(string(raw))
+		if token == "" {
+			return "", "", fmt.Errorf("API token file %q from %s is empty", tokenFile, source)
 		}
+		return token, source, nil
+	}
+
+	return os.Getenv(envToken), envToken, nil
+}
+
+// the Terraform CLI credentials file, eg. written by `terraform login`
+type credentialsFile struct {
+	Credentials map[string]struct {
+		Token string `json:"token"`
+	} `json:"credentials"`
+}
+
+func credentialsFilePath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".terraform.d", "credentials.tfrc.json"), nil
+}
+
+// reads the token for the host from the Terraform CLI credentials file, an empty token is returned
+// when the file or the host is missing
+func credentialsFileToken(host string) (string, error) {
+	path, err := credentialsFilePath()
+	if err != nil {
+		return "", nil
+	}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("unable to read Terraform CLI credentials file %q: %w", path, err)
+	}
+
+	creds := &credentialsFile{}
+	if err := json.Unmarshal(raw, creds); err != nil {
+		return "", fmt.Errorf("invalid Terraform CLI credentials file %q: %w", path, err)
+	}
+	return strings.TrimSpace(creds.Credentials[host].Token), nil
+}
+
+// resolves the hostname, in order of precedence: -hostname, TF_HOSTNAME, then HCP Terraform
+func Hostname(hostFlag string) string {
+	if hostFlag != "" {
+		return hostFlag
 	}
+	if hostEnv := os.Getenv("TF_HOSTNAME"); hostEnv != "" {
+		return hostEnv
+	}
+	return defaultHostname
+}
+
+// creates the go-tfe client sending requests with the transport, eg. from NewHTTPTransport. A nil transport uses
+// the default transport. Requests identify the platform in the User-Agent, and the CI run ID, when not empty, in the
+// X-TFCI-CI-Run-ID header
+func NewTfeClient(hostFlag string, tokenFlag string, tokenFileFlag string, platform string, ciRunID string, transport http.RoundTripper) (*tfe.Client, error) {
+	tfeConfig := tfe.DefaultConfig()
+
+	host := Hostname(hostFlag)
 
 	log.Printf("[DEBUG] Initializing HCP Terraform client, host: %s", host)
 
-	token := tokenFlag
-	if tokenFlag == "" {
-		tokenEnv := os.Getenv("TF_API_TOKEN")
-		if tokenEnv != "" {
-			token = tokenEnv
-		}
+	token, source, err := resolveToken(tokenFlag, tokenFileFlag)
+	if err != nil {
+		return nil, err
 	}
 
+	// retry rate limited and server error responses with bounded backoff, see retryTransport. Each
+	// attempt is traced when TF_LOG=TRACE
+	if transport == nil {
+		transport = tfeConfig.HTTPClient.Transport
+	}
+	tfeConfig.HTTPClient.Transport = newRetryTransport(newTraceTransport(transport))
 	tfeConfig.Headers.Set("User-Agent", getUserAgent(platform))
+	if ciRunID != "" {
+		tfeConfig.Headers.Set(ciRunIDHeader, ciRunID)
+	}
 	tfeConfig.Address = fmt.Sprintf("https://%s", host)
-	tfeConfig.Token = token
 
-	if tfeConfig.Token == "" {
-		return nil, fmt.Errorf("HCP Terraform API token is not set")
+	// a static token always takes precedence over OIDC. OIDC is explicitly opted into, so it precedes the
+	// Terraform CLI credentials file
+	if token == "" {
+		oidcConfig := oidcConfigFromEnv()
+		if oidcConfig.enabled() {
+			log.Printf("[DEBUG] No API token set, exchanging OIDC token for audience: %s", oidcConfig.Audience)
+			ctx, cancel := context.WithTimeout(context.Background(), oidcRequestTimeout)
+			defer cancel()
+			token, err = ExchangeOIDCToken(ctx, &http.Client{Timeout: oidcRequestTimeout, Transport: transport}, oidcConfig)
+			if err != nil {
+				return nil, err
+			}
+			source = "OIDC"
+		} else {
+			if token, err = credentialsFileToken(host); err != nil {
+				return nil, err
+			}
+			if token == "" {
+				return nil, fmt.Errorf("HCP Terraform API token is not set, provide -token, -token-file, %s or %s, add a token for %s to the Terraform CLI credentials file, or set %s to authenticate with OIDC", envTokenFile, envToken, host, envOIDCAudience)
+			}
+			source = "Terraform CLI credentials file"
+		}
 	}
 
-	log.Printf("[DEBUG] token has been set")
+	tfeConfig.Token = token
+	// whichever source it came from, the token is scrubbed from every later log entry
+	logging.Redact(token)
+
+	log.Printf("[DEBUG] token has been set, source: %s", source)
 
 	client, err := tfe.NewClient(tfeConfig)
 	if err != nil {
 		return nil, err
 	}
 
-	client.RetryServerErrors(true)
+	// server errors are retried by the http transport, so retries remain bounded
+	client.RetryServerErrors(false)
 
 	log.Printf("[DEBUG] TFC/E Version: %s", client.RemoteAPIVersion())
 
@@ -0,0 +1,222 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cloud
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/tfci/version"
+)
+
+func TestResolveToken(t *testing.T) {
+	dir := t.TempDir()
+	flagFile := filepath.Join(dir, "flag-token")
+	envFile := filepath.Join(dir, "env-token")
+	emptyFile := filepath.Join(dir, "empty-token")
+	for path, content := range map[string]string{flagFile: "  flag-file-token\n", envFile: "env-file-token\n", emptyFile: "\n"} {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		name        string
+		tokenFlag   string
+		tokenFile   string
+		envFile     string
+		envToken    string
+		expected    string
+		expectedErr string
+	}{
+		{
+			name:      "flag-takes-precedence",
+			tokenFlag: "flag-token",
+			tokenFile: flagFile,
+			envFile:   envFile,
+			envToken:  "env-token",
+			expected:  "flag-token",
+		},
+		{
+			name:      "token-file-flag-trimmed",
+			tokenFile: flagFile,
+			envFile:   envFile,
+			envToken:  "env-token",
+			expected:  "flag-file-token",
+		},
+		{
+			name:     "token-file-env",
+			envFile:  envFile,
+			envToken: "env-token",
+			expected: "env-file-token",
+		},
+		{
+			name:     "token-env",
+			envToken: "env-token",
+			expected: "env-token",
+		},
+		{
+			name:     "none-set",
+			expected: "",
+		},
+		{
+			name:        "missing-token-file",
+			tokenFile:   filepath.Join(dir, "missing"),
+			expectedErr: "unable to read API token from -token-file",
+		},
+		{
+			name:        "empty-token-file",
+			envFile:     emptyFile,
+			expectedErr: "from TF_API_TOKEN_FILE is empty",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(envTokenFile, tc.envFile)
+			t.Setenv(envToken, tc.envToken)
+
+			token, _, err := resolveToken(tc.tokenFlag, tc.tokenFile)
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Fatalf("expected error containing %q but received %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but received %s", err)
+			}
+			if token != tc.expected {
+				t.Errorf("expected token %q but received %q", tc.expected, token)
+			}
+		})
+	}
+}
+
+func TestCredentialsFileToken(t *testing.T) {
+	testCases := []struct {
+		name        string
+		content     string
+		host        string
+		expected    string
+		expectedErr string
+	}{
+		{
+			name:     "host-found",
+			content:  `{"credentials": {"app.terraform.io": {"token": "creds-token"}, "tfe.example.com": {"token": "other"}}}`,
+			host:     "app.terraform.io",
+			expected: "creds-token",
+		},
+		{
+			name:     "host-missing",
+			content:  `{"credentials": {"tfe.example.com": {"token": "other"}}}`,
+			host:     "app.terraform.io",
+			expected: "",
+		},
+		{
+			name:     "file-missing",
+			host:     "app.terraform.io",
+			expected: "",
+		},
+		{
+			name:        "invalid-json",
+			content:     `{"credentials":`,
+			host:        "app.terraform.io",
+			expectedErr: "invalid Terraform CLI credentials file",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			if tc.content != "" {
+				if err := os.MkdirAll(filepath.Join(home, ".terraform.d"), 0o700); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(home, ".terraform.d", "credentials.tfrc.json"), []byte(tc.content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			token, err := credentialsFileToken(tc.host)
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Fatalf("expected error containing %q but received %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but received %s", err)
+			}
+			if token != tc.expected {
+				t.Errorf("expected token %q but received %q", tc.expected, token)
+			}
+		})
+	}
+}
+
+func TestNewTfeClient_RequestHeaders(t *testing.T) {
+	testCases := []struct {
+		name              string
+		platform          string
+		ciRunID           string
+		suffix            string
+		expectedUserAgent string
+	}{
+		{
+			name:              "ci",
+			platform:          "GitHub",
+			ciRunID:           "gha-12345-1",
+			expectedUserAgent: "tfci/" + version.GetVersion() + " github",
+		},
+		{
+			name:              "suffix",
+			platform:          "GitLab",
+			ciRunID:           "gitlab-42",
+			suffix:            " infra-pipeline ",
+			expectedUserAgent: "tfci/" + version.GetVersion() + " gitlab infra-pipeline",
+		},
+		{
+			name:              "local",
+			platform:          "Other",
+			expectedUserAgent: "tfci/" + version.GetVersion(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(envUserAgentSuffix, tc.suffix)
+
+			var headers http.Header
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				headers = r.Header.Clone()
+				w.Header().Set("TFP-API-Version", "2.6")
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer server.Close()
+
+			host := strings.TrimPrefix(server.URL, "https://")
+			if _, err := NewTfeClient(host, "token", "", tc.platform, tc.ciRunID, server.Client().Transport); err != nil {
+				t.Fatalf("expected no error but received %s", err)
+			}
+			if headers == nil {
+				t.Fatal("expected a request to be sent")
+			}
+			if agent := headers.Get("User-Agent"); agent != tc.expectedUserAgent {
+				t.Errorf("expected User-Agent %q but received %q", tc.expectedUserAgent, agent)
+			}
+			if _, ok := headers[http.CanonicalHeaderKey(ciRunIDHeader)]; ok != (tc.ciRunID != "") {
+				t.Errorf("expected the %s header to be sent only with a CI run ID but received %q", ciRunIDHeader, headers.Get(ciRunIDHeader))
+			}
+			if id := headers.Get(ciRunIDHeader); id != tc.ciRunID {
+				t.Errorf("expected %s %q but received %q", ciRunIDHeader, tc.ciRunID, id)
+			}
+		})
+	}
+}
@@ -0,0 +1,453 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cloud
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/tfci/internal/logging"
+)
+
+const (
+	envMaxRetries     = "TFCI_MAX_RETRIES"
+	envRetryBaseDelay = "TFCI_RETRY_BASE_DELAY"
+	envCACert         = "TFCI_CA_CERT"
+
+	defaultMaxRetries     = 5
+	defaultRetryBaseDelay = 1 * time.Second
+	// upper bound for a single exponential backoff delay, Retry-After is always honored
+	maxRetryDelay = 30 * time.Second
+)
+
+// retryTransport retries rate limited (429) responses for every method, and server errors (5xx) and failed
+// connections only for idempotent methods, as a POST may have been processed, eg. creating a duplicate run. It honors
+// the Retry-After header and otherwise uses exponential backoff with jitter. It is the only retry layer, go-tfe's
+// server error retries are disabled and it never receives a retryable 429, see ErrRateLimited
+type retryTransport struct {
+	next       http.RoundTripper
+	maxRetries int
+	baseDelay  time.Duration
+}
+
+// ErrRateLimited is returned when a request is still rate limited after the last retry. go-tfe retries every 429
+// response it receives, up to 30 times, so returning the response would multiply the retries
+var ErrRateLimited = errors.New("rate limited, too many requests")
+
+// ServerError is returned when HCP Terraform responds with a server error (5xx) after the last retry, or to a request
+// which is not retried. go-tfe reports it with the status text only, so the status code is kept for callers
+type ServerError struct {
+	StatusCode int
+	Method     string
+	Path       string
+}
+
+func (e *ServerError) Error() string {
+	return fmt.Sprintf("server error, %d %s: %s %s", e.StatusCode, http.StatusText(e.StatusCode), e.Method, e.Path)
+}
+
+// marks a request context whose requests are retried by the caller, see withoutRetries
+type noRetriesKey struct{}
+
+// returns a context whose requests are sent once by retryTransport, eg. the archive upload which has its own
+// retries. The body is streamed rather than buffered for a retry
+func withoutRetries(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRetriesKey{}, true)
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if noRetries, _ := req.Context().Value(noRetriesKey{}).(bool); noRetries {
+		resp, err := t.next.RoundTrip(req)
+		if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+			return rateLimited(req, resp, 0)
+		}
+		return serverError(req, resp, err)
+	}
+
+	getBody, err := rewindableBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 {
+			attemptReq = req.Clone(req.Context())
+			if attemptReq.Body, err = getBody(); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, rtErr := t.next.RoundTrip(attemptReq)
+		if !shouldRetry(req.Method, resp, rtErr) || req.Context().Err() != nil {
+			return serverError(req, resp, rtErr)
+		}
+		if attempt >= t.maxRetries {
+			if rtErr == nil && resp.StatusCode == http.StatusTooManyRequests {
+				return rateLimited(req, resp, t.maxRetries)
+			}
+			return serverError(req, resp, rtErr)
+		}
+
+		delay := t.retryDelay(attempt, resp)
+		logging.Debug("Retrying HCP Terraform request",
+			"method", req.Method,
+			"path", redactPath(req.URL.Path),
+			"attempt", attempt+1,
+			"max_retries", t.maxRetries,
+			"status", responseStatus(resp),
+			"error", rtErr,
+			"delay", delay.String())
+
+		if resp != nil {
+			// drain body so the connection can be reused
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// replaces a final rate limited response with ErrRateLimited, so go-tfe does not retry it
+func rateLimited(req *http.Request, resp *http.Response, retries int) (*http.Response, error) {
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return nil, fmt.Errorf("%w: %s %s after %d retries", ErrRateLimited, req.Method, redactPath(req.URL.Path), retries)
+}
+
+// replaces a final server error response with a ServerError. Responses of signed archivist urls, eg. log reads,
+// are left to their callers
+func serverError(req *http.Request, resp *http.Response, err error) (*http.Response, error) {
+	if err != nil || resp.StatusCode < http.StatusInternalServerError || strings.HasPrefix(req.URL.Path, "/v1/object/") {
+		return resp, err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return nil, &ServerError{StatusCode: resp.StatusCode, Method: req.Method, Path: req.URL.Path}
+}
+
+func (t *retryTransport) retryDelay(attempt int, resp *http.Response) time.Duration {
+	if retryAfter, ok := parseRetryAfter(resp); ok {
+		return retryAfter
+	}
+
+	delay := t.baseDelay << attempt
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	// jitter between half and the full delay to prevent a thundering herd of CI jobs
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// a rate limited request was not processed, so it is safe to retry for any method
+func shouldRetry(method string, resp *http.Response, err error) bool {
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if !isIdempotent(method) {
+		return false
+	}
+	return err != nil || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// https://www.rfc-editor.org/rfc/rfc9110#name-idempotent-methods
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// Retry-After may be expressed in seconds or as an http date
+func parseRetryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
+
+// returns a func to produce a fresh copy of the request body for each attempt
+func rewindableBody(req *http.Request) (func() (io.ReadCloser, error), error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return func() (io.ReadCloser, error) { return http.NoBody, nil }, nil
+	}
+	if req.GetBody != nil {
+		return req.GetBody, nil
+	}
+
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	return func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}, nil
+}
+
+func responseStatus(resp *http.Response) int {
+	if resp == nil {
+		return 0
+	}
+	return resp.StatusCode
+}
+
+// traceTransport logs each HCP Terraform API request at the trace level. Headers are never logged,
+// and query values which may hold credentials are redacted, see sanitizeURL
+type traceTransport struct {
+	next http.RoundTripper
+}
+
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	logging.Trace("HCP Terraform API request",
+		"method", req.Method,
+		"url", sanitizeURL(req.URL),
+		"status", responseStatus(resp),
+		"latency", time.Since(start).String(),
+		"error", err)
+	return resp, err
+}
+
+// query parameters whose values are safe to log, eg. pagination and filters
+var traceQueryAllowlist = []string{"page[", "include", "filter[", "search[", "q"}
+
+// returns the url path and query for logging. Signed archivist urls, eg. for logs and uploads, carry
+// credentials in the path and query, so they are redacted along with any query value that is not allowlisted
+func sanitizeURL(u *url.URL) string {
+	path := redactPath(u.Path)
+
+	query := u.Query()
+	if len(query) == 0 {
+		return path
+	}
+	for key := range query {
+		if !slices.ContainsFunc(traceQueryAllowlist, func(prefix string) bool {
+			return key == prefix || (strings.HasSuffix(prefix, "[") && strings.HasPrefix(key, prefix))
+		}) {
+			query.Set(key, "REDACTED")
+		}
+	}
+	// keep brackets readable, eg. page[number]=2
+	decoded, err := url.QueryUnescape(query.Encode())
+	if err != nil {
+		decoded = query.Encode()
+	}
+	return path + "?" + decoded
+}
+
+// signed archivist urls carry credentials in the path
+func redactPath(path string) string {
+	if strings.HasPrefix(path, "/v1/object/") {
+		return "/v1/object/REDACTED"
+	}
+	return path
+}
+
+// HCP Terraform resource IDs, eg. run-CZcmD7eagjhyX0vN
+var resourceIDPattern = regexp.MustCompile(`^[a-z]+-[a-zA-Z0-9]{16}$`)
+
+// the requests sent to an endpoint and their total latency
+type EndpointCalls struct {
+	Count   int
+	Latency time.Duration
+}
+
+// CountingTransport counts the requests sent through it by endpoint. Installed below the retry transport, each
+// retried attempt is counted as a request
+type CountingTransport struct {
+	next      http.RoundTripper
+	mu        sync.Mutex
+	endpoints map[string]*EndpointCalls
+}
+
+func (t *CountingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	latency := time.Since(start)
+
+	endpoint := req.Method + " " + endpointPath(req.URL)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	calls, ok := t.endpoints[endpoint]
+	if !ok {
+		calls = &EndpointCalls{}
+		t.endpoints[endpoint] = calls
+	}
+	calls.Count++
+	calls.Latency += latency
+	return resp, err
+}
+
+// the total number of requests sent
+func (t *CountingTransport) Count() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	count := 0
+	for _, calls := range t.endpoints {
+		count += calls.Count
+	}
+	return count
+}
+
+// the requests sent by endpoint, eg. "GET /api/v2/runs/:id"
+func (t *CountingTransport) Endpoints() map[string]EndpointCalls {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	endpoints := make(map[string]EndpointCalls, len(t.endpoints))
+	for endpoint, calls := range t.endpoints {
+		endpoints[endpoint] = *calls
+	}
+	return endpoints
+}
+
+// the url path with resource IDs replaced, so requests to the same endpoint are grouped
+func endpointPath(u *url.URL) string {
+	segments := strings.Split(redactPath(u.Path), "/")
+	for i, segment := range segments {
+		if resourceIDPattern.MatchString(segment) {
+			segments[i] = ":id"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func NewCountingTransport(next http.RoundTripper) *CountingTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &CountingTransport{next: next, endpoints: make(map[string]*EndpointCalls)}
+}
+
+// wraps the transport to trace every request, only when TF_LOG=TRACE as tracing is verbose
+func newTraceTransport(next http.RoundTripper) http.RoundTripper {
+	if !logging.TraceEnabled() {
+		return next
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &traceTransport{next: next}
+}
+
+func newRetryTransport(next http.RoundTripper) *retryTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	maxRetries := defaultMaxRetries
+	if v := os.Getenv(envMaxRetries); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			maxRetries = n
+		} else {
+			logging.Warn("Invalid max retries, using default", "value", v, "default", defaultMaxRetries)
+		}
+	}
+
+	baseDelay := defaultRetryBaseDelay
+	if v := os.Getenv(envRetryBaseDelay); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			baseDelay = d
+		} else {
+			logging.Warn("Invalid retry base delay, using default", "value", v, "default", defaultRetryBaseDelay.String())
+		}
+	}
+
+	return &retryTransport{
+		next:       next,
+		maxRetries: maxRetries,
+		baseDelay:  baseDelay,
+	}
+}
+
+// TLS settings of the connection to HCP Terraform or a Terraform Enterprise installation
+type TLSOptions struct {
+	// path to a PEM encoded root CA trusted in addition to the system roots, defaults to TFCI_CA_CERT
+	CACert string
+	// disables verifying the server's certificate chain and hostname, for exceptional use only
+	SkipVerify bool
+}
+
+// builds the base transport of every request to HCP Terraform. The proxy is read from HTTPS_PROXY, HTTP_PROXY and
+// NO_PROXY, eg. for a Terraform Enterprise installation behind a corporate proxy
+func NewHTTPTransport(opts TLSOptions) (*http.Transport, error) {
+	tlsConfig, err := newTLSConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+func newTLSConfig(opts TLSOptions) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	caCert, source := opts.CACert, "-ca-cert"
+	if caCert == "" {
+		caCert, source = os.Getenv(envCACert), envCACert
+	}
+	if caCert != "" {
+		pem, err := os.ReadFile(caCert)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA certificate from %s: %w", source, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			logging.Debug("System certificate pool is unavailable, only trusting the CA certificate", "error", err)
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA certificate %q from %s does not contain a PEM encoded certificate", caCert, source)
+		}
+		logging.Debug("Trusting custom CA certificate", "path", caCert, "source", source)
+		config.RootCAs = pool
+	}
+
+	if opts.SkipVerify {
+		logging.Warn("TLS certificate verification is disabled by -tls-skip-verify. Connections to HCP Terraform can be intercepted, including the API token. Use -ca-cert to trust a private CA instead")
+		config.InsecureSkipVerify = true
+	}
+	return config, nil
+}
@@ -0,0 +1,394 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cloud
+
+import (
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryTransport(t *testing.T) {
+	testCases := []struct {
+		name         string
+		method       string
+		statuses     []int
+		retryAfter   string
+		maxRetries   int
+		expectStatus int
+		expectErr    bool
+		// status of the ServerError returned instead of the response
+		expectServerError int
+		expectAttempts    int
+	}{
+		{
+			name:           "success-without-retry",
+			method:         http.MethodPost,
+			statuses:       []int{http.StatusOK},
+			maxRetries:     3,
+			expectStatus:   http.StatusOK,
+			expectAttempts: 1,
+		},
+		{
+			name:           "rate-limited-then-success",
+			method:         http.MethodPost,
+			statuses:       []int{http.StatusTooManyRequests, http.StatusOK},
+			retryAfter:     "0",
+			maxRetries:     3,
+			expectStatus:   http.StatusOK,
+			expectAttempts: 2,
+		},
+		{
+			name:           "server-error-then-success",
+			method:         http.MethodPut,
+			statuses:       []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK},
+			maxRetries:     3,
+			expectStatus:   http.StatusOK,
+			expectAttempts: 3,
+		},
+		{
+			name:              "server-error-not-retried-for-post",
+			method:            http.MethodPost,
+			statuses:          []int{http.StatusBadGateway, http.StatusOK},
+			maxRetries:        3,
+			expectServerError: http.StatusBadGateway,
+			expectAttempts:    1,
+		},
+		{
+			name:              "retries-are-bounded",
+			method:            http.MethodPut,
+			statuses:          []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError},
+			maxRetries:        2,
+			expectServerError: http.StatusInternalServerError,
+			expectAttempts:    3,
+		},
+		{
+			// go-tfe would retry a 429 response again
+			name:           "rate-limited-after-retries",
+			method:         http.MethodPost,
+			statuses:       []int{http.StatusTooManyRequests, http.StatusTooManyRequests},
+			retryAfter:     "0",
+			maxRetries:     1,
+			expectErr:      true,
+			expectAttempts: 2,
+		},
+		{
+			name:           "client-error-not-retried",
+			method:         http.MethodPut,
+			statuses:       []int{http.StatusNotFound, http.StatusOK},
+			maxRetries:     3,
+			expectStatus:   http.StatusNotFound,
+			expectAttempts: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if string(body) != "payload" {
+					t.Errorf("expected request body to be resent, received: %q", string(body))
+				}
+				status := tc.statuses[attempts]
+				attempts++
+				if tc.retryAfter != "" {
+					w.Header().Set("Retry-After", tc.retryAfter)
+				}
+				w.WriteHeader(status)
+			}))
+			defer server.Close()
+
+			client := &http.Client{Transport: &retryTransport{
+				next:       http.DefaultTransport,
+				maxRetries: tc.maxRetries,
+				baseDelay:  time.Millisecond,
+			}}
+
+			req, _ := http.NewRequest(tc.method, server.URL, strings.NewReader("payload"))
+			resp, err := client.Do(req)
+			var serverErr *ServerError
+			switch {
+			case tc.expectErr:
+				if !errors.Is(err, ErrRateLimited) {
+					t.Fatalf("expected rate limited error but received %v", err)
+				}
+			case tc.expectServerError != 0:
+				if !errors.As(err, &serverErr) || serverErr.StatusCode != tc.expectServerError {
+					t.Fatalf("expected server error %d but received %v", tc.expectServerError, err)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("expected %v but received %s", nil, err)
+				}
+				resp.Body.Close()
+
+				if resp.StatusCode != tc.expectStatus {
+					t.Errorf("expected status %d but received %d", tc.expectStatus, resp.StatusCode)
+				}
+			}
+			if attempts != tc.expectAttempts {
+				t.Errorf("expected %d attempts but received %d", tc.expectAttempts, attempts)
+			}
+		})
+	}
+}
+
+func TestRetryTransport_ConnectionError(t *testing.T) {
+	testCases := []struct {
+		method         string
+		expectAttempts int
+	}{
+		{method: http.MethodGet, expectAttempts: 3},
+		// the request may have been processed before the connection failed
+		{method: http.MethodPost, expectAttempts: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.method, func(t *testing.T) {
+			attempts := 0
+			client := &http.Client{Transport: &retryTransport{
+				next: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+					attempts++
+					return nil, errors.New("connection reset by peer")
+				}),
+				maxRetries: 2,
+				baseDelay:  time.Millisecond,
+			}}
+
+			req, _ := http.NewRequest(tc.method, "https://app.terraform.io/api/v2/runs", nil)
+			if _, err := client.Do(req); err == nil {
+				t.Fatal("expected connection error")
+			}
+			if attempts != tc.expectAttempts {
+				t.Errorf("expected %d attempts but received %d", tc.expectAttempts, attempts)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Set("Retry-After", "7")
+
+	delay, ok := parseRetryAfter(resp)
+	if !ok || delay != 7*time.Second {
+		t.Errorf("expected %v but received %v", 7*time.Second, delay)
+	}
+
+	resp.Header.Set("Retry-After", "invalid")
+	if _, ok := parseRetryAfter(resp); ok {
+		t.Errorf("expected invalid Retry-After header to be ignored")
+	}
+}
+
+func TestSanitizeURL(t *testing.T) {
+	testCases := []struct {
+		name     string
+		url      string
+		expected string
+	}{
+		{
+			name:     "path-only",
+			url:      "https://app.terraform.io/api/v2/runs/run-123",
+			expected: "/api/v2/runs/run-123",
+		},
+		{
+			name:     "allowlisted-query",
+			url:      "https://app.terraform.io/api/v2/organizations/hashicorp/workspaces?page%5Bnumber%5D=2&search%5Bname%5D=my-ws&include=current_run",
+			expected: "/api/v2/organizations/hashicorp/workspaces?include=current_run&page[number]=2&search[name]=my-ws",
+		},
+		{
+			name:     "redacted-query",
+			url:      "https://app.terraform.io/api/v2/runs?token=secret&page%5Bsize%5D=100",
+			expected: "/api/v2/runs?page[size]=100&token=REDACTED",
+		},
+		{
+			name:     "archivist-object",
+			url:      "https://archivist.terraform.io/v1/object/dmF1bHQ6djE6c2VjcmV0?signature=abc",
+			expected: "/v1/object/REDACTED?signature=REDACTED",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := url.Parse(tc.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sanitized := sanitizeURL(u); sanitized != tc.expected {
+				t.Errorf("expected %q but received %q", tc.expected, sanitized)
+			}
+		})
+	}
+}
+
+func TestTraceTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("created"))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &traceTransport{next: http.DefaultTransport}}
+	resp, err := client.Get(server.URL + "/api/v2/runs")
+	if err != nil {
+		t.Fatalf("expected no error but received %s", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusCreated || string(body) != "created" {
+		t.Errorf("expected the response to pass through but received %d %q", resp.StatusCode, body)
+	}
+}
+
+func TestNewHTTPTransport_CACert(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	caCert := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(caCert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	invalidCert := filepath.Join(dir, "invalid.pem")
+	if err := os.WriteFile(invalidCert, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name          string
+		options       TLSOptions
+		envCACert     string
+		expectErr     bool
+		expectTrusted bool
+	}{
+		{
+			name:    "system-roots",
+			options: TLSOptions{},
+		},
+		{
+			name:          "ca-cert-flag",
+			options:       TLSOptions{CACert: caCert},
+			expectTrusted: true,
+		},
+		{
+			name:          "ca-cert-env",
+			envCACert:     caCert,
+			expectTrusted: true,
+		},
+		{
+			name:          "skip-verify",
+			options:       TLSOptions{SkipVerify: true},
+			expectTrusted: true,
+		},
+		{
+			name:      "missing-ca-cert",
+			options:   TLSOptions{CACert: filepath.Join(dir, "missing.pem")},
+			expectErr: true,
+		},
+		{
+			name:      "invalid-ca-cert",
+			options:   TLSOptions{CACert: invalidCert},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(envCACert, tc.envCACert)
+
+			transport, err := NewHTTPTransport(tc.options)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error: %t but received: %v", tc.expectErr, err)
+			}
+			if tc.expectErr {
+				return
+			}
+			if transport.Proxy == nil {
+				t.Error("expected the proxy to be read from the environment")
+			}
+
+			// the test server's certificate is only trusted when its CA is in the pool, or verification is skipped
+			resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if trusted := err == nil; trusted != tc.expectTrusted {
+				t.Errorf("expected trusted: %t but received: %v", tc.expectTrusted, err)
+			}
+		})
+	}
+}
+
+func TestCountingTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	counter := NewCountingTransport(http.DefaultTransport)
+	client := &http.Client{Transport: counter}
+	for _, path := range []string{"/api/v2/runs/run-CZcmD7eagjhyX0vN", "/api/v2/runs/run-aBcD7eagjhyX0vN1", "/api/v2/organizations/hashicorp/workspaces/my-workspace"} {
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("expected no error but received %s", err)
+		}
+		resp.Body.Close()
+	}
+
+	if count := counter.Count(); count != 3 {
+		t.Errorf("expected 3 requests but received %d", count)
+	}
+	endpoints := counter.Endpoints()
+	expected := map[string]int{
+		"GET /api/v2/runs/:id": 2,
+		"GET /api/v2/organizations/hashicorp/workspaces/my-workspace": 1,
+	}
+	if len(endpoints) != len(expected) {
+		t.Errorf("expected endpoints %v but received %v", expected, endpoints)
+	}
+	for endpoint, count := range expected {
+		if endpoints[endpoint].Count != count {
+			t.Errorf("expected %d requests to %q but received %d", count, endpoint, endpoints[endpoint].Count)
+		}
+	}
+}
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// signed archivist urls carry credentials, their server errors are left to the caller
+func TestRetryTransport_ObjectServerError(t *testing.T) {
+	client := &http.Client{Transport: &retryTransport{
+		next: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody, Request: req}, nil
+		}),
+		maxRetries: 0,
+		baseDelay:  time.Millisecond,
+	}}
+
+	resp, err := client.Get("https://archivist.terraform.io/v1/object/dmF1bHQ6djE6c2lnbmVk")
+	if err != nil {
+		t.Fatalf("expected %v but received %s", nil, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected status %d but received %d", http.StatusServiceUnavailable, resp.StatusCode)
+	}
+}
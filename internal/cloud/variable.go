@@ -0,0 +1,118 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+type VariableService interface {
+	SetVariable(context.Context, SetVariableOptions) (*tfe.Variable, bool, error)
+	ApplyVariableSet(context.Context, VariableSetOptions) (*tfe.VariableSet, error)
+	RemoveVariableSet(context.Context, VariableSetOptions) (*tfe.VariableSet, error)
+}
+
+type SetVariableOptions struct {
+	Organization string
+	Workspace    string
+	Key          string
+	Value        string
+	// terraform or env
+	Category tfe.CategoryType
+	HCL      bool
+	// marks the variable sensitive, nil leaves an existing variable as is. A sensitive variable cannot be made
+	// non-sensitive again
+	Sensitive *bool
+}
+
+type variableService struct {
+	*cloudMeta
+}
+
+// creates the workspace variable, or updates it when a variable with the same key and category exists.
+// Reports whether the variable was created. The value is never logged.
+func (s *variableService) SetVariable(ctx context.Context, options SetVariableOptions) (*tfe.Variable, bool, error) {
+	if err := s.skipDryRun("set variable", "organization", options.Organization, "workspace", options.Workspace,
+		"key", options.Key, "category", options.Category, "hcl", options.HCL, "sensitive", options.Sensitive); err != nil {
+		return nil, false, err
+	}
+
+	w, err := s.resolveWorkspace(ctx, options.Organization, options.Workspace)
+	if err != nil {
+		return nil, false, err
+	}
+
+	existing, err := s.findVariable(ctx, w.ID, options.Key, options.Category)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing != nil {
+		v, err := s.updateVariable(ctx, w.ID, existing.ID, options)
+		return v, false, err
+	}
+
+	v, cErr := s.tfe.Variables.Create(ctx, w.ID, tfe.VariableCreateOptions{
+		Key:       tfe.String(options.Key),
+		Value:     tfe.String(options.Value),
+		Category:  tfe.Category(options.Category),
+		HCL:       tfe.Bool(options.HCL),
+		Sensitive: options.Sensitive,
+	})
+	if cErr == nil {
+		return v, true, nil
+	}
+
+	log.Printf("[ERROR] error creating variable: %q workspace: %q, error: %s", options.Key, options.Workspace, cErr)
+	// the variable may have been concurrently created, eg. by a parallel pipeline
+	if existing, err = s.findVariable(ctx, w.ID, options.Key, options.Category); err == nil && existing != nil {
+		v, err := s.updateVariable(ctx, w.ID, existing.ID, options)
+		return v, false, err
+	}
+	return nil, false, fmt.Errorf("failed to create variable %q in workspace %q: %w", options.Key, options.Workspace, cErr)
+}
+
+func (s *variableService) updateVariable(ctx context.Context, workspaceID string, variableID string, options SetVariableOptions) (*tfe.Variable, error) {
+	v, err := s.tfe.Variables.Update(ctx, workspaceID, variableID, tfe.VariableUpdateOptions{
+		Value:     tfe.String(options.Value),
+		HCL:       tfe.Bool(options.HCL),
+		Sensitive: options.Sensitive,
+	})
+	if err != nil {
+		log.Printf("[ERROR] error updating variable: %q workspace: %q, error: %s", options.Key, options.Workspace, err)
+		return nil, fmt.Errorf("failed to update variable %q in workspace %q: %w", options.Key, options.Workspace, err)
+	}
+	return v, nil
+}
+
+// returns the workspace variable with the key and category, or nil when there is none
+func (s *variableService) findVariable(ctx context.Context, workspaceID string, key string, category tfe.CategoryType) (*tfe.Variable, error) {
+	listOpts := &tfe.VariableListOptions{
+		ListOptions: tfe.ListOptions{PageNumber: 1, PageSize: maxPageSize},
+	}
+	for {
+		list, err := s.tfe.Variables.List(ctx, workspaceID, listOpts)
+		if err != nil {
+			log.Printf("[ERROR] error listing variables of workspace: %q, error: %s", workspaceID, err)
+			return nil, fmt.Errorf("failed to list variables of workspace %q: %w", workspaceID, err)
+		}
+		for _, v := range list.Items {
+			if v.Key == key && v.Category == category {
+				return v, nil
+			}
+		}
+
+		if list.Pagination == nil || list.NextPage == 0 {
+			return nil, nil
+		}
+		listOpts.PageNumber = list.NextPage
+	}
+}
+
+func NewVariableService(meta *cloudMeta) *variableService {
+	return &variableService{meta}
+}
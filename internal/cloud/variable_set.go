@@ -0,0 +1,120 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+type VariableSetOptions struct {
+	Organization string
+	Workspace    string
+	// variable set name or ID
+	VariableSet string
+}
+
+// returned when assigning a global variable set, which already applies to every workspace
+// of the organization and cannot be attached to or removed from a single workspace
+type GlobalVariableSetError struct {
+	VariableSet string
+}
+
+func (e *GlobalVariableSetError) Error() string {
+	return fmt.Sprintf("variable set %q is global and applies to every workspace, it cannot be assigned to a single workspace. Change the variable set to apply to specific workspaces in its settings first", e.VariableSet)
+}
+
+// attaches the variable set to the workspace, attaching an already attached variable set succeeds
+func (s *variableService) ApplyVariableSet(ctx context.Context, options VariableSetOptions) (*tfe.VariableSet, error) {
+	if err := s.skipDryRun("apply variable set", "organization", options.Organization, "workspace", options.Workspace, "variable_set", options.VariableSet); err != nil {
+		return nil, err
+	}
+
+	vs, w, err := s.resolveAssignment(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tfe.VariableSets.ApplyToWorkspaces(ctx, vs.ID, &tfe.VariableSetApplyToWorkspacesOptions{
+		Workspaces: []*tfe.Workspace{w},
+	}); err != nil {
+		log.Printf("[ERROR] error applying variable set: %q to workspace: %q, error: %s", vs.ID, options.Workspace, err)
+		return nil, fmt.Errorf("failed to apply variable set %q to workspace %q: %w", options.VariableSet, options.Workspace, err)
+	}
+	return vs, nil
+}
+
+// detaches the variable set from the workspace, detaching a variable set that is not attached succeeds
+func (s *variableService) RemoveVariableSet(ctx context.Context, options VariableSetOptions) (*tfe.VariableSet, error) {
+	if err := s.skipDryRun("remove variable set", "organization", options.Organization, "workspace", options.Workspace, "variable_set", options.VariableSet); err != nil {
+		return nil, err
+	}
+
+	vs, w, err := s.resolveAssignment(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tfe.VariableSets.RemoveFromWorkspaces(ctx, vs.ID, &tfe.VariableSetRemoveFromWorkspacesOptions{
+		Workspaces: []*tfe.Workspace{w},
+	}); err != nil {
+		log.Printf("[ERROR] error removing variable set: %q from workspace: %q, error: %s", vs.ID, options.Workspace, err)
+		return nil, fmt.Errorf("failed to remove variable set %q from workspace %q: %w", options.VariableSet, options.Workspace, err)
+	}
+	return vs, nil
+}
+
+// resolves the non-global variable set and the workspace to assign it to
+func (s *variableService) resolveAssignment(ctx context.Context, options VariableSetOptions) (*tfe.VariableSet, *tfe.Workspace, error) {
+	vs, err := s.resolveVariableSet(ctx, options.Organization, options.VariableSet)
+	if err != nil {
+		return nil, nil, err
+	}
+	if vs.Global {
+		return nil, nil, &GlobalVariableSetError{VariableSet: vs.Name}
+	}
+
+	w, err := s.resolveWorkspace(ctx, options.Organization, options.Workspace)
+	if err != nil {
+		return nil, nil, err
+	}
+	return vs, w, nil
+}
+
+// resolves a variable set by ID, eg. `varset-***`, or by its exact name
+func (s *variableService) resolveVariableSet(ctx context.Context, orgName string, variableSet string) (*tfe.VariableSet, error) {
+	if strings.HasPrefix(variableSet, "varset-") {
+		vs, err := s.tfe.VariableSets.Read(ctx, variableSet, nil)
+		if err != nil {
+			log.Printf("[ERROR] error reading variable set: %q, error: %s", variableSet, err)
+			return nil, fmt.Errorf("failed to read variable set %q: %w", variableSet, err)
+		}
+		return vs, nil
+	}
+
+	// the query matches names partially, so the exact name is matched on every page
+	listOpts := &tfe.VariableSetListOptions{
+		ListOptions: tfe.ListOptions{PageNumber: 1, PageSize: maxPageSize},
+		Query:       variableSet,
+	}
+	for {
+		list, err := s.tfe.VariableSets.List(ctx, orgName, listOpts)
+		if err != nil {
+			log.Printf("[ERROR] error listing variable sets: %q organization: %q, error: %s", variableSet, orgName, err)
+			return nil, fmt.Errorf("failed to resolve variable set %q in organization %q: %w", variableSet, orgName, err)
+		}
+		for _, vs := range list.Items {
+			if vs.Name == variableSet {
+				return vs, nil
+			}
+		}
+
+		if list.Pagination == nil || list.NextPage == 0 {
+			return nil, fmt.Errorf("variable set %q was not found in organization %q", variableSet, orgName)
+		}
+		listOpts.PageNumber = list.NextPage
+	}
+}
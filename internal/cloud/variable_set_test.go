@@ -0,0 +1,67 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cloud
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/go-tfe/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestVariableService_ApplyVariableSet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mVariableSets := mocks.NewMockVariableSets(ctrl)
+	mWorkspaces := mocks.NewMockWorkspaces(ctrl)
+	workspace := &tfe.Workspace{ID: "ws-123"}
+	gomock.InOrder(
+		// the query matches partially, the exact name is on the second page
+		mVariableSets.EXPECT().List(ctx, "test", &tfe.VariableSetListOptions{ListOptions: tfe.ListOptions{PageNumber: 1, PageSize: maxPageSize}, Query: "aws"}).
+			Return(&tfe.VariableSetList{
+				Items:      []*tfe.VariableSet{{ID: "varset-other", Name: "aws-staging"}},
+				Pagination: &tfe.Pagination{NextPage: 2},
+			}, nil),
+		mVariableSets.EXPECT().List(ctx, "test", &tfe.VariableSetListOptions{ListOptions: tfe.ListOptions{PageNumber: 2, PageSize: maxPageSize}, Query: "aws"}).
+			Return(&tfe.VariableSetList{
+				Items:      []*tfe.VariableSet{{ID: "varset-123", Name: "aws"}},
+				Pagination: &tfe.Pagination{},
+			}, nil),
+		mWorkspaces.EXPECT().Read(ctx, "test", "my-workspace").Return(workspace, nil),
+		mVariableSets.EXPECT().ApplyToWorkspaces(ctx, "varset-123", &tfe.VariableSetApplyToWorkspacesOptions{
+			Workspaces: []*tfe.Workspace{workspace},
+		}).Return(nil),
+	)
+
+	client := NewVariableService(&cloudMeta{tfe: &tfe.Client{VariableSets: mVariableSets, Workspaces: mWorkspaces}, writer: &defaultWriter{}})
+	vs, err := client.ApplyVariableSet(ctx, VariableSetOptions{Organization: "test", Workspace: "my-workspace", VariableSet: "aws"})
+	if err != nil {
+		t.Fatalf("expected no error but received %s", err)
+	}
+	if vs.ID != "varset-123" {
+		t.Errorf("expected variable set %q but received %q", "varset-123", vs.ID)
+	}
+}
+
+func TestVariableService_RemoveVariableSet_Global(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mVariableSets := mocks.NewMockVariableSets(ctrl)
+	mVariableSets.EXPECT().Read(ctx, "varset-123", nil).Return(&tfe.VariableSet{ID: "varset-123", Name: "shared", Global: true}, nil)
+
+	client := NewVariableService(&cloudMeta{tfe: &tfe.Client{VariableSets: mVariableSets}, writer: &defaultWriter{}})
+	_, err := client.RemoveVariableSet(ctx, VariableSetOptions{Organization: "test", Workspace: "my-workspace", VariableSet: "varset-123"})
+
+	var globalErr *GlobalVariableSetError
+	if !errors.As(err, &globalErr) {
+		t.Fatalf("expected global variable set error but received %v", err)
+	}
+}
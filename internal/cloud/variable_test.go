@@ -0,0 +1,98 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cloud
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/go-tfe/mocks"
+	"go.uber.org/mock/gomock"
+)
+
+func TestVariableService_SetVariable(t *testing.T) {
+	listOpts := &tfe.VariableListOptions{ListOptions: tfe.ListOptions{PageNumber: 1, PageSize: maxPageSize}}
+	options := SetVariableOptions{
+		Organization: "test",
+		Workspace:    "my-workspace",
+		Key:          "ami_id",
+		Value:        "ami-123",
+		Category:     tfe.CategoryTerraform,
+	}
+
+	testCases := []struct {
+		name            string
+		mock            func(ctx context.Context, mVariables *mocks.MockVariables)
+		expectedCreated bool
+	}{
+		{
+			name: "created",
+			mock: func(ctx context.Context, mVariables *mocks.MockVariables) {
+				mVariables.EXPECT().List(ctx, "ws-123", listOpts).Return(&tfe.VariableList{
+					// same key in another category is a different variable
+					Items: []*tfe.Variable{{ID: "var-env", Key: "ami_id", Category: tfe.CategoryEnv}},
+				}, nil)
+				mVariables.EXPECT().Create(ctx, "ws-123", tfe.VariableCreateOptions{
+					Key:      tfe.String("ami_id"),
+					Value:    tfe.String("ami-123"),
+					Category: tfe.Category(tfe.CategoryTerraform),
+					HCL:      tfe.Bool(false),
+				}).Return(&tfe.Variable{ID: "var-new"}, nil)
+			},
+			expectedCreated: true,
+		},
+		{
+			name: "updated",
+			mock: func(ctx context.Context, mVariables *mocks.MockVariables) {
+				mVariables.EXPECT().List(ctx, "ws-123", listOpts).Return(&tfe.VariableList{
+					Items: []*tfe.Variable{{ID: "var-existing", Key: "ami_id", Category: tfe.CategoryTerraform}},
+				}, nil)
+				mVariables.EXPECT().Update(ctx, "ws-123", "var-existing", tfe.VariableUpdateOptions{
+					Value: tfe.String("ami-123"),
+					HCL:   tfe.Bool(false),
+				}).Return(&tfe.Variable{ID: "var-existing"}, nil)
+			},
+		},
+		{
+			name: "concurrently-created",
+			mock: func(ctx context.Context, mVariables *mocks.MockVariables) {
+				gomock.InOrder(
+					mVariables.EXPECT().List(ctx, "ws-123", listOpts).Return(&tfe.VariableList{}, nil),
+					mVariables.EXPECT().Create(ctx, "ws-123", gomock.Any()).Return(nil, errors.New("key has already been taken")),
+					mVariables.EXPECT().List(ctx, "ws-123", listOpts).Return(&tfe.VariableList{
+						Items: []*tfe.Variable{{ID: "var-existing", Key: "ami_id", Category: tfe.CategoryTerraform}},
+					}, nil),
+					mVariables.EXPECT().Update(ctx, "ws-123", "var-existing", gomock.Any()).Return(&tfe.Variable{ID: "var-existing"}, nil),
+				)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx := context.Background()
+			mWorkspaces := mocks.NewMockWorkspaces(ctrl)
+			mWorkspaces.EXPECT().Read(ctx, "test", "my-workspace").Return(&tfe.Workspace{ID: "ws-123"}, nil)
+			mVariables := mocks.NewMockVariables(ctrl)
+			tc.mock(ctx, mVariables)
+
+			client := NewVariableService(&cloudMeta{tfe: &tfe.Client{Workspaces: mWorkspaces, Variables: mVariables}, writer: &defaultWriter{}})
+			variable, created, err := client.SetVariable(ctx, options)
+			if err != nil {
+				t.Fatalf("expected no error but received %s", err)
+			}
+			if created != tc.expectedCreated {
+				t.Errorf("expected created %t but received %t", tc.expectedCreated, created)
+			}
+			if variable == nil || variable.ID == "" {
+				t.Errorf("expected a variable but received %v", variable)
+			}
+		})
+	}
+}
@@ -5,15 +5,124 @@ package cloud
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/logging"
 	"github.com/sethvargo/go-retry"
 )
 
+const (
+	envOutputSizeWarning     = "TFCI_OUTPUT_SIZE_WARNING"
+	defaultOutputSizeWarning = 1024 * 1024
+)
+
 type WorkspaceService interface {
+	GetWorkspace(context.Context, string, string) (*tfe.Workspace, error)
 	ReadStateOutputs(context.Context, string, string) (*tfe.StateVersionOutputsList, error)
+	WaitForStateVersion(context.Context, string, string, int64) (*tfe.StateVersion, error)
+	ReadCurrentStateVersion(context.Context, string, string) (*tfe.StateVersion, error)
+	DownloadState(context.Context, *tfe.StateVersion) ([]byte, error)
+	GetAssessmentResult(context.Context, string, string) (*AssessmentResult, error)
+	GetAssessmentDrift(context.Context, string) ([]*DriftedResource, error)
+	CreateWorkspace(context.Context, CreateWorkspaceOptions) (*tfe.Workspace, error)
+	ListWorkspacesByTags(context.Context, string, []string) ([]*tfe.Workspace, error)
+	SafeDeleteWorkspace(context.Context, string, string) error
+	LockWorkspace(context.Context, LockWorkspaceOptions) (*tfe.Workspace, bool, error)
+	UnlockWorkspace(context.Context, UnlockWorkspaceOptions) (*tfe.Workspace, bool, error)
+}
+
+type LockWorkspaceOptions struct {
+	Organization string
+	Workspace    string
+	Reason       string
+}
+
+type UnlockWorkspaceOptions struct {
+	Organization string
+	Workspace    string
+	// unlocks a lock held by another user, team or run with the force-unlock endpoint
+	Force bool
+}
+
+type CreateWorkspaceOptions struct {
+	Organization string
+	Name         string
+	// project name or ID, defaults to the organization's default project
+	Project          string
+	ExecutionMode    string
+	TerraformVersion string
+	Tags             []string
+}
+
+// health assessment result for a workspace, not currently supported by github.com/hashicorp/go-tfe
+// https://developer.hashicorp.com/terraform/cloud-docs/api-docs/assessment-results
+type AssessmentResult struct {
+	ID               string    `jsonapi:"primary,assessment-results"`
+	Drifted          bool      `jsonapi:"attr,drifted"`
+	Succeeded        bool      `jsonapi:"attr,succeeded"`
+	ErrorMsg         string    `jsonapi:"attr,error-msg"`
+	ResourcesDrifted int       `jsonapi:"attr,resources-drifted"`
+	CreatedAt        time.Time `jsonapi:"attr,created-at,iso8601"`
+}
+
+// a resource that changed outside of Terraform, as reported by a health assessment
+type DriftedResource struct {
+	Address string   `json:"address"`
+	Type    string   `json:"type"`
+	Actions []string `json:"actions"`
+	// top-level attributes whose values differ from the state
+	ChangedAttributes []string `json:"changed_attributes"`
+}
+
+// subset of the assessment's json plan describing drift
+// https://developer.hashicorp.com/terraform/internals/json-format#plan-representation
+type assessmentPlan struct {
+	ResourceDrift []struct {
+		Address string `json:"address"`
+		Type    string `json:"type"`
+		Change  struct {
+			Actions []string               `json:"actions"`
+			Before  map[string]interface{} `json:"before"`
+			After   map[string]interface{} `json:"after"`
+		} `json:"change"`
+	} `json:"resource_drift"`
+}
+
+// returned when a workspace does not exist, HCP Terraform also responds with not found
+// when the token does not have access to the workspace
+type WorkspaceNotFoundError struct {
+	Organization string
+	Workspace    string
+
+	err error
+}
+
+func (e *WorkspaceNotFoundError) Error() string {
+	return fmt.Sprintf("workspace %q was not found in organization %q, verify the workspace exists and the token has access to it", e.Workspace, e.Organization)
+}
+
+func (e *WorkspaceNotFoundError) Unwrap() error { return e.err }
+
+// returned when locking a workspace whose lock is held by another user, team or run, which the lock would not protect
+type WorkspaceLockedError struct {
+	Workspace string
+	// who holds the lock, eg. run "run-***"
+	LockedBy string
+}
+
+func (e *WorkspaceLockedError) Error() string {
+	return fmt.Sprintf("workspace %q is already locked by %s, wait for the lock to be released or unlock it with `workspace unlock -force`", e.Workspace, e.LockedBy)
 }
 
 type workspaceService struct {
@@ -31,10 +140,37 @@ func wServiceBackoff() retry.Backoff {
 	return backoff
 }
 
+// resolves a workspace by name, shared by all services. Rate limited and server error responses
+// are retried with backoff by the client's retryTransport, so a genuine not found response is
+// distinguished from a transient failure that persisted after retries.
+func (m *cloudMeta) resolveWorkspace(ctx context.Context, orgName string, wName string) (*tfe.Workspace, error) {
+	w, wErr := m.tfe.Workspaces.Read(ctx, orgName, wName)
+	if wErr == nil {
+		return w, nil
+	}
+
+	log.Printf("[ERROR] error reading workspace: %q organization: %q, error: %s", wName, orgName, wErr)
+	if errors.Is(wErr, tfe.ErrResourceNotFound) {
+		return nil, &WorkspaceNotFoundError{Organization: orgName, Workspace: wName, err: wErr}
+	}
+	return nil, fmt.Errorf("failed to resolve workspace %q in organization %q: %w", wName, orgName, wErr)
+}
+
+func (s *workspaceService) GetWorkspace(ctx context.Context, orgName string, wName string) (*tfe.Workspace, error) {
+	if s.placeholderDryRun("read workspace", "organization", orgName, "workspace", wName) {
+		return &tfe.Workspace{Name: wName}, nil
+	}
+
+	return s.resolveWorkspace(ctx, orgName, wName)
+}
+
 func (s *workspaceService) ReadStateOutputs(ctx context.Context, orgName string, wName string) (*tfe.StateVersionOutputsList, error) {
-	w, wErr := s.tfe.Workspaces.Read(ctx, orgName, wName)
+	if err := s.skipDryRun("read state outputs", "organization", orgName, "workspace", wName); err != nil {
+		return nil, err
+	}
+
+	w, wErr := s.resolveWorkspace(ctx, orgName, wName)
 	if wErr != nil {
-		log.Printf("[ERROR] error reading workspace: %q organization: %q, error: %s", wName, orgName, wErr)
 		return nil, wErr
 	}
 
@@ -66,13 +202,415 @@ func (s *workspaceService) ReadStateOutputs(ctx context.Context, orgName string,
 		}
 	}
 
-	svoList, svoErr := s.tfe.StateVersionOutputs.ReadCurrent(ctx, w.ID)
+	svoList, svoErr := s.listStateOutputs(ctx, currentSV.ID)
 	if svoErr != nil {
 		log.Printf("[ERROR] error reading state version output list: %s", svoErr)
 		return nil, svoErr
 	}
 
-	return svoList, svoErr
+	warnLargeOutputs(svoList.Items)
+	return svoList, nil
+}
+
+// polls until the workspace's current state version serial is at least the provided serial
+// primarily to prevent reading stale outputs before an apply in another job has finished
+func (s *workspaceService) WaitForStateVersion(ctx context.Context, orgName string, wName string, serial int64) (*tfe.StateVersion, error) {
+	if err := s.skipDryRun("wait for state version", "organization", orgName, "workspace", wName, "serial", serial); err != nil {
+		return nil, err
+	}
+
+	w, wErr := s.GetWorkspace(ctx, orgName, wName)
+	if wErr != nil {
+		return nil, wErr
+	}
+
+	var currentSV *tfe.StateVersion
+	retryErr := retry.Do(ctx, s.backoff(), func(ctx context.Context) error {
+		sv, csvErr := s.tfe.StateVersions.ReadCurrent(ctx, w.ID)
+		// return non-retryable error
+		if csvErr != nil {
+			return csvErr
+		}
+		currentSV = sv
+
+		s.writer.Output(fmt.Sprintf("Current State Version Serial: %d", sv.Serial))
+		if sv.Serial >= serial {
+			return nil
+		}
+		return retryableTimeoutError("wait for state version serial")
+	})
+
+	if retryErr != nil {
+		log.Printf("[ERROR] error waiting for state version serial: %d, error: %s", serial, retryErr)
+		return currentSV, retryErr
+	}
+
+	return currentSV, nil
+}
+
+func (s *workspaceService) ReadCurrentStateVersion(ctx context.Context, orgName string, wName string) (*tfe.StateVersion, error) {
+	if err := s.skipDryRun("read current state version", "organization", orgName, "workspace", wName); err != nil {
+		return nil, err
+	}
+
+	w, wErr := s.resolveWorkspace(ctx, orgName, wName)
+	if wErr != nil {
+		return nil, wErr
+	}
+
+	currentSV, csvErr := s.tfe.StateVersions.ReadCurrent(ctx, w.ID)
+	if csvErr != nil {
+		log.Printf("[ERROR] error reading current state version for workspace: %q, error: %s", wName, csvErr)
+		return nil, csvErr
+	}
+	return currentSV, nil
+}
+
+// downloads the raw state of the state version, callers must never log the state as it may contain secrets
+func (s *workspaceService) DownloadState(ctx context.Context, sv *tfe.StateVersion) ([]byte, error) {
+	if err := s.skipDryRun("download state"); err != nil {
+		return nil, err
+	}
+
+	if sv.DownloadURL == "" {
+		return nil, fmt.Errorf("state version %q has no download url", sv.ID)
+	}
+
+	state, err := s.tfe.StateVersions.Download(ctx, sv.DownloadURL)
+	if err != nil {
+		log.Printf("[ERROR] error downloading state version: %q, error: %s", sv.ID, err)
+		return nil, err
+	}
+	return state, nil
+}
+
+// returns nil result when health assessments are not enabled or no assessment has completed yet
+func (s *workspaceService) GetAssessmentResult(ctx context.Context, orgName string, wName string) (*AssessmentResult, error) {
+	// without a placeholder assessment the drift check is skipped, as when assessments are not enabled
+	if s.placeholderDryRun("read assessment result", "organization", orgName, "workspace", wName) {
+		return nil, nil
+	}
+
+	w, wErr := s.GetWorkspace(ctx, orgName, wName)
+	if wErr != nil {
+		return nil, wErr
+	}
+
+	req, reqErr := s.tfe.NewRequest("GET", fmt.Sprintf("workspaces/%s/current-assessment-result", url.PathEscape(w.ID)), nil)
+	if reqErr != nil {
+		return nil, reqErr
+	}
+
+	result := &AssessmentResult{}
+	if err := req.Do(ctx, result); err != nil {
+		if errors.Is(err, tfe.ErrResourceNotFound) {
+			log.Printf("[DEBUG] no current assessment result found for workspace: %q", wName)
+			return nil, nil
+		}
+		log.Printf("[ERROR] error reading current assessment result for workspace: %q, error: %s", wName, err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// reads the drifted resources of an assessment from its json plan, which requires admin access to the workspace
+func (s *workspaceService) GetAssessmentDrift(ctx context.Context, assessmentID string) ([]*DriftedResource, error) {
+	if err := s.skipDryRun("read assessment drift", "assessment_id", assessmentID); err != nil {
+		return nil, err
+	}
+
+	req, reqErr := s.tfe.NewRequest("GET", fmt.Sprintf("assessment-results/%s/json-output", url.PathEscape(assessmentID)), nil)
+	if reqErr != nil {
+		return nil, reqErr
+	}
+
+	plan := &assessmentPlan{}
+	if err := req.DoJSON(ctx, plan); err != nil {
+		log.Printf("[ERROR] error reading json output for assessment result: %q, error: %s", assessmentID, err)
+		return nil, err
+	}
+
+	drifted := make([]*DriftedResource, 0, len(plan.ResourceDrift))
+	for _, r := range plan.ResourceDrift {
+		drifted = append(drifted, &DriftedResource{
+			Address:           r.Address,
+			Type:              r.Type,
+			Actions:           r.Change.Actions,
+			ChangedAttributes: changedAttributes(r.Change.Before, r.Change.After),
+		})
+	}
+	return drifted, nil
+}
+
+// returns the sorted top-level attribute names with differing values
+func changedAttributes(before, after map[string]interface{}) []string {
+	changed := []string{}
+	for key, val := range before {
+		if afterVal, ok := after[key]; !ok || !reflect.DeepEqual(val, afterVal) {
+			changed = append(changed, key)
+		}
+	}
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// reads every page of the state version's outputs, a workspace may have more outputs than fit in a single page
+func (s *workspaceService) listStateOutputs(ctx context.Context, svID string) (*tfe.StateVersionOutputsList, error) {
+	svoList := &tfe.StateVersionOutputsList{Items: []*tfe.StateVersionOutput{}}
+	listOpts := &tfe.StateVersionOutputsListOptions{
+		ListOptions: tfe.ListOptions{PageNumber: 1, PageSize: maxPageSize},
+	}
+	for {
+		page, err := s.tfe.StateVersions.ListOutputs(ctx, svID, listOpts)
+		if err != nil {
+			return nil, err
+		}
+		svoList.Items = append(svoList.Items, page.Items...)
+
+		if page.Pagination == nil || page.NextPage == 0 {
+			return svoList, nil
+		}
+		listOpts.PageNumber = page.NextPage
+	}
+}
+
+// warns about outputs whose JSON value exceeds TFCI_OUTPUT_SIZE_WARNING bytes, as platforms
+// limit the size of step outputs
+func warnLargeOutputs(items []*tfe.StateVersionOutput) {
+	limit := outputSizeWarning()
+	if limit == 0 {
+		return
+	}
+	for _, svo := range items {
+		value, err := json.Marshal(svo.Value)
+		if err != nil || len(value) <= limit {
+			continue
+		}
+		logging.Warn("Output value is unusually large and may exceed platform output limits",
+			"name", svo.Name,
+			"size_bytes", len(value),
+			"warning_size_bytes", limit)
+	}
+}
+
+// the output size in bytes above which a warning is logged, zero disables the warning
+func outputSizeWarning() int {
+	if v := os.Getenv(envOutputSizeWarning); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			return n
+		}
+		logging.Warn("Invalid output size warning, using default", "value", v, "default", defaultOutputSizeWarning)
+	}
+	return defaultOutputSizeWarning
+}
+
+// lists the workspaces having all of the given tags, sorted by name
+func (s *workspaceService) ListWorkspacesByTags(ctx context.Context, orgName string, tags []string) ([]*tfe.Workspace, error) {
+	if err := s.skipDryRun("list workspaces by tags", "organization", orgName, "tags", tags); err != nil {
+		return nil, err
+	}
+
+	workspaces := []*tfe.Workspace{}
+	listOpts := &tfe.WorkspaceListOptions{
+		ListOptions: tfe.ListOptions{PageNumber: 1, PageSize: maxPageSize},
+		Tags:        strings.Join(tags, ","),
+	}
+	for {
+		list, err := s.tfe.Workspaces.List(ctx, orgName, listOpts)
+		if err != nil {
+			log.Printf("[ERROR] error listing workspaces with tags: %q organization: %q, error: %s", tags, orgName, err)
+			return nil, fmt.Errorf("failed to list workspaces with tags %q in organization %q: %w", tags, orgName, err)
+		}
+		workspaces = append(workspaces, list.Items...)
+
+		if list.Pagination == nil || list.NextPage == 0 {
+			return workspaces, nil
+		}
+		listOpts.PageNumber = list.NextPage
+	}
+}
+
+// deletes the workspace only when it is not managing any resources, otherwise returns
+// tfe.ErrWorkspaceNotSafeToDelete or tfe.ErrWorkspaceStillProcessing
+func (s *workspaceService) SafeDeleteWorkspace(ctx context.Context, orgName string, wName string) error {
+	if err := s.skipDryRun("safe delete workspace", "organization", orgName, "workspace", wName); err != nil {
+		return err
+	}
+
+	if err := s.tfe.Workspaces.SafeDelete(ctx, orgName, wName); err != nil {
+		log.Printf("[ERROR] error safe deleting workspace: %q organization: %q, error: %s", wName, orgName, err)
+		if errors.Is(err, tfe.ErrResourceNotFound) {
+			return &WorkspaceNotFoundError{Organization: orgName, Workspace: wName, err: err}
+		}
+		return err
+	}
+	return nil
+}
+
+// locks the workspace, reports whether the current user already held the lock, in which case the existing lock is kept.
+// A lock held by another user, team or run returns a WorkspaceLockedError
+func (s *workspaceService) LockWorkspace(ctx context.Context, options LockWorkspaceOptions) (*tfe.Workspace, bool, error) {
+	if err := s.skipDryRun("lock workspace", "organization", options.Organization, "workspace", options.Workspace, "reason", options.Reason); err != nil {
+		return nil, false, err
+	}
+
+	w, err := s.resolveWorkspace(ctx, options.Organization, options.Workspace)
+	if err != nil {
+		return nil, false, err
+	}
+	if w.Locked {
+		return s.checkLockHolder(ctx, w)
+	}
+
+	locked, err := s.tfe.Workspaces.Lock(ctx, w.ID, tfe.WorkspaceLockOptions{Reason: tfe.String(options.Reason)})
+	if err != nil {
+		log.Printf("[ERROR] error locking workspace: %q organization: %q, error: %s", options.Workspace, options.Organization, err)
+		// locked concurrently, eg. by a run started since the workspace was read
+		if errors.Is(err, tfe.ErrWorkspaceLocked) {
+			current, rErr := s.resolveWorkspace(ctx, options.Organization, options.Workspace)
+			if rErr != nil {
+				return nil, false, rErr
+			}
+			return s.checkLockHolder(ctx, current)
+		}
+		return nil, false, fmt.Errorf("failed to lock workspace %q in organization %q: %w", options.Workspace, options.Organization, err)
+	}
+	return locked, false, nil
+}
+
+// reports a lock held by the current user as already locked. A lock held by a team or run is never the current
+// user's, even with a team token, so the caller cannot assume the workspace is locked for it
+func (s *workspaceService) checkLockHolder(ctx context.Context, w *tfe.Workspace) (*tfe.Workspace, bool, error) {
+	if w.LockedBy != nil && w.LockedBy.User != nil {
+		user, err := s.tfe.Users.ReadCurrent(ctx)
+		if err != nil {
+			log.Printf("[ERROR] error reading current user: %s", err)
+			return nil, false, fmt.Errorf("failed to read the current user to check the lock of workspace %q: %w", w.Name, err)
+		}
+		if user.ID == w.LockedBy.User.ID {
+			return w, true, nil
+		}
+	}
+	return nil, false, &WorkspaceLockedError{Workspace: w.Name, LockedBy: lockHolder(w.LockedBy)}
+}
+
+// describes who holds a workspace lock
+func lockHolder(lockedBy *tfe.LockedByChoice) string {
+	switch {
+	case lockedBy == nil:
+		return "an unknown holder"
+	case lockedBy.Run != nil:
+		return fmt.Sprintf("run %q", lockedBy.Run.ID)
+	case lockedBy.Team != nil:
+		return fmt.Sprintf("team %q", lockedBy.Team.ID)
+	case lockedBy.User != nil:
+		return fmt.Sprintf("user %q", lockedBy.User.ID)
+	default:
+		return "an unknown holder"
+	}
+}
+
+// unlocks the workspace, reports whether it was already unlocked. Without Force, a lock held by another user, team
+// or run is not released
+func (s *workspaceService) UnlockWorkspace(ctx context.Context, options UnlockWorkspaceOptions) (*tfe.Workspace, bool, error) {
+	if err := s.skipDryRun("unlock workspace", "organization", options.Organization, "workspace", options.Workspace, "force", options.Force); err != nil {
+		return nil, false, err
+	}
+
+	w, err := s.resolveWorkspace(ctx, options.Organization, options.Workspace)
+	if err != nil {
+		return nil, false, err
+	}
+	if !w.Locked {
+		return w, true, nil
+	}
+
+	unlock := s.tfe.Workspaces.Unlock
+	if options.Force {
+		unlock = s.tfe.Workspaces.ForceUnlock
+	}
+	unlocked, err := unlock(ctx, w.ID)
+	if err != nil {
+		log.Printf("[ERROR] error unlocking workspace: %q organization: %q, force: %t, error: %s", options.Workspace, options.Organization, options.Force, err)
+		if errors.Is(err, tfe.ErrWorkspaceNotLocked) {
+			return w, true, nil
+		}
+		return nil, false, fmt.Errorf("failed to unlock workspace %q in organization %q: %w", options.Workspace, options.Organization, err)
+	}
+	return unlocked, false, nil
+}
+
+// creates a new workspace, returning the existing workspace if it was concurrently created
+// eg. by parallel pipelines for the same pull request
+func (s *workspaceService) CreateWorkspace(ctx context.Context, options CreateWorkspaceOptions) (*tfe.Workspace, error) {
+	if err := s.skipDryRun("create workspace", "organization", options.Organization, "workspace", options.Name, "options", options); err != nil {
+		return nil, err
+	}
+
+	createOpts := tfe.WorkspaceCreateOptions{
+		Name: tfe.String(options.Name),
+	}
+	if options.ExecutionMode != "" {
+		createOpts.ExecutionMode = tfe.String(options.ExecutionMode)
+	}
+	if options.TerraformVersion != "" {
+		createOpts.TerraformVersion = tfe.String(options.TerraformVersion)
+	}
+	for _, tag := range options.Tags {
+		createOpts.Tags = append(createOpts.Tags, &tfe.Tag{Name: tag})
+	}
+	if options.Project != "" {
+		project, pErr := s.resolveProject(ctx, options.Organization, options.Project)
+		if pErr != nil {
+			return nil, pErr
+		}
+		createOpts.Project = project
+	}
+
+	w, wErr := s.tfe.Workspaces.Create(ctx, options.Organization, createOpts)
+	if wErr != nil {
+		log.Printf("[ERROR] error creating workspace: %q organization: %q, error: %s", options.Name, options.Organization, wErr)
+		// only a workspace which already exists is used as is, any other failure is returned
+		if isAlreadyTaken(wErr) {
+			if existing, err := s.resolveWorkspace(ctx, options.Organization, options.Name); err == nil {
+				return existing, nil
+			}
+		}
+		return nil, fmt.Errorf("failed to create workspace %q in organization %q: %w", options.Name, options.Organization, wErr)
+	}
+	return w, nil
+}
+
+// the create request was rejected as the name is already in use, eg. `invalid attribute\n\nName has already been taken`
+func isAlreadyTaken(err error) bool {
+	return strings.Contains(err.Error(), "has already been taken")
+}
+
+// resolves a project by ID, eg. `prj-***`, or by its exact name
+func (s *workspaceService) resolveProject(ctx context.Context, orgName string, project string) (*tfe.Project, error) {
+	if strings.HasPrefix(project, "prj-") {
+		return &tfe.Project{ID: project}, nil
+	}
+
+	projects, err := s.tfe.Projects.List(ctx, orgName, &tfe.ProjectListOptions{
+		Name: project,
+	})
+	if err != nil {
+		log.Printf("[ERROR] error listing projects: %q organization: %q, error: %s", project, orgName, err)
+		return nil, fmt.Errorf("failed to resolve project %q in organization %q: %w", project, orgName, err)
+	}
+	for _, p := range projects.Items {
+		if p.Name == project {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("project %q was not found in organization %q", project, orgName)
 }
 
 func NewWorkspaceService(meta *cloudMeta) *workspaceService {
@@ -5,8 +5,13 @@ package cloud
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/go-tfe"
 	"github.com/hashicorp/go-tfe/mocks"
@@ -35,6 +40,7 @@ func TestWorkspaceService_ReadStateOutputs(t *testing.T) {
 			workspaceID:   "ws-***",
 			tfeWorkspace:  &tfe.Workspace{ID: "ws-***"},
 			tfeStateVersion: &tfe.StateVersion{
+				ID:                 "sv-***",
 				ResourcesProcessed: true,
 			},
 			tfeStateVersionOutputs: &tfe.StateVersionOutputsList{
@@ -68,17 +74,15 @@ func TestWorkspaceService_ReadStateOutputs(t *testing.T) {
 			)
 
 			// mock state version output
-			mockStateVersionOutputList := mocks.NewMockStateVersionOutputs(ctrl)
-			mockStateVersionOutputList.EXPECT().ReadCurrent(tc.ctx, tc.workspaceID).Return(
+			mockStateVersion.EXPECT().ListOutputs(tc.ctx, tc.tfeStateVersion.ID, gomock.Any()).Return(
 				tc.tfeStateVersionOutputs,
 				nil,
 			)
 
 			meta := &cloudMeta{
 				tfe: &tfe.Client{
-					Workspaces:          mWorkspace,
-					StateVersions:       mockStateVersion,
-					StateVersionOutputs: mockStateVersionOutputList,
+					Workspaces:    mWorkspace,
+					StateVersions: mockStateVersion,
 				},
 				writer: writer.NewWriter(cli.NewMockUi()),
 			}
@@ -130,6 +134,7 @@ func TestWorkspaceService_ReadStateOutputs_Retry(t *testing.T) {
 		retryCall := mockStateVersion.EXPECT().ReadCurrent(ctx, wID).Return(tfeStateVersion, nil).Times(3)
 		// Assert and mock retry is stopped when resources processed is set to true
 		doneCall := mockStateVersion.EXPECT().ReadCurrent(ctx, wID).Return(&tfe.StateVersion{
+			ID:                 "sv-***",
 			ResourcesProcessed: true,
 		}, nil)
 
@@ -139,17 +144,15 @@ func TestWorkspaceService_ReadStateOutputs_Retry(t *testing.T) {
 			doneCall,
 		)
 
-		mockStateVersionOutputList := mocks.NewMockStateVersionOutputs(ctrl)
-		mockStateVersionOutputList.EXPECT().ReadCurrent(ctx, wID).Return(
+		mockStateVersion.EXPECT().ListOutputs(ctx, "sv-***", gomock.Any()).Return(
 			tfeStateVersionOutputs,
 			nil,
 		)
 
 		meta := &cloudMeta{
 			tfe: &tfe.Client{
-				Workspaces:          mWorkspace,
-				StateVersions:       mockStateVersion,
-				StateVersionOutputs: mockStateVersionOutputList,
+				Workspaces:    mWorkspace,
+				StateVersions: mockStateVersion,
 			},
 			writer: writer.NewWriter(cli.NewMockUi()),
 		}
@@ -159,3 +162,500 @@ func TestWorkspaceService_ReadStateOutputs_Retry(t *testing.T) {
 		client.ReadStateOutputs(ctx, orgName, workspaceName)
 	})
 }
+
+func TestWorkspaceService_ReadStateOutputs_Paginated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, orgName, workspaceName, wID, svID := context.Background(), "test-org", "my-workspace", "ws-***", "sv-***"
+
+	mWorkspace := mocks.NewMockWorkspaces(ctrl)
+	mWorkspace.EXPECT().Read(ctx, orgName, workspaceName).Return(&tfe.Workspace{ID: wID}, nil)
+
+	mockStateVersion := mocks.NewMockStateVersions(ctrl)
+	mockStateVersion.EXPECT().ReadCurrent(ctx, wID).Return(&tfe.StateVersion{ID: svID, ResourcesProcessed: true}, nil)
+
+	pages := [][]string{{"a", "b"}, {"c", "d"}, {"e"}}
+	expected := []string{}
+	for i, names := range pages {
+		page := &tfe.StateVersionOutputsList{Pagination: &tfe.Pagination{CurrentPage: i + 1, TotalPages: len(pages)}}
+		if i < len(pages)-1 {
+			page.NextPage = i + 2
+		}
+		for _, name := range names {
+			page.Items = append(page.Items, &tfe.StateVersionOutput{Name: name, Value: name})
+			expected = append(expected, name)
+		}
+		mockStateVersion.EXPECT().ListOutputs(ctx, svID, &tfe.StateVersionOutputsListOptions{
+			ListOptions: tfe.ListOptions{PageNumber: i + 1, PageSize: maxPageSize},
+		}).Return(page, nil)
+	}
+
+	meta := &cloudMeta{
+		tfe: &tfe.Client{
+			Workspaces:    mWorkspace,
+			StateVersions: mockStateVersion,
+		},
+		writer: writer.NewWriter(cli.NewMockUi()),
+	}
+	client := NewWorkspaceService(meta)
+
+	result, err := client.ReadStateOutputs(ctx, orgName, workspaceName)
+	if err != nil {
+		t.Fatalf("expected %v but received %s", nil, err)
+	}
+	actual := []string{}
+	for _, svo := range result.Items {
+		actual = append(actual, svo.Name)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v but received %v", expected, actual)
+	}
+}
+
+func TestWorkspaceService_WaitForStateVersion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, orgName, workspaceName, wID := context.Background(), "test-org", "my-workspace", "ws-***"
+
+	mWorkspace := mocks.NewMockWorkspaces(ctrl)
+	mWorkspace.EXPECT().Read(ctx, orgName, workspaceName).Return(&tfe.Workspace{ID: wID}, nil)
+
+	mockStateVersion := mocks.NewMockStateVersions(ctrl)
+	// Assert and mock retry while serial is stale
+	retryCall := mockStateVersion.EXPECT().ReadCurrent(ctx, wID).Return(&tfe.StateVersion{Serial: 4}, nil).Times(2)
+	// Assert and mock retry is stopped when serial has been reached
+	doneCall := mockStateVersion.EXPECT().ReadCurrent(ctx, wID).Return(&tfe.StateVersion{Serial: 6}, nil)
+	gomock.InOrder(retryCall, doneCall)
+
+	meta := &cloudMeta{
+		tfe: &tfe.Client{
+			Workspaces:    mWorkspace,
+			StateVersions: mockStateVersion,
+		},
+		writer:       writer.NewWriter(cli.NewMockUi()),
+		pollInterval: time.Millisecond,
+	}
+	client := NewWorkspaceService(meta)
+
+	sv, err := client.WaitForStateVersion(ctx, orgName, workspaceName, 5)
+	if err != nil {
+		t.Fatalf("expected %v but received %s", nil, err)
+	}
+	if sv.Serial != 6 {
+		t.Errorf("expected serial %d but received %d", 6, sv.Serial)
+	}
+}
+
+func TestWorkspaceService_GetWorkspace(t *testing.T) {
+	testCases := []struct {
+		name           string
+		statuses       []int
+		expectAttempts int
+		expectErr      bool
+		expectNotFound bool
+	}{
+		{
+			name:           "transient-error-then-success",
+			statuses:       []int{http.StatusServiceUnavailable, http.StatusOK},
+			expectAttempts: 2,
+		},
+		{
+			name:           "not-found",
+			statuses:       []int{http.StatusNotFound},
+			expectAttempts: 1,
+			expectErr:      true,
+			expectNotFound: true,
+		},
+		{
+			name:           "persistent-server-error",
+			statuses:       []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			expectAttempts: 3,
+			expectErr:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/vnd.api+json")
+				if r.URL.Path == "/api/v2/ping" {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				status := tc.statuses[attempts]
+				attempts++
+				w.WriteHeader(status)
+				if status == http.StatusOK {
+					w.Write([]byte(`{"data":{"id":"ws-***","type":"workspaces","attributes":{"name":"my-workspace"}}}`))
+				}
+			}))
+			defer server.Close()
+
+			config := tfe.DefaultConfig()
+			config.Address = server.URL
+			config.Token = "token"
+			config.HTTPClient.Transport = &retryTransport{
+				next:       http.DefaultTransport,
+				maxRetries: 2,
+				baseDelay:  time.Millisecond,
+			}
+			tfeClient, err := tfe.NewClient(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tfeClient.RetryServerErrors(false)
+
+			client := NewWorkspaceService(&cloudMeta{tfe: tfeClient, writer: &defaultWriter{}})
+			w, err := client.GetWorkspace(context.Background(), "test-org", "my-workspace")
+
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error: %t but received: %v", tc.expectErr, err)
+			}
+			if !tc.expectErr && w.ID != "ws-***" {
+				t.Errorf("expected workspace %q but received %q", "ws-***", w.ID)
+			}
+
+			var notFoundErr *WorkspaceNotFoundError
+			if errors.As(err, &notFoundErr) != tc.expectNotFound {
+				t.Errorf("expected not found error: %t but received: %v", tc.expectNotFound, err)
+			}
+			if attempts != tc.expectAttempts {
+				t.Errorf("expected %d attempts but received %d", tc.expectAttempts, attempts)
+			}
+		})
+	}
+}
+
+func TestWorkspaceService_CreateWorkspace(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, orgName := context.Background(), "test-org"
+	project := &tfe.Project{ID: "prj-***", Name: "previews"}
+
+	mProjects := mocks.NewMockProjects(ctrl)
+	mProjects.EXPECT().List(ctx, orgName, &tfe.ProjectListOptions{Name: "previews"}).Return(&tfe.ProjectList{
+		Items: []*tfe.Project{project},
+	}, nil)
+
+	mWorkspace := mocks.NewMockWorkspaces(ctrl)
+	mWorkspace.EXPECT().Create(ctx, orgName, tfe.WorkspaceCreateOptions{
+		Name:             tfe.String("pr-1"),
+		ExecutionMode:    tfe.String("remote"),
+		TerraformVersion: tfe.String("1.9.0"),
+		Project:          project,
+	}).Return(&tfe.Workspace{ID: "ws-***", Name: "pr-1"}, nil)
+
+	client := NewWorkspaceService(&cloudMeta{
+		tfe: &tfe.Client{
+			Projects:   mProjects,
+			Workspaces: mWorkspace,
+		},
+		writer: &defaultWriter{},
+	})
+
+	w, err := client.CreateWorkspace(ctx, CreateWorkspaceOptions{
+		Organization:     orgName,
+		Name:             "pr-1",
+		Project:          "previews",
+		ExecutionMode:    "remote",
+		TerraformVersion: "1.9.0",
+	})
+	if err != nil {
+		t.Fatalf("expected %v but received %s", nil, err)
+	}
+	if w.ID != "ws-***" {
+		t.Errorf("expected workspace %q but received %q", "ws-***", w.ID)
+	}
+}
+
+func TestWorkspaceService_CreateWorkspace_Existing(t *testing.T) {
+	testCases := []struct {
+		name        string
+		createErr   error
+		readsByName bool
+		expectErr   bool
+	}{
+		{
+			name:        "already-exists",
+			createErr:   errors.New("invalid attribute\n\nName has already been taken"),
+			readsByName: true,
+		},
+		{
+			name:      "other-failure",
+			createErr: errors.New("invalid attribute\n\nExecution mode is not permitted"),
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx, orgName := context.Background(), "test-org"
+			mWorkspace := mocks.NewMockWorkspaces(ctrl)
+			mWorkspace.EXPECT().Create(ctx, orgName, gomock.Any()).Return(nil, tc.createErr)
+			if tc.readsByName {
+				mWorkspace.EXPECT().Read(ctx, orgName, "pr-1").Return(&tfe.Workspace{ID: "ws-existing", Name: "pr-1"}, nil)
+			}
+
+			client := NewWorkspaceService(&cloudMeta{tfe: &tfe.Client{Workspaces: mWorkspace}, writer: &defaultWriter{}})
+			w, err := client.CreateWorkspace(ctx, CreateWorkspaceOptions{Organization: orgName, Name: "pr-1"})
+			if tc.expectErr {
+				if !errors.Is(err, tc.createErr) {
+					t.Fatalf("expected the create error but received %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected %v but received %s", nil, err)
+			}
+			if w.ID != "ws-existing" {
+				t.Errorf("expected workspace %q but received %q", "ws-existing", w.ID)
+			}
+		})
+	}
+}
+
+func TestWorkspaceService_ListWorkspacesByTags(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, orgName := context.Background(), "test-org"
+
+	mWorkspace := mocks.NewMockWorkspaces(ctrl)
+	firstPage := mWorkspace.EXPECT().List(ctx, orgName, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, options *tfe.WorkspaceListOptions) (*tfe.WorkspaceList, error) {
+			if options.Tags != "prod,eu" || options.PageNumber != 1 {
+				t.Errorf("unexpected list options: %+v", options)
+			}
+			return &tfe.WorkspaceList{
+				Items:      []*tfe.Workspace{{Name: "api"}},
+				Pagination: &tfe.Pagination{NextPage: 2},
+			}, nil
+		})
+	lastPage := mWorkspace.EXPECT().List(ctx, orgName, gomock.Any()).Return(&tfe.WorkspaceList{
+		Items:      []*tfe.Workspace{{Name: "web"}},
+		Pagination: &tfe.Pagination{},
+	}, nil)
+	gomock.InOrder(firstPage, lastPage)
+
+	client := NewWorkspaceService(&cloudMeta{
+		tfe:    &tfe.Client{Workspaces: mWorkspace},
+		writer: &defaultWriter{},
+	})
+
+	workspaces, err := client.ListWorkspacesByTags(ctx, orgName, []string{"prod", "eu"})
+	if err != nil {
+		t.Fatalf("expected %v but received %s", nil, err)
+	}
+	if len(workspaces) != 2 || workspaces[0].Name != "api" || workspaces[1].Name != "web" {
+		t.Errorf("expected workspaces api and web but received %v", workspaces)
+	}
+}
+
+func TestWorkspaceService_GetAssessmentDrift(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v2/ping" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if r.URL.Path != "/api/v2/assessment-results/asmtres-***/json-output" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"format_version": "1.2",
+			"resource_drift": [{
+				"address": "aws_instance.web",
+				"type": "aws_instance",
+				"change": {
+					"actions": ["update"],
+					"before": {"ami": "ami-123", "instance_type": "t3.micro", "tags": {"env": "dev"}},
+					"after": {"ami": "ami-123", "instance_type": "t3.large", "tags": {"env": "prod"}}
+				}
+			}, {
+				"address": "aws_s3_bucket.logs",
+				"type": "aws_s3_bucket",
+				"change": {"actions": ["delete"], "before": {"bucket": "logs"}, "after": null}
+			}]
+		}`))
+	}))
+	defer server.Close()
+
+	config := tfe.DefaultConfig()
+	config.Address = server.URL
+	config.Token = "token"
+	tfeClient, err := tfe.NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewWorkspaceService(&cloudMeta{tfe: tfeClient, writer: &defaultWriter{}})
+	drifted, err := client.GetAssessmentDrift(context.Background(), "asmtres-***")
+	if err != nil {
+		t.Fatalf("expected no error but received %s", err)
+	}
+
+	expected := []*DriftedResource{
+		{Address: "aws_instance.web", Type: "aws_instance", Actions: []string{"update"}, ChangedAttributes: []string{"instance_type", "tags"}},
+		{Address: "aws_s3_bucket.logs", Type: "aws_s3_bucket", Actions: []string{"delete"}, ChangedAttributes: []string{"bucket"}},
+	}
+	if !reflect.DeepEqual(drifted, expected) {
+		t.Errorf("expected %+v but received %+v", expected, drifted)
+	}
+}
+
+func TestWorkspaceService_LockWorkspace(t *testing.T) {
+	lockedByUser := &tfe.LockedByChoice{User: &tfe.User{ID: "user-123"}}
+
+	testCases := []struct {
+		name            string
+		workspace       *tfe.Workspace
+		mock            func(ctx context.Context, mWorkspaces *mocks.MockWorkspaces)
+		currentUser     *tfe.User
+		expectedAlready bool
+		expectedErr     string
+	}{
+		{
+			name:      "locked",
+			workspace: &tfe.Workspace{ID: "ws-123"},
+			mock: func(ctx context.Context, mWorkspaces *mocks.MockWorkspaces) {
+				mWorkspaces.EXPECT().Lock(ctx, "ws-123", tfe.WorkspaceLockOptions{Reason: tfe.String("maintenance")}).
+					Return(&tfe.Workspace{ID: "ws-123", Locked: true}, nil)
+			},
+		},
+		{
+			name:            "already-locked-by-current-user",
+			workspace:       &tfe.Workspace{ID: "ws-123", Locked: true, LockedBy: lockedByUser},
+			mock:            func(ctx context.Context, mWorkspaces *mocks.MockWorkspaces) {},
+			currentUser:     &tfe.User{ID: "user-123"},
+			expectedAlready: true,
+		},
+		{
+			name:        "locked-by-other-user",
+			workspace:   &tfe.Workspace{ID: "ws-123", Name: "my-workspace", Locked: true, LockedBy: lockedByUser},
+			mock:        func(ctx context.Context, mWorkspaces *mocks.MockWorkspaces) {},
+			currentUser: &tfe.User{ID: "user-456"},
+			expectedErr: `workspace "my-workspace" is already locked by user "user-123"`,
+		},
+		{
+			name:        "locked-by-run",
+			workspace:   &tfe.Workspace{ID: "ws-123", Name: "my-workspace", Locked: true, LockedBy: &tfe.LockedByChoice{Run: &tfe.Run{ID: "run-123"}}},
+			mock:        func(ctx context.Context, mWorkspaces *mocks.MockWorkspaces) {},
+			expectedErr: `workspace "my-workspace" is already locked by run "run-123"`,
+		},
+		{
+			name:      "concurrently-locked",
+			workspace: &tfe.Workspace{ID: "ws-123", Name: "my-workspace"},
+			mock: func(ctx context.Context, mWorkspaces *mocks.MockWorkspaces) {
+				mWorkspaces.EXPECT().Lock(ctx, "ws-123", gomock.Any()).Return(nil, tfe.ErrWorkspaceLocked)
+				mWorkspaces.EXPECT().Read(ctx, "test", "my-workspace").
+					Return(&tfe.Workspace{ID: "ws-123", Name: "my-workspace", Locked: true, LockedBy: &tfe.LockedByChoice{Team: &tfe.Team{ID: "team-123"}}}, nil)
+			},
+			expectedErr: `workspace "my-workspace" is already locked by team "team-123"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx := context.Background()
+			mWorkspaces := mocks.NewMockWorkspaces(ctrl)
+			mWorkspaces.EXPECT().Read(ctx, "test", "my-workspace").Return(tc.workspace, nil)
+			tc.mock(ctx, mWorkspaces)
+			mUsers := mocks.NewMockUsers(ctrl)
+			if tc.currentUser != nil {
+				mUsers.EXPECT().ReadCurrent(ctx).Return(tc.currentUser, nil)
+			}
+
+			client := NewWorkspaceService(&cloudMeta{tfe: &tfe.Client{Workspaces: mWorkspaces, Users: mUsers}, writer: &defaultWriter{}})
+			w, already, err := client.LockWorkspace(ctx, LockWorkspaceOptions{Organization: "test", Workspace: "my-workspace", Reason: "maintenance"})
+			if tc.expectedErr != "" {
+				var lockedErr *WorkspaceLockedError
+				if !errors.As(err, &lockedErr) || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Fatalf("expected a locked error containing %q but received %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but received %s", err)
+			}
+			if already != tc.expectedAlready {
+				t.Errorf("expected already locked %t but received %t", tc.expectedAlready, already)
+			}
+			if w == nil || w.ID != "ws-123" {
+				t.Errorf("expected workspace %q but received %v", "ws-123", w)
+			}
+		})
+	}
+}
+
+func TestWorkspaceService_UnlockWorkspace(t *testing.T) {
+	testCases := []struct {
+		name            string
+		workspace       *tfe.Workspace
+		force           bool
+		mock            func(ctx context.Context, mWorkspaces *mocks.MockWorkspaces)
+		expectedAlready bool
+		expectedErr     error
+	}{
+		{
+			name:      "unlocked",
+			workspace: &tfe.Workspace{ID: "ws-123", Locked: true},
+			mock: func(ctx context.Context, mWorkspaces *mocks.MockWorkspaces) {
+				mWorkspaces.EXPECT().Unlock(ctx, "ws-123").Return(&tfe.Workspace{ID: "ws-123"}, nil)
+			},
+		},
+		{
+			name:      "force-unlocked",
+			workspace: &tfe.Workspace{ID: "ws-123", Locked: true},
+			force:     true,
+			mock: func(ctx context.Context, mWorkspaces *mocks.MockWorkspaces) {
+				mWorkspaces.EXPECT().ForceUnlock(ctx, "ws-123").Return(&tfe.Workspace{ID: "ws-123"}, nil)
+			},
+		},
+		{
+			name:            "already-unlocked",
+			workspace:       &tfe.Workspace{ID: "ws-123"},
+			mock:            func(ctx context.Context, mWorkspaces *mocks.MockWorkspaces) {},
+			expectedAlready: true,
+		},
+		{
+			name:      "locked-by-run",
+			workspace: &tfe.Workspace{ID: "ws-123", Locked: true},
+			mock: func(ctx context.Context, mWorkspaces *mocks.MockWorkspaces) {
+				mWorkspaces.EXPECT().Unlock(ctx, "ws-123").Return(nil, tfe.ErrWorkspaceLockedByRun)
+			},
+			expectedErr: tfe.ErrWorkspaceLockedByRun,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx := context.Background()
+			mWorkspaces := mocks.NewMockWorkspaces(ctrl)
+			mWorkspaces.EXPECT().Read(ctx, "test", "my-workspace").Return(tc.workspace, nil)
+			tc.mock(ctx, mWorkspaces)
+
+			client := NewWorkspaceService(&cloudMeta{tfe: &tfe.Client{Workspaces: mWorkspaces}, writer: &defaultWriter{}})
+			_, already, err := client.UnlockWorkspace(ctx, UnlockWorkspaceOptions{Organization: "test", Workspace: "my-workspace", Force: tc.force})
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected error %v but received %v", tc.expectedErr, err)
+			}
+			if already != tc.expectedAlready {
+				t.Errorf("expected already unlocked %t but received %t", tc.expectedAlready, already)
+			}
+		})
+	}
+}
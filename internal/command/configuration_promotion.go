@@ -0,0 +1,35 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/logging"
+)
+
+// adds whether the run's configuration version became the workspace's current configuration version, eg. to confirm
+// a provisional upload was promoted by applying the run. The outputs are omitted when they cannot be read.
+func (c *Meta) addConfigurationPromotion(run *tfe.Run) {
+	if run == nil {
+		return
+	}
+
+	promotion, err := c.cloud.GetConfigurationPromotion(c.appCtx, run)
+	if err != nil {
+		if !isDryRun(err) {
+			logging.Warn("Failed to read configuration promotion", "run_id", run.ID, "error", err)
+		}
+		return
+	}
+
+	c.addOutput("configuration_promoted", strconv.FormatBool(promotion.Promoted))
+	c.addOutput("workspace_configuration_version_id", promotion.CurrentConfigurationVersionID)
+	c.addOutput("configuration_promotion_reason", promotion.Reason)
+	if promotion.Reason != "" {
+		c.writer.Output(fmt.Sprintf("Configuration version %s was not promoted: %s", promotion.ConfigurationVersion.ID, promotion.Reason))
+	}
+}
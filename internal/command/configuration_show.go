@@ -0,0 +1,117 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+type ShowConfigurationCommand struct {
+	*Meta
+
+	Workspace              string
+	ConfigurationVersionID string
+}
+
+func (c *ShowConfigurationCommand) flags() *flag.FlagSet {
+	f := c.flagSet("configuration show")
+	f.StringVar(&c.Workspace, "workspace", "", "The name of the HCP Terraform Workspace the configuration version belongs to.")
+	f.StringVar(&c.ConfigurationVersionID, "configuration-version", "", "Existing HCP Terraform Configuration Version ID to show.")
+
+	return f
+}
+
+func (c *ShowConfigurationCommand) Run(args []string) int {
+	if err := c.setupCmd(args, c.flags(), c.requireOrganization(), requireWorkspace(&c.Workspace)); err != nil {
+		return 1
+	}
+
+	if c.ConfigurationVersionID == "" {
+		c.addOutput("status", string(Error))
+		c.closeOutput()
+		c.writer.ErrorResult("showing a configuration version requires a valid configuration version id")
+		return 1
+	}
+
+	configVersion, cvErr := c.cloud.GetConfigurationVersion(c.appCtx, c.ConfigurationVersionID)
+	if cvErr != nil {
+		status := c.resolveStatus(cvErr)
+		c.addOutput("status", string(status))
+		c.closeOutput()
+		c.errorResult(status, fmt.Sprintf("error showing configuration version, '%s' in HCP Terraform: %s", c.ConfigurationVersionID, cvErr.Error()))
+		return c.exitCode(status)
+	}
+
+	// a provisional configuration version only becomes current once a run using it is applied
+	workspace, wErr := c.cloud.GetWorkspace(c.appCtx, c.organization, c.Workspace)
+	if wErr != nil {
+		status := c.resolveStatus(wErr)
+		c.addOutput("status", string(status))
+		c.addConfigurationDetails(configVersion, nil)
+		c.errorResult(status, fmt.Sprintf("error showing workspace, '%s' in HCP Terraform: %s", c.Workspace, wErr.Error()))
+		c.writer.OutputResult(c.closeOutput())
+		return c.exitCode(status)
+	}
+
+	c.addOutput("status", string(Success))
+	c.addConfigurationDetails(configVersion, workspace)
+	c.writer.OutputResult(c.closeOutput())
+	return 0
+}
+
+// adds the configuration version outputs, the payload has the same shape as the `upload` payload
+func (c *ShowConfigurationCommand) addConfigurationDetails(config *tfe.ConfigurationVersion, workspace *tfe.Workspace) {
+	if config == nil {
+		return
+	}
+
+	c.addOutput("configuration_version_id", config.ID)
+	c.addOutput("configuration_version_status", string(config.Status))
+	c.addOutput("configuration_version_provisional", fmt.Sprint(config.Provisional))
+	c.addOutput("configuration_version_speculative", fmt.Sprint(config.Speculative))
+	if workspace != nil {
+		current := workspace.CurrentConfigurationVersion != nil && workspace.CurrentConfigurationVersion.ID == config.ID
+		c.addOutput("configuration_version_current", fmt.Sprint(current))
+	}
+
+	c.addOutputWithOpts("payload", config, &outputOpts{
+		stdOut:      false,
+		multiLine:   true,
+		platformOut: true,
+		fields:      c.payloadFields,
+	})
+}
+
+func (c *ShowConfigurationCommand) Help() string {
+	helpText := `
+Usage: tfci [global options] configuration show [options]
+
+	Returns configuration version details, including whether it is the workspace's current configuration version, e.g. to verify a provisional configuration version was promoted by an applied run.
+
+Global Options:
+
+	-hostname       The hostname of a Terraform Enterprise installation, if using Terraform Enterprise. Defaults to "app.terraform.io".
+
+	-token          The token used to authenticate with HCP Terraform. Defaults to reading "TF_API_TOKEN" environment variable.
+
+	-organization   HCP Terraform Organization Name. Also accepted as a command option, which overrides the global option for that command.
+
+Options:
+
+	-workspace              The name of the HCP Terraform Workspace the configuration version belongs to.
+
+	-configuration-version  Existing HCP Terraform Configuration Version ID to show.
+
+	-payload-fields         Comma separated list of top-level fields to include in the payload output, e.g. id,status,created-at. Defaults to all fields.
+	`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ShowConfigurationCommand) Synopsis() string {
+	return "Returns configuration version details, including whether it is the workspace's current configuration"
+}
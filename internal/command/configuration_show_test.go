@@ -0,0 +1,79 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+	"github.com/hashicorp/tfci/internal/environment"
+	"github.com/hashicorp/tfci/internal/writer"
+	"github.com/mitchellh/cli"
+)
+
+func TestShowConfigurationCommand(t *testing.T) {
+	configVersion := &tfe.ConfigurationVersion{ID: "cv-123", Status: tfe.ConfigurationUploaded, Provisional: true}
+
+	testCases := []struct {
+		name          string
+		args          []string
+		currentID     string
+		exitStatus    int
+		expectCurrent string
+	}{
+		{
+			name:          "promoted",
+			args:          []string{"-workspace=my-workspace", "-configuration-version=cv-123"},
+			currentID:     "cv-123",
+			expectCurrent: "true",
+		},
+		{
+			name:          "not-promoted",
+			args:          []string{"-workspace=my-workspace", "-configuration-version=cv-123"},
+			currentID:     "cv-122",
+			expectCurrent: "false",
+		},
+		{
+			name:       "missing-configuration-version",
+			args:       []string{"-workspace=my-workspace"},
+			exitStatus: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, w)
+			cloudMockService.ConfigVersionService = &SuccessfulUploader{configurationVersion: configVersion}
+			cloudMockService.WorkspaceService = &WorkspaceReader{workspace: &tfe.Workspace{
+				ID:                          "ws-123",
+				CurrentConfigurationVersion: &tfe.ConfigurationVersion{ID: tc.currentID},
+			}}
+			meta := NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))
+
+			if code := (&ShowConfigurationCommand{Meta: meta}).Run(tc.args); code != tc.exitStatus {
+				t.Fatalf("expected %d but received %d: %s", tc.exitStatus, code, ui.ErrorWriter.String())
+			}
+			if tc.exitStatus != 0 {
+				return
+			}
+
+			expected := map[string]string{
+				"configuration_version_id":          "cv-123",
+				"configuration_version_status":      string(tfe.ConfigurationUploaded),
+				"configuration_version_current":     tc.expectCurrent,
+				"configuration_version_provisional": "true",
+				"configuration_version_speculative": "false",
+			}
+			for name, value := range expected {
+				if actual := outputValue(meta, name); actual != value {
+					t.Errorf("expected %s %q but received %q", name, value, actual)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"strings"
+)
+
+type ContextCommand struct {
+	*Meta
+}
+
+func (c *ContextCommand) Run(args []string) int {
+	if err := c.setupCmd(args, c.flagSet("context")); err != nil {
+		return 1
+	}
+
+	c.addContextDetails()
+	c.addOutput("status", string(Success))
+	c.writer.OutputResult(c.closeOutput())
+	return 0
+}
+
+// adds the values tfci derives from the CI platform, eg. for run messages. Values the platform does not
+// provide are empty
+func (c *ContextCommand) addContextDetails() {
+	var ciID, sha, shaShort, author, platform string
+	if c.env != nil {
+		platform = string(c.env.PlatformType)
+		if c.env.Context != nil {
+			ciID = c.env.Context.ID()
+			sha = c.env.Context.SHA()
+			shaShort = c.env.Context.SHAShort()
+			author = c.env.Context.Author()
+		}
+	}
+
+	c.addOutput("ci_id", ciID)
+	c.addOutput("commit_sha", sha)
+	c.addOutput("commit_sha_short", shaShort)
+	c.addOutput("author", author)
+	c.addOutput("platform", platform)
+}
+
+func (c *ContextCommand) Help() string {
+	helpText := `
+Usage: tfci [global options] context [options]
+
+	Returns the CI environment details tfci uses, e.g. for run messages, as outputs: ci_id, commit_sha, commit_sha_short, author and platform. Values the platform does not provide are empty. No API requests are made.
+
+Options:
+
+	-json           Suppresses all logs and instead returns output value in JSON format.
+	`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ContextCommand) Synopsis() string {
+	return "Returns the CI environment details used by tfci"
+}
@@ -0,0 +1,85 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+	"github.com/hashicorp/tfci/internal/environment"
+	"github.com/hashicorp/tfci/internal/writer"
+	"github.com/mitchellh/cli"
+)
+
+type MetadataContext struct {
+	CommitContext
+	id string
+}
+
+func (c *MetadataContext) ID() string {
+	return c.id
+}
+
+func (c *MetadataContext) SHA() string {
+	return c.sha + "0000"
+}
+
+func TestContextCommand(t *testing.T) {
+	testCases := []struct {
+		name     string
+		env      *environment.CI
+		expected map[string]string
+	}{
+		{
+			name: "github",
+			env: &environment.CI{
+				CI:           true,
+				PlatformType: environment.GitHub,
+				Context:      &MetadataContext{CommitContext: CommitContext{author: "octocat", sha: "abc1234"}, id: "42"},
+			},
+			expected: map[string]string{
+				"ci_id":            "42",
+				"commit_sha":       "abc12340000",
+				"commit_sha_short": "abc1234",
+				"author":           "octocat",
+				"platform":         "GitHub",
+			},
+		},
+		{
+			name: "unknown-platform",
+			env:  &environment.CI{},
+			expected: map[string]string{
+				"ci_id":            "",
+				"commit_sha":       "",
+				"commit_sha_short": "",
+				"author":           "",
+				"platform":         "",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			// no api requests are made
+			cloudService := cloud.NewCloud(&tfe.Client{}, w)
+			meta := NewMetaOpts(context.Background(), cloudService, tc.env, WithWriter(w))
+
+			if code := (&ContextCommand{Meta: meta}).Run([]string{}); code != 0 {
+				t.Fatalf("expected 0 but received %d: %s", code, ui.ErrorWriter.String())
+			}
+			for name, expected := range tc.expected {
+				if _, ok := meta.messages[name]; !ok {
+					t.Errorf("expected output %q to be set", name)
+				}
+				if value := outputValue(meta, name); value != expected {
+					t.Errorf("expected %s %q but received %q", name, expected, value)
+				}
+			}
+		})
+	}
+}
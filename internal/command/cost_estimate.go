@@ -0,0 +1,28 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"github.com/hashicorp/go-tfe"
+)
+
+// adds the run's cost estimate outputs, which are empty when cost estimation is disabled for the workspace
+func (c *Meta) addCostEstimate(run *tfe.Run) {
+	ce := run.CostEstimate
+	if ce == nil || ce.ID == "" {
+		for _, name := range []string{"prior_monthly_cost", "proposed_monthly_cost", "delta_monthly_cost", "cost_estimate_payload"} {
+			c.addOutput(name, "")
+		}
+		return
+	}
+
+	c.addOutput("prior_monthly_cost", ce.PriorMonthlyCost)
+	c.addOutput("proposed_monthly_cost", ce.ProposedMonthlyCost)
+	c.addOutput("delta_monthly_cost", ce.DeltaMonthlyCost)
+	c.addOutputWithOpts("cost_estimate_payload", ce, &outputOpts{
+		stdOut:      false,
+		multiLine:   true,
+		platformOut: true,
+	})
+}
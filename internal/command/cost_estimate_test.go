@@ -0,0 +1,79 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+	"github.com/hashicorp/tfci/internal/environment"
+	"github.com/hashicorp/tfci/internal/writer"
+	"github.com/mitchellh/cli"
+)
+
+func TestShowRunCommand_CostEstimate(t *testing.T) {
+	testCases := []struct {
+		name          string
+		costEstimate  *tfe.CostEstimate
+		expected      map[string]string
+		expectPayload bool
+	}{
+		{
+			name: "cost-estimation-enabled",
+			costEstimate: &tfe.CostEstimate{
+				ID:                  "ce-***",
+				Status:              tfe.CostEstimateFinished,
+				PriorMonthlyCost:    "10.00",
+				ProposedMonthlyCost: "25.50",
+				DeltaMonthlyCost:    "15.50",
+			},
+			expected: map[string]string{
+				"cost_estimation_status": "finished",
+				"prior_monthly_cost":     "10.00",
+				"proposed_monthly_cost":  "25.50",
+				"delta_monthly_cost":     "15.50",
+			},
+			expectPayload: true,
+		},
+		{
+			name: "cost-estimation-disabled",
+			expected: map[string]string{
+				"prior_monthly_cost":    "",
+				"proposed_monthly_cost": "",
+				"delta_monthly_cost":    "",
+				"cost_estimate_payload": "",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			writer := writer.NewWriter(ui)
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, writer)
+			cloudMockService.RunService = &RunReader{run: &tfe.Run{
+				ID:                   "run-123",
+				Plan:                 &tfe.Plan{},
+				ConfigurationVersion: &tfe.ConfigurationVersion{},
+				CostEstimate:         tc.costEstimate,
+			}}
+			cmd := &ShowRunCommand{Meta: NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(writer), WithOrg("hashicorp"))}
+
+			if code := cmd.Run([]string{"-run=run-123"}); code != 0 {
+				t.Fatalf("expected %d but received %d", 0, code)
+			}
+			for name, expected := range tc.expected {
+				if actual := outputValue(cmd.Meta, name); actual != expected {
+					t.Errorf("expected %s %q but received %q", name, expected, actual)
+				}
+			}
+			if tc.expectPayload && !strings.Contains(outputValue(cmd.Meta, "cost_estimate_payload"), `"delta-monthly-cost":"15.50"`) {
+				t.Errorf("expected cost_estimate_payload to contain the delta, received %q", outputValue(cmd.Meta, "cost_estimate_payload"))
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+)
+
+type DoctorCommand struct {
+	*Meta
+
+	// the -hostname global option, empty defers to TF_HOSTNAME or HCP Terraform
+	Hostname string
+	// the error creating the HCP Terraform client, eg. a missing token or unreachable host, which doctor diagnoses
+	// instead of failing before the command runs
+	ClientErr error
+}
+
+const (
+	checkPassed  = "passed"
+	checkFailed  = "failed"
+	checkSkipped = "skipped"
+)
+
+// the result of one of the doctor's checks, listed by the checks output
+type doctorCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+	// how to resolve a failed check
+	Hint string `json:"hint,omitempty"`
+}
+
+// a doctor check returns a description of the result, and a hint to resolve the error if the check failed
+type checkFunc func(host string) (detail string, hint string, err error)
+
+func (c *DoctorCommand) Run(args []string) int {
+	if err := c.setupCmd(args, c.flagSet("doctor")); err != nil {
+		return 1
+	}
+
+	host := cloud.Hostname(c.Hostname)
+	steps := []struct {
+		name  string
+		check checkFunc
+	}{
+		{"hostname", c.checkHostname},
+		{"api", c.checkAPI},
+		{"token", c.checkToken},
+		{"organization", c.checkOrganization},
+	}
+
+	// each check depends on the previous one, so once a check fails the remaining checks are skipped
+	checks := make([]*doctorCheck, 0, len(steps))
+	failed, dryRun := "", false
+	for _, step := range steps {
+		result := &doctorCheck{Name: step.name}
+		checks = append(checks, result)
+		if failed != "" {
+			result.Status = checkSkipped
+			result.Detail = fmt.Sprintf("the %s check failed", failed)
+			continue
+		}
+
+		detail, hint, err := step.check(host)
+		switch {
+		case err == nil:
+			result.Status, result.Detail = checkPassed, detail
+		case isDryRun(err):
+			dryRun = true
+			result.Status, result.Detail = checkSkipped, err.Error()
+		default:
+			failed = step.name
+			result.Status, result.Detail, result.Hint = checkFailed, err.Error(), hint
+		}
+	}
+
+	for _, check := range checks {
+		c.writer.Output(fmt.Sprintf("[%s] %s: %s", check.Status, check.Name, check.Detail))
+		if check.Hint != "" {
+			c.writer.Output(fmt.Sprintf("    hint: %s", check.Hint))
+		}
+	}
+
+	c.addOutputWithOpts("checks", checks, &outputOpts{
+		stdOut:      true,
+		multiLine:   true,
+		platformOut: true,
+	})
+
+	status := Success
+	if failed != "" {
+		status = Error
+		c.writer.ErrorResult(fmt.Sprintf("doctor found a problem with the %s check, see the hint above", failed))
+	} else if dryRun {
+		status = DryRun
+	}
+	c.addOutput("status", string(status))
+	c.writer.OutputResult(c.closeOutput())
+	return c.exitCode(status)
+}
+
+func (c *DoctorCommand) checkHostname(host string) (string, string, error) {
+	name := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		name = h
+	}
+	addrs, err := net.DefaultResolver.LookupHost(c.appCtx, name)
+	if err != nil {
+		return "", "Check -hostname or TF_HOSTNAME is the hostname of HCP Terraform or your Terraform Enterprise installation, without a scheme or path, and that the runner's DNS can resolve it", err
+	}
+	return fmt.Sprintf("%s resolves to %s", host, strings.Join(addrs, ", ")), "", nil
+}
+
+func (c *DoctorCommand) checkAPI(host string) (string, string, error) {
+	address := fmt.Sprintf("https://%s", host)
+	version, err := c.cloud.PingAPI(c.appCtx, address)
+	if err != nil {
+		return "", fmt.Sprintf("Check the runner can reach %s, including any proxy set by HTTPS_PROXY and the trusted TLS certificates of a Terraform Enterprise installation", address), err
+	}
+	return fmt.Sprintf("%s responded with API version %s", address, version), "", nil
+}
+
+func (c *DoctorCommand) checkToken(host string) (string, string, error) {
+	if c.ClientErr != nil {
+		return "", fmt.Sprintf("Set -token, -token-file, TF_API_TOKEN_FILE or TF_API_TOKEN to a user or team token for %s", host), c.ClientErr
+	}
+
+	user, err := c.cloud.ReadCurrentUser(c.appCtx)
+	switch {
+	case err == nil:
+		return fmt.Sprintf("authenticated as %q", user.Username), "", nil
+	// only user and team tokens have an account, the organization check verifies other tokens
+	case errors.Is(err, tfe.ErrResourceNotFound):
+		return "the token is valid, but is not a user or team token", "", nil
+	case errors.Is(err, tfe.ErrUnauthorized):
+		return "", fmt.Sprintf("The token is invalid, expired or was created for another host than %s, create a new user or team token", host), err
+	default:
+		return "", "Retry the command, HCP Terraform may be temporarily unavailable", err
+	}
+}
+
+func (c *DoctorCommand) checkOrganization(_ string) (string, string, error) {
+	if c.organization == "" {
+		return "", "Set -organization or the TF_CLOUD_ORGANIZATION environment variable", errors.New("no organization is set")
+	}
+
+	org, err := c.cloud.ReadOrganization(c.appCtx, c.organization)
+	switch {
+	case err == nil:
+		return fmt.Sprintf("organization %q is accessible", org.Name), "", nil
+	case errors.Is(err, tfe.ErrResourceNotFound), errors.Is(err, tfe.ErrUnauthorized):
+		return "", fmt.Sprintf("Check the organization name %q, and that the token's user or team is a member of the organization", c.organization), err
+	default:
+		return "", "Retry the command, HCP Terraform may be temporarily unavailable", err
+	}
+}
+
+func (c *DoctorCommand) Help() string {
+	helpText := `
+Usage: tfci [global options] doctor [options]
+
+	Checks tfci can connect to HCP Terraform, e.g. when setting up a pipeline. Resolves the hostname, pings the API, verifies the token by reading the account it authenticates as, and confirms the organization is accessible. Prints a checklist with a hint for the first failed check, the remaining checks are skipped. Only read only requests are made.
+
+	The checks output is a JSON list of each check's name, status (passed, failed or skipped), detail and hint.
+
+Global Options:
+
+	-hostname       The hostname of a Terraform Enterprise installation, if using Terraform Enterprise. Defaults to "app.terraform.io".
+
+	-token          The token used to authenticate with HCP Terraform. Defaults to reading "TF_API_TOKEN" environment variable.
+
+	-organization   HCP Terraform Organization Name. Also accepted as a command option, which overrides the global option for that command.
+	`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *DoctorCommand) Synopsis() string {
+	return "Checks the connection to HCP Terraform, the token and the organization"
+}
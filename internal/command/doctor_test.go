@@ -0,0 +1,142 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+	"github.com/hashicorp/tfci/internal/environment"
+	"github.com/hashicorp/tfci/internal/writer"
+	"github.com/mitchellh/cli"
+)
+
+type AccountReader struct {
+	pingErr error
+	userErr error
+	orgErr  error
+	// names of the organizations the token can access
+	organizations []string
+}
+
+func (a *AccountReader) PingAPI(_ context.Context, _ string) (string, error) {
+	return "2.6", a.pingErr
+}
+
+func (a *AccountReader) ReadCurrentUser(_ context.Context) (*tfe.User, error) {
+	if a.userErr != nil {
+		return nil, a.userErr
+	}
+	return &tfe.User{Username: "octocat"}, nil
+}
+
+func (a *AccountReader) ReadOrganization(_ context.Context, organization string) (*tfe.Organization, error) {
+	if a.orgErr != nil {
+		return nil, a.orgErr
+	}
+	return &tfe.Organization{Name: organization}, nil
+}
+
+func (a *AccountReader) ListOrganizations(_ context.Context) ([]*tfe.Organization, error) {
+	organizations := []*tfe.Organization{}
+	for _, name := range a.organizations {
+		organizations = append(organizations, &tfe.Organization{Name: name})
+	}
+	return organizations, nil
+}
+
+func TestDoctorCommand(t *testing.T) {
+	testCases := []struct {
+		name         string
+		accounts     *AccountReader
+		organization string
+		clientErr    error
+		exitStatus   int
+		expected     []string
+	}{
+		{
+			name:         "healthy",
+			accounts:     &AccountReader{},
+			organization: "hashicorp",
+			expected:     []string{checkPassed, checkPassed, checkPassed, checkPassed},
+		},
+		{
+			name:         "unreachable",
+			accounts:     &AccountReader{pingErr: errors.New("connection refused")},
+			organization: "hashicorp",
+			exitStatus:   1,
+			expected:     []string{checkPassed, checkFailed, checkSkipped, checkSkipped},
+		},
+		{
+			name:         "missing-token",
+			accounts:     &AccountReader{},
+			organization: "hashicorp",
+			clientErr:    errors.New("HCP Terraform API token is not set"),
+			exitStatus:   1,
+			expected:     []string{checkPassed, checkPassed, checkFailed, checkSkipped},
+		},
+		{
+			name:         "invalid-token",
+			accounts:     &AccountReader{userErr: tfe.ErrUnauthorized},
+			organization: "hashicorp",
+			exitStatus:   1,
+			expected:     []string{checkPassed, checkPassed, checkFailed, checkSkipped},
+		},
+		{
+			name:         "organization-token",
+			accounts:     &AccountReader{userErr: tfe.ErrResourceNotFound},
+			organization: "hashicorp",
+			expected:     []string{checkPassed, checkPassed, checkPassed, checkPassed},
+		},
+		{
+			name:       "missing-organization",
+			accounts:   &AccountReader{},
+			exitStatus: 1,
+			expected:   []string{checkPassed, checkPassed, checkPassed, checkFailed},
+		},
+		{
+			name:         "inaccessible-organization",
+			accounts:     &AccountReader{orgErr: tfe.ErrResourceNotFound},
+			organization: "hashicorp",
+			exitStatus:   1,
+			expected:     []string{checkPassed, checkPassed, checkPassed, checkFailed},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, w)
+			cloudMockService.AccountService = tc.accounts
+			meta := NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(w), WithOrg(tc.organization))
+
+			// an IP address resolves without DNS
+			cmd := &DoctorCommand{Meta: meta, Hostname: "127.0.0.1", ClientErr: tc.clientErr}
+			if code := cmd.Run([]string{}); code != tc.exitStatus {
+				t.Fatalf("expected %d but received %d: %s", tc.exitStatus, code, ui.ErrorWriter.String())
+			}
+
+			checks := []*doctorCheck{}
+			if err := json.Unmarshal([]byte(outputValue(meta, "checks")), &checks); err != nil {
+				t.Fatalf("invalid checks output: %s", err)
+			}
+			if len(checks) != len(tc.expected) {
+				t.Fatalf("expected %d checks but received %d", len(tc.expected), len(checks))
+			}
+			for i, check := range checks {
+				if check.Status != tc.expected[i] {
+					t.Errorf("expected %s check %s but received %s: %s", check.Name, tc.expected[i], check.Status, check.Detail)
+				}
+				if (check.Status == checkFailed) != (check.Hint != "") {
+					t.Errorf("expected a hint only for a failed check, %s check %s has hint %q", check.Name, check.Status, check.Hint)
+				}
+			}
+		})
+	}
+}
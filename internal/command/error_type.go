@@ -0,0 +1,67 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+)
+
+// category of a command failure, emitted as the `error_type` output so pipelines can decide whether to retry
+const (
+	ErrorTypeNotFound     = "not_found"
+	ErrorTypeUnauthorized = "unauthorized"
+	ErrorTypeConflict     = "conflict"
+	ErrorTypeRateLimited  = "rate_limited"
+	ErrorTypeServerError  = "server_error"
+	ErrorTypeTimeout      = "timeout"
+	ErrorTypeUnknown      = "unknown"
+)
+
+// go-tfe errors for 409 responses, which HCP Terraform returns for workspace lock conflicts
+var conflictErrors = []error{
+	tfe.ErrWorkspaceLocked,
+	tfe.ErrWorkspaceNotLocked,
+	tfe.ErrWorkspaceLockedByRun,
+	tfe.ErrWorkspaceLockedByTeam,
+	tfe.ErrWorkspaceLockedByUser,
+	tfe.ErrWorkspaceLockedCannotDelete,
+	tfe.ErrWorkspaceStillProcessing,
+	tfe.ErrWorkspaceNotSafeToDelete,
+}
+
+// classifies a command error by the go-tfe and cloud error values it wraps, the message is never matched
+func classifyError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var notFoundErr *cloud.WorkspaceNotFoundError
+	var lockedErr *cloud.WorkspaceLockedError
+	var serverErr *cloud.ServerError
+	var timeoutErr *cloud.RetryTimeoutError
+	switch {
+	case errors.As(err, &notFoundErr), errors.Is(err, tfe.ErrResourceNotFound):
+		return ErrorTypeNotFound
+	case errors.Is(err, tfe.ErrUnauthorized):
+		return ErrorTypeUnauthorized
+	case errors.As(err, &lockedErr):
+		return ErrorTypeConflict
+	case errors.Is(err, cloud.ErrRateLimited):
+		return ErrorTypeRateLimited
+	case errors.As(err, &serverErr):
+		return ErrorTypeServerError
+	case errors.As(err, &timeoutErr), errors.Is(err, context.DeadlineExceeded):
+		return ErrorTypeTimeout
+	}
+	for _, conflictErr := range conflictErrors {
+		if errors.Is(err, conflictErr) {
+			return ErrorTypeConflict
+		}
+	}
+	return ErrorTypeUnknown
+}
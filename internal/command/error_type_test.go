@@ -0,0 +1,55 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+)
+
+func TestClassifyError(t *testing.T) {
+	// go-tfe returns transport errors wrapped by the http client
+	transportErr := func(err error) error {
+		return &url.Error{Op: "Get", URL: "https://app.terraform.io/api/v2/runs/run-123", Err: err}
+	}
+
+	testCases := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{name: "resource-not-found", err: tfe.ErrResourceNotFound, expected: ErrorTypeNotFound},
+		{name: "workspace-not-found", err: &cloud.WorkspaceNotFoundError{Organization: "my-org", Workspace: "my-workspace"}, expected: ErrorTypeNotFound},
+		{name: "unauthorized", err: tfe.ErrUnauthorized, expected: ErrorTypeUnauthorized},
+		{name: "wrapped-unauthorized", err: fmt.Errorf("failed to list workspaces: %w", tfe.ErrUnauthorized), expected: ErrorTypeUnauthorized},
+		{name: "workspace-locked", err: tfe.ErrWorkspaceLocked, expected: ErrorTypeConflict},
+		{name: "workspace-locked-by-run", err: tfe.ErrWorkspaceLockedByRun, expected: ErrorTypeConflict},
+		{name: "workspace-locked-by-team", err: tfe.ErrWorkspaceLockedByTeam, expected: ErrorTypeConflict},
+		{name: "workspace-locked-by-user", err: tfe.ErrWorkspaceLockedByUser, expected: ErrorTypeConflict},
+		{name: "workspace-not-safe-to-delete", err: tfe.ErrWorkspaceNotSafeToDelete, expected: ErrorTypeConflict},
+		{name: "workspace-lock-held", err: &cloud.WorkspaceLockedError{Workspace: "my-workspace", LockedBy: `run "run-123"`}, expected: ErrorTypeConflict},
+		{name: "rate-limited", err: transportErr(fmt.Errorf("%w: GET /api/v2/runs/run-123 after 5 retries", cloud.ErrRateLimited)), expected: ErrorTypeRateLimited},
+		{name: "server-error", err: fmt.Errorf("failed to read run: %w", transportErr(&cloud.ServerError{StatusCode: 503})), expected: ErrorTypeServerError},
+		{name: "retry-timeout", err: &cloud.RetryTimeoutError{}, expected: ErrorTypeTimeout},
+		{name: "deadline-exceeded", err: context.DeadlineExceeded, expected: ErrorTypeTimeout},
+		// the message of an untyped error is never matched
+		{name: "status-text", err: errors.New("503 Service Unavailable"), expected: ErrorTypeUnknown},
+		{name: "unknown", err: errors.New("invalid value for terraform version"), expected: ErrorTypeUnknown},
+		{name: "no-error", err: nil, expected: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := classifyError(tc.err); actual != tc.expected {
+				t.Errorf("expected %q but received %q", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -6,10 +6,19 @@ package command
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/hashicorp/go-tfe"
 	"github.com/hashicorp/tfci/internal/cloud"
 	"github.com/hashicorp/tfci/internal/environment"
 	"github.com/hashicorp/tfci/internal/logging"
@@ -22,12 +31,52 @@ const (
 	Error   Status = "Error"
 	Timeout Status = "Timeout"
 	Noop    Status = "Noop"
+	// -dry-run logged the request instead of sending it
+	DryRun Status = "dry_run"
+	// the plan violated a gate of the command, eg. `run create -fail-on-destroy`
+	PolicyBlocked Status = "policy_blocked"
+	// nothing to show, eg. `run show -workspace` for a workspace without runs
+	NotFound Status = "not_found"
 )
 
+// exit codes returned by commands
+const (
+	ExitSuccess = 0
+	ExitError   = 1
+	// returned with `run create -detailed-exitcode` when the plan has changes, matching `terraform plan -detailed-exitcode`
+	ExitPlanChanges = 2
+	// returned when HCP Terraform rejects the token, allowing pipelines to stop retrying
+	ExitUnauthorized = 3
+	// returned when waiting on a run or upload, or the whole command, times out. Kept apart from ExitPlanChanges
+	ExitTimeout = 4
+)
+
+// resolves the command exit code for the status, allowing pipelines to distinguish timeouts from errors
+func exitCode(status Status) int {
+	switch status {
+	case Success, Noop, DryRun, NotFound:
+		return ExitSuccess
+	case Timeout:
+		return ExitTimeout
+	default:
+		return ExitError
+	}
+}
+
+// resolves the command exit code for the status, an error caused by a rejected token is
+// reported with status Error but exits with ExitUnauthorized
+func (c *Meta) exitCode(status Status) int {
+	if status == Error && c.unauthorized {
+		return ExitUnauthorized
+	}
+	return exitCode(status)
+}
+
 type Writer interface {
 	UseJson(json bool)
 	Output(msg string)
 	Error(msg string)
+	SetResult(outputs map[string]interface{})
 	OutputResult(msg string)
 	ErrorResult(msg string)
 }
@@ -47,9 +96,128 @@ type Meta struct {
 	writer Writer
 	// flag to prevent non-json messages to stdout
 	json bool
+	// top-level fields the payload output is limited to
+	payloadFields []string
+	// when the command started, for the duration_ms output
+	start time.Time
+	// counts the API requests sent, for the api_call_count output
+	apiCalls *cloud.CountingTransport
+	// HCP Terraform rejected the token, set by resolveStatus
+	unauthorized bool
+}
+
+// an input the command cannot run without, set by any one of its options
+type requiredInput struct {
+	flags []string
+	// environment variable that also sets the input, if any
+	env    string
+	values []*string
+	// sets the input when none of its options are, if it can be looked up
+	discover func() error
+	// whether the command needs the input with its other options, always when nil
+	needed func() bool
+}
+
+func (c *Meta) requireOrganization() requiredInput {
+	return requiredInput{flags: []string{"organization"}, env: "TF_CLOUD_ORGANIZATION", values: []*string{&c.organization},
+		discover: c.discoverOrganization}
+}
+
+// selects the token's organization when it can only access one, eg. a team token. Fails listing the organizations
+// when it can access several, any other case is left to the missing input error
+func (c *Meta) discoverOrganization() error {
+	organizations, err := c.cloud.ListOrganizations(c.appCtx)
+	if err != nil {
+		if !isDryRun(err) {
+			logging.Debug("Failed to list the token's organizations", "error", err)
+		}
+		return nil
+	}
+
+	switch len(organizations) {
+	case 0:
+		return nil
+	case 1:
+		c.organization = organizations[0].Name
+		logging.Info("No organization set, using the only organization the token can access", "organization", c.organization)
+		return nil
+	}
+
+	names := make([]string, 0, len(organizations))
+	for _, o := range organizations {
+		names = append(names, o.Name)
+	}
+	slices.Sort(names)
+	return fmt.Errorf("the token can access %d organizations, select one with -organization (or the TF_CLOUD_ORGANIZATION environment variable): %s",
+		len(names), strings.Join(names, ", "))
+}
+
+// for commands reading a run by its id, the organization is only needed to select the workspace, eg. `run show -workspace`
+func (c *Meta) requireOrganizationForWorkspace(workspace *string) requiredInput {
+	r := c.requireOrganization()
+	r.needed = func() bool { return strings.TrimSpace(*workspace) != "" }
+	return r
+}
+
+func requireWorkspace(workspace *string) requiredInput {
+	return requiredInput{flags: []string{"workspace"}, values: []*string{workspace}}
+}
+
+// for commands selecting either a single workspace or every workspace having the tags
+func requireWorkspaceOrTags(workspace *string, tags *string) requiredInput {
+	return requiredInput{flags: []string{"workspace", "workspace-tags"}, values: []*string{workspace, tags}}
 }
 
-func (c *Meta) setupCmd(args []string, flags *flag.FlagSet) error {
+// for `run show`, either a run or the workspace whose current run is shown
+func requireRunOrWorkspace(run *string, workspace *string) requiredInput {
+	return requiredInput{flags: []string{"run", "workspace"}, values: []*string{run, workspace}}
+}
+
+// for `upload`, either a single workspace or a map of directories to workspaces
+func requireWorkspaceOrMap(workspace *string, workspaceMap *string) requiredInput {
+	return requiredInput{flags: []string{"workspace", "workspace-map"}, values: []*string{workspace, workspaceMap}}
+}
+
+func (r requiredInput) isNeeded() bool {
+	return r.needed == nil || r.needed()
+}
+
+func (r requiredInput) isSet() bool {
+	for _, v := range r.values {
+		if strings.TrimSpace(*v) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (r requiredInput) hint() string {
+	options := make([]string, 0, len(r.flags))
+	for _, f := range r.flags {
+		options = append(options, "-"+f)
+	}
+	hint := strings.Join(options, " or ")
+	if r.env != "" {
+		hint += fmt.Sprintf(" (or the %s environment variable)", r.env)
+	}
+	return hint
+}
+
+// returns an error listing every missing input and how to set it
+func validateRequired(required []requiredInput) error {
+	missing := []string{}
+	for _, r := range required {
+		if r.isNeeded() && !r.isSet() {
+			missing = append(missing, r.hint())
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("missing required input, set: %s", strings.Join(missing, ", "))
+}
+
+func (c *Meta) setupCmd(args []string, flags *flag.FlagSet, required ...requiredInput) error {
 	if err := flags.Parse(args); err != nil {
 		c.emitFlagOptions()
 		c.addOutput("status", string(Error))
@@ -59,6 +227,79 @@ func (c *Meta) setupCmd(args []string, flags *flag.FlagSet) error {
 	}
 
 	c.emitFlagOptions()
+
+	// every command writes the platform outputs, eg. to GITHUB_OUTPUT or TFCI_OUTPUT_PATH. The -log-file is
+	// opened before the command runs
+	if c.env != nil {
+		if err := c.checkOutputFiles(c.env.OutputPath()); err != nil {
+			return err
+		}
+	}
+
+	for _, r := range required {
+		if r.discover == nil || r.isSet() || !r.isNeeded() {
+			continue
+		}
+		if err := r.discover(); err != nil {
+			c.addOutput("status", string(Error))
+			c.closeOutput()
+			c.writer.ErrorResult(err.Error())
+			return err
+		}
+	}
+
+	// fail before doing any api work, rather than with a confusing api error
+	if err := validateRequired(required); err != nil {
+		c.addOutput("status", string(Error))
+		c.closeOutput()
+		c.writer.ErrorResult(err.Error())
+		return err
+	}
+	return nil
+}
+
+// fails before doing any api work when a file the command writes cannot be created, eg. -save-plan. Empty
+// paths are not written and skipped
+func (c *Meta) checkOutputFiles(paths ...string) error {
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		if err := checkOutputDir(filepath.Dir(path)); err != nil {
+			c.addOutput("status", string(Error))
+			c.addOutput("error_code", "io_error")
+			c.closeOutput()
+			c.writer.ErrorResult(err.Error())
+			return err
+		}
+	}
+	return nil
+}
+
+// verifies the directory exists and is writable
+func checkOutputDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("output directory %q is not accessible: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("output directory %q is not a directory", dir)
+	}
+
+	probe, err := os.CreateTemp(dir, ".tfci-")
+	if err != nil {
+		return fmt.Errorf("output directory %q is not writable: %w", dir, err)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	return nil
+}
+
+// writes a downloaded artifact readable only by the current user, as plans and state may contain secrets
+func writePrivateFile(path string, data []byte) error {
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("unable to write %q: %w", path, err)
+	}
 	return nil
 }
 
@@ -68,6 +309,15 @@ func (c *Meta) flagSet(name string) *flag.FlagSet {
 	f.Usage = func() {}
 
 	f.BoolVar(&c.json, "json", false, "Suppresses all logs and instead returns output value in JSON format")
+	f.Var((*flagStringSlice)(&c.payloadFields), "payload-fields", "Comma separated list of top-level fields to include in the payload output, eg. id,status,created-at. Defaults to all fields.")
+	// overrides the global -organization option or TF_CLOUD_ORGANIZATION for this command only
+	f.Func("organization", "HCP Terraform Organization Name, overrides the global option for this command.", func(v string) error {
+		if strings.TrimSpace(v) == "" {
+			return errors.New("organization cannot be empty")
+		}
+		c.organization = v
+		return nil
+	})
 
 	return f
 }
@@ -81,6 +331,37 @@ func (c *Meta) emitFlagOptions() {
 
 func (c *Meta) resolveStatus(err error) Status {
 	if err != nil {
+		if isDryRun(err) {
+			return DryRun
+		}
+		logging.Debug("Command error details", "error", err.Error(), "error_types", logging.ErrorTypes(err))
+		c.addOutput("error_type", classifyError(err))
+		// the whole command exceeded -timeout, the error is only a symptom of the canceled requests
+		if c.appCtx != nil && errors.Is(c.appCtx.Err(), context.DeadlineExceeded) {
+			c.writer.Error(context.Cause(c.appCtx).Error())
+			return Timeout
+		}
+		// only a genuine not found response, transient failures are reported without an error code
+		var notFoundErr *cloud.WorkspaceNotFoundError
+		if errors.As(err, &notFoundErr) {
+			c.addOutput("error_code", "not_found")
+		}
+		var adminErr *cloud.AdminAccessRequiredError
+		if errors.As(err, &adminErr) {
+			c.addOutput("error_code", "admin_required")
+		}
+		var lockedErr *cloud.WorkspaceLockedError
+		if errors.As(err, &lockedErr) {
+			c.addOutput("error_code", "locked")
+		}
+		var globalErr *cloud.GlobalVariableSetError
+		if errors.As(err, &globalErr) {
+			c.addOutput("error_code", "global_variable_set")
+		}
+		if errors.Is(err, tfe.ErrUnauthorized) {
+			c.addOutput("error_code", "unauthorized")
+			c.unauthorized = true
+		}
 		switch err.(type) {
 		case *cloud.RetryTimeoutError:
 			return Timeout
@@ -91,6 +372,45 @@ func (c *Meta) resolveStatus(err error) Status {
 	return Success
 }
 
+// reports the error of a failed request, a request logged by -dry-run is not a failure and nothing is reported
+func (c *Meta) errorResult(status Status, msg string) {
+	if status == DryRun {
+		return
+	}
+	c.writer.ErrorResult(msg)
+}
+
+// the request was logged by -dry-run instead of being sent, which is not a failure
+func isDryRun(err error) bool {
+	var dryRunErr *cloud.DryRunError
+	return errors.As(err, &dryRunErr)
+}
+
+// adds the run_link output with the run's url on the configured host, the output is skipped
+// when the link cannot be built
+func (c *Meta) addRunLink(run *tfe.Run) string {
+	if run == nil || c.organization == "" {
+		return ""
+	}
+	link, err := c.cloud.RunLink(c.appCtx, c.organization, run)
+	if err != nil || link == "" {
+		return ""
+	}
+	c.addOutput("run_link", link)
+	return link
+}
+
+// adds the run, including any related resources read with it such as the plan, as the JSON payload output. Written
+// to the platform only, as it is too large for the command result
+func (c *Meta) addRunPayload(run *tfe.Run) {
+	c.addOutputWithOpts("payload", run, &outputOpts{
+		stdOut:      false,
+		multiLine:   true,
+		platformOut: true,
+		fields:      c.payloadFields,
+	})
+}
+
 // adds new output value to map as &OutputMessage{}
 func (c *Meta) addOutput(name string, value string) {
 	c.messages[name] = newOutputMessage(name, value, defaultOutputOpts)
@@ -104,6 +424,8 @@ func (c *Meta) addOutputWithOpts(name string, value interface{}, opts *outputOpt
 // returns json result string, containing all outputs
 // if running in ci, will send outputs to platform
 func (c *Meta) closeOutput() string {
+	c.addMetrics()
+
 	// using map[string]any to pretty marshal collection
 	stdOutput := make(map[string]interface{})
 	// map[string]OutputI interface
@@ -125,7 +447,11 @@ func (c *Meta) closeOutput() string {
 				// don't include value if issue serializing value
 				continue
 			}
-			platOutput[m.name] = environment.NewOutput(val, m.multiLine)
+			if m.sensitive && len(m.masks) > 0 {
+				platOutput[m.name] = environment.NewMaskedOutput(val, m.multiLine, m.masks)
+			} else {
+				platOutput[m.name] = environment.NewOutput(val, m.multiLine, m.sensitive)
+			}
 		}
 	}
 
@@ -149,6 +475,9 @@ func (c *Meta) closeOutput() string {
 		}
 	}
 
+	// record outputs for the structured result, eg. -output-format=json
+	c.writer.SetResult(stdOutput)
+
 	outJson, err := json.MarshalIndent(stdOutput, "", "  ")
 	if err != nil {
 		logging.Error("Failed to marshal JSON output", "error", err)
@@ -157,6 +486,31 @@ func (c *Meta) closeOutput() string {
 	return string(outJson)
 }
 
+// adds how long the command took and how many API requests it sent, every command closes its output on both
+// success and failure so the metrics are always set
+func (c *Meta) addMetrics() {
+	c.addOutput("duration_ms", strconv.FormatInt(time.Since(c.start).Milliseconds(), 10))
+	if c.apiCalls == nil {
+		return
+	}
+
+	c.addOutput("api_call_count", strconv.Itoa(c.apiCalls.Count()))
+	endpoints := c.apiCalls.Endpoints()
+	for _, endpoint := range slices.Sorted(maps.Keys(endpoints)) {
+		logging.Debug("HCP Terraform API calls",
+			"endpoint", endpoint,
+			"count", endpoints[endpoint].Count,
+			"latency", endpoints[endpoint].Latency.String())
+	}
+}
+
+// flushes the outputs accumulated before the command panicked with the Error status, so pipelines always receive a
+// terminal status to branch on
+func (c *Meta) FlushOutputOnPanic() {
+	c.addOutput("status", string(Error))
+	c.writer.OutputResult(c.closeOutput())
+}
+
 func WithOrg(org string) func(*Meta) {
 	return func(m *Meta) {
 		m.organization = org
@@ -169,12 +523,20 @@ func WithWriter(w Writer) func(*Meta) {
 	}
 }
 
+// counts the API requests of the command with the transport of its go-tfe client
+func WithAPICallCounter(counter *cloud.CountingTransport) func(*Meta) {
+	return func(m *Meta) {
+		m.apiCalls = counter
+	}
+}
+
 func NewMetaOpts(ctx context.Context, tfeClient *cloud.Cloud, ciEnv *environment.CI, setters ...func(*Meta)) *Meta {
 	m := &Meta{
 		cloud:    tfeClient,
 		appCtx:   ctx,
 		env:      ciEnv,
 		messages: make(map[string]*outputMessage),
+		start:    time.Now(),
 	}
 
 	for _, setter := range setters {
@@ -188,5 +550,6 @@ func NewMeta(c *cloud.Cloud) *Meta {
 	return &Meta{
 		cloud:    c,
 		messages: make(map[string]*outputMessage),
+		start:    time.Now(),
 	}
 }
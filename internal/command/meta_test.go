@@ -0,0 +1,637 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+	"github.com/hashicorp/tfci/internal/environment"
+	"github.com/hashicorp/tfci/internal/writer"
+	"github.com/mitchellh/cli"
+)
+
+func TestMeta_RequiredInputs(t *testing.T) {
+	missingOrg := "missing required input, set: -organization (or the TF_CLOUD_ORGANIZATION environment variable)"
+
+	testCases := []struct {
+		name     string
+		command  func(meta *Meta) cli.Command
+		org      string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "run-show-missing-organization",
+			command:  func(meta *Meta) cli.Command { return &ShowRunCommand{Meta: meta} },
+			args:     []string{"-workspace=my-workspace"},
+			expected: missingOrg,
+		},
+		{
+			name:     "run-show-missing-run",
+			command:  func(meta *Meta) cli.Command { return &ShowRunCommand{Meta: meta} },
+			org:      "hashicorp",
+			expected: "missing required input, set: -run or -workspace",
+		},
+		{
+			name:     "run-create-missing-all",
+			command:  func(meta *Meta) cli.Command { return &CreateRunCommand{Meta: meta} },
+			expected: "missing required input, set: -organization (or the TF_CLOUD_ORGANIZATION environment variable), -workspace or -workspace-tags",
+		},
+		{
+			name:     "run-list-missing-workspace",
+			command:  func(meta *Meta) cli.Command { return &ListRunCommand{Meta: meta} },
+			org:      "hashicorp",
+			expected: "missing required input, set: -workspace or -workspace-tags",
+		},
+		{
+			name:     "upload-missing-workspace",
+			command:  func(meta *Meta) cli.Command { return &UploadConfigurationCommand{Meta: meta} },
+			org:      "hashicorp",
+			args:     []string{"-directory=dir/"},
+			expected: "missing required input, set: -workspace or -workspace-map",
+		},
+		{
+			name:     "workspace-show-blank-workspace",
+			command:  func(meta *Meta) cli.Command { return &ShowWorkspaceCommand{Meta: meta} },
+			args:     []string{"-organization=hashicorp", "-workspace= "},
+			expected: "missing required input, set: -workspace",
+		},
+		{
+			name:     "state-show-missing-all",
+			command:  func(meta *Meta) cli.Command { return &ShowStateCommand{Meta: meta} },
+			expected: "missing required input, set: -organization (or the TF_CLOUD_ORGANIZATION environment variable), -workspace",
+		},
+		{
+			name:     "workspace-cleanup-missing-organization",
+			command:  func(meta *Meta) cli.Command { return &CleanupWorkspaceCommand{Meta: meta} },
+			args:     []string{"-tag=preview"},
+			expected: missingOrg,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			// services are left unset, any api call would panic
+			cloudService := cloud.NewCloud(&tfe.Client{}, w)
+			cloudService.RunService = nil
+			cloudService.WorkspaceService = nil
+			cloudService.ConfigVersionService = nil
+			// the token cannot access any organization to select
+			cloudService.AccountService = &AccountReader{}
+			meta := NewMetaOpts(context.Background(), cloudService, &environment.CI{}, WithWriter(w), WithOrg(tc.org))
+
+			if code := tc.command(meta).Run(tc.args); code != ExitError {
+				t.Fatalf("expected %d but received %d", ExitError, code)
+			}
+			if output := ui.ErrorWriter.String(); output != tc.expected+"\n" {
+				t.Errorf("expected %q but received %q", tc.expected, output)
+			}
+			if status, _ := meta.messages["status"].Value(); status != string(Error) {
+				t.Errorf("expected status %q but received %q", Error, status)
+			}
+		})
+	}
+}
+
+// platform context writing outputs to a file, eg. TFCI_OUTPUT_PATH
+type OutputPathContext struct {
+	environment.Common
+	path string
+}
+
+func (o *OutputPathContext) OutputPath() string {
+	return o.path
+}
+
+func (o *OutputPathContext) SetOutput(_ environment.OutputMap) {}
+
+func (o *OutputPathContext) CloseOutput() error {
+	return nil
+}
+
+func TestMeta_OutputFiles(t *testing.T) {
+	// a regular file where a directory is expected, which fails for the root user as well
+	notADir := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(notADir, []byte(""), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	missingDir := filepath.Join(t.TempDir(), "missing")
+
+	testCases := []struct {
+		name       string
+		outputPath string
+		command    func(meta *Meta) cli.Command
+		args       []string
+		// directory named in the error, empty when the command is expected to run
+		expectDir string
+	}{
+		{
+			name:       "platform-output-missing-directory",
+			outputPath: filepath.Join(missingDir, "outputs"),
+			command:    func(meta *Meta) cli.Command { return &ShowWorkspaceCommand{Meta: meta} },
+			args:       []string{"-workspace=my-workspace"},
+			expectDir:  missingDir,
+		},
+		{
+			name:       "platform-output-not-a-directory",
+			outputPath: filepath.Join(notADir, "outputs"),
+			command:    func(meta *Meta) cli.Command { return &ShowWorkspaceCommand{Meta: meta} },
+			args:       []string{"-workspace=my-workspace"},
+			expectDir:  notADir,
+		},
+		{
+			name:      "save-plan-missing-directory",
+			command:   func(meta *Meta) cli.Command { return &OutputPlanCommand{Meta: meta} },
+			args:      []string{"-plan=plan-***", "-save-plan=" + filepath.Join(missingDir, "plan.json")},
+			expectDir: missingDir,
+		},
+		{
+			name:      "save-state-not-a-directory",
+			command:   func(meta *Meta) cli.Command { return &ShowStateCommand{Meta: meta} },
+			args:      []string{"-workspace=my-workspace", "-save-state=" + filepath.Join(notADir, "state.json")},
+			expectDir: notADir,
+		},
+		{
+			// commands without an output file are not checked
+			name:    "no-output-files",
+			command: func(meta *Meta) cli.Command { return &ShowWorkspaceCommand{Meta: meta} },
+			args:    []string{"-workspace=my-workspace"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			// reads fail the test when the check does not stop the command first
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, w)
+			cloudMockService.WorkspaceService = &WorkspaceReader{workspace: &tfe.Workspace{ID: "ws-***", Name: "my-workspace"}}
+			env := &environment.CI{Context: &OutputPathContext{path: tc.outputPath}}
+			meta := NewMetaOpts(context.Background(), cloudMockService, env, WithWriter(w), WithOrg("hashicorp"))
+
+			code := tc.command(meta).Run(tc.args)
+			if tc.expectDir == "" {
+				if code != 0 {
+					t.Fatalf("expected %d but received %d: %s", 0, code, ui.ErrorWriter.String())
+				}
+				return
+			}
+			if code != 1 {
+				t.Fatalf("expected %d but received %d", 1, code)
+			}
+			if errorCode := outputValue(meta, "error_code"); errorCode != "io_error" {
+				t.Errorf("expected error_code %q but received %q", "io_error", errorCode)
+			}
+			if !strings.Contains(ui.ErrorWriter.String(), tc.expectDir) {
+				t.Errorf("expected error to contain the output directory %q, received: %q", tc.expectDir, ui.ErrorWriter.String())
+			}
+		})
+	}
+}
+
+func TestMeta_DiscoverOrganization(t *testing.T) {
+	testCases := []struct {
+		name          string
+		organizations []string
+		exitStatus    int
+		expected      string
+		expectedError string
+	}{
+		{
+			name:          "single",
+			organizations: []string{"hashicorp"},
+			exitStatus:    0,
+			expected:      "hashicorp",
+		},
+		{
+			name:          "multiple",
+			organizations: []string{"tfci", "hashicorp"},
+			exitStatus:    ExitError,
+			expectedError: "the token can access 2 organizations, select one with -organization (or the TF_CLOUD_ORGANIZATION environment variable): hashicorp, tfci\n",
+		},
+		{
+			name:          "none",
+			exitStatus:    ExitError,
+			expectedError: "missing required input, set: -organization (or the TF_CLOUD_ORGANIZATION environment variable)\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			cloudService := cloud.NewCloud(&tfe.Client{}, w)
+			cloudService.AccountService = &AccountReader{organizations: tc.organizations}
+			variables := &VariableWriter{}
+			cloudService.VariableService = variables
+			meta := NewMetaOpts(context.Background(), cloudService, &environment.CI{}, WithWriter(w))
+
+			if code := (&SetVariableCommand{Meta: meta}).Run([]string{"-workspace=my-workspace", "-key=ami_id"}); code != tc.exitStatus {
+				t.Fatalf("expected %d but received %d: %s", tc.exitStatus, code, ui.ErrorWriter.String())
+			}
+			if meta.organization != tc.expected {
+				t.Errorf("expected organization %q but received %q", tc.expected, meta.organization)
+			}
+			if variables.options != nil && variables.options.Organization != tc.expected {
+				t.Errorf("expected the variable to be set in organization %q but received %q", tc.expected, variables.options.Organization)
+			}
+			if output := ui.ErrorWriter.String(); tc.expectedError != "" && output != tc.expectedError {
+				t.Errorf("expected %q but received %q", tc.expectedError, output)
+			}
+		})
+	}
+}
+
+type DeadlineRunService struct {
+	RunReader
+}
+
+func (r *DeadlineRunService) GetRun(ctx context.Context, _ cloud.GetRunOptions) (*tfe.Run, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestMeta_CommandTimeout(t *testing.T) {
+	ui := cli.NewMockUi()
+	w := writer.NewWriter(ui)
+	cloudService := cloud.NewCloud(&tfe.Client{}, w)
+	cloudService.RunService = &DeadlineRunService{}
+	ctx, cancel := context.WithTimeoutCause(context.Background(), time.Millisecond, errors.New("operation timed out after 1ms, see -timeout or TFCI_TIMEOUT"))
+	defer cancel()
+	meta := NewMetaOpts(ctx, cloudService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))
+
+	if code := (&ShowRunCommand{Meta: meta}).Run([]string{"-run=run-123"}); code != ExitTimeout {
+		t.Fatalf("expected %d but received %d", ExitTimeout, code)
+	}
+	if status, _ := meta.messages["status"].Value(); status != string(Timeout) {
+		t.Errorf("expected status %q but received %q", Timeout, status)
+	}
+	if output := ui.ErrorWriter.String(); !strings.Contains(output, "operation timed out after 1ms") {
+		t.Errorf("expected a timeout error but received %q", output)
+	}
+}
+
+func TestMeta_DryRun(t *testing.T) {
+	testCases := []struct {
+		name    string
+		command func(meta *Meta) cli.Command
+		args    []string
+	}{
+		{
+			name:    "upload",
+			command: func(meta *Meta) cli.Command { return &UploadConfigurationCommand{Meta: meta} },
+			args:    []string{"-workspace=ws-1", "-directory=dir/"},
+		},
+		{
+			name:    "run-create",
+			command: func(meta *Meta) cli.Command { return &CreateRunCommand{Meta: meta} },
+			args:    []string{"-workspace=ws-1", "-configuration_version=cv-1"},
+		},
+		{
+			name:    "run-create-fail-on-drift",
+			command: func(meta *Meta) cli.Command { return &CreateRunCommand{Meta: meta} },
+			args:    []string{"-workspace=ws-1", "-fail-on-drift"},
+		},
+		{
+			name:    "upload-create-workspace",
+			command: func(meta *Meta) cli.Command { return &UploadConfigurationCommand{Meta: meta} },
+			args:    []string{"-workspace=ws-1", "-directory=dir/", "-create-workspace"},
+		},
+		{
+			name:    "run-apply",
+			command: func(meta *Meta) cli.Command { return &ApplyRunCommand{Meta: meta} },
+			args:    []string{"-run=run-1"},
+		},
+		{
+			name:    "run-cancel",
+			command: func(meta *Meta) cli.Command { return &CancelRunCommand{Meta: meta} },
+			args:    []string{"-run=run-1"},
+		},
+		{
+			name:    "run-discard",
+			command: func(meta *Meta) cli.Command { return &DiscardRunCommand{Meta: meta} },
+			args:    []string{"-run=run-1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			// the client is unconfigured, any request would fail
+			cloudService := cloud.NewCloud(&tfe.Client{}, w, cloud.WithDryRun(true))
+			meta := NewMetaOpts(context.Background(), cloudService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))
+
+			if code := tc.command(meta).Run(tc.args); code != ExitSuccess {
+				t.Fatalf("expected %d but received %d", ExitSuccess, code)
+			}
+			if status, _ := meta.messages["status"].Value(); status != string(DryRun) {
+				t.Errorf("expected status %q but received %q", DryRun, status)
+			}
+			// a logged request is not a failure
+			if output := ui.ErrorWriter.String(); output != "" {
+				t.Errorf("expected no error but received %q", output)
+			}
+		})
+	}
+}
+
+// platform context recording the outputs it is closed with
+type OutputRecorder struct {
+	environment.Common
+	output environment.OutputMap
+	closed bool
+}
+
+func (o *OutputRecorder) SetOutput(output environment.OutputMap) {
+	o.output = output
+}
+
+func (o *OutputRecorder) CloseOutput() error {
+	o.closed = true
+	return nil
+}
+
+func TestMeta_FlushOutputOnPanic(t *testing.T) {
+	ui := cli.NewMockUi()
+	w := writer.NewWriter(ui)
+	recorder := &OutputRecorder{}
+	meta := NewMetaOpts(context.Background(), cloud.NewCloud(&tfe.Client{}, w), &environment.CI{Context: recorder}, WithWriter(w))
+
+	// outputs added before the panic are kept
+	meta.addOutput("status", string(Success))
+	meta.addOutput("run_id", "run-***")
+	meta.FlushOutputOnPanic()
+
+	if !recorder.closed {
+		t.Fatal("expected the platform output to be closed")
+	}
+	if status := recorder.output["status"]; status == nil || status.String() != string(Error) {
+		t.Errorf("expected status %q but received %v", Error, status)
+	}
+	if runID := recorder.output["run_id"]; runID == nil || runID.String() != "run-***" {
+		t.Errorf("expected run_id %q but received %v", "run-***", runID)
+	}
+}
+
+func TestMeta_Metrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	counter := cloud.NewCountingTransport(http.DefaultTransport)
+	for range 2 {
+		resp, err := (&http.Client{Transport: counter}).Get(server.URL + "/api/v2/ping")
+		if err != nil {
+			t.Fatalf("expected no error but received %s", err)
+		}
+		resp.Body.Close()
+	}
+
+	ui := cli.NewMockUi()
+	w := writer.NewWriter(ui)
+	recorder := &OutputRecorder{}
+	meta := NewMetaOpts(context.Background(), cloud.NewCloud(&tfe.Client{}, w), &environment.CI{Context: recorder},
+		WithWriter(w), WithOrg("hashicorp"), WithAPICallCounter(counter))
+
+	// the metrics are set on failure, eg. a missing -workspace
+	if code := (&ShowWorkspaceCommand{Meta: meta}).Run(nil); code != 1 {
+		t.Fatalf("expected 1 but received %d", code)
+	}
+	if count := recorder.output["api_call_count"]; count == nil || count.String() != "2" {
+		t.Errorf("expected api_call_count %q but received %v", "2", count)
+	}
+	duration := recorder.output["duration_ms"]
+	if duration == nil {
+		t.Fatal("expected duration_ms to be set")
+	}
+	if _, err := strconv.Atoi(duration.String()); err != nil {
+		t.Errorf("expected duration_ms to be milliseconds but received %q", duration.String())
+	}
+}
+
+func TestRunCommands_Payload(t *testing.T) {
+	run := &tfe.Run{
+		ID:     "run-123",
+		Status: tfe.RunPlanned,
+		Plan:   &tfe.Plan{ID: "plan-123"},
+		Actions: &tfe.RunActions{
+			IsCancelable:  true,
+			IsConfirmable: true,
+			IsDiscardable: true,
+		},
+	}
+	testCases := []struct {
+		name    string
+		command func(*Meta) cli.Command
+		service cloud.RunService
+	}{
+		{
+			name:    "apply",
+			command: func(m *Meta) cli.Command { return &ApplyRunCommand{Meta: m} },
+			service: &RunReader{run: run},
+		},
+		{
+			name:    "cancel",
+			command: func(m *Meta) cli.Command { return &CancelRunCommand{Meta: m} },
+			service: &CancelRunService{RunReader: RunReader{run: run}},
+		},
+		{
+			name:    "discard",
+			command: func(m *Meta) cli.Command { return &DiscardRunCommand{Meta: m} },
+			service: &DiscardRunService{RunReader: RunReader{run: run}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, w)
+			cloudMockService.RunService = tc.service
+			cloudMockService.ConfigVersionService = &SuccessfulUploader{}
+			meta := NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))
+
+			if code := tc.command(meta).Run([]string{"-run=run-123"}); code != 0 {
+				t.Fatalf("expected 0 but received %d: %s", code, ui.ErrorWriter.String())
+			}
+			payload := outputValue(meta, "payload")
+			if !json.Valid([]byte(payload)) || !strings.Contains(payload, `"run-123"`) {
+				t.Errorf("expected the run as the JSON payload but received %q", payload)
+			}
+			if strings.Contains(ui.OutputWriter.String(), `"payload"`) {
+				t.Errorf("expected the payload to not be written to stdout but received %q", ui.OutputWriter.String())
+			}
+		})
+	}
+}
+
+func TestMeta_OutputFormatJSON(t *testing.T) {
+	testCases := []struct {
+		name       string
+		args       []string
+		exitStatus int
+		status     string
+		errMessage string
+	}{
+		{
+			name:       "success",
+			args:       []string{"-workspace=my-workspace"},
+			exitStatus: 0,
+			status:     "Success",
+		},
+		{
+			name:       "missing-workspace",
+			args:       []string{},
+			exitStatus: 1,
+			status:     "Error",
+			errMessage: "missing required input, set: -workspace",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui, writer.WithOutputFormat(writer.FormatJSON))
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, w)
+			cloudMockService.WorkspaceService = &WorkspaceReader{workspace: &tfe.Workspace{ID: "ws-***", Name: "my-workspace"}}
+			cmd := &ShowWorkspaceCommand{Meta: NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))}
+
+			if actual := cmd.Run(tc.args); actual != tc.exitStatus {
+				t.Fatalf("expected %d but received %d", tc.exitStatus, actual)
+			}
+			w.Flush()
+
+			if stderr := ui.ErrorWriter.String(); stderr != "" {
+				t.Errorf("expected no output to stderr, received: %q", stderr)
+			}
+
+			result := struct {
+				Status  string            `json:"status"`
+				Outputs map[string]string `json:"outputs"`
+				Error   string            `json:"error"`
+			}{}
+			if err := json.Unmarshal([]byte(ui.OutputWriter.String()), &result); err != nil {
+				t.Fatalf("expected stdout to be a single json object, received: %q", ui.OutputWriter.String())
+			}
+			if result.Status != tc.status {
+				t.Errorf("expected status %q but received %q", tc.status, result.Status)
+			}
+			if result.Outputs["status"] != tc.status {
+				t.Errorf("expected status output %q but received %q", tc.status, result.Outputs["status"])
+			}
+			if result.Error != tc.errMessage {
+				t.Errorf("expected error %q but received %q", tc.errMessage, result.Error)
+			}
+		})
+	}
+}
+
+func TestMeta_OutputFormatOneLineJSON(t *testing.T) {
+	ui := cli.NewMockUi()
+	w := writer.NewWriter(ui, writer.WithOutputFormat(writer.FormatOneLineJSON))
+	cloudMockService := cloud.NewCloud(&tfe.Client{}, w)
+	cloudMockService.WorkspaceService = &WorkspaceReader{workspace: &tfe.Workspace{ID: "ws-***", Name: "my-workspace"}}
+	cmd := &ShowWorkspaceCommand{Meta: NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))}
+
+	if actual := cmd.Run([]string{"-workspace=my-workspace"}); actual != 0 {
+		t.Fatalf("expected %d but received %d", 0, actual)
+	}
+	w.Flush()
+
+	stdout := strings.TrimSuffix(ui.OutputWriter.String(), "\n")
+	if strings.Contains(stdout, "\n") {
+		t.Errorf("expected a single line on stdout, received: %q", stdout)
+	}
+
+	summary := map[string]string{}
+	if err := json.Unmarshal([]byte(stdout), &summary); err != nil {
+		t.Fatalf("expected stdout to be a json object, received: %q", stdout)
+	}
+	// the duration varies between runs
+	if _, ok := summary["duration_ms"]; !ok {
+		t.Errorf("expected duration_ms in %v", summary)
+	}
+	delete(summary, "duration_ms")
+	expected := map[string]string{
+		"status":         "Success",
+		"workspace_id":   "ws-***",
+		"workspace_name": "my-workspace",
+	}
+	if !reflect.DeepEqual(summary, expected) {
+		t.Errorf("expected %v but received %v", expected, summary)
+	}
+}
+
+func TestMeta_WorkspaceErrorCode(t *testing.T) {
+	testCases := []struct {
+		name       string
+		err        error
+		errorCode  string
+		exitStatus int
+	}{
+		{
+			name:       "workspace-not-found",
+			err:        &cloud.WorkspaceNotFoundError{Organization: "my-org", Workspace: "my-workspace"},
+			errorCode:  "not_found",
+			exitStatus: 1,
+		},
+		{
+			name:       "transient-failure",
+			err:        errors.New("failed to resolve workspace: 503 Service Unavailable"),
+			errorCode:  "",
+			exitStatus: 1,
+		},
+		{
+			name:       "unauthorized",
+			err:        fmt.Errorf("failed to resolve workspace: %w", tfe.ErrUnauthorized),
+			errorCode:  "unauthorized",
+			exitStatus: 3,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, w)
+			cloudMockService.WorkspaceService = &WorkspaceReader{err: tc.err}
+			cmd := &ShowWorkspaceCommand{Meta: NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))}
+
+			if actual := cmd.Run([]string{"-workspace=my-workspace"}); actual != tc.exitStatus {
+				t.Fatalf("expected %d but received %d", tc.exitStatus, actual)
+			}
+
+			outputs := cmd.messages
+			errorCode := ""
+			if m, ok := outputs["error_code"]; ok {
+				errorCode, _ = m.Value()
+			}
+			if errorCode != tc.errorCode {
+				t.Errorf("expected error_code %q but received %q", tc.errorCode, errorCode)
+			}
+			// existing `status == 'Error'` checks keep matching, the error_code tells the failures apart
+			if status := outputValue(cmd.Meta, "status"); status != string(Error) {
+				t.Errorf("expected status %q but received %q", Error, status)
+			}
+		})
+	}
+}
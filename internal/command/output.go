@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/hashicorp/jsonapi"
 )
@@ -23,6 +24,12 @@ type outputMessage struct {
 	platformOut bool
 	// if the value may contain strings/json that is multiline
 	multiLine bool
+	// if the value should be masked by platforms that support it
+	sensitive bool
+	// the sensitive values a collection contains, masked individually instead of the whole value
+	masks []string
+	// top-level fields the serialized value is limited to, all fields by default
+	fields []string
 }
 
 func (o *outputMessage) IncludeWithPlatform() bool {
@@ -30,13 +37,29 @@ func (o *outputMessage) IncludeWithPlatform() bool {
 }
 
 func (o *outputMessage) Value() (string, error) {
+	val, err := o.marshalValue()
+	if err != nil || len(o.fields) == 0 {
+		return val, err
+	}
+	return filterFields(val, o.fields)
+}
+
+func (o *outputMessage) marshalValue() (string, error) {
 	switch o.value.(type) {
 	case string:
 		return o.value.(string), nil
 	default:
 		reflectVal := reflect.ValueOf(o.value)
 		reflectInd := reflect.Indirect(reflectVal)
+		// nil value, eg. the payload of a failed upload
+		if !reflectInd.IsValid() {
+			return "", nil
+		}
 		refType := reflectInd.Type()
+		// collection of go-tfe structs, eg. []*tfe.Run, also require the `jsonapi` marshaler
+		if refType.Kind() == reflect.Slice && isJsonAPISlice(refType) {
+			return marshalJsonAPI(o.value)
+		}
 		// if type is not a struct, return as marshaled string
 		if refType.Kind() != reflect.Struct {
 			b, bErr := json.Marshal(o.value)
@@ -66,6 +89,10 @@ func (o *outputMessage) MultiLine() bool {
 	return o.multiLine
 }
 
+func (o *outputMessage) Sensitive() bool {
+	return o.sensitive
+}
+
 var defaultOutputOpts = &outputOpts{
 	stdOut:      true,
 	platformOut: true,
@@ -79,6 +106,12 @@ type outputOpts struct {
 	platformOut bool
 	// option to indicate if value contains a multiline value as some platforms: gitlab do not support multiline values in `.env`
 	multiLine bool
+	// option to indicate if value contains sensitive data and should be masked by the platform, eg. github
+	sensitive bool
+	// option to mask the individual sensitive values of a collection, eg. `workspace output -include-sensitive`
+	masks []string
+	// option to limit a serialized value to the top-level fields, eg. `-payload-fields`
+	fields []string
 }
 
 func newOutputMessage(name string, value interface{}, opts *outputOpts) *outputMessage {
@@ -88,6 +121,48 @@ func newOutputMessage(name string, value interface{}, opts *outputOpts) *outputM
 		stdOut:      opts.stdOut,
 		platformOut: opts.platformOut,
 		multiLine:   opts.multiLine,
+		sensitive:   opts.sensitive,
+		masks:       opts.masks,
+		fields:      opts.fields,
+	}
+}
+
+// returns each value of a sensitive output as it appears in the rendered outputs, so platforms can mask the values
+// where they are printed on their own. Strings are masked both as is and json escaped, booleans and null are not
+// masked as they would mask every occurrence of the word in the logs
+func maskValues(value interface{}) []string {
+	switch v := value.(type) {
+	case nil, bool:
+		return nil
+	case string:
+		if v == "" {
+			return nil
+		}
+		masks := []string{v}
+		if b, err := json.Marshal(v); err == nil {
+			if escaped := string(b[1 : len(b)-1]); escaped != v {
+				masks = append(masks, escaped)
+			}
+		}
+		return masks
+	case []interface{}:
+		masks := []string{}
+		for _, item := range v {
+			masks = append(masks, maskValues(item)...)
+		}
+		return masks
+	case map[string]interface{}:
+		masks := []string{}
+		for _, item := range v {
+			masks = append(masks, maskValues(item)...)
+		}
+		return masks
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil
+		}
+		return []string{string(b)}
 	}
 }
 
@@ -112,6 +187,15 @@ func resolveMarshaler(t reflect.Type) Marshaler {
 	return ""
 }
 
+// checks if slice elements are pointers to structs decorated with `jsonapi` fields
+func isJsonAPISlice(t reflect.Type) bool {
+	elem := t.Elem()
+	if elem.Kind() != reflect.Pointer || elem.Elem().Kind() != reflect.Struct {
+		return false
+	}
+	return resolveMarshaler(elem.Elem()) == JSONAPI
+}
+
 func marshalJson(data interface{}) (string, error) {
 	bytes, err := json.Marshal(data)
 	if err != nil {
@@ -133,3 +217,84 @@ func marshalJsonAPI(data interface{}) (string, error) {
 
 	return outJson, nil
 }
+
+// limits a serialized json value to the requested top-level fields. For json:api documents, eg. go-tfe structs,
+// the fields select the attributes and relationships of each resource in `data`, the resource `id` and `type`
+// are always kept and `included` resources are omitted.
+func filterFields(raw string, fields []string) (string, error) {
+	keep := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		if f = strings.TrimSpace(f); f != "" {
+			keep[f] = true
+		}
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	// preserve number formatting when re-encoding
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return "", err
+	}
+
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		if data, ok := v["data"]; ok {
+			doc = map[string]interface{}{"data": filterResources(data, keep)}
+		} else {
+			doc = filterObject(v, keep)
+		}
+	case []interface{}:
+		for i, item := range v {
+			if obj, ok := item.(map[string]interface{}); ok {
+				v[i] = filterObject(obj, keep)
+			}
+		}
+	}
+
+	buffer := new(bytes.Buffer)
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(doc); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buffer.String(), "\n"), nil
+}
+
+// filters a json:api primary data resource, or collection of resources
+func filterResources(data interface{}, keep map[string]bool) interface{} {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		resource := map[string]interface{}{}
+		for _, key := range []string{"id", "type"} {
+			if val, ok := v[key]; ok {
+				resource[key] = val
+			}
+		}
+		for _, key := range []string{"attributes", "relationships"} {
+			if obj, ok := v[key].(map[string]interface{}); ok {
+				if filtered := filterObject(obj, keep); len(filtered) > 0 {
+					resource[key] = filtered
+				}
+			}
+		}
+		return resource
+	case []interface{}:
+		for i, item := range v {
+			v[i] = filterResources(item, keep)
+		}
+		return v
+	default:
+		return data
+	}
+}
+
+func filterObject(obj map[string]interface{}, keep map[string]bool) map[string]interface{} {
+	filtered := map[string]interface{}{}
+	for key, val := range obj {
+		if keep[key] {
+			filtered[key] = val
+		}
+	}
+	return filtered
+}
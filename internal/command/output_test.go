@@ -0,0 +1,85 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+func TestOutputMessage_Fields(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    interface{}
+		fields   []string
+		expected string
+	}{
+		{
+			name: "jsonapi-resource",
+			value: &tfe.Run{
+				ID:      "run-123",
+				Status:  tfe.RunPlanned,
+				Message: "Triggered via CI",
+				Plan:    &tfe.Plan{ID: "plan-123"},
+			},
+			fields:   []string{"status", " message", "plan"},
+			expected: `{"data":{"attributes":{"message":"Triggered via CI","status":"planned"},"id":"run-123","relationships":{"plan":{"data":{"id":"plan-123","type":"plans"}}},"type":"runs"}}`,
+		},
+		{
+			name: "jsonapi-collection",
+			value: []*tfe.Run{
+				{ID: "run-1", Status: tfe.RunApplied},
+				{ID: "run-2", Status: tfe.RunErrored},
+			},
+			fields:   []string{"status"},
+			expected: `{"data":[{"attributes":{"status":"applied"},"id":"run-1","type":"runs"},{"attributes":{"status":"errored"},"id":"run-2","type":"runs"}]}`,
+		},
+		{
+			name:     "json-object",
+			value:    &WorkspaceOutput{Name: "image_id", Value: "ami-123456"},
+			fields:   []string{"name"},
+			expected: `{"name":"image_id"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			message := newOutputMessage("payload", tc.value, &outputOpts{fields: tc.fields})
+			actual, err := message.Value()
+			if err != nil {
+				t.Fatalf("expected no error but received %s", err)
+			}
+			if actual != tc.expected {
+				t.Errorf("expected %s but received %s", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestMaskValues(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    interface{}
+		expected []string
+	}{
+		{name: "string", value: "hunter2", expected: []string{"hunter2"}},
+		{name: "escaped-string", value: "line one\nline \"two\"", expected: []string{"line one\nline \"two\"", `line one\nline \"two\"`}},
+		{name: "empty-string", value: "", expected: nil},
+		{name: "number", value: float64(8080), expected: []string{"8080"}},
+		{name: "bool", value: true, expected: nil},
+		{name: "null", value: nil, expected: nil},
+		{name: "list", value: []interface{}{"a1", true, float64(2.5)}, expected: []string{"a1", "2.5"}},
+		{name: "object", value: map[string]interface{}{"password": "hunter2"}, expected: []string{"hunter2"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := maskValues(tc.value); !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected masks %q but received %q", tc.expected, actual)
+			}
+		})
+	}
+}
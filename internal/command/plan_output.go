@@ -6,20 +6,32 @@ package command
 import (
 	"flag"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
 )
 
 type OutputPlanCommand struct {
 	*Meta
 
-	PlanID string
+	PlanID                 string
+	SavePlan               string
+	IncludeResourceChanges bool
+	IncludeOutputs         bool
+	Names                  []string
+	IncludeSensitive       bool
 }
 
 func (c *OutputPlanCommand) flags() *flag.FlagSet {
 	f := c.flagSet("plan output")
 	f.StringVar(&c.PlanID, "plan", "", "The plan ID to retrieve JSON execution plan.")
+	f.StringVar(&c.SavePlan, "save-plan", "", "Path to write the JSON execution plan to, waiting for the plan to finish.")
+	f.BoolVar(&c.IncludeResourceChanges, "include-resource-changes", false, "Adds the resource_changes_payload output, a JSON array of the address, action and resource type of each changed resource.")
+	f.BoolVar(&c.IncludeOutputs, "include-outputs", false, "Adds the planned_outputs output, a JSON array of the name and planned value of each root module output.")
+	f.Var((*flagStringSlice)(&c.Names), "name", "Name of a planned output to return, implies -include-outputs. You can use this option multiple times.")
+	f.BoolVar(&c.IncludeSensitive, "include-sensitive", false, "Includes sensitive planned outputs, each sensitive value is masked in GitHub Actions and Azure Pipelines logs.")
 
 	return f
 }
@@ -28,6 +40,16 @@ func (c *OutputPlanCommand) Run(args []string) int {
 	if err := c.setupCmd(args, c.flags()); err != nil {
 		return 1
 	}
+	if err := c.checkOutputFiles(c.SavePlan); err != nil {
+		return 1
+	}
+
+	if c.IncludeSensitive && !c.IncludeOutputs && len(c.Names) == 0 {
+		c.addOutput("status", string(Error))
+		c.closeOutput()
+		c.writer.ErrorResult("-include-sensitive requires -include-outputs or -name")
+		return 1
+	}
 
 	plan, pErr := c.cloud.GetPlan(c.appCtx, c.PlanID)
 	if pErr != nil {
@@ -38,12 +60,140 @@ func (c *OutputPlanCommand) Run(args []string) int {
 		return 1
 	}
 
+	if c.SavePlan != "" {
+		if err := c.savePlanJSON(); err != nil {
+			status := c.resolveStatus(err)
+			c.addOutput("status", string(status))
+			c.addPlanDetails(plan)
+			c.errorResult(status, fmt.Sprintf("error saving JSON execution plan: %s\n", err.Error()))
+			c.writer.OutputResult(c.closeOutput())
+			return c.exitCode(status)
+		}
+	}
+
+	if c.IncludeOutputs || len(c.Names) > 0 {
+		if err := c.addPlannedOutputs(); err != nil {
+			status := c.resolveStatus(err)
+			c.addOutput("status", string(status))
+			c.addPlanDetails(plan)
+			c.errorResult(status, fmt.Sprintf("error reading planned outputs: %s\n", err.Error()))
+			c.writer.OutputResult(c.closeOutput())
+			return c.exitCode(status)
+		}
+	}
+
 	c.addOutput("status", string(Success))
 	c.addPlanDetails(plan)
+	if c.IncludeResourceChanges {
+		c.addResourceChanges(c.PlanID)
+	}
 	c.writer.OutputResult(c.closeOutput())
 	return 0
 }
 
+// downloads the JSON execution plan to -save-plan, the plan's human readable outputs are unchanged
+func (c *OutputPlanCommand) savePlanJSON() error {
+	planJSON, err := c.cloud.ReadPlanJSON(c.appCtx, c.PlanID)
+	if err != nil {
+		return err
+	}
+	if err := writePrivateFile(c.SavePlan, planJSON); err != nil {
+		c.addOutput("error_code", "io_error")
+		return err
+	}
+	c.writer.Output(fmt.Sprintf("Saved JSON execution plan to: %s", c.SavePlan))
+	c.addOutput("plan_json_path", c.SavePlan)
+	return nil
+}
+
+// adds the selected planned outputs from the JSON execution plan, unlike resource changes a plan that cannot be
+// read fails the command as the outputs were explicitly requested. The sensitive values are only read from the
+// unredacted plan when -include-sensitive is set
+func (c *OutputPlanCommand) addPlannedOutputs() error {
+	readPlanJSON := c.cloud.ReadRedactedPlanJSON
+	if c.IncludeSensitive {
+		readPlanJSON = c.cloud.ReadPlanJSON
+	}
+	planJSON, err := readPlanJSON(c.appCtx, c.PlanID)
+	if err != nil {
+		return err
+	}
+	outputs, err := cloud.ParsePlannedOutputs(planJSON)
+	if err != nil {
+		return err
+	}
+	selected, err := c.selectOutputs(outputs)
+	if err != nil {
+		return err
+	}
+
+	sensitive := false
+	masks := []string{}
+	for _, o := range selected {
+		if o.Sensitive {
+			sensitive = true
+			masks = append(masks, maskValues(o.Value)...)
+		}
+	}
+	c.addOutputWithOpts("planned_outputs", selected, &outputOpts{
+		stdOut:      true,
+		multiLine:   true,
+		platformOut: true,
+		sensitive:   sensitive,
+		masks:       masks,
+	})
+	return nil
+}
+
+// filters the planned outputs to the requested -name options, in the requested order, and omits sensitive outputs
+// unless -include-sensitive is set, like `workspace output`
+func (c *OutputPlanCommand) selectOutputs(outputs []*cloud.PlannedOutput) ([]*cloud.PlannedOutput, error) {
+	if len(c.Names) == 0 {
+		selected := []*cloud.PlannedOutput{}
+		omitted := 0
+		for _, o := range outputs {
+			if o.Sensitive && !c.IncludeSensitive {
+				omitted++
+				continue
+			}
+			selected = append(selected, o)
+		}
+		if omitted > 0 {
+			c.writer.Output(fmt.Sprintf("Omitting %d sensitive planned outputs, use -include-sensitive to include them", omitted))
+		}
+		return selected, nil
+	}
+
+	byName := make(map[string]*cloud.PlannedOutput, len(outputs))
+	available := make([]string, 0, len(outputs))
+	for _, o := range outputs {
+		byName[o.Name] = o
+		available = append(available, o.Name)
+	}
+	sort.Strings(available)
+
+	selected := []*cloud.PlannedOutput{}
+	missing := []string{}
+	for _, name := range c.Names {
+		o, ok := byName[name]
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		if o.Sensitive && !c.IncludeSensitive {
+			return nil, fmt.Errorf("planned output %q is sensitive, use -include-sensitive to include it", name)
+		}
+		selected = append(selected, o)
+	}
+	if len(missing) > 0 {
+		if len(available) == 0 {
+			return nil, fmt.Errorf("outputs not found in plan: %s. The plan has no outputs", strings.Join(missing, ", "))
+		}
+		return nil, fmt.Errorf("outputs not found in plan: %s. Available outputs: %s", strings.Join(missing, ", "), strings.Join(available, ", "))
+	}
+	return selected, nil
+}
+
 func (c *OutputPlanCommand) addPlanDetails(plan *tfe.Plan) {
 	if plan == nil {
 		return
@@ -58,6 +208,7 @@ func (c *OutputPlanCommand) addPlanDetails(plan *tfe.Plan) {
 		stdOut:      false,
 		multiLine:   true,
 		platformOut: true,
+		fields:      c.payloadFields,
 	})
 }
 
@@ -73,11 +224,23 @@ Global Options:
 
 	-token          The token used to authenticate with HCP Terraform. Defaults to reading "TF_API_TOKEN" environment variable.
 
-	-organization   HCP Terraform Organization Name.
+	-organization   HCP Terraform Organization Name. Also accepted as a command option, which overrides the global option for that command.
 
 Options:
 
 	-plan           Returns the plan details for the provided Plan ID.
+
+	-save-plan      Path to write the JSON execution plan to, the "terraform show -json" format. Waits for the plan to finish, and fails if the plan errored or was canceled.
+
+	-include-resource-changes Adds the resource_changes_payload output, a JSON array of {address, action, resource_type} for each resource the plan creates, updates, deletes or replaces. Empty when the plan has no changes. Waits for the plan to finish.
+
+	-include-outputs Adds the planned_outputs output, a JSON array of {name, value} for each root module output of the plan, sorted by name. "unknown" is true when the value is only known after apply. Waits for the plan to finish.
+
+	-name           Name of a planned output to return, implies -include-outputs. This option accepts multiple instances by providing additional name option flags. Fails listing the available outputs when a name is not in the plan.
+
+	-include-sensitive Includes sensitive planned outputs, each sensitive value is masked in GitHub Actions and Azure Pipelines logs. Sensitive outputs are omitted by default.
+
+	-payload-fields Comma separated list of top-level fields to include in the payload output, e.g. id,status,created-at. Defaults to all fields.
 	`
 	return strings.TrimSpace(helpText)
 }
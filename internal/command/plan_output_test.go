@@ -0,0 +1,220 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+	"github.com/hashicorp/tfci/internal/environment"
+	"github.com/hashicorp/tfci/internal/writer"
+	"github.com/mitchellh/cli"
+)
+
+type PlanReader struct {
+	plan     *tfe.Plan
+	planJSON []byte
+	// defaults to planJSON
+	redactedPlanJSON []byte
+}
+
+func (p *PlanReader) GetPlan(_ context.Context, _ string) (*tfe.Plan, error) {
+	return p.plan, nil
+}
+
+func (p *PlanReader) ReadPlanJSON(_ context.Context, _ string) ([]byte, error) {
+	return p.planJSON, nil
+}
+
+func (p *PlanReader) ReadRedactedPlanJSON(_ context.Context, _ string) ([]byte, error) {
+	if p.redactedPlanJSON != nil {
+		return p.redactedPlanJSON, nil
+	}
+	return p.planJSON, nil
+}
+
+func TestOutputPlanCommand_SavePlan(t *testing.T) {
+	planJSON := `{"format_version":"1.2","resource_changes":[]}`
+	testCases := []struct {
+		name         string
+		path         func(dir string) string
+		expectedCode int
+	}{
+		{
+			name:         "saved",
+			path:         func(dir string) string { return filepath.Join(dir, "plan.json") },
+			expectedCode: 0,
+		},
+		{
+			name:         "unwritable-path",
+			path:         func(dir string) string { return filepath.Join(dir, "missing", "plan.json") },
+			expectedCode: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			writer := writer.NewWriter(ui)
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, writer)
+			cloudMockService.PlanService = &PlanReader{
+				plan:     &tfe.Plan{ID: "plan-***", Status: tfe.PlanFinished},
+				planJSON: []byte(planJSON),
+			}
+			meta := NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(writer), WithOrg("hashicorp"))
+			cmd := &OutputPlanCommand{Meta: meta}
+
+			path := tc.path(t.TempDir())
+			if code := cmd.Run([]string{"-plan=plan-***", "-save-plan=" + path}); code != tc.expectedCode {
+				t.Fatalf("expected %d but received %d: %s", tc.expectedCode, code, ui.ErrorWriter.String())
+			}
+
+			if tc.expectedCode != 0 {
+				if errorCode := outputValue(meta, "error_code"); errorCode != "io_error" {
+					t.Errorf("expected error_code %q but received %q", "io_error", errorCode)
+				}
+				return
+			}
+
+			if actual := outputValue(meta, "plan_json_path"); actual != path {
+				t.Errorf("expected plan_json_path %q but received %q", path, actual)
+			}
+			saved, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(saved) != planJSON {
+				t.Errorf("expected saved plan %s but received %s", planJSON, saved)
+			}
+		})
+	}
+}
+
+func TestOutputPlanCommand_IncludeResourceChanges(t *testing.T) {
+	testCases := []struct {
+		name     string
+		args     []string
+		planJSON string
+		expected string
+	}{
+		{
+			name:     "changes",
+			args:     []string{"-plan=plan-***", "-include-resource-changes"},
+			planJSON: `{"resource_changes":[{"address":"aws_instance.web","type":"aws_instance","change":{"actions":["delete","create"]}}]}`,
+			expected: `[{"address":"aws_instance.web","action":"replace","resource_type":"aws_instance"}]`,
+		},
+		{
+			name:     "no-changes",
+			args:     []string{"-plan=plan-***", "-include-resource-changes"},
+			planJSON: `{"resource_changes":[{"address":"aws_instance.web","type":"aws_instance","change":{"actions":["no-op"]}}]}`,
+			expected: `[]`,
+		},
+		{
+			name:     "not-included",
+			args:     []string{"-plan=plan-***"},
+			planJSON: `{"resource_changes":[]}`,
+			expected: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			writer := writer.NewWriter(ui)
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, writer)
+			cloudMockService.PlanService = &PlanReader{
+				plan:     &tfe.Plan{ID: "plan-***", Status: tfe.PlanFinished},
+				planJSON: []byte(tc.planJSON),
+			}
+			meta := NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(writer), WithOrg("hashicorp"))
+
+			if code := (&OutputPlanCommand{Meta: meta}).Run(tc.args); code != 0 {
+				t.Fatalf("expected 0 but received %d: %s", code, ui.ErrorWriter.String())
+			}
+			if actual := outputValue(meta, "resource_changes_payload"); actual != tc.expected {
+				t.Errorf("expected resource_changes_payload %s but received %s", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestOutputPlanCommand_PlannedOutputs(t *testing.T) {
+	planJSON := `{"output_changes":{
+		"vpc_id":{"actions":["create"],"after":"vpc-123","after_unknown":false,"after_sensitive":false},
+		"endpoint":{"actions":["create"],"after_unknown":true,"after_sensitive":false},
+		"db_password":{"actions":["create"],"after":"secret","after_unknown":false,"after_sensitive":true}
+	}}`
+	// sensitive values are only read from the unredacted plan
+	redactedPlanJSON := strings.Replace(planJSON, `"after":"secret",`, "", 1)
+	testCases := []struct {
+		name          string
+		args          []string
+		expectedCode  int
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "all",
+			args:     []string{"-plan=plan-***", "-include-outputs"},
+			expected: `[{"name":"endpoint","value":null,"unknown":true},{"name":"vpc_id","value":"vpc-123"}]`,
+		},
+		{
+			name:     "named",
+			args:     []string{"-plan=plan-***", "-name=vpc_id"},
+			expected: `[{"name":"vpc_id","value":"vpc-123"}]`,
+		},
+		{
+			name:     "named-sensitive",
+			args:     []string{"-plan=plan-***", "-name=db_password", "-include-sensitive"},
+			expected: `[{"name":"db_password","value":"secret"}]`,
+		},
+		{
+			name:          "sensitive-not-included",
+			args:          []string{"-plan=plan-***", "-name=db_password"},
+			expectedCode:  1,
+			expectedError: `error reading planned outputs: planned output "db_password" is sensitive, use -include-sensitive to include it`,
+		},
+		{
+			name:          "missing",
+			args:          []string{"-plan=plan-***", "-name=vpc_id", "-name=subnet_id"},
+			expectedCode:  1,
+			expectedError: "error reading planned outputs: outputs not found in plan: subnet_id. Available outputs: db_password, endpoint, vpc_id",
+		},
+		{
+			name:          "include-sensitive-alone",
+			args:          []string{"-plan=plan-***", "-include-sensitive"},
+			expectedCode:  1,
+			expectedError: "-include-sensitive requires -include-outputs or -name",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			writer := writer.NewWriter(ui)
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, writer)
+			cloudMockService.PlanService = &PlanReader{
+				plan:             &tfe.Plan{ID: "plan-***", Status: tfe.PlanFinished},
+				planJSON:         []byte(planJSON),
+				redactedPlanJSON: []byte(redactedPlanJSON),
+			}
+			meta := NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(writer), WithOrg("hashicorp"))
+
+			if code := (&OutputPlanCommand{Meta: meta}).Run(tc.args); code != tc.expectedCode {
+				t.Fatalf("expected %d but received %d: %s", tc.expectedCode, code, ui.ErrorWriter.String())
+			}
+			if actual := outputValue(meta, "planned_outputs"); actual != tc.expected {
+				t.Errorf("expected planned_outputs %s but received %s", tc.expected, actual)
+			}
+			if tc.expectedError != "" && !strings.Contains(ui.ErrorWriter.String(), tc.expectedError) {
+				t.Errorf("expected error %q but received %q", tc.expectedError, ui.ErrorWriter.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+)
+
+// adds the run's policy check outputs when policies apply to the run. Returns an error when a
+// mandatory policy hard failed, or when the policy results could not be read, so the command
+// fails even if the run itself was not errored.
+func (c *Meta) addPolicyResults(run *tfe.Run) error {
+	if run == nil {
+		return nil
+	}
+
+	results, err := c.cloud.GetPolicyResults(c.appCtx, run)
+	if err != nil {
+		return fmt.Errorf("failed to read policy results for run %q: %w", run.ID, err)
+	}
+	if results == nil || len(results.Outcomes) == 0 {
+		return nil
+	}
+
+	c.addOutput("policy_check_status", results.Status)
+	c.addOutput("policy_soft_failed", fmt.Sprint(results.SoftFailed))
+	c.addOutput("policy_advisory_failed", fmt.Sprint(results.AdvisoryFailed))
+	c.addOutputWithOpts("policy_payload", results.Outcomes, &outputOpts{
+		stdOut:      true,
+		multiLine:   true,
+		platformOut: true,
+	})
+
+	if results.Status == cloud.PolicyStatusHardFailed {
+		c.addOutput("error_code", "policy_hard_failed")
+		return fmt.Errorf("a mandatory policy failed for run %q", run.ID)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+	"github.com/hashicorp/tfci/internal/environment"
+	"github.com/hashicorp/tfci/internal/writer"
+	"github.com/mitchellh/cli"
+)
+
+func TestShowRunCommand_PolicyResults(t *testing.T) {
+	testCases := []struct {
+		name       string
+		policies   *cloud.PolicyResults
+		err        error
+		exitStatus int
+		expected   map[string]string
+	}{
+		{
+			name:     "no-policies",
+			policies: &cloud.PolicyResults{Outcomes: []*cloud.PolicyOutcome{}},
+			expected: map[string]string{
+				"status":              "Success",
+				"policy_check_status": "",
+			},
+		},
+		{
+			name: "soft-failed",
+			policies: &cloud.PolicyResults{
+				Status:     cloud.PolicyStatusSoftFailed,
+				SoftFailed: true,
+				Outcomes: []*cloud.PolicyOutcome{
+					{Kind: "opa", ID: "poleval-1", Policy: "tags", EnforcementLevel: "mandatory", Status: cloud.PolicyStatusSoftFailed},
+				},
+			},
+			expected: map[string]string{
+				"status":              "Success",
+				"policy_check_status": "soft_failed",
+				"policy_soft_failed":  "true",
+				"policy_payload":      `[{"kind":"opa","id":"poleval-1","policy":"tags","enforcement_level":"mandatory","status":"soft_failed"}]`,
+			},
+		},
+		{
+			name: "hard-failed",
+			policies: &cloud.PolicyResults{
+				Status:         cloud.PolicyStatusHardFailed,
+				AdvisoryFailed: 1,
+				Outcomes: []*cloud.PolicyOutcome{
+					{Kind: "sentinel", ID: "polchk-1", Status: cloud.PolicyStatusHardFailed, Counts: &cloud.PolicyOutcomeCounts{AdvisoryFailed: 1, HardFailed: 1}},
+				},
+			},
+			exitStatus: 1,
+			expected: map[string]string{
+				"status":                 "Error",
+				"error_code":             "policy_hard_failed",
+				"policy_check_status":    "hard_failed",
+				"policy_soft_failed":     "false",
+				"policy_advisory_failed": "1",
+			},
+		},
+		{
+			name:       "read-failed",
+			err:        errors.New("unauthorized"),
+			exitStatus: 1,
+			expected: map[string]string{
+				"status":              "Error",
+				"policy_check_status": "",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			writer := writer.NewWriter(ui)
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, writer)
+			cloudMockService.RunService = &RunReader{
+				run: &tfe.Run{
+					ID:                   "run-123",
+					Status:               tfe.RunPolicySoftFailed,
+					Plan:                 &tfe.Plan{},
+					ConfigurationVersion: &tfe.ConfigurationVersion{},
+				},
+				policies:    tc.policies,
+				policiesErr: tc.err,
+			}
+			cmd := &ShowRunCommand{Meta: NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(writer), WithOrg("hashicorp"))}
+
+			if code := cmd.Run([]string{"-run=run-123"}); code != tc.exitStatus {
+				t.Fatalf("expected %d but received %d", tc.exitStatus, code)
+			}
+			for name, expected := range tc.expected {
+				if actual := outputValue(cmd.Meta, name); actual != expected {
+					t.Errorf("expected %s %q but received %q", name, expected, actual)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"github.com/hashicorp/tfci/internal/cloud"
+	"github.com/hashicorp/tfci/internal/logging"
+)
+
+// adds the resources changed by the plan as a JSON array to the resource_changes_payload output, eg. for a
+// reviewer friendly summary of the changes. The output is omitted when the JSON execution plan cannot be read.
+func (c *Meta) addResourceChanges(planID string) {
+	if planID == "" {
+		return
+	}
+
+	planJSON, err := c.cloud.ReadPlanJSON(c.appCtx, planID)
+	if err != nil {
+		if !isDryRun(err) {
+			logging.Warn("Failed to read JSON execution plan for resource changes", "plan_id", planID, "error", err)
+		}
+		return
+	}
+	changes, err := cloud.ParseResourceChanges(planJSON)
+	if err != nil {
+		logging.Warn("Failed to parse resource changes", "plan_id", planID, "error", err)
+		return
+	}
+
+	c.addOutputWithOpts("resource_changes_payload", changes, &outputOpts{
+		stdOut:      false,
+		multiLine:   true,
+		platformOut: true,
+	})
+}
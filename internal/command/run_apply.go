@@ -6,6 +6,7 @@ package command
 import (
 	"flag"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/hashicorp/go-tfe"
@@ -15,14 +16,16 @@ import (
 type ApplyRunCommand struct {
 	*Meta
 
-	RunID   string
-	Comment string
+	RunID               string
+	Comment             string
+	MaxMonthlyCostDelta string
 }
 
 func (c *ApplyRunCommand) flags() *flag.FlagSet {
 	f := c.flagSet("run apply")
 	f.StringVar(&c.RunID, "run", "", "Existing HCP Terraform Run ID to Apply.")
 	f.StringVar(&c.Comment, "comment", "", "An optional comment about the run.")
+	f.StringVar(&c.MaxMonthlyCostDelta, "max-monthly-cost-delta", "", "Refuses to apply the run if the cost estimate's proposed monthly cost delta exceeds this amount.")
 
 	return f
 }
@@ -51,11 +54,22 @@ func (c *ApplyRunCommand) Run(args []string) int {
 		return 1
 	}
 
+	// speculative runs never apply, even though they finish like a run without changes
+	if run.PlanOnly {
+		c.addOutput("status", string(Error))
+		c.addOutput("error_code", "plan_only")
+		c.addRunDetails(run)
+		c.writer.ErrorResult(fmt.Sprintf("run %s is a plan-only run and cannot be applied, create a run without -plan-only to apply changes", c.RunID))
+		c.writer.OutputResult(c.closeOutput())
+		return 1
+	}
+
 	// check if run can be applied at this moment
 	if !run.Actions.IsConfirmable {
 		if run.Status == tfe.RunPlannedAndFinished {
 			c.addOutput("status", string(Noop))
 			c.addRunDetails(run)
+			c.addConfigurationPromotion(run)
 			c.writer.ErrorResult(fmt.Sprintf("run %s, is planned and finished. There is nothing to do.", c.RunID))
 			c.writer.OutputResult(c.closeOutput())
 			return 0
@@ -67,6 +81,11 @@ func (c *ApplyRunCommand) Run(args []string) int {
 		return 1
 	}
 
+	// check if run exceeds the allowed cost threshold
+	if c.MaxMonthlyCostDelta != "" && c.exceedsCostThreshold(run) {
+		return 1
+	}
+
 	latestRun, applyError := c.cloud.ApplyRun(c.appCtx, cloud.ApplyRunOptions{
 		RunID:   c.RunID,
 		Comment: c.Comment,
@@ -80,27 +99,65 @@ func (c *ApplyRunCommand) Run(args []string) int {
 		status := c.resolveStatus(applyError)
 		c.addOutput("status", string(status))
 		c.addRunDetails(run)
-		c.writer.ErrorResult(fmt.Sprintf("error applying run, '%s' in HCP Terraform: %s", c.RunID, applyError.Error()))
+		c.addConfigurationPromotion(run)
+		c.errorResult(status, fmt.Sprintf("error applying run, '%s' in HCP Terraform: %s", c.RunID, applyError.Error()))
 		c.writer.OutputResult(c.closeOutput())
-		return 1
+		return c.exitCode(status)
 	}
 
 	c.addOutput("status", string(Success))
 	c.addRunDetails(run)
+	c.addConfigurationPromotion(run)
 	c.writer.OutputResult(c.closeOutput())
 	return 0
 }
 
+// compares the run's cost estimate against the max monthly cost delta, returns true and writes outputs if the run cannot be applied
+func (c *ApplyRunCommand) exceedsCostThreshold(run *tfe.Run) bool {
+	maxDelta, parseErr := strconv.ParseFloat(c.MaxMonthlyCostDelta, 64)
+	if parseErr != nil {
+		c.addOutput("status", string(Error))
+		c.addRunDetails(run)
+		c.writer.ErrorResult(fmt.Sprintf("invalid max monthly cost delta: %q", c.MaxMonthlyCostDelta))
+		c.writer.OutputResult(c.closeOutput())
+		return true
+	}
+
+	if run.CostEstimate == nil || run.CostEstimate.Status != tfe.CostEstimateFinished {
+		c.writer.Output(fmt.Sprintf("Warning: cost estimation has not finished for run %s, skipping cost threshold check", c.RunID))
+		return false
+	}
+
+	delta, deltaErr := strconv.ParseFloat(run.CostEstimate.DeltaMonthlyCost, 64)
+	if deltaErr != nil {
+		c.addOutput("status", string(Error))
+		c.addRunDetails(run)
+		c.writer.ErrorResult(fmt.Sprintf("unable to read cost estimate delta: %q for run %s", run.CostEstimate.DeltaMonthlyCost, c.RunID))
+		c.writer.OutputResult(c.closeOutput())
+		return true
+	}
+
+	c.addOutput("cost_estimation_delta_monthly_cost", run.CostEstimate.DeltaMonthlyCost)
+	if delta <= maxDelta {
+		return false
+	}
+
+	c.addOutput("status", string(Error))
+	c.addOutput("error_code", "cost_exceeded")
+	c.addRunDetails(run)
+	c.writer.ErrorResult(fmt.Sprintf("run %s, proposed monthly cost delta (%s) exceeds the maximum allowed (%s)", c.RunID, run.CostEstimate.DeltaMonthlyCost, c.MaxMonthlyCostDelta))
+	c.writer.OutputResult(c.closeOutput())
+	return true
+}
+
 func (c *ApplyRunCommand) addRunDetails(run *tfe.Run) {
 	if run == nil {
 		return
 	}
-	link, _ := c.cloud.RunLink(c.appCtx, c.organization, run)
-	if link != "" {
-		c.addOutput("run_link", link)
-	}
+	c.addRunLink(run)
 	c.addOutput("run_id", run.ID)
 	c.addOutput("run_status", string(run.Status))
+	c.addRunPayload(run)
 }
 
 func (c *ApplyRunCommand) readApplyLogs(run *tfe.Run) {
@@ -116,7 +173,7 @@ func (c *ApplyRunCommand) Help() string {
 	helpText := `
 Usage: tfci [global options] run apply [options]
 
-	Applies a run that is paused waiting for confirmation after a plan.
+	Applies a run that is paused waiting for confirmation after a plan. Plan-only runs cannot be applied.
 
 Global Options:
 
@@ -124,13 +181,15 @@ Global Options:
 
 	-token          The token used to authenticate with HCP Terraform. Defaults to reading "TF_API_TOKEN" environment variable.
 
-	-organization   HCP Terraform Organization Name.
+	-organization   HCP Terraform Organization Name. Also accepted as a command option, which overrides the global option for that command.
 
 Options:
 
 	-run         Existing HCP Terraform Run ID to Apply.
 
 	-comment     An optional comment about the run.
+
+	-max-monthly-cost-delta    Refuses to apply the run if the cost estimate's proposed monthly cost delta exceeds this amount.
 	`
 	return strings.TrimSpace(helpText)
 }
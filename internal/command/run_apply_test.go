@@ -0,0 +1,199 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+	"github.com/hashicorp/tfci/internal/environment"
+	"github.com/hashicorp/tfci/internal/writer"
+	"github.com/mitchellh/cli"
+)
+
+// satisfies cloud.RunService, only overriding the methods used by the command under test
+type RunReader struct {
+	cloud.RunService
+
+	run      *tfe.Run
+	applied  bool
+	created  *cloud.CreateRunOptions
+	policies *cloud.PolicyResults
+	// error returned by GetPolicyResults
+	policiesErr error
+	queue       *cloud.RunQueue
+	// run created by a previous attempt, returned by FindDuplicateRun
+	duplicate *tfe.Run
+	waited    bool
+}
+
+func (r *RunReader) RunLink(_ context.Context, _ string, _ *tfe.Run) (string, error) {
+	return "", nil
+}
+
+func (r *RunReader) GetPolicyResults(_ context.Context, _ *tfe.Run) (*cloud.PolicyResults, error) {
+	return r.policies, r.policiesErr
+}
+
+func (r *RunReader) GetRun(_ context.Context, _ cloud.GetRunOptions) (*tfe.Run, error) {
+	return r.run, nil
+}
+
+func (r *RunReader) CreateRun(_ context.Context, options cloud.CreateRunOptions) (*tfe.Run, error) {
+	r.created = &options
+	return r.run, nil
+}
+
+func (r *RunReader) FindDuplicateRun(_ context.Context, _ cloud.CreateRunOptions) (*tfe.Run, error) {
+	return r.duplicate, nil
+}
+
+func (r *RunReader) WaitRun(_ context.Context, _ cloud.WaitRunOptions) (*tfe.Run, error) {
+	r.waited = true
+	return r.run, nil
+}
+
+func (r *RunReader) GetRunQueue(_ context.Context, _ string, _ string) (*cloud.RunQueue, error) {
+	if r.queue == nil {
+		return &cloud.RunQueue{}, nil
+	}
+	return r.queue, nil
+}
+
+func (r *RunReader) ApplyRun(_ context.Context, _ cloud.ApplyRunOptions) (*tfe.Run, error) {
+	r.applied = true
+	return nil, nil
+}
+
+func testApplyRunCommand(t *testing.T, run *tfe.Run) (*cli.MockUi, *RunReader, *ApplyRunCommand) {
+	t.Helper()
+
+	ui := cli.NewMockUi()
+	writer := writer.NewWriter(ui)
+	cloudMockService := cloud.NewCloud(&tfe.Client{}, writer)
+	runService := &RunReader{run: run}
+	cloudMockService.RunService = runService
+	cloudMockService.ConfigVersionService = &SuccessfulUploader{}
+
+	meta := NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(writer), WithOrg("hashicorp"))
+
+	return ui, runService, &ApplyRunCommand{Meta: meta}
+}
+
+func TestApplyRunCommand_MaxMonthlyCostDelta(t *testing.T) {
+	testCases := []struct {
+		name         string
+		args         []string
+		costEstimate *tfe.CostEstimate
+		exitStatus   int
+		applied      bool
+		errorCode    string
+	}{
+		{
+			name: "within-threshold",
+			args: []string{"-run=run-***", "-max-monthly-cost-delta=100"},
+			costEstimate: &tfe.CostEstimate{
+				Status:           tfe.CostEstimateFinished,
+				DeltaMonthlyCost: "99.99",
+			},
+			exitStatus: 0,
+			applied:    true,
+		},
+		{
+			name: "exceeds-threshold",
+			args: []string{"-run=run-***", "-max-monthly-cost-delta=100"},
+			costEstimate: &tfe.CostEstimate{
+				Status:           tfe.CostEstimateFinished,
+				DeltaMonthlyCost: "100.01",
+			},
+			exitStatus: 1,
+			applied:    false,
+			errorCode:  "cost_exceeded",
+		},
+		{
+			name:         "cost-estimation-disabled",
+			args:         []string{"-run=run-***", "-max-monthly-cost-delta=100"},
+			costEstimate: nil,
+			exitStatus:   0,
+			applied:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui, runService, cmd := testApplyRunCommand(t, &tfe.Run{
+				ID:           "run-***",
+				Status:       tfe.RunCostEstimated,
+				Actions:      &tfe.RunActions{IsConfirmable: true},
+				CostEstimate: tc.costEstimate,
+			})
+
+			if actual := cmd.Run(tc.args); actual != tc.exitStatus {
+				t.Fatalf("expected %d but received %d", tc.exitStatus, actual)
+			}
+
+			if runService.applied != tc.applied {
+				t.Errorf("expected run applied: %t, but was: %t", tc.applied, runService.applied)
+			}
+
+			outputs := map[string]string{}
+			json.Unmarshal([]byte(ui.OutputWriter.String()), &outputs)
+			if outputs["error_code"] != tc.errorCode {
+				t.Errorf("expected error_code %q but received %q", tc.errorCode, outputs["error_code"])
+			}
+		})
+	}
+}
+
+func TestApplyRunCommand_PlanOnly(t *testing.T) {
+	_, runService, cmd := testApplyRunCommand(t, &tfe.Run{
+		ID:       "run-***",
+		Status:   tfe.RunPlannedAndFinished,
+		PlanOnly: true,
+		Actions:  &tfe.RunActions{},
+	})
+
+	if actual := cmd.Run([]string{"-run=run-***"}); actual != 1 {
+		t.Fatalf("expected %d but received %d", 1, actual)
+	}
+	if runService.applied {
+		t.Error("expected a plan-only run to never be applied")
+	}
+	if errorCode := outputValue(cmd.Meta, "error_code"); errorCode != "plan_only" {
+		t.Errorf("expected error_code %q but received %q", "plan_only", errorCode)
+	}
+}
+
+func TestApplyRunCommand_ConfigurationPromotion(t *testing.T) {
+	_, runService, cmd := testApplyRunCommand(t, &tfe.Run{
+		ID:                   "run-***",
+		Status:               tfe.RunPlanned,
+		Actions:              &tfe.RunActions{IsConfirmable: true},
+		ConfigurationVersion: &tfe.ConfigurationVersion{ID: "cv-new"},
+	})
+	cmd.cloud.ConfigVersionService = &SuccessfulUploader{promotion: &cloud.ConfigurationPromotion{
+		ConfigurationVersion:          &tfe.ConfigurationVersion{ID: "cv-new"},
+		CurrentConfigurationVersionID: "cv-old",
+		Reason:                        "run run-*** did not apply, its status is planned",
+	}}
+
+	if actual := cmd.Run([]string{"-run=run-***"}); actual != 0 {
+		t.Fatalf("expected %d but received %d", 0, actual)
+	}
+	if !runService.applied {
+		t.Fatal("expected the run to be applied")
+	}
+	if promoted := outputValue(cmd.Meta, "configuration_promoted"); promoted != "false" {
+		t.Errorf("expected configuration_promoted %q but received %q", "false", promoted)
+	}
+	if current := outputValue(cmd.Meta, "workspace_configuration_version_id"); current != "cv-old" {
+		t.Errorf("expected workspace_configuration_version_id %q but received %q", "cv-old", current)
+	}
+	if reason := outputValue(cmd.Meta, "configuration_promotion_reason"); reason == "" {
+		t.Error("expected a configuration_promotion_reason when the configuration version was not promoted")
+	}
+}
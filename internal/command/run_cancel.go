@@ -7,6 +7,7 @@ import (
 	"flag"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/go-tfe"
 	"github.com/hashicorp/tfci/internal/cloud"
@@ -24,7 +25,9 @@ func (c *CancelRunCommand) flags() *flag.FlagSet {
 	f := c.flagSet("run cancel")
 	f.StringVar(&c.RunID, "run", "", "Existing HCP Terraform Run ID to Discard.")
 	f.StringVar(&c.Comment, "comment", "", "An optional comment about the run.")
-	f.BoolVar(&c.ForceCancel, "force-cancel", false, "Ends the run immediately.")
+	f.BoolVar(&c.ForceCancel, "force", false, "Force cancels a run still running after a normal cancel, ending it immediately. A normal cancel must be requested first.")
+	// kept for compatibility, same as -force
+	f.BoolVar(&c.ForceCancel, "force-cancel", false, "Same as -force.")
 
 	return f
 }
@@ -55,7 +58,7 @@ func (c *CancelRunCommand) Run(args []string) int {
 	if c.ForceCancel && !run.Actions.IsForceCancelable {
 		c.addOutput("status", string(Error))
 		c.addRunDetails(run)
-		c.writer.ErrorResult(fmt.Sprintf("run %s, cannot be force-cancelled", c.RunID))
+		c.writer.ErrorResult(forceCancelUnavailable(run))
 		c.writer.OutputResult(c.closeOutput())
 		return 1
 	}
@@ -82,9 +85,9 @@ func (c *CancelRunCommand) Run(args []string) int {
 		status := c.resolveStatus(cancelErr)
 		c.addOutput("status", string(status))
 		c.addRunDetails(run)
-		c.writer.ErrorResult(fmt.Sprintf("error discarding run, '%s' in HCP Terraform: %s", c.RunID, cancelErr.Error()))
+		c.errorResult(status, fmt.Sprintf("error %s run, '%s' in HCP Terraform: %s", c.action(), c.RunID, cancelErr.Error()))
 		c.writer.OutputResult(c.closeOutput())
-		return 1
+		return c.exitCode(status)
 	}
 
 	c.addOutput("status", string(Success))
@@ -93,16 +96,34 @@ func (c *CancelRunCommand) Run(args []string) int {
 	return 0
 }
 
+func (c *CancelRunCommand) action() string {
+	if c.ForceCancel {
+		return "force canceling"
+	}
+	return "canceling"
+}
+
+// explains why the run cannot be force canceled, HCP Terraform only allows it once a normal cancel
+// was requested and the run has not stopped after a cool-off period
+func forceCancelUnavailable(run *tfe.Run) string {
+	switch {
+	case run.Status == tfe.RunCanceled:
+		return fmt.Sprintf("run %s cannot be force canceled, it has already been canceled", run.ID)
+	case run.ForceCancelAvailableAt.IsZero():
+		return fmt.Sprintf("run %s cannot be force canceled, a normal cancel must be requested first with `run cancel` without -force", run.ID)
+	default:
+		return fmt.Sprintf("run %s cannot be force canceled until %s, after the cool-off period following the normal cancel", run.ID, run.ForceCancelAvailableAt.UTC().Format(time.RFC3339))
+	}
+}
+
 func (c *CancelRunCommand) addRunDetails(run *tfe.Run) {
 	if run == nil {
 		return
 	}
-	link, _ := c.cloud.RunLink(c.appCtx, c.organization, run)
-	if link != "" {
-		c.addOutput("run_link", link)
-	}
+	c.addRunLink(run)
 	c.addOutput("run_id", run.ID)
 	c.addOutput("run_status", string(run.Status))
+	c.addRunPayload(run)
 }
 
 func (c *CancelRunCommand) Help() string {
@@ -117,7 +138,7 @@ Global Options:
 
 	-token          The token used to authenticate with HCP Terraform. Defaults to reading "TF_API_TOKEN" environment variable.
 
-	-organization   HCP Terraform Organization Name.
+	-organization   HCP Terraform Organization Name. Also accepted as a command option, which overrides the global option for that command.
 
 Options:
 
@@ -125,7 +146,7 @@ Options:
 
 	-comment        An optional comment about the run.
 
-	-force-cancel   Ends the run immediately.
+	-force          Force cancels a run still running after a normal cancel, ending it immediately. A normal cancel must be requested first, and HCP Terraform only allows force canceling after a cool-off period. Also accepted as -force-cancel.
 	`
 	return strings.TrimSpace(helpText)
 }
@@ -0,0 +1,100 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+	"github.com/hashicorp/tfci/internal/environment"
+	"github.com/hashicorp/tfci/internal/writer"
+	"github.com/mitchellh/cli"
+)
+
+type CancelRunService struct {
+	RunReader
+	canceled *cloud.CancelRunOptions
+}
+
+func (r *CancelRunService) CancelRun(_ context.Context, options cloud.CancelRunOptions) (*tfe.Run, error) {
+	r.canceled = &options
+	return &tfe.Run{ID: options.RunID, Status: tfe.RunCanceled}, nil
+}
+
+func TestCancelRunCommand(t *testing.T) {
+	testCases := []struct {
+		name        string
+		args        []string
+		run         *tfe.Run
+		exitStatus  int
+		expectForce bool
+		expectError string
+	}{
+		{
+			name: "cancel",
+			args: []string{"-run=run-123"},
+			run:  &tfe.Run{ID: "run-123", Status: tfe.RunPlanning, Actions: &tfe.RunActions{IsCancelable: true}},
+		},
+		{
+			name:        "force",
+			args:        []string{"-run=run-123", "-force"},
+			run:         &tfe.Run{ID: "run-123", Status: tfe.RunPlanning, Actions: &tfe.RunActions{IsForceCancelable: true}},
+			expectForce: true,
+		},
+		{
+			name:        "force-without-cancel",
+			args:        []string{"-run=run-123", "-force"},
+			run:         &tfe.Run{ID: "run-123", Status: tfe.RunPlanning, Actions: &tfe.RunActions{IsCancelable: true}},
+			exitStatus:  1,
+			expectError: "a normal cancel must be requested first",
+		},
+		{
+			name: "force-during-cool-off",
+			args: []string{"-run=run-123", "-force-cancel"},
+			run: &tfe.Run{
+				ID:                     "run-123",
+				Status:                 tfe.RunPlanning,
+				Actions:                &tfe.RunActions{},
+				ForceCancelAvailableAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+			},
+			exitStatus:  1,
+			expectError: "cannot be force canceled until 2026-01-02T03:04:05Z",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, w)
+			runService := &CancelRunService{RunReader: RunReader{run: tc.run}}
+			cloudMockService.RunService = runService
+			meta := NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))
+
+			if code := (&CancelRunCommand{Meta: meta}).Run(tc.args); code != tc.exitStatus {
+				t.Fatalf("expected %d but received %d: %s", tc.exitStatus, code, ui.ErrorWriter.String())
+			}
+			if tc.exitStatus != 0 {
+				if runService.canceled != nil {
+					t.Errorf("expected the run not to be canceled")
+				}
+				if output := ui.ErrorWriter.String(); !strings.Contains(output, tc.expectError) {
+					t.Errorf("expected error %q but received %q", tc.expectError, output)
+				}
+				return
+			}
+
+			if runService.canceled.ForceCancel != tc.expectForce {
+				t.Errorf("expected force cancel: %t but received %t", tc.expectForce, runService.canceled.ForceCancel)
+			}
+			if status := outputValue(meta, "run_status"); status != string(tfe.RunCanceled) {
+				t.Errorf("expected run_status %q but received %q", tfe.RunCanceled, status)
+			}
+		})
+	}
+}
@@ -4,9 +4,11 @@
 package command
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
 
 	"github.com/hashicorp/go-tfe"
@@ -17,14 +19,39 @@ type CreateRunCommand struct {
 	*Meta
 
 	Workspace              string
+	WorkspaceTags          string
 	ConfigurationVersionID string
 	Message                string
 	TargetAddrs            []string
-
-	PlanOnly   bool
-	IsDestroy  bool
-	SavePlan   bool
-	AsyncNoLog bool
+	ReplaceAddrs           []string
+	Variables              []string
+	VarFiles               []string
+	VarType                string
+	TerraformVersion       string
+	IdempotencyKey         string
+
+	PlanOnly         bool
+	IsDestroy        bool
+	SavePlan         bool
+	RefreshOnly      bool
+	AllowEmptyApply  bool
+	AsyncNoLog       bool
+	Wait             bool
+	FailOnDrift      bool
+	FailIfBusy       bool
+	DetailedExitCode bool
+	FailOnDestroy    bool
+	FailOnNoChanges  bool
+
+	IncludeResourceChanges bool
+	// unset unless -auto-apply is passed, so the workspace's setting applies
+	AutoApply flagOptionalBool
+	// unset unless -refresh is passed, so resources are refreshed by default
+	Refresh flagOptionalBool
+
+	RetryFailedRuns bool
+	MaxRunRetries   int
+	RetryPattern    string
 }
 
 // flagStringSlice is a flag.Value implementation which allows collecting
@@ -48,61 +75,466 @@ func (v *flagStringSlice) Set(raw string) error {
 	return nil
 }
 
+// flagOptionalBool is a boolean flag.Value which records whether the flag was
+// passed, so an omitted flag can defer to a default set elsewhere, such as
+// the workspace's auto-apply setting.
+type flagOptionalBool struct {
+	value bool
+	set   bool
+}
+
+var _ flag.Value = (*flagOptionalBool)(nil)
+
+func (v *flagOptionalBool) String() string {
+	if !v.set {
+		return ""
+	}
+	return strconv.FormatBool(v.value)
+}
+func (v *flagOptionalBool) Set(raw string) error {
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		return err
+	}
+	v.value, v.set = value, true
+	return nil
+}
+func (v *flagOptionalBool) IsBoolFlag() bool {
+	return true
+}
+
+// returns nil when the flag was not passed
+func (v *flagOptionalBool) Bool() *bool {
+	if !v.set {
+		return nil
+	}
+	return tfe.Bool(v.value)
+}
+
 func (c *CreateRunCommand) flags() *flag.FlagSet {
 	f := c.flagSet("run create")
 	f.StringVar(&c.Workspace, "workspace", "", "The name of the HCP Terraform Workspace.")
+	f.StringVar(&c.WorkspaceTags, "workspace-tags", "", "Comma-separated list of tags, creates a run in every workspace having all of the tags instead of a single -workspace.")
 	f.StringVar(&c.ConfigurationVersionID, "configuration_version", "", "The Configuration Version ID to use for this run.")
-	f.StringVar(&c.Message, "message", "", "Specifies the message to be associated with this run. A default message will be set.")
+	// spelled like `upload -configuration-version`, so the configuration_version_id output of `upload` can be passed as is
+	f.StringVar(&c.ConfigurationVersionID, "configuration-version", "", "The Configuration Version ID to use for this run, an alias of -configuration_version.")
+	f.StringVar(&c.IdempotencyKey, "idempotency-key", "", "Identifies the run across retries of the command, a run with the key created in the last 10 minutes is reused instead of creating a duplicate.")
+	f.StringVar(&c.Message, "message", "", "Specifies the message shown for this run in HCP Terraform. Defaults to the triggering actor and commit, e.g. \"Triggered by octocat for 1a2b3c4 via tfci\".")
 	f.BoolVar(&c.PlanOnly, "plan-only", false, "Specifies if this is a HCP Terraform speculative, plan-only run that cannot be applied.")
+	f.StringVar(&c.TerraformVersion, "terraform-version", "", "Terraform version of a -plan-only run, e.g. to test an upgrade. Defaults to the workspace's Terraform version.")
 	f.BoolVar(&c.IsDestroy, "is-destroy", false, "Specifies that the plan is a destroy plan. When true, the plan destroys all provisioned resources.")
+	f.BoolVar(&c.RefreshOnly, "refresh-only", false, "Creates a refresh-only run, which updates the state to match the real infrastructure without proposing configuration changes.")
+	f.Var(&c.Refresh, "refresh", "-refresh=false skips refreshing the state before planning. Defaults to true.")
+	f.BoolVar(&c.AllowEmptyApply, "allow-empty-apply", false, "Allows the run to be applied even when the plan has no changes, e.g. to update the state's outputs.")
 	f.BoolVar(&c.SavePlan, "save-plan", false, "Specifies whether to create a saved plan. Saved-plan runs perform their plan and checks immediately, but won't lock the workspace and become its current run until they are confirmed for apply.")
 	f.BoolVar(&c.AsyncNoLog, "async-no-log", false, "Specifies whether to run the plan asynchronously and not log the plan output.")
+	f.BoolVar(&c.Wait, "wait", true, "Waits for the run to reach its desired status, -wait=false returns as soon as the run is queued.")
+	f.Var(&c.AutoApply, "auto-apply", "Overrides the workspace's auto-apply setting for this run, -auto-apply applies the run without confirmation and -auto-apply=false requires confirmation. Defaults to the workspace's setting.")
+	f.BoolVar(&c.DetailedExitCode, "detailed-exitcode", false, "Returns exit code 2 when the plan has changes, 0 when there are no changes and 1 on error, matching terraform plan -detailed-exitcode.")
+	f.BoolVar(&c.IncludeResourceChanges, "include-resource-changes", false, "Adds the resource_changes_payload output, a JSON array of the address, action and resource type of each changed resource.")
+	f.BoolVar(&c.FailOnDrift, "fail-on-drift", false, "Refuses to create the run if the workspace's latest health assessment has detected drift.")
+	f.BoolVar(&c.FailIfBusy, "fail-if-busy", false, "Refuses to create the run if the workspace has an active run, instead of queuing behind it.")
+	f.BoolVar(&c.FailOnDestroy, "fail-on-destroy", false, "Fails with status policy_blocked when the plan destroys or replaces any resource.")
+	f.BoolVar(&c.FailOnNoChanges, "fail-on-no-changes", false, "Fails with status policy_blocked when the plan has no resource changes.")
 	f.Var((*flagStringSlice)(&c.TargetAddrs), "target", "Limit the planning operation to only the given module, resource, or resource instance and all of its dependencies. You can use this option multiple times to include more than one object. This is for exceptional use only. e.g. -target=aws_s3_bucket.foo")
+	f.Var((*flagVarSlice)(&c.Variables), "var", "Set a Terraform variable for this run only, the variable does not persist on the workspace. You can use this option multiple times. e.g. -var 'image_tag=v1.2.3'")
+	f.Var((*flagVarSlice)(&c.VarFiles), "var-file", "Set Terraform variables for this run only from a .tfvars or .tfvars.json file. You can use this option multiple times, values from later files and -var take precedence.")
+	f.StringVar(&c.VarType, "var-type", VarTypeString, "How -var values are interpreted: string, hcl, auto. Defaults to string, auto detects HCL literals such as numbers, bools, lists and maps.")
+	f.Var((*flagVarSlice)(&c.ReplaceAddrs), "replace", "Force replacement of the given resource instance. You can use this option multiple times to replace more than one object. e.g. -replace=aws_instance.foo")
+	f.BoolVar(&c.RetryFailedRuns, "retry-failed-runs", false, "Creates a new run when the run errors with a transient failure matching -retry-pattern in its plan or apply log.")
+	f.IntVar(&c.MaxRunRetries, "max-run-retries", defaultMaxRunRetries, "Max number of new runs created by -retry-failed-runs.")
+	f.StringVar(&c.RetryPattern, "retry-pattern", defaultRetryPattern, "Regular expression matched against the log of an errored run to detect transient failures, requires -retry-failed-runs.")
 	return f
 }
 
 func (c *CreateRunCommand) Run(args []string) int {
-	if err := c.setupCmd(args, c.flags()); err != nil {
+	if err := c.setupCmd(args, c.flags(), c.requireOrganization(), requireWorkspaceOrTags(&c.Workspace, &c.WorkspaceTags)); err != nil {
+		return 1
+	}
+
+	// fire and forget, the run can be tracked by a separate job with `run wait`
+	if !c.Wait {
+		c.AsyncNoLog = true
+	}
+
+	if c.DetailedExitCode && c.AsyncNoLog {
+		c.addOutput("status", string(Error))
+		c.closeOutput()
+		c.writer.ErrorResult("-detailed-exitcode cannot be used with -async-no-log or -wait=false, as the plan has not finished when the command returns")
+		return 1
+	}
+
+	if c.IncludeResourceChanges && c.AsyncNoLog {
+		c.addOutput("status", string(Error))
+		c.closeOutput()
+		c.writer.ErrorResult("-include-resource-changes cannot be used with -async-no-log or -wait=false, as the plan has not finished when the command returns")
+		return 1
+	}
+
+	if (c.FailOnDestroy || c.FailOnNoChanges) && c.AsyncNoLog {
+		c.addOutput("status", string(Error))
+		c.closeOutput()
+		c.writer.ErrorResult("-fail-on-destroy and -fail-on-no-changes cannot be used with -async-no-log or -wait=false, as the plan has not finished when the command returns")
+		return 1
+	}
+
+	if c.TerraformVersion != "" && !c.PlanOnly {
+		c.addOutput("status", string(Error))
+		c.closeOutput()
+		c.writer.ErrorResult("-terraform-version requires -plan-only, only plan-only runs can use another Terraform version than the workspace's")
+		return 1
+	}
+
+	if err := c.validateRunMode(); err != nil {
+		c.addOutput("status", string(Error))
+		c.closeOutput()
+		c.writer.ErrorResult(err.Error())
+		return 1
+	}
+
+	if autoApply := c.AutoApply.Bool(); autoApply != nil && *autoApply {
+		if c.FailOnDestroy || c.FailOnNoChanges {
+			c.addOutput("status", string(Error))
+			c.closeOutput()
+			c.writer.ErrorResult("-auto-apply cannot be used with -fail-on-destroy or -fail-on-no-changes, as the run would be applied before its plan is checked")
+			return 1
+		}
+		if c.PlanOnly || c.SavePlan {
+			c.addOutput("status", string(Error))
+			c.closeOutput()
+			c.writer.ErrorResult("-auto-apply cannot be used with -plan-only or -save-plan, as the run cannot be applied")
+			return 1
+		}
+		c.writer.Output("Warning: -auto-apply is set, the run will be applied without manual confirmation regardless of the workspace's auto-apply setting")
+	}
+
+	if err := c.validateWorkspaceTags(); err != nil {
+		c.addOutput("status", string(Error))
+		c.closeOutput()
+		c.writer.ErrorResult(err.Error())
+		return 1
+	}
+
+	retryPattern, retryErr := c.validateRunRetries()
+	if retryErr != nil {
+		c.addOutput("status", string(Error))
+		c.closeOutput()
+		c.writer.ErrorResult(retryErr.Error())
 		return 1
 	}
 
-	runVars := collectVariables()
+	if err := c.validateResourceAddrs(); err != nil {
+		c.addOutput("status", string(Error))
+		c.closeOutput()
+		c.writer.ErrorResult(err.Error())
+		return 1
+	}
+
+	flagVars, varErr := parseFlagVariables(c.Variables, c.VarType)
+	if varErr != nil {
+		c.addOutput("status", string(Error))
+		c.closeOutput()
+		c.writer.ErrorResult(varErr.Error())
+		return 1
+	}
+
+	fileVars, fileErr := parseVarFiles(c.VarFiles)
+	if fileErr != nil {
+		c.addOutput("status", string(Error))
+		c.closeOutput()
+		c.writer.ErrorResult(fileErr.Error())
+		return 1
+	}
+
+	if c.FailOnDrift {
+		if status, drifted := c.hasDrift(); drifted {
+			return c.exitCode(status)
+		}
+	}
+
+	// speculative runs and saved plans start immediately, only other runs wait for the workspace's queue
+	if c.WorkspaceTags == "" && !c.PlanOnly && !c.SavePlan {
+		if status, busy := c.isBusy(); busy {
+			return c.exitCode(status)
+		}
+	}
+
+	runVars := collectVariables(fileVars, flagVars)
 
 	// default formatted message for run, include vcs ci runner information
 	if c.Message == "" {
 		c.Message = c.defaultRunMessage()
 	}
 
-	run, runError := c.cloud.CreateRun(c.appCtx, cloud.CreateRunOptions{
+	if c.WorkspaceTags != "" {
+		return c.createTaggedRuns(runVars)
+	}
+
+	run, runError := c.createRun(runVars, retryPattern)
+
+	if runError != nil {
+		status := c.resolveStatus(runError)
+		errMsg := fmt.Sprintf("error while creating run in HCP Terraform: %s", runError.Error())
+		if !c.AsyncNoLog {
+			// a hard failed policy errors the run, the policy outputs describe why
+			c.addPolicyResults(run)
+		}
+		c.addOutput("status", string(status))
+		c.addRunDetails(run)
+		c.errorResult(status, errMsg)
+		c.writer.OutputResult(c.closeOutput())
+		// any failure, including timeouts, is an error with a detailed exit code
+		if c.DetailedExitCode {
+			return ExitError
+		}
+		return c.exitCode(status)
+	}
+
+	if !c.AsyncNoLog {
+		if policyErr := c.addPolicyResults(run); policyErr != nil {
+			c.addOutput("status", string(Error))
+			c.addRunDetails(run)
+			c.writer.ErrorResult(policyErr.Error())
+			c.writer.OutputResult(c.closeOutput())
+			return ExitError
+		}
+	}
+
+	c.addOutput("status", string(Success))
+	c.addRunDetails(run)
+	if c.IncludeResourceChanges && run.Plan != nil {
+		c.addResourceChanges(run.Plan.ID)
+	}
+	if violations := c.planGateViolations(run.Plan); len(violations) > 0 {
+		c.addOutput("status", string(PolicyBlocked))
+		c.discardBlockedRun(run, violations)
+		c.writer.ErrorResult(fmt.Sprintf("run %s is blocked: %s", run.ID, strings.Join(violations, ", ")))
+		c.writer.OutputResult(c.closeOutput())
+		return c.exitCode(PolicyBlocked)
+	}
+	c.writer.OutputResult(c.closeOutput())
+	if c.DetailedExitCode && run.Plan != nil && run.Plan.HasChanges {
+		return ExitPlanChanges
+	}
+	return 0
+}
+
+func (c *CreateRunCommand) createRunOptions(workspace string, runVars []*tfe.RunVariable) cloud.CreateRunOptions {
+	return cloud.CreateRunOptions{
 		Organization:           c.organization,
-		Workspace:              c.Workspace,
+		Workspace:              workspace,
 		ConfigurationVersionID: c.ConfigurationVersionID,
 		Message:                c.Message,
 		PlanOnly:               c.PlanOnly,
 		IsDestroy:              c.IsDestroy,
 		SavePlan:               c.SavePlan,
+		RefreshOnly:            c.RefreshOnly,
+		Refresh:                c.Refresh.Bool(),
+		AllowEmptyApply:        c.AllowEmptyApply,
+		IdempotencyKey:         c.IdempotencyKey,
 		AsyncNoLog:             c.AsyncNoLog,
 		RunVariables:           runVars,
 		TargetAddrs:            c.TargetAddrs,
+		ReplaceAddrs:           c.ReplaceAddrs,
+		AutoApply:              c.runAutoApply(),
+		TerraformVersion:       c.TerraformVersion,
+	}
+}
+
+// -workspace-tags replaces -workspace, and options scoped to a single workspace cannot be fanned out
+func (c *CreateRunCommand) validateWorkspaceTags() error {
+	if c.WorkspaceTags == "" {
+		return nil
+	}
+	if c.Workspace != "" {
+		return errors.New("-workspace-tags cannot be combined with -workspace")
+	}
+	if len(parseWorkspaceTags(c.WorkspaceTags)) == 0 {
+		return errors.New("-workspace-tags requires at least one tag")
+	}
+	if c.ConfigurationVersionID != "" {
+		return errors.New("-workspace-tags cannot be combined with -configuration_version, as a configuration version belongs to a single workspace")
+	}
+	if c.FailOnDrift {
+		return errors.New("-workspace-tags cannot be combined with -fail-on-drift")
+	}
+	if c.FailIfBusy {
+		return errors.New("-workspace-tags cannot be combined with -fail-if-busy")
+	}
+	if c.IncludeResourceChanges {
+		return errors.New("-workspace-tags cannot be combined with -include-resource-changes")
+	}
+	if c.IdempotencyKey != "" {
+		return errors.New("-workspace-tags cannot be combined with -idempotency-key")
+	}
+	if c.FailOnDestroy || c.FailOnNoChanges {
+		return errors.New("-workspace-tags cannot be combined with -fail-on-destroy or -fail-on-no-changes")
+	}
+	return nil
+}
+
+// creates a run in every workspace having the tags, plan logs are not streamed as the runs execute concurrently
+func (c *CreateRunCommand) createTaggedRuns(runVars []*tfe.RunVariable) int {
+	workspaces, err := c.resolveTaggedWorkspaces(parseWorkspaceTags(c.WorkspaceTags))
+	if err != nil {
+		status := c.resolveStatus(err)
+		c.addOutput("status", string(status))
+		c.errorResult(status, fmt.Sprintf("error selecting workspaces in HCP Terraform: %s", err.Error()))
+		c.writer.OutputResult(c.closeOutput())
+		return c.exitCode(status)
+	}
+
+	results := forEachWorkspace(workspaces, func(w *tfe.Workspace) *workspaceResult {
+		run, runErr := c.cloud.CreateRun(c.appCtx, c.createRunOptions(w.Name, runVars))
+		return &workspaceResult{workspace: w.Name, runs: []*tfe.Run{run}, err: runErr}
 	})
-	if run != nil && !c.AsyncNoLog {
-		c.readPlanLogs(run)
+
+	status := c.addWorkspaceResults(results)
+	if status != Success {
+		c.writer.ErrorResult("error while creating runs in HCP Terraform, see failed_workspaces for details")
 	}
+	c.writer.OutputResult(c.closeOutput())
 
-	if runError != nil {
-		status := c.resolveStatus(runError)
-		errMsg := fmt.Sprintf("error while creating run in HCP Terraform: %s", runError.Error())
+	if status != Success {
+		return ExitError
+	}
+	if c.DetailedExitCode {
+		for _, result := range results {
+			if run := result.runs[0]; run != nil && run.Plan != nil && run.Plan.HasChanges {
+				return ExitPlanChanges
+			}
+		}
+	}
+	return ExitSuccess
+}
+
+// rejects refresh and apply options which Terraform cannot combine, and logs the effective options
+func (c *CreateRunCommand) validateRunMode() error {
+	refresh := c.Refresh.Bool()
+	if c.RefreshOnly {
+		switch {
+		case refresh != nil && !*refresh:
+			return errors.New("-refresh-only cannot be used with -refresh=false, a refresh-only run only refreshes the state")
+		case c.IsDestroy:
+			return errors.New("-refresh-only cannot be used with -is-destroy")
+		case len(c.TargetAddrs) > 0 || len(c.ReplaceAddrs) > 0:
+			return errors.New("-refresh-only cannot be used with -target or -replace, a refresh-only run refreshes every resource and proposes no changes")
+		}
+	}
+	if refresh != nil && !*refresh && len(c.ReplaceAddrs) > 0 {
+		return errors.New("-replace cannot be used with -refresh=false")
+	}
+	if c.AllowEmptyApply && c.PlanOnly {
+		return errors.New("-allow-empty-apply cannot be used with -plan-only, as the run cannot be applied")
+	}
+
+	log.Printf("[DEBUG] PlanOnly: %t, IsDestroy: %t, SavePlan: %t, RefreshOnly: %t, Refresh: %t, AllowEmptyApply: %t",
+		c.PlanOnly, c.IsDestroy, c.SavePlan, c.RefreshOnly, refresh == nil || *refresh, c.AllowEmptyApply)
+	return nil
+}
+
+// rejects empty resource addresses, and warns that targeted runs are operationally risky
+func (c *CreateRunCommand) validateResourceAddrs() error {
+	for _, addr := range c.TargetAddrs {
+		if strings.TrimSpace(addr) == "" {
+			return errors.New("invalid -target address, resource addresses must not be empty")
+		}
+	}
+	for _, addr := range c.ReplaceAddrs {
+		if strings.TrimSpace(addr) == "" {
+			return errors.New("invalid -replace address, resource addresses must not be empty")
+		}
+	}
+
+	if len(c.TargetAddrs) > 0 {
+		c.writer.Output(fmt.Sprintf("Warning: run is limited to the targeted resources: %s. This is for exceptional use only", strings.Join(c.TargetAddrs, ", ")))
+	}
+	if len(c.ReplaceAddrs) > 0 {
+		c.writer.Output(fmt.Sprintf("Warning: run forces replacement of the resources: %s", strings.Join(c.ReplaceAddrs, ", ")))
+	}
+	return nil
+}
+
+// returns the run a previous attempt of the command created, eg. when the response to the create request was lost to
+// a network failure, so retrying the command does not create a duplicate run. nil when a new run is needed
+func (c *CreateRunCommand) findDuplicateRun(runVars []*tfe.RunVariable) *tfe.Run {
+	run, err := c.cloud.FindDuplicateRun(c.appCtx, c.createRunOptions(c.Workspace, runVars))
+	if err != nil {
+		// the check is best effort, a run is created regardless
+		if !isDryRun(err) {
+			c.writer.Output(fmt.Sprintf("Warning: unable to check workspace %q for a duplicate run: %s", c.Workspace, err.Error()))
+		}
+		return nil
+	}
+	c.addOutput("run_reused", strconv.FormatBool(run != nil))
+	return run
+}
+
+// checks the workspace's latest health assessment, returns true with the command status and writes outputs if drift
+// was detected or unable to be determined
+func (c *CreateRunCommand) hasDrift() (Status, bool) {
+	assessment, err := c.cloud.GetAssessmentResult(c.appCtx, c.organization, c.Workspace)
+	if err != nil {
+		status := c.resolveStatus(err)
 		c.addOutput("status", string(status))
-		c.addRunDetails(run)
-		c.writer.ErrorResult(errMsg)
+		c.errorResult(status, fmt.Sprintf("error reading health assessment for workspace, '%s' in HCP Terraform: %s", c.Workspace, err.Error()))
 		c.writer.OutputResult(c.closeOutput())
-		return 1
+		return status, true
 	}
 
-	c.addOutput("status", string(Success))
-	c.addRunDetails(run)
+	if assessment == nil {
+		c.writer.Output(fmt.Sprintf("Health assessments are not enabled or have not completed for workspace: %q, skipping drift check", c.Workspace))
+		return Success, false
+	}
+
+	c.addOutput("drifted_resources", fmt.Sprint(assessment.ResourcesDrifted))
+	if !assessment.Drifted {
+		return Success, false
+	}
+
+	c.addOutput("status", string(Error))
+	c.writer.ErrorResult(fmt.Sprintf("workspace '%s' has drifted (%d resources), refusing to create run", c.Workspace, assessment.ResourcesDrifted))
 	c.writer.OutputResult(c.closeOutput())
-	return 0
+	return Error, true
+}
+
+// reports the active run the new run queues behind, returns true with the command status and writes outputs if
+// -fail-if-busy is set and the workspace is busy or its queue is unable to be read
+func (c *CreateRunCommand) isBusy() (Status, bool) {
+	queue, err := c.cloud.GetRunQueue(c.appCtx, c.organization, c.Workspace)
+	if err != nil {
+		// without -fail-if-busy the queue is informational, the run is created regardless
+		if !c.FailIfBusy {
+			if !isDryRun(err) {
+				c.writer.Output(fmt.Sprintf("Warning: unable to read the run queue of workspace %q: %s", c.Workspace, err.Error()))
+			}
+			return Success, false
+		}
+		status := c.resolveStatus(err)
+		c.addOutput("status", string(status))
+		c.errorResult(status, fmt.Sprintf("error reading run queue for workspace, '%s' in HCP Terraform: %s", c.Workspace, err.Error()))
+		c.writer.OutputResult(c.closeOutput())
+		return status, true
+	}
+
+	c.addOutput("run_queue_position", fmt.Sprint(queue.Position))
+	if queue.BlockingRun == nil {
+		c.addOutput("blocked_by_run_id", "")
+		return Success, false
+	}
+
+	blocking := queue.BlockingRun
+	c.addOutput("blocked_by_run_id", blocking.ID)
+	if !c.FailIfBusy {
+		c.writer.Output(fmt.Sprintf("Workspace %q has %d active run(s), the new run queues behind run %q (status: %q)", c.Workspace, queue.Position, blocking.ID, blocking.Status))
+		return Success, false
+	}
+
+	c.addOutput("status", string(Error))
+	c.addOutput("error_code", "workspace_busy")
+	c.writer.ErrorResult(fmt.Sprintf("workspace '%s' is busy with run %s (status: %s) and %d active run(s), refusing to create run", c.Workspace, blocking.ID, blocking.Status, queue.Position))
+	c.writer.OutputResult(c.closeOutput())
+	return Error, true
 }
 
 func (c *CreateRunCommand) addRunDetails(run *tfe.Run) {
@@ -110,17 +542,30 @@ func (c *CreateRunCommand) addRunDetails(run *tfe.Run) {
 		log.Printf("[ERROR] run is not detected")
 		return
 	}
-	runLink, _ := c.cloud.RunService.RunLink(c.appCtx, c.organization, run)
-	if runLink != "" {
-		c.addOutput("run_link", runLink)
-	}
+	runLink := c.addRunLink(run)
+	c.writeRunSummary(run, runLink)
 	c.addOutput("run_id", run.ID)
 	c.addOutput("run_status", string(run.Status))
+	// whether `run apply` is required, eg. planned runs in workspaces without auto-apply
+	confirmable := run.Actions != nil && run.Actions.IsConfirmable
+	c.addOutput("is_confirmable", fmt.Sprint(confirmable))
+	c.addOutput("auto_apply", fmt.Sprint(run.AutoApply))
+	c.addOutput("is_plan_only", fmt.Sprint(run.PlanOnly))
+	if confirmable {
+		c.writer.Output(fmt.Sprintf("Run is awaiting confirmation, apply it with `run apply -run=%s`", run.ID))
+	}
 	c.addOutput("run_message", run.Message)
 	c.addOutput("plan_id", run.Plan.ID)
 	c.addOutput("plan_status", string(run.Plan.Status))
+	c.addOutput("has_changes", fmt.Sprint(run.Plan.HasChanges))
+	c.addOutput("resource_additions", fmt.Sprint(run.Plan.ResourceAdditions))
+	c.addOutput("resource_changes", fmt.Sprint(run.Plan.ResourceChanges))
+	c.addOutput("resource_destructions", fmt.Sprint(run.Plan.ResourceDestructions))
 	c.addOutput("configuration_version_id", run.ConfigurationVersion.ID)
+	c.addOutput("commit_sha", runCommitSHA(run))
+	c.addRunTiming(run)
 
+	c.addCostEstimate(run)
 	// add cost estimation info if enabled on run
 	if run.CostEstimate != nil {
 		c.addOutput("cost_estimation_id", run.CostEstimate.ID)
@@ -130,11 +575,62 @@ func (c *CreateRunCommand) addRunDetails(run *tfe.Run) {
 		}
 	}
 
-	c.addOutputWithOpts("payload", run, &outputOpts{
-		stdOut:      false,
-		multiLine:   true,
-		platformOut: true,
+	c.addRunPayload(run)
+}
+
+// the run's auto-apply override. A run gated by -fail-on-destroy or -fail-on-no-changes is never auto-applied, so
+// its plan is checked before it can be applied
+func (c *CreateRunCommand) runAutoApply() *bool {
+	if c.FailOnDestroy || c.FailOnNoChanges {
+		return tfe.Bool(false)
+	}
+	return c.AutoApply.Bool()
+}
+
+// discards a run blocked by -fail-on-destroy or -fail-on-no-changes, so it cannot be applied from the awaiting
+// confirmation state. Plan-only runs have already finished and are not discardable
+func (c *CreateRunCommand) discardBlockedRun(run *tfe.Run, violations []string) {
+	if run.Actions == nil || !run.Actions.IsDiscardable {
+		return
+	}
+	discarded, err := c.cloud.DiscardRun(c.appCtx, cloud.DiscardRunOptions{
+		RunID:   run.ID,
+		Comment: fmt.Sprintf("Discarded by tfci: %s", strings.Join(violations, ", ")),
 	})
+	if err != nil {
+		c.writer.Output(fmt.Sprintf("Warning: unable to discard blocked run %s, discard it before it is applied: %s", run.ID, err.Error()))
+		c.addOutput("run_discarded", "false")
+		return
+	}
+	c.addOutput("run_discarded", "true")
+	if discarded != nil {
+		c.addOutput("run_status", string(discarded.Status))
+	}
+}
+
+// describes each -fail-on-destroy and -fail-on-no-changes gate the plan's resource counts violate, none when the
+// gates are not set or pass
+func (c *CreateRunCommand) planGateViolations(plan *tfe.Plan) []string {
+	if plan == nil {
+		return nil
+	}
+	violations := []string{}
+	if c.FailOnDestroy && plan.ResourceDestructions > 0 {
+		violations = append(violations, fmt.Sprintf("the plan destroys %d resource(s) and -fail-on-destroy is set", plan.ResourceDestructions))
+	}
+	if c.FailOnNoChanges && plan.ResourceAdditions+plan.ResourceChanges+plan.ResourceDestructions+plan.ResourceImports == 0 {
+		violations = append(violations, "the plan has no resource changes and -fail-on-no-changes is set")
+	}
+	return violations
+}
+
+// the VCS commit the run's configuration was ingressed from, empty for uploaded configuration versions and for runs
+// which have not been read back, eg. with -wait=false
+func runCommitSHA(run *tfe.Run) string {
+	if run.ConfigurationVersion == nil || run.ConfigurationVersion.IngressAttributes == nil {
+		return ""
+	}
+	return run.ConfigurationVersion.IngressAttributes.CommitSHA
 }
 
 func (c *CreateRunCommand) readPlanLogs(run *tfe.Run) {
@@ -154,11 +650,21 @@ func (c *CreateRunCommand) readPlanLogs(run *tfe.Run) {
 	}
 }
 
+// builds the run message from the pipeline's actor and commit, eg. "Triggered by octocat for 1a2b3c4 via tfci",
+// omitting whichever is unavailable
 func (c *CreateRunCommand) defaultRunMessage() string {
-	if c.env.Context != nil {
-		return fmt.Sprintf("Triggered from HCP Terraform CI by Author (%s) for SHA (%s)", c.env.Context.Author(), c.env.Context.SHAShort())
+	if c.env == nil || c.env.Context == nil {
+		return "Triggered via tfci"
 	}
-	return `Triggered from HCP Terraform CI`
+
+	message := "Triggered"
+	if author := c.env.Context.Author(); author != "" {
+		message += fmt.Sprintf(" by %s", author)
+	}
+	if sha := c.env.Context.SHAShort(); sha != "" {
+		message += fmt.Sprintf(" for %s", sha)
+	}
+	return message + " via tfci"
 }
 
 func (c *CreateRunCommand) Help() string {
@@ -173,21 +679,51 @@ Global Options:
 
 	-token          The token used to authenticate with HCP Terraform. Defaults to reading "TF_API_TOKEN" environment variable.
 
-	-organization   HCP Terraform Organization Name.
+	-organization   HCP Terraform Organization Name. Also accepted as a command option, which overrides the global option for that command.
 
 Options:
 
 	-workspace              The name of the HCP Terraform Workspace.
 
-	-configuration_version  The Configuration Version ID to use for this run.
+	-workspace-tags         Comma-separated list of tags, creates a run in every workspace having all of the tags instead of a single -workspace. Runs are created concurrently, and a failure in one workspace does not abort the remaining workspaces.
+
+	-configuration_version  The Configuration Version ID to use for this run, e.g. the configuration_version_id output of upload, including speculative and provisional versions. The configuration version must belong to the -workspace. Also accepted as -configuration-version. Runs of VCS-backed workspaces use the latest commit of the workspace's branch instead, and can only be given a speculative configuration version with -plan-only.
+
+	-idempotency-key        Identifies the run across retries of the command, e.g. the CI job ID. The key is appended to the run message, and a run with the key created in the last 10 minutes is reused instead of creating a duplicate run, unless it was canceled, discarded or errored.
+
+	-message                Specifies the message shown for this run in HCP Terraform. Defaults to the triggering actor and commit, e.g. "Triggered by octocat for 1a2b3c4 via tfci".
 
-	-message                Specifies the message to be associated with this run. A default message will be set.
+	-plan-only              Specifies if this is a HCP Terraform speculative, plan-only run that cannot be applied. Plans against the configuration version, or the workspace's current configuration, without uploading a speculative configuration.
 
-	-plan-only              Specifies if this is a HCP Terraform speculative, plan-only run that cannot be applied.
+	-terraform-version      Terraform version of a -plan-only run, e.g. to test an upgrade before changing the workspace's version. Defaults to the workspace's Terraform version.
+
+	-refresh-only           Creates a refresh-only run, which updates the state to match changes made outside of Terraform without proposing configuration changes, e.g. to accept drift. Cannot be used with -target, -replace, -is-destroy or -refresh=false.
+
+	-refresh                -refresh=false skips refreshing the state before planning, which is faster but may plan against stale state. Defaults to true.
+
+	-allow-empty-apply      Allows the run to be applied even when the plan has no changes, e.g. to update the state after upgrading providers. Cannot be used with -plan-only.
 
 	-save-plan              Specifies whether to create a saved plan. Saved-plan runs perform their plan and checks immediately, but won't lock the workspace and become its current run until they are confirmed for apply.
 	-is-destroy				Specifies whether to create a destroy run.
+	-wait                   Waits for the run to reach its desired status. Defaults to true, -wait=false returns as soon as the run is queued with the run_id, run_status and run_link outputs, e.g. to track the run in a separate job with "run wait".
+	-detailed-exitcode      Returns exit code 2 when the plan has changes, 0 when there are no changes and 1 on error, matching "terraform plan -detailed-exitcode".
+	-include-resource-changes Adds the resource_changes_payload output, a JSON array of {address, action, resource_type} for each resource the plan creates, updates, deletes or replaces. Empty when the plan has no changes.
+	-auto-apply             Overrides the workspace's auto-apply setting for this run. -auto-apply applies the run without manual confirmation, bypassing review, and -auto-apply=false requires confirmation. Defaults to the workspace's setting. The auto_apply output reports the run's effective setting.
+	-fail-on-drift          Refuses to create the run if the workspace's latest health assessment has detected drift.
+	-fail-if-busy           Refuses to create the run if the workspace has an active run, instead of queuing behind it. The blocked_by_run_id and run_queue_position outputs describe the active runs either way.
+	-fail-on-destroy        Fails with status policy_blocked and exit code 1 when the plan destroys or replaces any resource. The run is not auto-applied, so the plan is checked before it can be applied, and a blocked run is discarded. Cannot be used with -auto-apply.
+	-fail-on-no-changes     Fails with status policy_blocked and exit code 1 when the plan adds, changes, destroys and imports no resources. Like -fail-on-destroy, the run is not auto-applied and a blocked run is discarded. Combinable with -fail-on-destroy.
 	-target					Focuses Terraform's attention on only a subset of resources and their dependencies. This option accepts multiple instances by providing additional target option flags.
+	-var                    Sets a Terraform variable for this run only, e.g. -var 'image_tag=v1.2.3'. Run variables do not persist on the workspace. This option accepts multiple instances by providing additional var option flags.
+	-var-file               Sets Terraform variables for this run only from a .tfvars or .tfvars.json file, e.g. -var-file=prod.tfvars. This option accepts multiple instances, values from later files take precedence and -var takes precedence over all files.
+	-var-type               How -var values are interpreted: "string", "hcl" or "auto". Defaults to "string", which quotes every value. Use "hcl" for numbers, bools, lists and maps, or "auto" to detect HCL literals and otherwise treat the value as a string. "auto" keeps values such as "1.10" or "inf" as strings, as they would change as numbers.
+	-replace				Forces replacement of the given resource instance. This option accepts multiple instances by providing additional replace option flags.
+
+	-retry-failed-runs      Creates a new run when the run errors with a transient failure, such as a provider timeout, matching -retry-pattern in the log of the failed plan or apply. Only errored runs are retried, never canceled, discarded or policy failed runs.
+	-max-run-retries        Max number of new runs created by -retry-failed-runs. Defaults to 2.
+	-retry-pattern          Regular expression matched against the log of an errored run to detect transient failures. Defaults to common network, throttling and provider timeout errors.
+
+	-payload-fields         Comma separated list of top-level fields to include in the payload output, e.g. id,status,created-at. Defaults to all fields.
 	`
 	return strings.TrimSpace(helpText)
 }
@@ -0,0 +1,1226 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+	"github.com/hashicorp/tfci/internal/environment"
+	"github.com/hashicorp/tfci/internal/writer"
+	"github.com/mitchellh/cli"
+)
+
+func TestCreateRunCommand_ResourceAddrs(t *testing.T) {
+	testCases := []struct {
+		name          string
+		args          []string
+		exitStatus    int
+		expectTargets []string
+		expectReplace []string
+	}{
+		{
+			name:          "target-and-replace",
+			args:          []string{"-workspace=my-workspace", "-async-no-log", "-target=aws_instance.foo", "-target=aws_s3_bucket.bar", "-replace=aws_instance.foo"},
+			exitStatus:    0,
+			expectTargets: []string{"aws_instance.foo", "aws_s3_bucket.bar"},
+			expectReplace: []string{"aws_instance.foo"},
+		},
+		{
+			name:       "empty-target-address",
+			args:       []string{"-workspace=my-workspace", "-async-no-log", "-target=aws_instance.foo,,aws_s3_bucket.bar"},
+			exitStatus: 1,
+		},
+		{
+			name:          "replace-address-with-comma",
+			args:          []string{"-workspace=my-workspace", "-async-no-log", `-replace=aws_instance.foo["a,b"]`, "-replace=aws_instance.bar"},
+			exitStatus:    0,
+			expectReplace: []string{`aws_instance.foo["a,b"]`, "aws_instance.bar"},
+		},
+		{
+			name:       "empty-replace-address",
+			args:       []string{"-workspace=my-workspace", "-async-no-log", "-replace="},
+			exitStatus: 1,
+		},
+		{
+			name:       "blank-replace-address",
+			args:       []string{"-workspace=my-workspace", "-async-no-log", "-replace= "},
+			exitStatus: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, w)
+			runService := &RunReader{run: &tfe.Run{
+				ID:                   "run-***",
+				Plan:                 &tfe.Plan{},
+				ConfigurationVersion: &tfe.ConfigurationVersion{},
+			}}
+			cloudMockService.RunService = runService
+			cmd := &CreateRunCommand{Meta: NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))}
+
+			if actual := cmd.Run(tc.args); actual != tc.exitStatus {
+				t.Fatalf("expected %d but received %d, stderr: %s", tc.exitStatus, actual, ui.ErrorWriter.String())
+			}
+
+			if tc.exitStatus != 0 {
+				if runService.created != nil {
+					t.Errorf("expected run not to be created with invalid resource addresses")
+				}
+				return
+			}
+			if !reflect.DeepEqual(runService.created.TargetAddrs, tc.expectTargets) {
+				t.Errorf("expected targets %v but received %v", tc.expectTargets, runService.created.TargetAddrs)
+			}
+			if !reflect.DeepEqual(runService.created.ReplaceAddrs, tc.expectReplace) {
+				t.Errorf("expected replace %v but received %v", tc.expectReplace, runService.created.ReplaceAddrs)
+			}
+		})
+	}
+}
+
+func TestCreateRunCommand_FailOnDrift(t *testing.T) {
+	testCases := []struct {
+		name          string
+		assessment    *cloud.AssessmentResult
+		err           error
+		exitStatus    int
+		expectCreated bool
+		expectDrifted string
+	}{
+		{
+			name:          "assessments-disabled",
+			exitStatus:    0,
+			expectCreated: true,
+		},
+		{
+			name:          "no-drift",
+			assessment:    &cloud.AssessmentResult{Drifted: false},
+			exitStatus:    0,
+			expectCreated: true,
+			expectDrifted: "0",
+		},
+		{
+			name:          "drifted",
+			assessment:    &cloud.AssessmentResult{Drifted: true, ResourcesDrifted: 2},
+			exitStatus:    1,
+			expectDrifted: "2",
+		},
+		{
+			name:       "assessment-read-failed",
+			err:        errors.New("503 Service Unavailable"),
+			exitStatus: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, w)
+			runService := &RunReader{run: &tfe.Run{
+				ID:                   "run-***",
+				Plan:                 &tfe.Plan{},
+				ConfigurationVersion: &tfe.ConfigurationVersion{},
+			}}
+			cloudMockService.RunService = runService
+			cloudMockService.WorkspaceService = &WorkspaceReader{assessment: tc.assessment, err: tc.err}
+			cmd := &CreateRunCommand{Meta: NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))}
+
+			if actual := cmd.Run([]string{"-workspace=my-workspace", "-async-no-log", "-fail-on-drift"}); actual != tc.exitStatus {
+				t.Fatalf("expected %d but received %d, stderr: %s", tc.exitStatus, actual, ui.ErrorWriter.String())
+			}
+			if created := runService.created != nil; created != tc.expectCreated {
+				t.Errorf("expected run created %t but received %t", tc.expectCreated, created)
+			}
+			if drifted := outputValue(cmd.Meta, "drifted_resources"); drifted != tc.expectDrifted {
+				t.Errorf("expected drifted_resources %q but received %q", tc.expectDrifted, drifted)
+			}
+			if tc.exitStatus != 0 {
+				if status := outputValue(cmd.Meta, "status"); status != string(Error) {
+					t.Errorf("expected status %q but received %q", Error, status)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateRunCommand_DetailedExitCode(t *testing.T) {
+	testCases := []struct {
+		name        string
+		args        []string
+		hasChanges  bool
+		exitStatus  int
+		expectValue string
+	}{
+		{
+			name:        "changes-without-detailed-exitcode",
+			args:        []string{"-workspace=my-workspace", "-plan-only"},
+			hasChanges:  true,
+			exitStatus:  0,
+			expectValue: "true",
+		},
+		{
+			name:        "changes-with-detailed-exitcode",
+			args:        []string{"-workspace=my-workspace", "-plan-only", "-detailed-exitcode"},
+			hasChanges:  true,
+			exitStatus:  2,
+			expectValue: "true",
+		},
+		{
+			name:        "no-changes-with-detailed-exitcode",
+			args:        []string{"-workspace=my-workspace", "-plan-only", "-detailed-exitcode"},
+			hasChanges:  false,
+			exitStatus:  0,
+			expectValue: "false",
+		},
+		{
+			name:       "async-no-log-with-detailed-exitcode",
+			args:       []string{"-workspace=my-workspace", "-async-no-log", "-detailed-exitcode"},
+			exitStatus: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, w)
+			cloudMockService.RunService = &RunLogReader{RunReader: RunReader{run: &tfe.Run{
+				ID: "run-***",
+				Plan: &tfe.Plan{
+					HasChanges:        tc.hasChanges,
+					ResourceAdditions: 1,
+				},
+				ConfigurationVersion: &tfe.ConfigurationVersion{},
+			}}}
+			cmd := &CreateRunCommand{Meta: NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))}
+
+			if actual := cmd.Run(tc.args); actual != tc.exitStatus {
+				t.Fatalf("expected %d but received %d", tc.exitStatus, actual)
+			}
+			if tc.expectValue == "" {
+				return
+			}
+
+			outputs := map[string]string{}
+			json.Unmarshal([]byte(ui.OutputWriter.String()), &outputs)
+			if outputs["has_changes"] != tc.expectValue {
+				t.Errorf("expected has_changes %q but received %q", tc.expectValue, outputs["has_changes"])
+			}
+			if outputs["resource_additions"] != "1" {
+				t.Errorf("expected resource_additions %q but received %q", "1", outputs["resource_additions"])
+			}
+		})
+	}
+}
+
+// satisfies the log methods used by the command after a run has been created
+type RunLogReader struct {
+	RunReader
+}
+
+func (r *RunLogReader) LogTaskStage(_ context.Context, _ *tfe.Run, _ tfe.Stage) error {
+	return nil
+}
+
+func (r *RunLogReader) GetPlanLogs(_ context.Context, _ string) error {
+	return nil
+}
+
+func (r *RunLogReader) LogCostEstimation(_ context.Context, _ *tfe.Run) {}
+
+func (r *RunLogReader) GetPolicyCheckLogs(_ context.Context, _ *tfe.Run) error {
+	return nil
+}
+
+// returns the next run for each created run, errored runs are returned with an error
+type RetryRunService struct {
+	RunLogReader
+
+	runs    []*tfe.Run
+	logs    map[string]string
+	created int
+}
+
+func (r *RetryRunService) CreateRun(_ context.Context, _ cloud.CreateRunOptions) (*tfe.Run, error) {
+	run := r.runs[r.created]
+	r.created++
+	if run.Status == tfe.RunErrored {
+		return run, errors.New("run errored")
+	}
+	return run, nil
+}
+
+// waits on the run a previous attempt created, errored runs are returned with an error
+func (r *RetryRunService) WaitRun(_ context.Context, _ cloud.WaitRunOptions) (*tfe.Run, error) {
+	r.waited = true
+	if r.duplicate.Status == tfe.RunErrored {
+		return r.duplicate, errors.New("run errored")
+	}
+	return r.duplicate, nil
+}
+
+func (r *RetryRunService) ReadRunLog(_ context.Context, run *tfe.Run) (string, error) {
+	return r.logs[run.ID], nil
+}
+
+// optionally fails to create the run, eg. when the workspace is locked
+type CreateErrorRunService struct {
+	RunReader
+	err error
+}
+
+func (r *CreateErrorRunService) CreateRun(_ context.Context, options cloud.CreateRunOptions) (*tfe.Run, error) {
+	r.created = &options
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.run, nil
+}
+
+func TestCreateRunCommand_NoWait(t *testing.T) {
+	testCases := []struct {
+		name         string
+		args         []string
+		createErr    error
+		exitStatus   int
+		expectStatus string
+	}{
+		{
+			name:         "returns-once-queued",
+			args:         []string{"-workspace=my-workspace", "-wait=false"},
+			exitStatus:   0,
+			expectStatus: string(tfe.RunPending),
+		},
+		{
+			name:       "create-error",
+			args:       []string{"-workspace=my-workspace", "-wait=false"},
+			createErr:  errors.New("workspace is locked"),
+			exitStatus: 1,
+		},
+		{
+			name:       "detailed-exitcode",
+			args:       []string{"-workspace=my-workspace", "-wait=false", "-detailed-exitcode"},
+			exitStatus: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, w)
+			runService := &CreateErrorRunService{RunReader: RunReader{run: &tfe.Run{
+				ID:                   "run-***",
+				Status:               tfe.RunPending,
+				Plan:                 &tfe.Plan{},
+				ConfigurationVersion: &tfe.ConfigurationVersion{},
+			}}, err: tc.createErr}
+			cloudMockService.RunService = runService
+			meta := NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))
+			cmd := &CreateRunCommand{Meta: meta}
+
+			if actual := cmd.Run(tc.args); actual != tc.exitStatus {
+				t.Fatalf("expected %d but received %d, stderr: %s", tc.exitStatus, actual, ui.ErrorWriter.String())
+			}
+			if tc.exitStatus != 0 {
+				if status := outputValue(meta, "status"); status != string(Error) {
+					t.Errorf("expected status %q but received %q", Error, status)
+				}
+				return
+			}
+
+			if !runService.created.AsyncNoLog {
+				t.Errorf("expected the run not to be waited on with -wait=false")
+			}
+			if actual := outputValue(meta, "run_id"); actual != "run-***" {
+				t.Errorf("expected run_id %q but received %q", "run-***", actual)
+			}
+			if actual := outputValue(meta, "run_status"); actual != tc.expectStatus {
+				t.Errorf("expected run_status %q but received %q", tc.expectStatus, actual)
+			}
+		})
+	}
+}
+
+func TestCreateRunCommand_RetryFailedRuns(t *testing.T) {
+	newRun := func(id string, status tfe.RunStatus) *tfe.Run {
+		return &tfe.Run{ID: id, Status: status, Plan: &tfe.Plan{}, ConfigurationVersion: &tfe.ConfigurationVersion{}}
+	}
+	transientLog := jsonLog(`{"@level":"error","@message":"Error: reading S3 Bucket (logs): RequestError: send request failed","@module":"terraform.ui","diagnostic":{"severity":"error","summary":"reading S3 Bucket (logs): RequestError: send request failed","detail":"caused by: Get \"https://logs.s3.amazonaws.com/\": dial tcp: i/o timeout","address":"aws_s3_bucket.logs"},"type":"diagnostic"}`)
+
+	testCases := []struct {
+		name          string
+		args          []string
+		duplicate     *tfe.Run
+		runs          []*tfe.Run
+		logs          map[string]string
+		exitStatus    int
+		expectCreated int
+		expectRunID   string
+		expectAttempt string
+	}{
+		{
+			name:          "transient-failure-retried",
+			args:          []string{"-workspace=my-workspace", "-retry-failed-runs"},
+			runs:          []*tfe.Run{newRun("run-1", tfe.RunErrored), newRun("run-2", tfe.RunPlanned)},
+			logs:          map[string]string{"run-1": transientLog},
+			expectCreated: 2,
+			expectRunID:   "run-2",
+			expectAttempt: "2",
+		},
+		{
+			name:          "retries-are-capped",
+			args:          []string{"-workspace=my-workspace", "-retry-failed-runs", "-max-run-retries=1"},
+			runs:          []*tfe.Run{newRun("run-1", tfe.RunErrored), newRun("run-2", tfe.RunErrored)},
+			logs:          map[string]string{"run-1": transientLog, "run-2": transientLog},
+			exitStatus:    1,
+			expectCreated: 2,
+			expectRunID:   "run-2",
+			expectAttempt: "2",
+		},
+		{
+			name:          "non-transient-failure-not-retried",
+			args:          []string{"-workspace=my-workspace", "-retry-failed-runs"},
+			runs:          []*tfe.Run{newRun("run-1", tfe.RunErrored)},
+			logs:          map[string]string{"run-1": jsonLog(`{"@level":"error","@message":"Error: Unsupported argument","@module":"terraform.ui","diagnostic":{"severity":"error","summary":"Unsupported argument","detail":"An argument named \"timeout\" is not expected here."},"type":"diagnostic"}`)},
+			exitStatus:    1,
+			expectCreated: 1,
+			expectRunID:   "run-1",
+			expectAttempt: "1",
+		},
+		{
+			name: "planned-attribute-does-not-match",
+			args: []string{"-workspace=my-workspace", "-retry-failed-runs"},
+			runs: []*tfe.Run{newRun("run-1", tfe.RunErrored)},
+			logs: map[string]string{"run-1": jsonLog(
+				`{"@level":"info","@message":"aws_lambda_function.api: Plan to create","@module":"terraform.ui","change":{"resource":{"addr":"aws_lambda_function.api"},"action":"create","timeout":"30"},"type":"planned_change"}`,
+				`{"@level":"error","@message":"Error: creating instance: InvalidAMIID.NotFound","@module":"terraform.ui","diagnostic":{"severity":"error","summary":"creating instance: InvalidAMIID.NotFound","detail":""},"type":"diagnostic"}`,
+			)},
+			exitStatus:    1,
+			expectCreated: 1,
+			expectRunID:   "run-1",
+			expectAttempt: "1",
+		},
+		{
+			name:          "custom-pattern",
+			args:          []string{"-workspace=my-workspace", "-retry-failed-runs", "-retry-pattern=InsufficientInstanceCapacity"},
+			runs:          []*tfe.Run{newRun("run-1", tfe.RunErrored), newRun("run-2", tfe.RunPlanned)},
+			logs:          map[string]string{"run-1": jsonLog(`{"@level":"error","@message":"Error: creating instance: InsufficientInstanceCapacity","@module":"terraform.ui","diagnostic":{"severity":"error","summary":"creating instance: InsufficientInstanceCapacity","detail":""},"type":"diagnostic"}`)},
+			expectCreated: 2,
+			expectRunID:   "run-2",
+			expectAttempt: "2",
+		},
+		{
+			name:          "reused-run-retried",
+			args:          []string{"-workspace=my-workspace", "-retry-failed-runs", "-idempotency-key=job-123"},
+			duplicate:     newRun("run-1", tfe.RunErrored),
+			runs:          []*tfe.Run{newRun("run-2", tfe.RunPlanned)},
+			logs:          map[string]string{"run-1": transientLog},
+			expectCreated: 1,
+			expectRunID:   "run-2",
+			expectAttempt: "2",
+		},
+		{
+			name:       "invalid-pattern",
+			args:       []string{"-workspace=my-workspace", "-retry-failed-runs", "-retry-pattern=("},
+			exitStatus: 1,
+		},
+		{
+			name:       "async-no-log",
+			args:       []string{"-workspace=my-workspace", "-retry-failed-runs", "-async-no-log"},
+			exitStatus: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, w)
+			runService := &RetryRunService{runs: tc.runs, logs: tc.logs}
+			runService.duplicate = tc.duplicate
+			cloudMockService.RunService = runService
+			cmd := &CreateRunCommand{Meta: NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))}
+
+			if actual := cmd.Run(tc.args); actual != tc.exitStatus {
+				t.Fatalf("expected %d but received %d, stderr: %s", tc.exitStatus, actual, ui.ErrorWriter.String())
+			}
+			if runService.created != tc.expectCreated {
+				t.Errorf("expected %d runs to be created but received %d", tc.expectCreated, runService.created)
+			}
+			if tc.expectCreated == 0 {
+				return
+			}
+			if runID := outputValue(cmd.Meta, "run_id"); runID != tc.expectRunID {
+				t.Errorf("expected run_id %q but received %q", tc.expectRunID, runID)
+			}
+			if attempts := outputValue(cmd.Meta, "run_attempts"); attempts != tc.expectAttempt {
+				t.Errorf("expected run_attempts %q but received %q", tc.expectAttempt, attempts)
+			}
+		})
+	}
+}
+
+type CommitContext struct {
+	SummaryContext
+	author string
+	sha    string
+}
+
+func (c *CommitContext) Author() string {
+	return c.author
+}
+
+func (c *CommitContext) SHAShort() string {
+	return c.sha
+}
+
+// a structured run log, preceded by the version line HCP Terraform writes first
+func jsonLog(lines ...string) string {
+	version := `{"@level":"info","@message":"Terraform 1.9.5","@module":"terraform.ui","terraform":"1.9.5","type":"version","ui":"1.2"}`
+	return strings.Join(append([]string{version}, lines...), "\n")
+}
+
+func TestErrorDiagnostics(t *testing.T) {
+	testCases := []struct {
+		name     string
+		log      string
+		expected string
+	}{
+		{
+			name: "json-diagnostic",
+			log: jsonLog(
+				`{"@level":"info","@message":"aws_s3_bucket.logs: Refreshing state... [id=logs]","@module":"terraform.ui","hook":{"resource":{"addr":"aws_s3_bucket.logs"}},"type":"refresh_start"}`,
+				`{"@level":"error","@message":"Error: reading S3 Bucket (logs): RequestError: send request failed","@module":"terraform.ui","diagnostic":{"severity":"error","summary":"reading S3 Bucket (logs): RequestError: send request failed","detail":"dial tcp: i/o timeout"},"type":"diagnostic"}`,
+			),
+			expected: "Error: reading S3 Bucket (logs): RequestError: send request failed\ndial tcp: i/o timeout",
+		},
+		{
+			name:     "json-warning-skipped",
+			log:      jsonLog(`{"@level":"warn","@message":"Warning: Argument is deprecated","@module":"terraform.ui","diagnostic":{"severity":"warning","summary":"Argument is deprecated","detail":"Use timeouts instead"},"type":"diagnostic"}`),
+			expected: "",
+		},
+		{
+			name:     "json-error-without-diagnostic",
+			log:      jsonLog(`{"@level":"error","@message":"Error: connection reset by peer","@module":"terraform.ui"}`),
+			expected: "Error: connection reset by peer",
+		},
+		{
+			name:     "rendered-diagnostic",
+			log:      "      + timeout = 30\n╷\n│ Error: creating instance: InvalidAMIID.NotFound\n╵",
+			expected: "│ Error: creating instance: InvalidAMIID.NotFound",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := errorDiagnostics(tc.log); actual != tc.expected {
+				t.Errorf("expected %q but received %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCreateRunCommand_Message(t *testing.T) {
+	testCases := []struct {
+		name     string
+		args     []string
+		context  environment.Common
+		expected string
+	}{
+		{
+			name:     "default-from-context",
+			args:     []string{"-workspace=my-workspace", "-wait=false"},
+			context:  &CommitContext{author: "octocat", sha: "1a2b3c4"},
+			expected: "Triggered by octocat for 1a2b3c4 via tfci",
+		},
+		{
+			name:     "default-without-commit",
+			args:     []string{"-workspace=my-workspace", "-wait=false"},
+			context:  &CommitContext{author: "octocat"},
+			expected: "Triggered by octocat via tfci",
+		},
+		{
+			name:     "default-without-context",
+			args:     []string{"-workspace=my-workspace", "-wait=false"},
+			expected: "Triggered via tfci",
+		},
+		{
+			name:     "flag-overrides-default",
+			args:     []string{"-workspace=my-workspace", "-wait=false", "-message=Release v1.2.3"},
+			context:  &CommitContext{author: "octocat", sha: "1a2b3c4"},
+			expected: "Release v1.2.3",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, w)
+			runService := &RunReader{run: &tfe.Run{
+				ID:                   "run-***",
+				Status:               tfe.RunPending,
+				Plan:                 &tfe.Plan{},
+				ConfigurationVersion: &tfe.ConfigurationVersion{},
+			}}
+			cloudMockService.RunService = runService
+			cmd := &CreateRunCommand{Meta: NewMetaOpts(context.Background(), cloudMockService, &environment.CI{Context: tc.context}, WithWriter(w), WithOrg("hashicorp"))}
+
+			if code := cmd.Run(tc.args); code != 0 {
+				t.Fatalf("expected %d but received %d: %s", 0, code, ui.ErrorWriter.String())
+			}
+			if runService.created.Message != tc.expected {
+				t.Errorf("expected message %q but received %q", tc.expected, runService.created.Message)
+			}
+		})
+	}
+}
+
+func TestCreateRunCommand_Confirmable(t *testing.T) {
+	testCases := []struct {
+		name        string
+		run         *tfe.Run
+		confirmable string
+		autoApply   string
+	}{
+		{
+			name:        "planned-awaiting-confirmation",
+			run:         &tfe.Run{Status: tfe.RunPlanned, Actions: &tfe.RunActions{IsConfirmable: true}},
+			confirmable: "true",
+			autoApply:   "false",
+		},
+		{
+			name:        "planned-and-finished",
+			run:         &tfe.Run{Status: tfe.RunPlannedAndFinished, Actions: &tfe.RunActions{}},
+			confirmable: "false",
+			autoApply:   "false",
+		},
+		{
+			name:        "auto-applied",
+			run:         &tfe.Run{Status: tfe.RunApplied, AutoApply: true, Actions: &tfe.RunActions{}},
+			confirmable: "false",
+			autoApply:   "true",
+		},
+		{
+			name:        "without-actions",
+			run:         &tfe.Run{Status: tfe.RunPending},
+			confirmable: "false",
+			autoApply:   "false",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, w)
+			tc.run.ID = "run-***"
+			tc.run.Plan = &tfe.Plan{}
+			tc.run.ConfigurationVersion = &tfe.ConfigurationVersion{}
+			cloudMockService.RunService = &RunLogReader{RunReader: RunReader{run: tc.run}}
+			meta := NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))
+
+			if code := (&CreateRunCommand{Meta: meta}).Run([]string{"-workspace=my-workspace"}); code != 0 {
+				t.Fatalf("expected %d but received %d: %s", 0, code, ui.ErrorWriter.String())
+			}
+			if status := outputValue(meta, "run_status"); status != string(tc.run.Status) {
+				t.Errorf("expected run_status %q but received %q", tc.run.Status, status)
+			}
+			if confirmable := outputValue(meta, "is_confirmable"); confirmable != tc.confirmable {
+				t.Errorf("expected is_confirmable %q but received %q", tc.confirmable, confirmable)
+			}
+			if autoApply := outputValue(meta, "auto_apply"); autoApply != tc.autoApply {
+				t.Errorf("expected auto_apply %q but received %q", tc.autoApply, autoApply)
+			}
+		})
+	}
+}
+
+func TestCreateRunCommand_RunQueue(t *testing.T) {
+	testCases := []struct {
+		name     string
+		args     []string
+		queue    *cloud.RunQueue
+		want     int
+		created  bool
+		blocking string
+		position string
+	}{
+		{
+			name:     "idle",
+			args:     []string{"-workspace=my-workspace"},
+			want:     0,
+			created:  true,
+			position: "0",
+		},
+		{
+			name:     "queued-behind-active-run",
+			args:     []string{"-workspace=my-workspace"},
+			queue:    &cloud.RunQueue{BlockingRun: &tfe.Run{ID: "run-active", Status: tfe.RunApplying}, Position: 2},
+			want:     0,
+			created:  true,
+			blocking: "run-active",
+			position: "2",
+		},
+		{
+			name:     "fail-if-busy",
+			args:     []string{"-workspace=my-workspace", "-fail-if-busy"},
+			queue:    &cloud.RunQueue{BlockingRun: &tfe.Run{ID: "run-active", Status: tfe.RunPlanned}, Position: 1},
+			want:     1,
+			blocking: "run-active",
+			position: "1",
+		},
+		{
+			name:     "fail-if-busy-idle",
+			args:     []string{"-workspace=my-workspace", "-fail-if-busy"},
+			queue:    &cloud.RunQueue{},
+			want:     0,
+			created:  true,
+			position: "0",
+		},
+		{
+			// speculative runs never wait for the queue
+			name:    "plan-only-skips-queue",
+			args:    []string{"-workspace=my-workspace", "-plan-only", "-fail-if-busy"},
+			queue:   &cloud.RunQueue{BlockingRun: &tfe.Run{ID: "run-active"}, Position: 1},
+			want:    0,
+			created: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, w)
+			runService := &RunLogReader{RunReader: RunReader{
+				run:   &tfe.Run{ID: "run-new", Plan: &tfe.Plan{}, ConfigurationVersion: &tfe.ConfigurationVersion{}},
+				queue: tc.queue,
+			}}
+			cloudMockService.RunService = runService
+			meta := NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))
+
+			if code := (&CreateRunCommand{Meta: meta}).Run(tc.args); code != tc.want {
+				t.Fatalf("expected %d but received %d: %s", tc.want, code, ui.ErrorWriter.String())
+			}
+			if created := runService.created != nil; created != tc.created {
+				t.Errorf("expected run created %t but received %t", tc.created, created)
+			}
+			if blocking := outputValue(meta, "blocked_by_run_id"); blocking != tc.blocking {
+				t.Errorf("expected blocked_by_run_id %q but received %q", tc.blocking, blocking)
+			}
+			if position := outputValue(meta, "run_queue_position"); position != tc.position {
+				t.Errorf("expected run_queue_position %q but received %q", tc.position, position)
+			}
+			if tc.want == 1 {
+				if errorCode := outputValue(meta, "error_code"); errorCode != "workspace_busy" {
+					t.Errorf("expected error_code %q but received %q", "workspace_busy", errorCode)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateRunCommand_AutoApply(t *testing.T) {
+	testCases := []struct {
+		name       string
+		args       []string
+		exitStatus int
+		expected   *bool
+		warning    bool
+	}{
+		{
+			name: "workspace-setting",
+			args: []string{"-workspace=my-workspace"},
+		},
+		{
+			name:     "auto-apply",
+			args:     []string{"-workspace=my-workspace", "-auto-apply"},
+			expected: tfe.Bool(true),
+			warning:  true,
+		},
+		{
+			name:     "manual-apply",
+			args:     []string{"-workspace=my-workspace", "-auto-apply=false"},
+			expected: tfe.Bool(false),
+		},
+		{
+			name:       "plan-only",
+			args:       []string{"-workspace=my-workspace", "-auto-apply", "-plan-only"},
+			exitStatus: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, w)
+			runService := &RunLogReader{RunReader: RunReader{run: &tfe.Run{
+				ID:                   "run-***",
+				Status:               tfe.RunApplied,
+				AutoApply:            true,
+				Plan:                 &tfe.Plan{},
+				ConfigurationVersion: &tfe.ConfigurationVersion{},
+			}}}
+			cloudMockService.RunService = runService
+			meta := NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))
+
+			if code := (&CreateRunCommand{Meta: meta}).Run(tc.args); code != tc.exitStatus {
+				t.Fatalf("expected %d but received %d: %s", tc.exitStatus, code, ui.ErrorWriter.String())
+			}
+			if tc.exitStatus != 0 {
+				if runService.created != nil {
+					t.Errorf("expected no run to be created but received %+v", runService.created)
+				}
+				return
+			}
+
+			actual := runService.created.AutoApply
+			if (actual == nil) != (tc.expected == nil) || (actual != nil && *actual != *tc.expected) {
+				t.Errorf("expected auto apply %v but received %v", tc.expected, actual)
+			}
+			if warned := strings.Contains(ui.OutputWriter.String(), "Warning: -auto-apply is set"); warned != tc.warning {
+				t.Errorf("expected warning %t but received output: %s", tc.warning, ui.OutputWriter.String())
+			}
+		})
+	}
+}
+
+func TestCreateRunCommand_PlanOnly(t *testing.T) {
+	testCases := []struct {
+		name             string
+		args             []string
+		exitStatus       int
+		terraformVersion string
+	}{
+		{
+			name: "plan-only",
+			args: []string{"-workspace=my-workspace", "-plan-only"},
+		},
+		{
+			name:             "terraform-version",
+			args:             []string{"-workspace=my-workspace", "-plan-only", "-terraform-version=1.9.0"},
+			terraformVersion: "1.9.0",
+		},
+		{
+			name:       "terraform-version-without-plan-only",
+			args:       []string{"-workspace=my-workspace", "-terraform-version=1.9.0"},
+			exitStatus: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, w)
+			runService := &RunLogReader{RunReader: RunReader{run: &tfe.Run{
+				ID:                   "run-***",
+				Status:               tfe.RunPlannedAndFinished,
+				PlanOnly:             true,
+				Plan:                 &tfe.Plan{},
+				ConfigurationVersion: &tfe.ConfigurationVersion{},
+			}}}
+			cloudMockService.RunService = runService
+			meta := NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))
+
+			if code := (&CreateRunCommand{Meta: meta}).Run(tc.args); code != tc.exitStatus {
+				t.Fatalf("expected %d but received %d: %s", tc.exitStatus, code, ui.ErrorWriter.String())
+			}
+			if tc.exitStatus != 0 {
+				if runService.created != nil {
+					t.Errorf("expected no run to be created but received %+v", runService.created)
+				}
+				return
+			}
+
+			if !runService.created.PlanOnly || runService.created.TerraformVersion != tc.terraformVersion {
+				t.Errorf("expected a plan-only run with Terraform version %q but received %+v", tc.terraformVersion, runService.created)
+			}
+			if planOnly := outputValue(meta, "is_plan_only"); planOnly != "true" {
+				t.Errorf("expected is_plan_only %q but received %q", "true", planOnly)
+			}
+		})
+	}
+}
+
+func TestCreateRunCommand_RunMode(t *testing.T) {
+	testCases := []struct {
+		name                    string
+		args                    []string
+		exitStatus              int
+		expectedRefreshOnly     bool
+		expectedRefresh         *bool
+		expectedAllowEmptyApply bool
+	}{
+		{
+			name: "defaults",
+			args: []string{"-workspace=my-workspace"},
+		},
+		{
+			name:                "refresh-only",
+			args:                []string{"-workspace=my-workspace", "-refresh-only"},
+			expectedRefreshOnly: true,
+		},
+		{
+			name:            "no-refresh",
+			args:            []string{"-workspace=my-workspace", "-refresh=false"},
+			expectedRefresh: tfe.Bool(false),
+		},
+		{
+			name:                    "allow-empty-apply",
+			args:                    []string{"-workspace=my-workspace", "-allow-empty-apply"},
+			expectedAllowEmptyApply: true,
+		},
+		{
+			name:       "refresh-only-with-target",
+			args:       []string{"-workspace=my-workspace", "-refresh-only", "-target=aws_instance.web"},
+			exitStatus: 1,
+		},
+		{
+			name:       "refresh-only-without-refresh",
+			args:       []string{"-workspace=my-workspace", "-refresh-only", "-refresh=false"},
+			exitStatus: 1,
+		},
+		{
+			name:       "refresh-only-destroy",
+			args:       []string{"-workspace=my-workspace", "-refresh-only", "-is-destroy"},
+			exitStatus: 1,
+		},
+		{
+			name:       "replace-without-refresh",
+			args:       []string{"-workspace=my-workspace", "-refresh=false", "-replace=aws_instance.web"},
+			exitStatus: 1,
+		},
+		{
+			name:       "allow-empty-apply-plan-only",
+			args:       []string{"-workspace=my-workspace", "-allow-empty-apply", "-plan-only"},
+			exitStatus: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, w)
+			runService := &RunLogReader{RunReader: RunReader{run: &tfe.Run{
+				ID:                   "run-***",
+				Status:               tfe.RunPlannedAndFinished,
+				Plan:                 &tfe.Plan{},
+				ConfigurationVersion: &tfe.ConfigurationVersion{},
+			}}}
+			cloudMockService.RunService = runService
+			meta := NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))
+
+			if code := (&CreateRunCommand{Meta: meta}).Run(tc.args); code != tc.exitStatus {
+				t.Fatalf("expected %d but received %d: %s", tc.exitStatus, code, ui.ErrorWriter.String())
+			}
+			if tc.exitStatus != 0 {
+				if runService.created != nil {
+					t.Errorf("expected no run to be created but received %+v", runService.created)
+				}
+				return
+			}
+
+			created := runService.created
+			if created.RefreshOnly != tc.expectedRefreshOnly || created.AllowEmptyApply != tc.expectedAllowEmptyApply {
+				t.Errorf("expected refresh-only %t and allow-empty-apply %t but received %+v", tc.expectedRefreshOnly, tc.expectedAllowEmptyApply, created)
+			}
+			if !reflect.DeepEqual(created.Refresh, tc.expectedRefresh) {
+				t.Errorf("expected refresh %v but received %v", tc.expectedRefresh, created.Refresh)
+			}
+		})
+	}
+}
+
+func TestCreateRunCommand_ConfigurationVersion(t *testing.T) {
+	for _, flagName := range []string{"configuration_version", "configuration-version"} {
+		t.Run(flagName, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, w)
+			runService := &RunLogReader{RunReader: RunReader{run: &tfe.Run{
+				ID:                   "run-***",
+				Status:               tfe.RunPlannedAndFinished,
+				Plan:                 &tfe.Plan{},
+				ConfigurationVersion: &tfe.ConfigurationVersion{ID: "cv-***"},
+			}}}
+			cloudMockService.RunService = runService
+			meta := NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))
+
+			if code := (&CreateRunCommand{Meta: meta}).Run([]string{"-workspace=my-workspace", "-" + flagName + "=cv-***"}); code != 0 {
+				t.Fatalf("expected 0 but received %d: %s", code, ui.ErrorWriter.String())
+			}
+			if id := runService.created.ConfigurationVersionID; id != "cv-***" {
+				t.Errorf("expected the run to be created with configuration version %q but received %q", "cv-***", id)
+			}
+		})
+	}
+}
+
+func TestCreateRunCommand_CommitSHA(t *testing.T) {
+	testCases := []struct {
+		name          string
+		configVersion *tfe.ConfigurationVersion
+		expected      string
+	}{
+		{
+			name: "vcs",
+			configVersion: &tfe.ConfigurationVersion{
+				ID:                "cv-***",
+				Source:            tfe.ConfigurationSourceGithub,
+				IngressAttributes: &tfe.IngressAttributes{CommitSHA: "1a2b3c4d5e6f"},
+			},
+			expected: "1a2b3c4d5e6f",
+		},
+		{
+			name:          "uploaded",
+			configVersion: &tfe.ConfigurationVersion{ID: "cv-***", Source: tfe.ConfigurationSourceAPI},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, w)
+			runService := &RunLogReader{RunReader: RunReader{run: &tfe.Run{
+				ID:                   "run-***",
+				Status:               tfe.RunPlannedAndFinished,
+				Plan:                 &tfe.Plan{},
+				ConfigurationVersion: tc.configVersion,
+			}}}
+			cloudMockService.RunService = runService
+			meta := NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))
+
+			if code := (&CreateRunCommand{Meta: meta}).Run([]string{"-workspace=my-workspace"}); code != 0 {
+				t.Fatalf("expected 0 but received %d: %s", code, ui.ErrorWriter.String())
+			}
+			if id := runService.created.ConfigurationVersionID; id != "" {
+				t.Errorf("expected the run to be created without a configuration version but received %q", id)
+			}
+			if sha := outputValue(meta, "commit_sha"); sha != tc.expected {
+				t.Errorf("expected commit_sha %q but received %q", tc.expected, sha)
+			}
+		})
+	}
+}
+
+// discards runs blocked by a plan gate
+type GatedRunService struct {
+	RunLogReader
+	discarded *cloud.DiscardRunOptions
+}
+
+func (r *GatedRunService) DiscardRun(_ context.Context, options cloud.DiscardRunOptions) (*tfe.Run, error) {
+	r.discarded = &options
+	return &tfe.Run{ID: options.RunID, Status: tfe.RunDiscarded}, nil
+}
+
+func TestCreateRunCommand_PlanGates(t *testing.T) {
+	testCases := []struct {
+		name           string
+		args           []string
+		plan           *tfe.Plan
+		exitStatus     int
+		status         Status
+		expectError    string
+		expectDiscard  bool
+		expectNoCreate bool
+	}{
+		{
+			name:   "gates-off",
+			args:   []string{"-workspace=my-workspace"},
+			plan:   &tfe.Plan{ResourceDestructions: 1, HasChanges: true},
+			status: Success,
+		},
+		{
+			name:   "destroy-passes",
+			args:   []string{"-workspace=my-workspace", "-fail-on-destroy"},
+			plan:   &tfe.Plan{ResourceAdditions: 2, HasChanges: true},
+			status: Success,
+		},
+		{
+			name:          "destroy-blocked",
+			args:          []string{"-workspace=my-workspace", "-fail-on-destroy"},
+			plan:          &tfe.Plan{ResourceAdditions: 1, ResourceDestructions: 2, HasChanges: true},
+			exitStatus:    1,
+			status:        PolicyBlocked,
+			expectError:   "the plan destroys 2 resource(s) and -fail-on-destroy is set",
+			expectDiscard: true,
+		},
+		{
+			name:          "no-changes-blocked",
+			args:          []string{"-workspace=my-workspace", "-fail-on-no-changes"},
+			plan:          &tfe.Plan{},
+			exitStatus:    1,
+			status:        PolicyBlocked,
+			expectError:   "the plan has no resource changes and -fail-on-no-changes is set",
+			expectDiscard: true,
+		},
+		{
+			name:   "no-changes-passes",
+			args:   []string{"-workspace=my-workspace", "-fail-on-no-changes", "-fail-on-destroy"},
+			plan:   &tfe.Plan{ResourceChanges: 1, HasChanges: true},
+			status: Success,
+		},
+		{
+			name:          "detailed-exitcode",
+			args:          []string{"-workspace=my-workspace", "-fail-on-destroy", "-detailed-exitcode"},
+			plan:          &tfe.Plan{ResourceDestructions: 1, HasChanges: true},
+			exitStatus:    1,
+			status:        PolicyBlocked,
+			expectError:   "-fail-on-destroy is set",
+			expectDiscard: true,
+		},
+		{
+			name:           "async",
+			args:           []string{"-workspace=my-workspace", "-fail-on-no-changes", "-wait=false"},
+			plan:           &tfe.Plan{},
+			exitStatus:     1,
+			status:         Error,
+			expectError:    "cannot be used with -async-no-log or -wait=false",
+			expectNoCreate: true,
+		},
+		{
+			name:           "auto-apply",
+			args:           []string{"-workspace=my-workspace", "-fail-on-destroy", "-auto-apply"},
+			plan:           &tfe.Plan{},
+			exitStatus:     1,
+			status:         Error,
+			expectError:    "-auto-apply cannot be used with -fail-on-destroy or -fail-on-no-changes",
+			expectNoCreate: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, w)
+			runService := &GatedRunService{RunLogReader: RunLogReader{RunReader: RunReader{run: &tfe.Run{
+				ID:                   "run-***",
+				Status:               tfe.RunPlanned,
+				Actions:              &tfe.RunActions{IsDiscardable: true},
+				Plan:                 tc.plan,
+				ConfigurationVersion: &tfe.ConfigurationVersion{},
+			}}}}
+			cloudMockService.RunService = runService
+			meta := NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))
+
+			if code := (&CreateRunCommand{Meta: meta}).Run(tc.args); code != tc.exitStatus {
+				t.Fatalf("expected %d but received %d: %s", tc.exitStatus, code, ui.ErrorWriter.String())
+			}
+			if status := outputValue(meta, "status"); status != string(tc.status) {
+				t.Errorf("expected status %q but received %q", tc.status, status)
+			}
+			if !strings.Contains(ui.ErrorWriter.String(), tc.expectError) {
+				t.Errorf("expected error containing %q but received %q", tc.expectError, ui.ErrorWriter.String())
+			}
+			if tc.expectNoCreate {
+				return
+			}
+
+			// the plan is checked before the run can be applied
+			gated := strings.Contains(strings.Join(tc.args, " "), "-fail-on-")
+			if autoApply := runService.created.AutoApply; gated && (autoApply == nil || *autoApply) {
+				t.Errorf("expected a gated run to not be auto-applied")
+			}
+			if discarded := runService.discarded != nil; discarded != tc.expectDiscard {
+				t.Errorf("expected run discarded: %t but received: %t", tc.expectDiscard, discarded)
+			}
+			if tc.expectDiscard && outputValue(meta, "run_status") != string(tfe.RunDiscarded) {
+				t.Errorf("expected run_status %q but received %q", tfe.RunDiscarded, outputValue(meta, "run_status"))
+			}
+		})
+	}
+}
+
+func TestCreateRunCommand_DuplicateRun(t *testing.T) {
+	testCases := []struct {
+		name           string
+		duplicate      *tfe.Run
+		args           []string
+		exitStatus     int
+		expectedReused string
+	}{
+		{
+			name:           "created",
+			args:           []string{"-workspace=my-workspace", "-idempotency-key=job-123"},
+			expectedReused: "false",
+		},
+		{
+			name:           "reused",
+			duplicate:      &tfe.Run{ID: "run-***", Status: tfe.RunPlanning},
+			args:           []string{"-workspace=my-workspace", "-idempotency-key=job-123"},
+			expectedReused: "true",
+		},
+		{
+			name:       "workspace-tags",
+			args:       []string{"-workspace-tags=app", "-idempotency-key=job-123"},
+			exitStatus: 1,
+		},
+		{
+			name:      "without-key",
+			duplicate: &tfe.Run{ID: "run-***", Status: tfe.RunPlanning},
+			args:      []string{"-workspace=my-workspace", "-configuration_version=cv-***"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, w)
+			runService := &RunLogReader{RunReader: RunReader{
+				run: &tfe.Run{
+					ID:                   "run-***",
+					Status:               tfe.RunPlannedAndFinished,
+					Plan:                 &tfe.Plan{},
+					ConfigurationVersion: &tfe.ConfigurationVersion{},
+				},
+				duplicate: tc.duplicate,
+			}}
+			cloudMockService.RunService = runService
+			meta := NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))
+
+			if code := (&CreateRunCommand{Meta: meta}).Run(tc.args); code != tc.exitStatus {
+				t.Fatalf("expected %d but received %d: %s", tc.exitStatus, code, ui.ErrorWriter.String())
+			}
+			if reused := outputValue(meta, "run_reused"); reused != tc.expectedReused {
+				t.Errorf("expected run_reused %q but received %q", tc.expectedReused, reused)
+			}
+			if tc.exitStatus != 0 {
+				return
+			}
+
+			if tc.expectedReused == "true" {
+				if runService.created != nil || !runService.waited {
+					t.Errorf("expected the duplicate run to be waited on instead of creating a run")
+				}
+			} else if runService.created == nil {
+				t.Errorf("expected a run to be created")
+			} else if runService.created.IdempotencyKey != "" && runService.created.IdempotencyKey != "job-123" {
+				t.Errorf("expected a run with idempotency key %q but received %+v", "job-123", runService.created)
+			}
+			if runID := outputValue(meta, "run_id"); runID != "run-***" {
+				t.Errorf("expected run_id %q but received %q", "run-***", runID)
+			}
+		})
+	}
+}
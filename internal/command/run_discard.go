@@ -50,11 +50,16 @@ func (c *DiscardRunCommand) Run(args []string) int {
 		return 1
 	}
 
-	// first check if not able to discard run
-	if !run.Actions.IsDiscardable {
+	// the status the run was discarded from, run_status is the run's status after the discard
+	c.addOutput("pre_discard_run_status", string(run.Status))
+
+	// HCP Terraform only allows discarding runs waiting for confirmation or priority, explain why any other run
+	// cannot be discarded instead of surfacing the API's rejection
+	if run.Actions == nil || !run.Actions.IsDiscardable {
 		c.addOutput("status", string(Error))
+		c.addOutput("error_code", "not_discardable")
 		c.addRunDetails(run)
-		c.writer.ErrorResult(fmt.Sprintf("run: %s cannot be discarded", c.RunID))
+		c.writer.ErrorResult(discardUnavailable(run))
 		c.writer.OutputResult(c.closeOutput())
 		return 1
 	}
@@ -72,9 +77,9 @@ func (c *DiscardRunCommand) Run(args []string) int {
 		status := c.resolveStatus(discardErr)
 		c.addOutput("status", string(status))
 		c.addRunDetails(run)
-		c.writer.ErrorResult(fmt.Sprintf("error discarding run, '%s' in HCP Terraform: %s", c.RunID, discardErr.Error()))
+		c.errorResult(status, fmt.Sprintf("error discarding run, '%s' in HCP Terraform: %s", c.RunID, discardErr.Error()))
 		c.writer.OutputResult(c.closeOutput())
-		return 1
+		return c.exitCode(status)
 	}
 
 	c.addOutput("status", string(Success))
@@ -83,23 +88,38 @@ func (c *DiscardRunCommand) Run(args []string) int {
 	return 0
 }
 
+// explains why the run cannot be discarded based on its status
+func discardUnavailable(run *tfe.Run) string {
+	switch run.Status {
+	case tfe.RunDiscarded:
+		return fmt.Sprintf("run %s cannot be discarded, it has already been discarded", run.ID)
+	case tfe.RunConfirmed, tfe.RunQueuingApply, tfe.RunApplyQueued, tfe.RunPreApplyRunning, tfe.RunPreApplyCompleted, tfe.RunApplying, tfe.RunApplied:
+		return fmt.Sprintf("run %s cannot be discarded, it has already been confirmed and is %s", run.ID, run.Status)
+	case tfe.RunPlannedAndFinished, tfe.RunPlannedAndSaved, tfe.RunCanceled, tfe.RunErrored:
+		return fmt.Sprintf("run %s cannot be discarded, it has already finished with status %s and there is nothing to discard", run.ID, run.Status)
+	case tfe.RunPending, tfe.RunFetching, tfe.RunFetchingCompleted, tfe.RunQueuing, tfe.RunPrePlanRunning, tfe.RunPrePlanCompleted,
+		tfe.RunPlanQueued, tfe.RunPlanning, tfe.RunCostEstimating, tfe.RunPolicyChecking, tfe.RunPostPlanRunning:
+		return fmt.Sprintf("run %s cannot be discarded while it is %s, wait for it to await confirmation or stop it with `run cancel`", run.ID, run.Status)
+	default:
+		return fmt.Sprintf("run %s cannot be discarded in status %s, only runs waiting for confirmation or priority can be discarded", run.ID, run.Status)
+	}
+}
+
 func (c *DiscardRunCommand) addRunDetails(run *tfe.Run) {
 	if run == nil {
 		return
 	}
-	link, _ := c.cloud.RunLink(c.appCtx, c.organization, run)
-	if link != "" {
-		c.addOutput("run_link", link)
-	}
+	c.addRunLink(run)
 	c.addOutput("run_id", run.ID)
 	c.addOutput("run_status", string(run.Status))
+	c.addRunPayload(run)
 }
 
 func (c *DiscardRunCommand) Help() string {
 	helpText := `
 Usage: tfci [global options] run discard [options]
 
-	Skips any remaining work on runs that are paused waiting for confirmation or priority.
+	Skips any remaining work on runs that are paused waiting for confirmation or priority. The run is read first, and a run in any other status, e.g. still planning or already applied, fails with the not_discardable error code and a message explaining its status. The pre_discard_run_status output is the run's status before the discard.
 
 Global Options:
 
@@ -107,7 +127,7 @@ Global Options:
 
 	-token          The token used to authenticate with HCP Terraform. Defaults to reading "TF_API_TOKEN" environment variable.
 
-	-organization   HCP Terraform Organization Name.
+	-organization   HCP Terraform Organization Name. Also accepted as a command option, which overrides the global option for that command.
 
 Options:
 
@@ -0,0 +1,101 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+	"github.com/hashicorp/tfci/internal/environment"
+	"github.com/hashicorp/tfci/internal/writer"
+	"github.com/mitchellh/cli"
+)
+
+type DiscardRunService struct {
+	RunReader
+	discarded *cloud.DiscardRunOptions
+}
+
+func (r *DiscardRunService) DiscardRun(_ context.Context, options cloud.DiscardRunOptions) (*tfe.Run, error) {
+	r.discarded = &options
+	return &tfe.Run{ID: options.RunID, Status: tfe.RunDiscarded}, nil
+}
+
+func TestDiscardRunCommand(t *testing.T) {
+	testCases := []struct {
+		name              string
+		run               *tfe.Run
+		exitStatus        int
+		expectedRunStatus tfe.RunStatus
+		expectError       string
+	}{
+		{
+			name:              "planned",
+			run:               &tfe.Run{ID: "run-123", Status: tfe.RunPlanned, Actions: &tfe.RunActions{IsDiscardable: true}},
+			expectedRunStatus: tfe.RunDiscarded,
+		},
+		{
+			name:              "policy-checked",
+			run:               &tfe.Run{ID: "run-123", Status: tfe.RunPolicyChecked, Actions: &tfe.RunActions{IsDiscardable: true}},
+			expectedRunStatus: tfe.RunDiscarded,
+		},
+		{
+			name:              "applied",
+			run:               &tfe.Run{ID: "run-123", Status: tfe.RunApplied, Actions: &tfe.RunActions{}},
+			exitStatus:        1,
+			expectedRunStatus: tfe.RunApplied,
+			expectError:       "it has already been confirmed and is applied",
+		},
+		{
+			name:              "planning",
+			run:               &tfe.Run{ID: "run-123", Status: tfe.RunPlanning, Actions: &tfe.RunActions{IsCancelable: true}},
+			exitStatus:        1,
+			expectedRunStatus: tfe.RunPlanning,
+			expectError:       "cannot be discarded while it is planning",
+		},
+		{
+			name:              "discarded",
+			run:               &tfe.Run{ID: "run-123", Status: tfe.RunDiscarded, Actions: &tfe.RunActions{}},
+			exitStatus:        1,
+			expectedRunStatus: tfe.RunDiscarded,
+			expectError:       "it has already been discarded",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, w)
+			runService := &DiscardRunService{RunReader: RunReader{run: tc.run}}
+			cloudMockService.RunService = runService
+			meta := NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))
+
+			if code := (&DiscardRunCommand{Meta: meta}).Run([]string{"-run=run-123"}); code != tc.exitStatus {
+				t.Fatalf("expected %d but received %d: %s", tc.exitStatus, code, ui.ErrorWriter.String())
+			}
+			if discarded := runService.discarded != nil; discarded != (tc.exitStatus == 0) {
+				t.Errorf("expected the run to be discarded: %t but received %t", tc.exitStatus == 0, discarded)
+			}
+			if status := outputValue(meta, "pre_discard_run_status"); status != string(tc.run.Status) {
+				t.Errorf("expected pre_discard_run_status %q but received %q", tc.run.Status, status)
+			}
+			if status := outputValue(meta, "run_status"); status != string(tc.expectedRunStatus) {
+				t.Errorf("expected run_status %q but received %q", tc.expectedRunStatus, status)
+			}
+			if tc.expectError == "" {
+				return
+			}
+			if code := outputValue(meta, "error_code"); code != "not_discardable" {
+				t.Errorf("expected error_code %q but received %q", "not_discardable", code)
+			}
+			if !strings.Contains(ui.ErrorWriter.String(), tc.expectError) {
+				t.Errorf("expected error containing %q but received %q", tc.expectError, ui.ErrorWriter.String())
+			}
+		})
+	}
+}
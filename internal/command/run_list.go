@@ -0,0 +1,152 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+)
+
+type ListRunCommand struct {
+	*Meta
+
+	Workspace     string
+	WorkspaceTags string
+	Status        string
+	MaxItems      int
+}
+
+func (c *ListRunCommand) flags() *flag.FlagSet {
+	f := c.flagSet("run list")
+	f.StringVar(&c.Workspace, "workspace", "", "The name of the HCP Terraform Workspace.")
+	f.StringVar(&c.WorkspaceTags, "workspace-tags", "", "Comma-separated list of tags, lists runs for every workspace having all of the tags instead of a single -workspace.")
+	f.StringVar(&c.Status, "status", "", "Comma-separated list of run statuses to filter by. e.g. -status=planning,applied")
+	f.IntVar(&c.MaxItems, "max-items", 20, "Maximum number of runs to return, most recent first. 0 returns every run.")
+
+	return f
+}
+
+func (c *ListRunCommand) Run(args []string) int {
+	if err := c.setupCmd(args, c.flags(), c.requireOrganization(), requireWorkspaceOrTags(&c.Workspace, &c.WorkspaceTags)); err != nil {
+		return 1
+	}
+
+	if c.Workspace != "" && c.WorkspaceTags != "" {
+		c.addOutput("status", string(Error))
+		c.closeOutput()
+		c.writer.ErrorResult("-workspace-tags cannot be combined with -workspace")
+		return 1
+	}
+
+	if c.WorkspaceTags != "" {
+		return c.listTaggedRuns()
+	}
+
+	runs, listErr := c.cloud.ListRuns(c.appCtx, c.listRunsOptions(c.Workspace))
+	if listErr != nil {
+		status := c.resolveStatus(listErr)
+		c.addOutput("status", string(status))
+		c.closeOutput()
+		c.errorResult(status, fmt.Sprintf("error listing runs for workspace, '%s' in HCP Terraform: %s", c.Workspace, listErr.Error()))
+		return c.exitCode(status)
+	}
+
+	c.addOutput("status", string(Success))
+	c.addRunListDetails(runs)
+	c.writer.OutputResult(c.closeOutput())
+	return 0
+}
+
+func (c *ListRunCommand) listRunsOptions(workspace string) cloud.ListRunsOptions {
+	return cloud.ListRunsOptions{
+		Organization: c.organization,
+		Workspace:    workspace,
+		Status:       c.Status,
+		MaxItems:     c.MaxItems,
+	}
+}
+
+// lists runs for every workspace having the tags, -max-items applies to each workspace
+func (c *ListRunCommand) listTaggedRuns() int {
+	tags := parseWorkspaceTags(c.WorkspaceTags)
+	if len(tags) == 0 {
+		c.addOutput("status", string(Error))
+		c.closeOutput()
+		c.writer.ErrorResult("-workspace-tags requires at least one tag")
+		return 1
+	}
+
+	workspaces, err := c.resolveTaggedWorkspaces(tags)
+	if err != nil {
+		status := c.resolveStatus(err)
+		c.addOutput("status", string(status))
+		c.closeOutput()
+		c.errorResult(status, fmt.Sprintf("error selecting workspaces in HCP Terraform: %s", err.Error()))
+		return c.exitCode(status)
+	}
+
+	results := forEachWorkspace(workspaces, func(w *tfe.Workspace) *workspaceResult {
+		runs, listErr := c.cloud.ListRuns(c.appCtx, c.listRunsOptions(w.Name))
+		return &workspaceResult{workspace: w.Name, runs: runs, err: listErr}
+	})
+
+	status := c.addWorkspaceResults(results)
+	if status != Success {
+		c.writer.ErrorResult("error listing runs in HCP Terraform, see failed_workspaces for details")
+	}
+	c.writer.OutputResult(c.closeOutput())
+	return c.exitCode(status)
+}
+
+func (c *ListRunCommand) addRunListDetails(runs []*tfe.Run) {
+	runIDs := make([]string, 0, len(runs))
+	for _, run := range runs {
+		runIDs = append(runIDs, run.ID)
+	}
+	c.addOutput("run_ids", strings.Join(runIDs, ","))
+
+	c.addOutputWithOpts("payload", runs, &outputOpts{
+		stdOut:      false,
+		multiLine:   true,
+		platformOut: true,
+		fields:      c.payloadFields,
+	})
+}
+
+func (c *ListRunCommand) Help() string {
+	helpText := `
+Usage: tfci [global options] run list [options]
+
+	Returns a list of runs for the provided workspace, most recent first.
+
+Global Options:
+
+	-hostname       The hostname of a Terraform Enterprise installation, if using Terraform Enterprise. Defaults to "app.terraform.io".
+
+	-token          The token used to authenticate with HCP Terraform. Defaults to reading "TF_API_TOKEN" environment variable.
+
+	-organization   HCP Terraform Organization Name. Also accepted as a command option, which overrides the global option for that command.
+
+Options:
+
+	-workspace      The name of the HCP Terraform Workspace.
+
+	-workspace-tags Comma-separated list of tags, lists runs for every workspace having all of the tags instead of a single -workspace. The run_ids output has a line per workspace, e.g. my-workspace=run-1,run-2
+
+	-status         Comma-separated list of run statuses to filter by. e.g. -status=planning,applied
+
+	-max-items      Maximum number of runs to return, most recent first. Defaults to 20, 0 returns every run of the workspace.
+
+	-payload-fields Comma separated list of top-level fields to include in the payload output, e.g. id,status,created-at. Defaults to all fields.
+	`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ListRunCommand) Synopsis() string {
+	return "Returns a list of runs for the provided workspace"
+}
@@ -0,0 +1,138 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+)
+
+type LogsRunCommand struct {
+	*Meta
+
+	RunID string
+	Phase string
+}
+
+func (c *LogsRunCommand) flags() *flag.FlagSet {
+	f := c.flagSet("run logs")
+	f.StringVar(&c.RunID, "run", "", "Existing HCP Terraform Run ID to read the logs of.")
+	f.StringVar(&c.Phase, "phase", "", "The phase to read the log of: plan or apply. Defaults to the plan log, followed by the apply log when the run has started applying.")
+
+	return f
+}
+
+func (c *LogsRunCommand) Run(args []string) int {
+	if err := c.setupCmd(args, c.flags()); err != nil {
+		return 1
+	}
+
+	if c.RunID == "" {
+		c.addOutput("status", string(Error))
+		c.closeOutput()
+		c.writer.ErrorResult("reading run logs requires a valid run id")
+		return 1
+	}
+
+	if c.Phase != "" && c.Phase != cloud.LogPhasePlan && c.Phase != cloud.LogPhaseApply {
+		c.addOutput("status", string(Error))
+		c.closeOutput()
+		c.writer.ErrorResult(fmt.Sprintf("invalid -phase %q, must be one of: %s, %s", c.Phase, cloud.LogPhasePlan, cloud.LogPhaseApply))
+		return 1
+	}
+
+	run, err := c.cloud.GetRun(c.appCtx, cloud.GetRunOptions{
+		RunID: c.RunID,
+	})
+	if err != nil {
+		return c.logsError(run, err)
+	}
+
+	phase := c.Phase
+	if phase == "" {
+		phase = cloud.LogPhasePlan
+	}
+	if err := c.cloud.StreamRunLogs(c.appCtx, run, cloud.StreamLogOptions{Phase: phase}); err != nil {
+		return c.logsError(run, err)
+	}
+
+	// the plan has completed, the run may have continued on to apply
+	if c.Phase == "" {
+		if run, err = c.cloud.GetRun(c.appCtx, cloud.GetRunOptions{RunID: c.RunID}); err != nil {
+			return c.logsError(run, err)
+		}
+		if applyStarted(run) {
+			if err := c.cloud.StreamRunLogs(c.appCtx, run, cloud.StreamLogOptions{Phase: cloud.LogPhaseApply}); err != nil {
+				return c.logsError(run, err)
+			}
+		}
+	}
+
+	c.addOutput("status", string(Success))
+	c.addRunDetails(run)
+	c.writer.OutputResult(c.closeOutput())
+	return 0
+}
+
+func (c *LogsRunCommand) logsError(run *tfe.Run, err error) int {
+	status := c.resolveStatus(err)
+	c.addOutput("status", string(status))
+	c.addRunDetails(run)
+	c.errorResult(status, fmt.Sprintf("error reading logs of run, '%s' in HCP Terraform: %s", c.RunID, err.Error()))
+	c.writer.OutputResult(c.closeOutput())
+	return c.exitCode(status)
+}
+
+// reports whether the run has an apply log, including applies which errored or were canceled
+func applyStarted(run *tfe.Run) bool {
+	if run == nil || run.Apply == nil {
+		return false
+	}
+	switch run.Status {
+	case tfe.RunApplyQueued, tfe.RunApplying, tfe.RunApplied:
+		return true
+	}
+	return run.StatusTimestamps != nil && !run.StatusTimestamps.ApplyingAt.IsZero()
+}
+
+func (c *LogsRunCommand) addRunDetails(run *tfe.Run) {
+	if run == nil {
+		return
+	}
+
+	c.addRunLink(run)
+	c.addOutput("run_id", run.ID)
+	c.addOutput("run_status", string(run.Status))
+}
+
+func (c *LogsRunCommand) Help() string {
+	helpText := `
+Usage: tfci [global options] run logs [options]
+
+	Streams the plan and apply logs of an existing run to stdout. Logs of a phase that is still in progress are followed until the phase completes, honoring the -run-timeout global option.
+
+Global Options:
+
+	-hostname       The hostname of a Terraform Enterprise installation, if using Terraform Enterprise. Defaults to "app.terraform.io".
+
+	-token          The token used to authenticate with HCP Terraform. Defaults to reading "TF_API_TOKEN" environment variable.
+
+	-organization   HCP Terraform Organization Name. Also accepted as a command option, which overrides the global option for that command.
+
+Options:
+
+	-run            Existing HCP Terraform Run ID to read the logs of.
+
+	-phase          The phase to read the log of: plan or apply. Defaults to the plan log, followed by the apply log when the run has started applying.
+	`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *LogsRunCommand) Synopsis() string {
+	return "Streams the plan and apply logs of an existing run"
+}
@@ -0,0 +1,94 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+	"github.com/hashicorp/tfci/internal/environment"
+	"github.com/hashicorp/tfci/internal/writer"
+	"github.com/mitchellh/cli"
+)
+
+type LogsRunService struct {
+	RunReader
+	phases []string
+}
+
+func (r *LogsRunService) StreamRunLogs(_ context.Context, _ *tfe.Run, options cloud.StreamLogOptions) error {
+	r.phases = append(r.phases, options.Phase)
+	return nil
+}
+
+func TestLogsRunCommand(t *testing.T) {
+	planned := &tfe.Run{ID: "run-123", Status: tfe.RunPlanned, Plan: &tfe.Plan{ID: "plan-123"}, Apply: &tfe.Apply{ID: "apply-123"}}
+	applyErrored := &tfe.Run{
+		ID:               "run-123",
+		Status:           tfe.RunErrored,
+		Plan:             &tfe.Plan{ID: "plan-123"},
+		Apply:            &tfe.Apply{ID: "apply-123"},
+		StatusTimestamps: &tfe.RunStatusTimestamps{ApplyingAt: time.Now()},
+	}
+
+	testCases := []struct {
+		name         string
+		args         []string
+		run          *tfe.Run
+		exitStatus   int
+		expectPhases []string
+	}{
+		{
+			name:         "plan-only-run",
+			args:         []string{"-run=run-123"},
+			run:          planned,
+			expectPhases: []string{"plan"},
+		},
+		{
+			name:         "plan-and-apply",
+			args:         []string{"-run=run-123"},
+			run:          applyErrored,
+			expectPhases: []string{"plan", "apply"},
+		},
+		{
+			name:         "apply-phase",
+			args:         []string{"-run=run-123", "-phase=apply"},
+			run:          applyErrored,
+			expectPhases: []string{"apply"},
+		},
+		{
+			name:       "invalid-phase",
+			args:       []string{"-run=run-123", "-phase=policy"},
+			run:        planned,
+			exitStatus: 1,
+		},
+		{
+			name:       "missing-run",
+			run:        planned,
+			exitStatus: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, w)
+			runService := &LogsRunService{RunReader: RunReader{run: tc.run}}
+			cloudMockService.RunService = runService
+			meta := NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))
+
+			if code := (&LogsRunCommand{Meta: meta}).Run(tc.args); code != tc.exitStatus {
+				t.Fatalf("expected %d but received %d: %s", tc.exitStatus, code, ui.ErrorWriter.String())
+			}
+			if !reflect.DeepEqual(runService.phases, tc.expectPhases) {
+				t.Errorf("expected logs of phases %v but received %v", tc.expectPhases, runService.phases)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+	"github.com/hashicorp/tfci/internal/logging"
+)
+
+const defaultMaxRunRetries = 2
+
+// common network, throttling and provider timeout errors, matched case insensitively
+const defaultRetryPattern = `(?i)(i/o timeout|TLS handshake timeout|deadline exceeded|timed out|connection reset|connection refused|unexpected EOF|throttl|rate exceeded|too many requests|service unavailable|bad gateway|internal server error|try again later)`
+
+// compiles -retry-pattern, run level retries require waiting on the run and a single workspace
+func (c *CreateRunCommand) validateRunRetries() (*regexp.Regexp, error) {
+	if !c.RetryFailedRuns {
+		return nil, nil
+	}
+	if c.AsyncNoLog {
+		return nil, errors.New("-retry-failed-runs cannot be used with -async-no-log or -wait=false, as the run has not finished when the command returns")
+	}
+	if c.WorkspaceTags != "" {
+		return nil, errors.New("-retry-failed-runs cannot be combined with -workspace-tags")
+	}
+	if c.MaxRunRetries < 1 {
+		return nil, errors.New("-max-run-retries must be at least 1")
+	}
+	pattern, err := regexp.Compile(c.RetryPattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -retry-pattern: %w", err)
+	}
+	return pattern, nil
+}
+
+// creates the run, and with -retry-failed-runs creates a new run while the run errors with a transient failure
+func (c *CreateRunCommand) createRun(runVars []*tfe.RunVariable, retryPattern *regexp.Regexp) (*tfe.Run, error) {
+	for attempt := 1; ; attempt++ {
+		// a run created by a previous attempt of the command is only reused for the first run
+		run, err := c.createOrReuseRun(runVars, attempt == 1)
+		if run != nil && !c.AsyncNoLog {
+			c.readPlanLogs(run)
+		}
+		if retryPattern == nil {
+			return run, err
+		}
+
+		if err == nil || attempt > c.MaxRunRetries || !c.isTransientFailure(run, retryPattern) {
+			c.addOutput("run_attempts", fmt.Sprint(attempt))
+			return run, err
+		}
+		c.writer.Output(fmt.Sprintf("Run %s errored with a transient failure, creating a new run (retry %d of %d)", run.ID, attempt, c.MaxRunRetries))
+	}
+}
+
+// creates the run, or with -idempotency-key waits on the run a previous attempt of the command created
+func (c *CreateRunCommand) createOrReuseRun(runVars []*tfe.RunVariable, reuse bool) (*tfe.Run, error) {
+	if reuse && c.IdempotencyKey != "" {
+		if duplicate := c.findDuplicateRun(runVars); duplicate != nil {
+			c.writer.Output(fmt.Sprintf("Reusing Run ID: %q (status: %q), created by a previous attempt of the command", duplicate.ID, duplicate.Status))
+			if c.AsyncNoLog {
+				return duplicate, nil
+			}
+			return c.cloud.WaitRun(c.appCtx, cloud.WaitRunOptions{RunID: duplicate.ID})
+		}
+	}
+	return c.cloud.CreateRun(c.appCtx, c.createRunOptions(c.Workspace, runVars))
+}
+
+// only errored runs are retried, when their log matches the pattern
+func (c *CreateRunCommand) isTransientFailure(run *tfe.Run, retryPattern *regexp.Regexp) bool {
+	if run == nil || run.Status != tfe.RunErrored {
+		return false
+	}
+	runLog, err := c.cloud.ReadRunLog(c.appCtx, run)
+	if err != nil {
+		logging.Warn("Failed to read run log, the run is not retried", "run_id", run.ID, "error", err)
+		return false
+	}
+	if match := retryPattern.FindString(errorDiagnostics(runLog)); match != "" {
+		logging.Debug("Run log matched -retry-pattern", "run_id", run.ID, "match", match)
+		return true
+	}
+	return false
+}
+
+// a line of the structured logs HCP Terraform writes for runs, eg.
+// {"@level":"error","@message":"Error: ...","diagnostic":{"severity":"error","summary":"...","detail":"..."},"type":"diagnostic"}
+type jsonLogLine struct {
+	Level      string `json:"@level"`
+	Message    string `json:"@message"`
+	Diagnostic *struct {
+		Summary string `json:"summary"`
+		Detail  string `json:"detail"`
+	} `json:"diagnostic"`
+}
+
+// returns the lines of the error diagnostics in a log, so the pattern is not matched against
+// planned changes, eg. a resource attribute named timeout
+func errorDiagnostics(runLog string) string {
+	lines := []string{}
+	for _, line := range strings.Split(runLog, "\n") {
+		trimmed := strings.TrimSpace(line)
+		// runs using structured logs write a JSON object per line
+		if strings.HasPrefix(trimmed, "{") {
+			var logLine jsonLogLine
+			if err := json.Unmarshal([]byte(trimmed), &logLine); err == nil {
+				if logLine.Level != "error" {
+					continue
+				}
+				if logLine.Diagnostic == nil {
+					lines = append(lines, logLine.Message)
+					continue
+				}
+				lines = append(lines, "Error: "+logLine.Diagnostic.Summary, logLine.Diagnostic.Detail)
+				continue
+			}
+		}
+		// diagnostics are rendered in a box, eg. "│ Error: ..."
+		if strings.HasPrefix(trimmed, "│") || strings.HasPrefix(trimmed, "Error:") {
+			lines = append(lines, trimmed)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
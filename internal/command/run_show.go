@@ -10,33 +10,50 @@ import (
 
 	"github.com/hashicorp/go-tfe"
 	"github.com/hashicorp/tfci/internal/cloud"
+	"github.com/hashicorp/tfci/internal/logging"
 )
 
 type ShowRunCommand struct {
 	*Meta
 
-	RunID string
+	RunID     string
+	Workspace string
+	Logs      bool
+	Tail      bool
+	Watch     bool
 }
 
 func (c *ShowRunCommand) flags() *flag.FlagSet {
 	f := c.flagSet("run show")
 	f.StringVar(&c.RunID, "run", "", "Existing HCP Terraform Run ID to show.")
+	f.StringVar(&c.Workspace, "workspace", "", "Shows the current run of the HCP Terraform Workspace instead of a -run.")
+	f.BoolVar(&c.Logs, "logs", false, "Streams the log of the run's current plan or apply until it completes.")
+	f.BoolVar(&c.Tail, "tail", false, "Streams only new log output from the point of attaching, skipping historical output. Implies -logs.")
+	f.BoolVar(&c.Watch, "watch", false, "Polls the run until it reaches a final status, printing each status transition.")
 
 	return f
 }
 
 func (c *ShowRunCommand) Run(args []string) int {
-	if err := c.setupCmd(args, c.flags()); err != nil {
+	if err := c.setupCmd(args, c.flags(), c.requireOrganizationForWorkspace(&c.Workspace), requireRunOrWorkspace(&c.RunID, &c.Workspace)); err != nil {
 		return 1
 	}
 
-	if c.RunID == "" {
+	// both follow the run until it completes, the log already reports the run's progress
+	if c.Watch && (c.Logs || c.Tail) {
 		c.addOutput("status", string(Error))
 		c.closeOutput()
-		c.writer.ErrorResult("showing a run requires a valid run id")
+		c.writer.ErrorResult("-watch cannot be combined with -logs or -tail")
 		return 1
 	}
 
+	// -run takes precedence, the workspace is only read when no run is provided
+	if c.RunID == "" {
+		if status, done := c.resolveCurrentRun(); done {
+			return c.exitCode(status)
+		}
+	}
+
 	// fetch run
 	run, err := c.cloud.GetRun(c.appCtx, cloud.GetRunOptions{
 		RunID: c.RunID,
@@ -46,9 +63,45 @@ func (c *ShowRunCommand) Run(args []string) int {
 		status := c.resolveStatus(err)
 		c.addOutput("status", string(status))
 		c.addRunDetails(run)
-		c.writer.ErrorResult(fmt.Sprintf("error showing run, '%s' in HCP Terraform: %s", c.RunID, err.Error()))
+		c.errorResult(status, fmt.Sprintf("error showing run, '%s' in HCP Terraform: %s", c.RunID, err.Error()))
 		c.writer.OutputResult(c.closeOutput())
-		return 1
+		return c.exitCode(status)
+	}
+
+	if c.Logs || c.Tail {
+		latest, refreshErr := c.streamLogs(run)
+		if refreshErr != nil {
+			status := c.resolveStatus(refreshErr)
+			c.addOutput("status", string(status))
+			c.addRunDetails(run)
+			c.errorResult(status, fmt.Sprintf("error reading run, '%s' in HCP Terraform after streaming its logs: %s", c.RunID, refreshErr.Error()))
+			c.writer.OutputResult(c.closeOutput())
+			return c.exitCode(status)
+		}
+		run = latest
+	}
+
+	if c.Watch {
+		latest, watchErr := c.cloud.WatchRun(c.appCtx, cloud.WatchRunOptions{RunID: c.RunID})
+		if latest != nil {
+			run = latest
+		}
+		if watchErr != nil {
+			status := c.resolveStatus(watchErr)
+			c.addOutput("status", string(status))
+			c.addRunDetails(run)
+			c.errorResult(status, fmt.Sprintf("error watching run, '%s' in HCP Terraform: %s", c.RunID, watchErr.Error()))
+			c.writer.OutputResult(c.closeOutput())
+			return c.exitCode(status)
+		}
+	}
+
+	if policyErr := c.addPolicyResults(run); policyErr != nil {
+		c.addOutput("status", string(Error))
+		c.addRunDetails(run)
+		c.writer.ErrorResult(policyErr.Error())
+		c.writer.OutputResult(c.closeOutput())
+		return ExitError
 	}
 
 	c.addOutput("status", string(Success))
@@ -57,22 +110,67 @@ func (c *ShowRunCommand) Run(args []string) int {
 	return 0
 }
 
+// sets the run id to the workspace's current run, returns true with the command status and writes outputs if
+// the workspace is unable to be read or has no runs yet
+func (c *ShowRunCommand) resolveCurrentRun() (Status, bool) {
+	workspace, err := c.cloud.GetWorkspace(c.appCtx, c.organization, c.Workspace)
+	if err != nil {
+		status := c.resolveStatus(err)
+		c.addOutput("status", string(status))
+		c.errorResult(status, fmt.Sprintf("error reading workspace, '%s' in HCP Terraform: %s", c.Workspace, err.Error()))
+		c.writer.OutputResult(c.closeOutput())
+		return status, true
+	}
+
+	if workspace.CurrentRun == nil || workspace.CurrentRun.ID == "" {
+		c.addOutput("status", string(NotFound))
+		c.addOutput("run_id", "")
+		c.writer.Output(fmt.Sprintf("Workspace %q has no runs yet", c.Workspace))
+		c.writer.OutputResult(c.closeOutput())
+		return NotFound, true
+	}
+
+	c.RunID = workspace.CurrentRun.ID
+	c.writer.Output(fmt.Sprintf("Showing current run %q of workspace %q", c.RunID, c.Workspace))
+	return Success, false
+}
+
+// streams logs for an in-progress run, returning the latest run details once the log completes. A failure to
+// stream is reported and the run details are kept, a failure to read the run after streaming is returned
+func (c *ShowRunCommand) streamLogs(run *tfe.Run) (*tfe.Run, error) {
+	if logErr := c.cloud.StreamRunLogs(c.appCtx, run, cloud.StreamLogOptions{Tail: c.Tail}); logErr != nil {
+		c.writer.ErrorResult(fmt.Sprintf("failed to stream run logs: %s", logErr.Error()))
+		return run, nil
+	}
+
+	return c.cloud.GetRun(c.appCtx, cloud.GetRunOptions{
+		RunID: c.RunID,
+	})
+}
+
 func (c *ShowRunCommand) addRunDetails(run *tfe.Run) {
 	if run == nil {
 		return
 	}
 
-	runLink, _ := c.cloud.RunLink(c.appCtx, c.organization, run)
+	runLink := c.addRunLink(run)
 	if runLink != "" {
-		c.addOutput("run_link", runLink)
+		c.addLogURLs(runLink, run)
 	}
+	c.writeRunSummary(run, runLink)
 	c.addOutput("run_id", run.ID)
 	c.addOutput("run_status", string(run.Status))
 	c.addOutput("run_message", run.Message)
 	c.addOutput("plan_id", run.Plan.ID)
 	c.addOutput("plan_status", string(run.Plan.Status))
 	c.addOutput("configuration_version_id", run.ConfigurationVersion.ID)
+	c.addIngressDetails(run.ConfigurationVersion.ID)
+	if run.Status == tfe.RunApplied {
+		c.addConfigurationPromotion(run)
+	}
+	c.addRunTiming(run)
 
+	c.addCostEstimate(run)
 	if run.CostEstimate != nil {
 		c.addOutput("cost_estimation_id", run.CostEstimate.ID)
 		c.addOutput("cost_estimation_status", string(run.CostEstimate.Status))
@@ -81,18 +179,63 @@ func (c *ShowRunCommand) addRunDetails(run *tfe.Run) {
 		}
 	}
 
-	c.addOutputWithOpts("payload", run, &outputOpts{
-		stdOut:      false,
-		multiLine:   true,
+	c.addRunPayload(run)
+}
+
+// adds links to the plan and apply logs in the HCP Terraform UI. The authenticated log read urls
+// expire and must not be shared, so they are intentionally not emitted.
+func (c *ShowRunCommand) addLogURLs(runLink string, run *tfe.Run) {
+	if run.Plan != nil {
+		c.addOutput("plan_log_url", runLink+"#plan")
+	}
+	if hasStartedApply(run) {
+		c.addOutput("apply_log_url", runLink+"#apply")
+	}
+}
+
+// reports whether the run has an apply with log output, plan-only and unconfirmed runs do not
+func hasStartedApply(run *tfe.Run) bool {
+	if run.Apply == nil || run.PlanOnly {
+		return false
+	}
+	switch run.Status {
+	case tfe.RunApplyQueued, tfe.RunApplying, tfe.RunApplied:
+		return true
+	}
+	// errored or canceled during the apply
+	return run.StatusTimestamps != nil && !run.StatusTimestamps.ApplyingAt.IsZero()
+}
+
+// adds the commit details when the run's configuration version was sourced from VCS
+func (c *ShowRunCommand) addIngressDetails(configVersionID string) {
+	if configVersionID == "" {
+		return
+	}
+
+	ingress, err := c.cloud.GetIngressAttributes(c.appCtx, configVersionID)
+	if err != nil {
+		logging.Warn("Unable to read configuration version ingress attributes", "configuration_version_id", configVersionID, "error", err)
+		return
+	}
+	if ingress == nil {
+		return
+	}
+
+	c.addOutputWithOpts("commit_message", ingress.CommitMessage, &outputOpts{
+		stdOut:      true,
+		multiLine:   strings.Contains(ingress.CommitMessage, "\n"),
 		platformOut: true,
 	})
+	c.addOutput("commit_sha", ingress.CommitSHA)
+	// the user whose VCS event created the configuration version, the commit's author is not part of the ingress attributes
+	c.addOutput("commit_sender", ingress.SenderUsername)
 }
 
 func (c *ShowRunCommand) Help() string {
 	helpText := `
 Usage: tfci [global options] run show [options]
 
-	Returns run details for the provided HCP Terraform run ID.
+	Returns run details for the provided HCP Terraform run ID, or the current run of a workspace.
 
 Global Options:
 
@@ -100,11 +243,21 @@ Global Options:
 
 	-token          The token used to authenticate with HCP Terraform. Defaults to reading "TF_API_TOKEN" environment variable.
 
-	-organization   HCP Terraform Organization Name.
+	-organization   HCP Terraform Organization Name. Also accepted as a command option, which overrides the global option for that command.
 
 Options:
 
 	-run            Existing HCP Terraform Run ID to show.
+
+	-workspace      Shows the current run of the HCP Terraform Workspace instead of a -run, the latest run which is not speculative. -run takes precedence when both are set. A workspace without runs reports the status not_found.
+
+	-logs           Streams the log of the run's current plan or apply until it completes.
+
+	-tail           Streams only new log output from the point of attaching, skipping historical output. Implies -logs.
+
+	-watch          Polls the run every -poll-interval until it is applied, errored, canceled, discarded or finished without an apply, printing each status transition with the time it was observed. A run paused for confirmation is watched until it is confirmed or discarded, up to the -run-timeout. Cannot be combined with -logs or -tail.
+
+	-payload-fields Comma separated list of top-level fields to include in the payload output, e.g. id,status,created-at. Defaults to all fields.
 	`
 	return strings.TrimSpace(helpText)
 }
@@ -0,0 +1,326 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+	"github.com/hashicorp/tfci/internal/environment"
+	"github.com/hashicorp/tfci/internal/writer"
+	"github.com/mitchellh/cli"
+)
+
+// records the id of the run read
+type RunIDReader struct {
+	RunReader
+	runID string
+}
+
+func (r *RunIDReader) GetRun(_ context.Context, options cloud.GetRunOptions) (*tfe.Run, error) {
+	r.runID = options.RunID
+	return &tfe.Run{ID: options.RunID, Plan: &tfe.Plan{}, ConfigurationVersion: &tfe.ConfigurationVersion{}}, nil
+}
+
+func TestShowRunCommand_Workspace(t *testing.T) {
+	testCases := []struct {
+		name      string
+		args      []string
+		workspace *tfe.Workspace
+		// no organization is set
+		noOrg  bool
+		want   int
+		runID  string
+		status string
+	}{
+		{
+			name:      "current-run",
+			args:      []string{"-workspace=my-workspace"},
+			workspace: &tfe.Workspace{Name: "my-workspace", CurrentRun: &tfe.Run{ID: "run-current"}},
+			want:      0,
+			runID:     "run-current",
+			status:    string(Success),
+		},
+		{
+			name:      "run-takes-precedence",
+			args:      []string{"-run=run-123", "-workspace=my-workspace"},
+			workspace: &tfe.Workspace{Name: "my-workspace", CurrentRun: &tfe.Run{ID: "run-current"}},
+			want:      0,
+			runID:     "run-123",
+			status:    string(Success),
+		},
+		{
+			name:      "no-runs",
+			args:      []string{"-workspace=my-workspace"},
+			workspace: &tfe.Workspace{Name: "my-workspace"},
+			want:      0,
+			status:    string(NotFound),
+		},
+		{
+			name:   "run-without-organization",
+			args:   []string{"-run=run-123"},
+			noOrg:  true,
+			want:   0,
+			runID:  "run-123",
+			status: string(Success),
+		},
+		{
+			name:   "missing-run-and-workspace",
+			want:   1,
+			status: string(Error),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			cloudService := cloud.NewCloud(&tfe.Client{}, w)
+			runService := &RunIDReader{}
+			cloudService.RunService = runService
+			cloudService.WorkspaceService = &WorkspaceReader{workspace: tc.workspace}
+			opts := []func(*Meta){WithWriter(w)}
+			if !tc.noOrg {
+				opts = append(opts, WithOrg("hashicorp"))
+			}
+			meta := NewMetaOpts(context.Background(), cloudService, &environment.CI{}, opts...)
+
+			if code := (&ShowRunCommand{Meta: meta}).Run(tc.args); code != tc.want {
+				t.Fatalf("expected %d but received %d: %s", tc.want, code, ui.ErrorWriter.String())
+			}
+			if runService.runID != tc.runID {
+				t.Errorf("expected run %q to be read but received %q", tc.runID, runService.runID)
+			}
+			if runID := outputValue(meta, "run_id"); runID != tc.runID {
+				t.Errorf("expected run_id %q but received %q", tc.runID, runID)
+			}
+			if status := outputValue(meta, "status"); status != tc.status {
+				t.Errorf("expected status %q but received %q", tc.status, status)
+			}
+		})
+	}
+}
+
+// returns the run in a final status once watched
+type RunWatcher struct {
+	RunIDReader
+	watched bool
+}
+
+func (r *RunWatcher) WatchRun(_ context.Context, options cloud.WatchRunOptions) (*tfe.Run, error) {
+	r.watched = true
+	return &tfe.Run{ID: options.RunID, Status: tfe.RunErrored, Plan: &tfe.Plan{}, ConfigurationVersion: &tfe.ConfigurationVersion{}}, nil
+}
+
+func TestShowRunCommand_Watch(t *testing.T) {
+	testCases := []struct {
+		name    string
+		args    []string
+		want    int
+		watched bool
+		status  string
+	}{
+		{
+			name:    "watched",
+			args:    []string{"-run=run-123", "-watch"},
+			want:    0,
+			watched: true,
+			status:  string(tfe.RunErrored),
+		},
+		{
+			name: "combined-with-logs",
+			args: []string{"-run=run-123", "-watch", "-logs"},
+			want: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			cloudService := cloud.NewCloud(&tfe.Client{}, w)
+			runService := &RunWatcher{}
+			cloudService.RunService = runService
+			meta := NewMetaOpts(context.Background(), cloudService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))
+
+			if code := (&ShowRunCommand{Meta: meta}).Run(tc.args); code != tc.want {
+				t.Fatalf("expected %d but received %d: %s", tc.want, code, ui.ErrorWriter.String())
+			}
+			if runService.watched != tc.watched {
+				t.Errorf("expected watched %t but received %t", tc.watched, runService.watched)
+			}
+			// the outputs are written once, from the run in its final status
+			if status := outputValue(meta, "run_status"); status != tc.status {
+				t.Errorf("expected run_status %q but received %q", tc.status, status)
+			}
+		})
+	}
+}
+
+func TestShowRunCommand_LogURLs(t *testing.T) {
+	runLink := "https://app.terraform.io/app/hashicorp/workspaces/my-workspace/runs/run-123"
+	appliedAt := &tfe.RunStatusTimestamps{ApplyingAt: time.Date(2026, 10, 15, 11, 0, 0, 0, time.UTC)}
+
+	testCases := []struct {
+		name     string
+		run      *tfe.Run
+		planURL  string
+		applyURL string
+	}{
+		{
+			name: "without-plan",
+			run:  &tfe.Run{Status: tfe.RunPending},
+		},
+		{
+			name:    "planned",
+			run:     &tfe.Run{Status: tfe.RunPlanned, Plan: &tfe.Plan{}, Apply: &tfe.Apply{}},
+			planURL: runLink + "#plan",
+		},
+		{
+			name:     "applying",
+			run:      &tfe.Run{Status: tfe.RunApplying, Plan: &tfe.Plan{}, Apply: &tfe.Apply{}},
+			planURL:  runLink + "#plan",
+			applyURL: runLink + "#apply",
+		},
+		{
+			name:     "applied",
+			run:      &tfe.Run{Status: tfe.RunApplied, Plan: &tfe.Plan{}, Apply: &tfe.Apply{}},
+			planURL:  runLink + "#plan",
+			applyURL: runLink + "#apply",
+		},
+		{
+			name:     "errored-during-apply",
+			run:      &tfe.Run{Status: tfe.RunErrored, Plan: &tfe.Plan{}, Apply: &tfe.Apply{}, StatusTimestamps: appliedAt},
+			planURL:  runLink + "#plan",
+			applyURL: runLink + "#apply",
+		},
+		{
+			name:    "errored-during-plan",
+			run:     &tfe.Run{Status: tfe.RunErrored, Plan: &tfe.Plan{}, Apply: &tfe.Apply{}, StatusTimestamps: &tfe.RunStatusTimestamps{}},
+			planURL: runLink + "#plan",
+		},
+		{
+			name:    "plan-only",
+			run:     &tfe.Run{Status: tfe.RunApplied, Plan: &tfe.Plan{}, Apply: &tfe.Apply{}, PlanOnly: true},
+			planURL: runLink + "#plan",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := writer.NewWriter(cli.NewMockUi())
+			meta := NewMetaOpts(context.Background(), cloud.NewCloud(&tfe.Client{}, w), &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))
+			cmd := &ShowRunCommand{Meta: meta}
+
+			cmd.addLogURLs(runLink, tc.run)
+
+			if planURL := outputValue(meta, "plan_log_url"); planURL != tc.planURL {
+				t.Errorf("expected plan_log_url %q but received %q", tc.planURL, planURL)
+			}
+			if applyURL := outputValue(meta, "apply_log_url"); applyURL != tc.applyURL {
+				t.Errorf("expected apply_log_url %q but received %q", tc.applyURL, applyURL)
+			}
+		})
+	}
+}
+
+// fails reading the run after its logs were streamed
+type RefreshFailedRunService struct {
+	LogsRunService
+	reads int
+}
+
+func (r *RefreshFailedRunService) GetRun(_ context.Context, _ cloud.GetRunOptions) (*tfe.Run, error) {
+	r.reads++
+	if r.reads > 1 {
+		return nil, errors.New("503 Service Unavailable")
+	}
+	return r.run, nil
+}
+
+func TestShowRunCommand_LogsRefreshFailed(t *testing.T) {
+	ui := cli.NewMockUi()
+	w := writer.NewWriter(ui)
+	cloudMockService := cloud.NewCloud(&tfe.Client{}, w)
+	runService := &RefreshFailedRunService{LogsRunService: LogsRunService{RunReader: RunReader{run: &tfe.Run{
+		ID:                   "run-123",
+		Status:               tfe.RunPlanning,
+		Plan:                 &tfe.Plan{},
+		ConfigurationVersion: &tfe.ConfigurationVersion{},
+	}}}}
+	cloudMockService.RunService = runService
+	meta := NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))
+	cmd := &ShowRunCommand{Meta: meta}
+
+	if code := cmd.Run([]string{"-run=run-123", "-logs"}); code != ExitError {
+		t.Fatalf("expected %d but received %d", ExitError, code)
+	}
+	if status := outputValue(meta, "status"); status != string(Error) {
+		t.Errorf("expected status %q but received %q", Error, status)
+	}
+	if runID := outputValue(meta, "run_id"); runID != "run-123" {
+		t.Errorf("expected run_id %q from the run read before streaming but received %q", "run-123", runID)
+	}
+	if stderr := ui.ErrorWriter.String(); !strings.Contains(stderr, "after streaming its logs: 503 Service Unavailable") {
+		t.Errorf("expected the read error to be reported, received: %q", stderr)
+	}
+}
+
+func TestShowRunCommand_IngressDetails(t *testing.T) {
+	testCases := []struct {
+		name     string
+		ingress  *tfe.IngressAttributes
+		expected map[string]string
+	}{
+		{
+			name: "vcs-sourced",
+			ingress: &tfe.IngressAttributes{
+				CommitMessage:  "Add bucket",
+				CommitSHA:      "abc123",
+				SenderUsername: "octocat",
+			},
+			expected: map[string]string{
+				"commit_message": "Add bucket",
+				"commit_sha":     "abc123",
+				"commit_sender":  "octocat",
+			},
+		},
+		{
+			name: "uploaded",
+			expected: map[string]string{
+				"commit_message": "",
+				"commit_sha":     "",
+				"commit_sender":  "",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := writer.NewWriter(cli.NewMockUi())
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, w)
+			cloudMockService.RunService = &RunReader{run: &tfe.Run{
+				ID:                   "run-123",
+				Plan:                 &tfe.Plan{},
+				ConfigurationVersion: &tfe.ConfigurationVersion{ID: "cv-123"},
+			}}
+			cloudMockService.ConfigVersionService = &SuccessfulUploader{ingress: tc.ingress}
+			meta := NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))
+
+			if code := (&ShowRunCommand{Meta: meta}).Run([]string{"-run=run-123"}); code != 0 {
+				t.Fatalf("expected %d but received %d", 0, code)
+			}
+			for name, expected := range tc.expected {
+				if actual := outputValue(meta, name); actual != expected {
+					t.Errorf("expected %s %q but received %q", name, expected, actual)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/logging"
+)
+
+// builds a compact markdown summary of the run for the platform's job summary page
+func runSummaryMarkdown(run *tfe.Run, runLink string) string {
+	var b strings.Builder
+
+	title := run.ID
+	if runLink != "" {
+		title = fmt.Sprintf("[%s](%s)", run.ID, runLink)
+	}
+	fmt.Fprintf(&b, "### HCP Terraform run %s\n\n", title)
+	b.WriteString("| Status | Plan | Triggered by |\n")
+	b.WriteString("| --- | --- | --- |\n")
+
+	plan := "-"
+	if run.Plan != nil && run.Plan.Status == tfe.PlanFinished {
+		plan = fmt.Sprintf("%d to add, %d to change, %d to destroy", run.Plan.ResourceAdditions, run.Plan.ResourceChanges, run.Plan.ResourceDestructions)
+	}
+	fmt.Fprintf(&b, "| `%s` | %s | %s |\n\n", run.Status, plan, runTriggeredBy(run))
+	return b.String()
+}
+
+// the user that created the run, falling back to the run's source when the user is unavailable
+func runTriggeredBy(run *tfe.Run) string {
+	if run.CreatedBy != nil && run.CreatedBy.Username != "" {
+		return run.CreatedBy.Username
+	}
+	if run.Source != "" {
+		return string(run.Source)
+	}
+	return "-"
+}
+
+// writes the run summary to the platform's job summary page, a failure is only a warning
+func (c *Meta) writeRunSummary(run *tfe.Run, runLink string) {
+	if c.env == nil || run == nil {
+		return
+	}
+	if err := c.env.WriteStepSummary(runSummaryMarkdown(run, runLink)); err != nil {
+		logging.Warn("Failed to write run summary to the job summary", "run_id", run.ID, "error", err)
+	}
+}
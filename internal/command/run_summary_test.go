@@ -0,0 +1,88 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+	"github.com/hashicorp/tfci/internal/environment"
+	"github.com/hashicorp/tfci/internal/writer"
+	"github.com/mitchellh/cli"
+)
+
+type SummaryContext struct {
+	environment.Common
+	summary string
+	err     error
+}
+
+func (s *SummaryContext) WriteStepSummary(markdown string) error {
+	s.summary += markdown
+	return s.err
+}
+
+func (s *SummaryContext) SetOutput(_ environment.OutputMap) {}
+
+func (s *SummaryContext) CloseOutput() error {
+	return nil
+}
+
+func TestShowRunCommand_StepSummary(t *testing.T) {
+	run := &tfe.Run{
+		ID:     "run-123",
+		Status: tfe.RunPlanned,
+		Plan: &tfe.Plan{
+			Status:               tfe.PlanFinished,
+			ResourceAdditions:    1,
+			ResourceChanges:      2,
+			ResourceDestructions: 3,
+		},
+		ConfigurationVersion: &tfe.ConfigurationVersion{},
+		CreatedBy:            &tfe.User{Username: "octocat"},
+	}
+
+	testCases := []struct {
+		name       string
+		summaryErr error
+	}{
+		{
+			name: "written",
+		},
+		{
+			name:       "write-failure-is-not-fatal",
+			summaryErr: errors.New("permission denied"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			writer := writer.NewWriter(ui)
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, writer)
+			cloudMockService.RunService = &RunReader{run: run}
+			summaryCtx := &SummaryContext{err: tc.summaryErr}
+
+			meta := NewMetaOpts(context.Background(), cloudMockService, &environment.CI{Context: summaryCtx}, WithWriter(writer), WithOrg("hashicorp"))
+			cmd := &ShowRunCommand{Meta: meta}
+
+			if code := cmd.Run([]string{"-run=run-123"}); code != 0 {
+				t.Fatalf("expected %d but received %d: %s", 0, code, ui.ErrorWriter.String())
+			}
+
+			for _, expected := range []string{
+				"### HCP Terraform run run-123",
+				"| `planned` | 1 to add, 2 to change, 3 to destroy | octocat |",
+			} {
+				if !strings.Contains(summaryCtx.summary, expected) {
+					t.Errorf("expected summary to contain %q but received %q", expected, summaryCtx.summary)
+				}
+			}
+		})
+	}
+}
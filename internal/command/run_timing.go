@@ -0,0 +1,90 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+// adds how long the run spent queued, planning and applying, eg. for SLO tracking. A duration is empty when the
+// phase has not completed or never ran, such as the apply of a plan-only run
+func (c *Meta) addRunTiming(run *tfe.Run) {
+	ts := run.StatusTimestamps
+	if ts == nil {
+		ts = &tfe.RunStatusTimestamps{}
+	}
+
+	// queued from creation, including waiting on other runs of the workspace, until the plan started
+	c.addOutput("queued_duration_ms", durationMs(run.CreatedAt, ts.PlanningAt))
+
+	planEnd := firstTime(ts.PlannedAt, ts.PlannedAndFinishedAt, ts.PlannedAndSavedAt)
+	if planEnd.IsZero() && ts.ApplyingAt.IsZero() {
+		// errored or canceled while planning
+		planEnd = firstTime(ts.ErroredAt, ts.CanceledAt, ts.ForceCanceledAt)
+	}
+	c.addOutput("plan_duration_ms", durationMs(ts.PlanningAt, planEnd))
+
+	applyEnd := ts.AppliedAt
+	if applyEnd.IsZero() && !ts.ApplyingAt.IsZero() {
+		applyEnd = firstTime(ts.ErroredAt, ts.CanceledAt, ts.ForceCanceledAt)
+	}
+	c.addOutput("apply_duration_ms", durationMs(ts.ApplyingAt, applyEnd))
+
+	c.addOutputWithOpts("timing_payload", runTimestamps(run), &outputOpts{
+		stdOut:      false,
+		multiLine:   true,
+		platformOut: true,
+	})
+}
+
+// milliseconds between the times, empty when either is unset
+func durationMs(start time.Time, end time.Time) string {
+	if start.IsZero() || end.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(end.Sub(start).Milliseconds(), 10)
+}
+
+// the earliest of the times which are set
+func firstTime(times ...time.Time) time.Time {
+	first := time.Time{}
+	for _, t := range times {
+		if !t.IsZero() && (first.IsZero() || t.Before(first)) {
+			first = t
+		}
+	}
+	return first
+}
+
+// the run's creation and status timestamps in RFC 3339, keyed like `created_at` and `plan_queued_at`. Statuses
+// the run never reached are omitted
+func runTimestamps(run *tfe.Run) map[string]string {
+	timestamps := map[string]string{}
+	if !run.CreatedAt.IsZero() {
+		timestamps["created_at"] = run.CreatedAt.Format(time.RFC3339)
+	}
+	if run.StatusTimestamps == nil {
+		return timestamps
+	}
+
+	// keyed by the API's attribute names, eg. `jsonapi:"attr,plan-queued-at,rfc3339"`, so new statuses are included
+	v := reflect.ValueOf(*run.StatusTimestamps)
+	for i := 0; i < v.NumField(); i++ {
+		t, ok := v.Field(i).Interface().(time.Time)
+		if !ok || t.IsZero() {
+			continue
+		}
+		tag := strings.Split(v.Type().Field(i).Tag.Get("jsonapi"), ",")
+		if len(tag) < 2 {
+			continue
+		}
+		timestamps[strings.ReplaceAll(tag[1], "-", "_")] = t.Format(time.RFC3339)
+	}
+	return timestamps
+}
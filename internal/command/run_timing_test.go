@@ -0,0 +1,123 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+	"github.com/hashicorp/tfci/internal/environment"
+	"github.com/hashicorp/tfci/internal/writer"
+	"github.com/mitchellh/cli"
+)
+
+func TestShowRunCommand_RunTiming(t *testing.T) {
+	created := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return created.Add(time.Duration(seconds) * time.Second) }
+
+	testCases := []struct {
+		name       string
+		status     tfe.RunStatus
+		timestamps *tfe.RunStatusTimestamps
+		expected   map[string]string
+	}{
+		{
+			name:   "applied",
+			status: tfe.RunApplied,
+			timestamps: &tfe.RunStatusTimestamps{
+				PlanQueuedAt: at(5),
+				PlanningAt:   at(30),
+				PlannedAt:    at(90),
+				ConfirmedAt:  at(100),
+				ApplyingAt:   at(110),
+				AppliedAt:    at(200),
+			},
+			expected: map[string]string{
+				"queued_duration_ms": "30000",
+				"plan_duration_ms":   "60000",
+				"apply_duration_ms":  "90000",
+			},
+		},
+		{
+			name:   "plan-only",
+			status: tfe.RunPlannedAndFinished,
+			timestamps: &tfe.RunStatusTimestamps{
+				PlanningAt:           at(1),
+				PlannedAndFinishedAt: at(3),
+			},
+			expected: map[string]string{
+				"queued_duration_ms": "1000",
+				"plan_duration_ms":   "2000",
+				"apply_duration_ms":  "",
+			},
+		},
+		{
+			name:   "errored-while-planning",
+			status: tfe.RunErrored,
+			timestamps: &tfe.RunStatusTimestamps{
+				PlanningAt: at(1),
+				ErroredAt:  at(4),
+			},
+			expected: map[string]string{
+				"queued_duration_ms": "1000",
+				"plan_duration_ms":   "3000",
+				"apply_duration_ms":  "",
+			},
+		},
+		{
+			name:   "pending",
+			status: tfe.RunPending,
+			expected: map[string]string{
+				"queued_duration_ms": "",
+				"plan_duration_ms":   "",
+				"apply_duration_ms":  "",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			cloudService := cloud.NewCloud(&tfe.Client{}, w)
+			cloudService.RunService = &RunReader{run: &tfe.Run{
+				ID:                   "run-123",
+				Status:               tc.status,
+				CreatedAt:            created,
+				StatusTimestamps:     tc.timestamps,
+				Plan:                 &tfe.Plan{},
+				ConfigurationVersion: &tfe.ConfigurationVersion{},
+			}}
+			cloudService.ConfigVersionService = &SuccessfulUploader{}
+			meta := NewMetaOpts(context.Background(), cloudService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))
+
+			if code := (&ShowRunCommand{Meta: meta}).Run([]string{"-run=run-123"}); code != 0 {
+				t.Fatalf("expected 0 but received %d: %s", code, ui.ErrorWriter.String())
+			}
+			for name, expected := range tc.expected {
+				if actual := outputValue(meta, name); actual != expected {
+					t.Errorf("expected %s %q but received %q", name, expected, actual)
+				}
+			}
+
+			timestamps := map[string]string{}
+			if err := json.Unmarshal([]byte(outputValue(meta, "timing_payload")), &timestamps); err != nil {
+				t.Fatalf("expected timing_payload to be JSON: %s", err)
+			}
+			if timestamps["created_at"] != "2026-10-15T12:00:00Z" {
+				t.Errorf("expected created_at %q but received %q", "2026-10-15T12:00:00Z", timestamps["created_at"])
+			}
+			if tc.timestamps != nil && timestamps["planning_at"] != tc.timestamps.PlanningAt.Format(time.RFC3339) {
+				t.Errorf("expected planning_at %q but received %v", tc.timestamps.PlanningAt.Format(time.RFC3339), timestamps)
+			}
+			if _, ok := timestamps["applied_at"]; ok != (tc.name == "applied") {
+				t.Errorf("expected applied_at only once the run applied, received %v", timestamps)
+			}
+		})
+	}
+}
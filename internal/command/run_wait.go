@@ -0,0 +1,101 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+)
+
+type WaitRunCommand struct {
+	*Meta
+
+	RunID string
+}
+
+func (c *WaitRunCommand) flags() *flag.FlagSet {
+	f := c.flagSet("run wait")
+	f.StringVar(&c.RunID, "run", "", "Existing HCP Terraform Run ID to wait on.")
+
+	return f
+}
+
+func (c *WaitRunCommand) Run(args []string) int {
+	if err := c.setupCmd(args, c.flags()); err != nil {
+		return 1
+	}
+
+	if c.RunID == "" {
+		c.addOutput("status", string(Error))
+		c.closeOutput()
+		c.writer.ErrorResult("waiting on a run requires a valid run id")
+		return 1
+	}
+
+	run, err := c.cloud.WaitRun(c.appCtx, cloud.WaitRunOptions{
+		RunID: c.RunID,
+	})
+
+	if err != nil {
+		status := c.resolveStatus(err)
+		c.addOutput("status", string(status))
+		c.addRunDetails(run)
+		c.errorResult(status, fmt.Sprintf("error waiting on run, '%s' in HCP Terraform: %s", c.RunID, err.Error()))
+		c.writer.OutputResult(c.closeOutput())
+		return c.exitCode(status)
+	}
+
+	c.addOutput("status", string(Success))
+	c.addRunDetails(run)
+	c.writer.OutputResult(c.closeOutput())
+	return 0
+}
+
+func (c *WaitRunCommand) addRunDetails(run *tfe.Run) {
+	if run == nil {
+		return
+	}
+
+	c.addRunLink(run)
+	c.addOutput("run_id", run.ID)
+	c.addOutput("run_status", string(run.Status))
+	c.addOutput("run_message", run.Message)
+	if run.Plan != nil {
+		c.addOutput("plan_id", run.Plan.ID)
+		c.addOutput("plan_status", string(run.Plan.Status))
+	}
+
+	c.addRunPayload(run)
+}
+
+func (c *WaitRunCommand) Help() string {
+	helpText := `
+Usage: tfci [global options] run wait [options]
+
+	Waits on an existing run until it completes, honoring the -poll-interval and -run-timeout global options. Returns a non-zero exit code when the run errored or was canceled.
+
+Global Options:
+
+	-hostname       The hostname of a Terraform Enterprise installation, if using Terraform Enterprise. Defaults to "app.terraform.io".
+
+	-token          The token used to authenticate with HCP Terraform. Defaults to reading "TF_API_TOKEN" environment variable.
+
+	-organization   HCP Terraform Organization Name. Also accepted as a command option, which overrides the global option for that command.
+
+Options:
+
+	-run            Existing HCP Terraform Run ID to wait on.
+
+	-payload-fields Comma separated list of top-level fields to include in the payload output, e.g. id,status,created-at. Defaults to all fields.
+	`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *WaitRunCommand) Synopsis() string {
+	return "Waits on an existing run until it completes"
+}
@@ -0,0 +1,118 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+type ShowStateCommand struct {
+	*Meta
+
+	Workspace string
+	SaveState string
+}
+
+func (c *ShowStateCommand) flags() *flag.FlagSet {
+	f := c.flagSet("state show")
+	f.StringVar(&c.Workspace, "workspace", "", "The name of the HCP Terraform Workspace.")
+	f.StringVar(&c.SaveState, "save-state", "", "Path to write the raw state of the current state version to.")
+
+	return f
+}
+
+func (c *ShowStateCommand) Run(args []string) int {
+	if err := c.setupCmd(args, c.flags(), c.requireOrganization(), requireWorkspace(&c.Workspace)); err != nil {
+		return 1
+	}
+	if err := c.checkOutputFiles(c.SaveState); err != nil {
+		return 1
+	}
+
+	sv, svErr := c.cloud.ReadCurrentStateVersion(c.appCtx, c.organization, c.Workspace)
+	if svErr != nil {
+		status := c.resolveStatus(svErr)
+		c.addOutput("status", string(status))
+		c.closeOutput()
+		c.errorResult(status, fmt.Sprintf("error reading current state version for workspace, '%s' in HCP Terraform: %s", c.Workspace, svErr.Error()))
+		return c.exitCode(status)
+	}
+
+	if c.SaveState != "" {
+		if err := c.saveState(sv); err != nil {
+			status := c.resolveStatus(err)
+			c.addOutput("status", string(status))
+			c.addStateDetails(sv)
+			c.errorResult(status, fmt.Sprintf("error saving state version '%s': %s", sv.ID, err.Error()))
+			c.writer.OutputResult(c.closeOutput())
+			return c.exitCode(status)
+		}
+	}
+
+	c.addOutput("status", string(Success))
+	c.addStateDetails(sv)
+	c.writer.OutputResult(c.closeOutput())
+	return 0
+}
+
+// writes the raw state to -save-state, only the path is ever logged as the state may contain secrets
+func (c *ShowStateCommand) saveState(sv *tfe.StateVersion) error {
+	state, err := c.cloud.DownloadState(c.appCtx, sv)
+	if err != nil {
+		return err
+	}
+	if err := writePrivateFile(c.SaveState, state); err != nil {
+		c.addOutput("error_code", "io_error")
+		return err
+	}
+	c.writer.Output(fmt.Sprintf("Saved state version %s to: %s", sv.ID, c.SaveState))
+	c.addOutput("state_path", c.SaveState)
+	return nil
+}
+
+func (c *ShowStateCommand) addStateDetails(sv *tfe.StateVersion) {
+	if sv == nil {
+		return
+	}
+	c.addOutput("state_version_id", sv.ID)
+	c.addOutput("state_serial", fmt.Sprint(sv.Serial))
+	// the download url is pre-signed and grants access to the state without a token
+	c.addOutputWithOpts("state_download_url", sv.DownloadURL, &outputOpts{
+		stdOut:      false,
+		multiLine:   false,
+		platformOut: true,
+		sensitive:   true,
+	})
+}
+
+func (c *ShowStateCommand) Help() string {
+	helpText := `
+Usage: tfci [global options] state show [options]
+
+	Returns the current state version of a workspace, optionally saving the raw state to a file.
+
+Global Options:
+
+	-hostname       The hostname of a Terraform Enterprise installation, if using Terraform Enterprise. Defaults to "app.terraform.io".
+
+	-token          The token used to authenticate with HCP Terraform. Defaults to reading "TF_API_TOKEN" environment variable.
+
+	-organization   HCP Terraform Organization Name. Also accepted as a command option, which overrides the global option for that command.
+
+Options:
+
+	-workspace      Existing HCP Terraform Workspace.
+
+	-save-state     Path to write the raw state of the current state version to. The file is only readable by the current user, as state may contain secrets.
+	`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ShowStateCommand) Synopsis() string {
+	return "Returns the current state version of a workspace"
+}
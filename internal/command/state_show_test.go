@@ -0,0 +1,63 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+	"github.com/hashicorp/tfci/internal/environment"
+	"github.com/hashicorp/tfci/internal/writer"
+	"github.com/mitchellh/cli"
+)
+
+func TestShowStateCommand_SaveState(t *testing.T) {
+	state := `{"version":4,"serial":7,"outputs":{"db_password":{"value":"hunter2","sensitive":true}}}`
+	path := filepath.Join(t.TempDir(), "terraform.tfstate")
+
+	ui := cli.NewMockUi()
+	writer := writer.NewWriter(ui)
+	cloudMockService := cloud.NewCloud(&tfe.Client{}, writer)
+	cloudMockService.WorkspaceService = &WorkspaceReader{
+		stateVersion: &tfe.StateVersion{ID: "sv-***", Serial: 7, DownloadURL: "https://archivist.terraform.io/v1/object/***"},
+		state:        []byte(state),
+	}
+	meta := NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(writer), WithOrg("hashicorp"))
+	cmd := &ShowStateCommand{Meta: meta}
+
+	if code := cmd.Run([]string{"-workspace=my-workspace", "-save-state=" + path}); code != 0 {
+		t.Fatalf("expected %d but received %d: %s", 0, code, ui.ErrorWriter.String())
+	}
+
+	expected := map[string]string{
+		"state_version_id":   "sv-***",
+		"state_serial":       "7",
+		"state_download_url": "https://archivist.terraform.io/v1/object/***",
+		"state_path":         path,
+	}
+	for name, value := range expected {
+		if actual := outputValue(meta, name); actual != value {
+			t.Errorf("expected %s %q but received %q", name, value, actual)
+		}
+	}
+	if !meta.messages["state_download_url"].Sensitive() {
+		t.Errorf("expected state_download_url to be sensitive")
+	}
+
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(saved) != state {
+		t.Errorf("expected saved state %s but received %s", state, saved)
+	}
+	if strings.Contains(ui.OutputWriter.String()+ui.ErrorWriter.String(), "hunter2") {
+		t.Errorf("expected state contents not to be logged")
+	}
+}
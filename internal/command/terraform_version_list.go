@@ -0,0 +1,98 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+type ListTerraformVersionsCommand struct {
+	*Meta
+
+	Version string
+}
+
+func (c *ListTerraformVersionsCommand) flags() *flag.FlagSet {
+	f := c.flagSet("tf-version list")
+	f.StringVar(&c.Version, "version", "", "Terraform version to validate is available, eg. 1.9.5.")
+
+	return f
+}
+
+func (c *ListTerraformVersionsCommand) Run(args []string) int {
+	if err := c.setupCmd(args, c.flags()); err != nil {
+		return 1
+	}
+
+	versions, vErr := c.cloud.ListTerraformVersions(c.appCtx)
+	if vErr != nil {
+		status := c.resolveStatus(vErr)
+		c.addOutput("status", string(status))
+		c.closeOutput()
+		c.errorResult(status, fmt.Sprintf("error listing Terraform versions: %s", vErr.Error()))
+		return c.exitCode(status)
+	}
+
+	// disabled versions cannot be selected for a workspace
+	available := []string{}
+	for _, v := range versions {
+		if v.Enabled {
+			available = append(available, v.Version)
+		}
+	}
+
+	c.addOutputWithOpts("terraform_versions", available, &outputOpts{
+		stdOut:      true,
+		multiLine:   true,
+		platformOut: true,
+	})
+
+	if c.Version != "" {
+		found := false
+		for _, v := range available {
+			if v == c.Version {
+				found = true
+				break
+			}
+		}
+		c.addOutput("version_available", fmt.Sprint(found))
+		if !found {
+			c.addOutput("status", string(Error))
+			c.writer.ErrorResult(fmt.Sprintf("Terraform version %q is not available, it may be disabled or not installed on this instance", c.Version))
+			c.writer.OutputResult(c.closeOutput())
+			return 1
+		}
+	}
+
+	c.addOutput("status", string(Success))
+	c.writer.OutputResult(c.closeOutput())
+	return 0
+}
+
+func (c *ListTerraformVersionsCommand) Help() string {
+	helpText := `
+Usage: tfci [global options] tf-version list [options]
+
+	Returns the Terraform versions available on a Terraform Enterprise instance, optionally validating a given version is available. Requires a site admin token, the admin API is not available on HCP Terraform.
+
+Global Options:
+
+	-hostname       The hostname of a Terraform Enterprise installation, if using Terraform Enterprise. Defaults to "app.terraform.io".
+
+	-token          The token used to authenticate with HCP Terraform. Defaults to reading "TF_API_TOKEN" environment variable.
+
+	-organization   HCP Terraform Organization Name. Also accepted as a command option, which overrides the global option for that command.
+
+Options:
+
+	-version        Terraform version to validate is available, eg. 1.9.5. The command fails when the version is disabled or not installed.
+	`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ListTerraformVersionsCommand) Synopsis() string {
+	return "Returns the Terraform versions available on a Terraform Enterprise instance"
+}
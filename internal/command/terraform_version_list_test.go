@@ -0,0 +1,97 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+	"github.com/hashicorp/tfci/internal/environment"
+	"github.com/hashicorp/tfci/internal/writer"
+	"github.com/mitchellh/cli"
+)
+
+type TerraformVersionReader struct {
+	versions []*tfe.AdminTerraformVersion
+	err      error
+}
+
+func (r *TerraformVersionReader) ListTerraformVersions(_ context.Context) ([]*tfe.AdminTerraformVersion, error) {
+	return r.versions, r.err
+}
+
+func TestListTerraformVersionsCommand(t *testing.T) {
+	versions := []*tfe.AdminTerraformVersion{
+		{Version: "1.9.5", Enabled: true},
+		{Version: "1.10.0-beta1", Enabled: true, Beta: true},
+		{Version: "0.12.31", Enabled: false, Deprecated: true},
+	}
+
+	testCases := []struct {
+		name              string
+		args              []string
+		err               error
+		expectedCode      int
+		expectedVersions  string
+		expectedAvailable string
+		expectedErrorCode string
+	}{
+		{
+			name:             "list",
+			expectedVersions: `["1.9.5","1.10.0-beta1"]`,
+		},
+		{
+			name:              "version-available",
+			args:              []string{"-version=1.9.5"},
+			expectedVersions:  `["1.9.5","1.10.0-beta1"]`,
+			expectedAvailable: "true",
+		},
+		{
+			name:              "version-disabled",
+			args:              []string{"-version=0.12.31"},
+			expectedCode:      1,
+			expectedVersions:  `["1.9.5","1.10.0-beta1"]`,
+			expectedAvailable: "false",
+		},
+		{
+			name:              "admin-required",
+			err:               &cloud.AdminAccessRequiredError{},
+			expectedCode:      1,
+			expectedErrorCode: "admin_required",
+		},
+		{
+			name:         "list-error",
+			err:          errors.New("bad gateway"),
+			expectedCode: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			writer := writer.NewWriter(ui)
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, writer)
+			cloudMockService.TerraformVersionService = &TerraformVersionReader{versions: versions, err: tc.err}
+			meta := NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(writer), WithOrg("hashicorp"))
+			cmd := &ListTerraformVersionsCommand{Meta: meta}
+
+			if code := cmd.Run(tc.args); code != tc.expectedCode {
+				t.Fatalf("expected %d but received %d: %s", tc.expectedCode, code, ui.ErrorWriter.String())
+			}
+
+			if actual := outputValue(meta, "terraform_versions"); actual != tc.expectedVersions {
+				t.Errorf("expected terraform_versions %s but received %s", tc.expectedVersions, actual)
+			}
+			if actual := outputValue(meta, "version_available"); actual != tc.expectedAvailable {
+				t.Errorf("expected version_available %q but received %q", tc.expectedAvailable, actual)
+			}
+			if actual := outputValue(meta, "error_code"); actual != tc.expectedErrorCode {
+				t.Errorf("expected error_code %q but received %q", tc.expectedErrorCode, actual)
+			}
+		})
+	}
+}
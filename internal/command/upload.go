@@ -4,9 +4,13 @@
 package command
 
 import (
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/hashicorp/go-tfe"
@@ -18,56 +22,136 @@ type UploadConfigurationCommand struct {
 	*Meta
 	Workspace   string
 	Directory   string
+	Archive     string
 	Speculative bool
 	Provisional bool
+	// fails before any api work when the directory has no terraform files, eg. a mistyped path
+	RequireTFFiles bool
+	// creates the workspace when it does not exist, eg. for per pull request workspaces
+	CreateWorkspace  bool
+	Project          string
+	ExecutionMode    string
+	TerraformVersion string
+	// json file mapping configuration directories to workspaces, uploaded concurrently
+	WorkspaceMap string
+	// glob patterns of paths to leave out of the packed directory, in addition to .terraformignore
+	Excludes []string
+	// pending configuration version to resume a failed upload into, eg. when retrying the pipeline step
+	ConfigurationVersion string
 }
 
+var executionModes = []string{"remote", "local", "agent"}
+
 func (c *UploadConfigurationCommand) flags() *flag.FlagSet {
 	f := c.flagSet("upload")
 
 	f.StringVar(&c.Workspace, "workspace", "", "The name of the workspace to create the new configuration version in.")
+	f.StringVar(&c.WorkspaceMap, "workspace-map", "", "Path to a JSON file mapping configuration directories to workspace names, each directory is uploaded to its workspace concurrently.")
 	f.StringVar(&c.Directory, "directory", "", "Path to the configuration files on disk.")
+	f.StringVar(&c.Archive, "archive", "", "Path to a pre-built .tar.gz of the configuration files, uploaded as is instead of packing -directory.")
+	f.StringVar(&c.ConfigurationVersion, "configuration-version", "", "ID of a pending configuration version to resume a failed upload into, instead of creating a new configuration version.")
+	f.Var((*flagVarSlice)(&c.Excludes), "exclude", "Glob pattern of paths relative to -directory to leave out of the upload, in addition to .terraformignore. You can use this option multiple times. e.g. -exclude='**/*.tfvars'")
 	f.BoolVar(&c.Speculative, "speculative", false, "When true, this configuration version may only be used to create runs which are speculative, that is, can neither be confirmed nor applied.")
 	f.BoolVar(&c.Provisional, "provisional", false, "When true, this configuration version does not immediately become the workspace's current configuration until a run referencing it is ultimately applied.")
+	f.BoolVar(&c.RequireTFFiles, "require-tf-files", false, "Fails before uploading when -directory does not contain any .tf or .tf.json files at its top level.")
+	f.BoolVar(&c.CreateWorkspace, "create-workspace", false, "Creates the workspace if it does not exist.")
+	f.StringVar(&c.Project, "workspace-project", "", "The project name or ID to create the workspace in, requires -create-workspace.")
+	f.StringVar(&c.ExecutionMode, "execution-mode", "", "The execution mode of the created workspace: remote, local or agent, requires -create-workspace.")
+	f.StringVar(&c.TerraformVersion, "terraform-version", "", "The Terraform version of the created workspace, requires -create-workspace.")
 	return f
 }
 
 func (c *UploadConfigurationCommand) Run(args []string) int {
-	if err := c.setupCmd(args, c.flags()); err != nil {
+	if err := c.setupCmd(args, c.flags(), c.requireOrganization(), requireWorkspaceOrMap(&c.Workspace, &c.WorkspaceMap)); err != nil {
 		return 1
 	}
 
+	if c.WorkspaceMap != "" {
+		return c.runWorkspaceMap()
+	}
+
 	logging.Debug("Uploading configuration", 
 		"workspace", c.Workspace,
 		"directory", c.Directory,
+		"archive", c.Archive,
+		"excludes", c.Excludes,
+		"configuration_version", c.ConfigurationVersion,
 		"speculative", c.Speculative,
 		"provisional", c.Provisional)
 
-	dirPath, dirError := filepath.Abs(c.Directory)
-	if dirError != nil {
+	if err := c.validateSource(); err != nil {
+		c.addOutput("status", string(Error))
+		c.closeOutput()
+		c.writer.ErrorResult(err.Error())
+		return 1
+	}
+
+	if err := c.validateWorkspaceOptions(); err != nil {
 		c.addOutput("status", string(Error))
 		c.closeOutput()
-		c.writer.ErrorResult(fmt.Sprintf("error resolving directory path %s", dirError.Error()))
+		c.writer.ErrorResult(err.Error())
 		return 1
 	}
 
-	logging.Debug("Target directory for configuration upload", "path", dirPath)
+	if err := c.checkTerraformFiles(); err != nil {
+		c.addOutput("status", string(Error))
+		c.closeOutput()
+		c.writer.ErrorResult(err.Error())
+		return 1
+	}
+
+	if c.CreateWorkspace {
+		workspace, wErr := c.ensureWorkspace(c.Workspace)
+		if wErr != nil {
+			status := c.resolveStatus(wErr)
+			c.addOutput("status", string(status))
+			c.errorResult(status, fmt.Sprintf("error creating workspace in HCP Terraform: %s", wErr.Error()))
+			c.writer.OutputResult(c.closeOutput())
+			return c.exitCode(status)
+		}
+		c.addOutput("workspace_id", workspace.ID)
+	}
 
-	configVersion, cvError := c.cloud.UploadConfig(c.appCtx, cloud.UploadOptions{
+	uploadOpts := cloud.UploadOptions{
 		Workspace:              c.Workspace,
 		Organization:           c.organization,
-		ConfigurationDirectory: dirPath,
 		Speculative:            c.Speculative,
 		Provisional:            c.Provisional,
-	})
+		Excludes:               c.Excludes,
+		ConfigurationVersionID: c.ConfigurationVersion,
+	}
+	if c.Archive != "" {
+		archivePath, archiveError := filepath.Abs(c.Archive)
+		if archiveError != nil {
+			c.addOutput("status", string(Error))
+			c.closeOutput()
+			c.writer.ErrorResult(fmt.Sprintf("error resolving archive path %s", archiveError.Error()))
+			return 1
+		}
+		logging.Debug("Target archive for configuration upload", "path", archivePath)
+		uploadOpts.ConfigurationArchive = archivePath
+	} else {
+		dirPath, dirError := filepath.Abs(c.Directory)
+		if dirError != nil {
+			c.addOutput("status", string(Error))
+			c.closeOutput()
+			c.writer.ErrorResult(fmt.Sprintf("error resolving directory path %s", dirError.Error()))
+			return 1
+		}
+		logging.Debug("Target directory for configuration upload", "path", dirPath)
+		uploadOpts.ConfigurationDirectory = dirPath
+		uploadOpts.Excludes = c.uploadExcludes(dirPath)
+	}
+
+	configVersion, cvError := c.cloud.UploadConfig(c.appCtx, uploadOpts)
 
 	if cvError != nil {
 		status := c.resolveStatus(cvError)
 		c.addOutput("status", string(status))
 		c.addConfigurationDetails(configVersion)
-		c.writer.ErrorResult(fmt.Sprintf("error uploading configuration version to HCP Terraform: %s", cvError.Error()))
+		c.errorResult(status, fmt.Sprintf("error uploading configuration version to HCP Terraform: %s", cvError.Error()))
 		c.writer.OutputResult(c.closeOutput())
-		return 1
+		return c.exitCode(status)
 	}
 
 	c.addOutput("status", string(Success))
@@ -76,6 +160,236 @@ func (c *UploadConfigurationCommand) Run(args []string) int {
 	return 0
 }
 
+// the configuration is either packed from -directory, the current directory by default, or uploaded from a pre-built -archive
+func (c *UploadConfigurationCommand) validateSource() error {
+	if c.Directory != "" && c.Archive != "" {
+		return errors.New("-archive cannot be combined with -directory")
+	}
+	if c.RequireTFFiles && c.Archive != "" {
+		return errors.New("-require-tf-files cannot be combined with -archive")
+	}
+	if len(c.Excludes) > 0 && c.Archive != "" {
+		return errors.New("-exclude cannot be combined with -archive, which is uploaded as is")
+	}
+	// the options of a resumed configuration version were set when it was created
+	if c.ConfigurationVersion != "" && (c.Speculative || c.Provisional || c.CreateWorkspace) {
+		return errors.New("-configuration-version cannot be combined with -speculative, -provisional or -create-workspace, as the configuration version already exists")
+	}
+	return nil
+}
+
+// counts the terraform files at the top level of -directory, failing when there are none and
+// -require-tf-files is set. Modules in subdirectories are not counted.
+func (c *UploadConfigurationCommand) checkTerraformFiles() error {
+	if c.Archive != "" {
+		return nil
+	}
+	dir := c.Directory
+	if dir == "" {
+		dir = "."
+	}
+	return c.checkDirectoryTerraformFiles(dir)
+}
+
+func (c *UploadConfigurationCommand) checkDirectoryTerraformFiles(dir string) error {
+	if dir == "" {
+		return nil
+	}
+
+	count, err := countTerraformFiles(dir)
+	if err != nil {
+		if c.RequireTFFiles {
+			return fmt.Errorf("unable to check configuration directory %q for terraform files: %w", dir, err)
+		}
+		logging.Debug("Unable to count terraform files in configuration directory", "directory", dir, "error", err)
+		return nil
+	}
+	logging.Debug("Terraform files found in configuration directory", "directory", dir, "count", count)
+
+	if count == 0 && c.RequireTFFiles {
+		return fmt.Errorf("no .tf or .tf.json files found at the top level of %q, verify -directory points at the terraform configuration", dir)
+	}
+	return nil
+}
+
+func countTerraformFiles(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if name := entry.Name(); strings.HasSuffix(name, ".tf") || strings.HasSuffix(name, ".tf.json") {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (c *UploadConfigurationCommand) validateWorkspaceOptions() error {
+	if !c.CreateWorkspace {
+		if c.Project != "" || c.ExecutionMode != "" || c.TerraformVersion != "" {
+			return errors.New("-workspace-project, -execution-mode and -terraform-version require -create-workspace")
+		}
+		return nil
+	}
+	if c.ExecutionMode != "" && !slices.Contains(executionModes, c.ExecutionMode) {
+		return fmt.Errorf("invalid -execution-mode %q, must be one of: %s", c.ExecutionMode, strings.Join(executionModes, ", "))
+	}
+	return nil
+}
+
+// returns the existing workspace, creating it when it does not exist
+func (c *UploadConfigurationCommand) ensureWorkspace(name string) (*tfe.Workspace, error) {
+	workspace, err := c.cloud.GetWorkspace(c.appCtx, c.organization, name)
+	if err == nil {
+		return workspace, nil
+	}
+
+	var notFoundErr *cloud.WorkspaceNotFoundError
+	if !errors.As(err, &notFoundErr) {
+		return nil, err
+	}
+
+	c.writer.Output(fmt.Sprintf("Workspace %q does not exist, creating it", name))
+	return c.cloud.CreateWorkspace(c.appCtx, cloud.CreateWorkspaceOptions{
+		Organization:     c.organization,
+		Name:             name,
+		Project:          c.Project,
+		ExecutionMode:    c.ExecutionMode,
+		TerraformVersion: c.TerraformVersion,
+	})
+}
+
+// returns the -exclude patterns, with the platform output file when it is written under the packed directory, eg.
+// the Bitbucket dotenv in the clone directory, so outputs are never uploaded with the configuration
+func (c *UploadConfigurationCommand) uploadExcludes(dir string) []string {
+	if c.env == nil || c.env.Context == nil || c.env.OutputPath() == "" {
+		return c.Excludes
+	}
+	outputPath, err := filepath.Abs(c.env.OutputPath())
+	if err != nil {
+		return c.Excludes
+	}
+	rel, err := filepath.Rel(dir, outputPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return c.Excludes
+	}
+	logging.Debug("Excluding the output file from the upload", "path", outputPath)
+	return append(slices.Clone(c.Excludes), filepath.ToSlash(rel))
+}
+
+// uploads each directory of -workspace-map to its workspace, a failed upload does not cancel the others
+func (c *UploadConfigurationCommand) runWorkspaceMap() int {
+	directories, err := c.readWorkspaceMap()
+	if err == nil {
+		err = c.validateWorkspaceOptions()
+	}
+	if err == nil {
+		for _, dir := range directories {
+			if err = c.checkDirectoryTerraformFiles(dir); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		c.addOutput("status", string(Error))
+		c.closeOutput()
+		c.writer.ErrorResult(err.Error())
+		return 1
+	}
+
+	workspaces := make([]*tfe.Workspace, 0, len(directories))
+	for name := range directories {
+		workspaces = append(workspaces, &tfe.Workspace{Name: name})
+	}
+	slices.SortFunc(workspaces, func(a, b *tfe.Workspace) int { return strings.Compare(a.Name, b.Name) })
+
+	results := forEachWorkspace(workspaces, func(w *tfe.Workspace) *workspaceResult {
+		return c.uploadWorkspace(w.Name, directories[w.Name])
+	})
+
+	status := c.addWorkspaceSummary(results)
+	cvIDs := []string{}
+	for _, result := range results {
+		if result.configVersion != nil {
+			cvIDs = append(cvIDs, fmt.Sprintf("%s=%s", result.workspace, result.configVersion.ID))
+		}
+	}
+	c.addOutputWithOpts("configuration_version_ids", strings.Join(cvIDs, "\n"), &outputOpts{
+		stdOut:      true,
+		multiLine:   true,
+		platformOut: true,
+	})
+	c.addFailedWorkspaces(results)
+
+	if status != Success {
+		c.writer.ErrorResult("error uploading configuration versions to HCP Terraform, see failed_workspaces for details")
+	}
+	c.writer.OutputResult(c.closeOutput())
+	return c.exitCode(status)
+}
+
+func (c *UploadConfigurationCommand) uploadWorkspace(workspace, dir string) *workspaceResult {
+	if c.CreateWorkspace {
+		if _, err := c.ensureWorkspace(workspace); err != nil {
+			return &workspaceResult{workspace: workspace, err: fmt.Errorf("error creating workspace: %w", err)}
+		}
+	}
+
+	dirPath, err := filepath.Abs(dir)
+	if err != nil {
+		return &workspaceResult{workspace: workspace, err: fmt.Errorf("error resolving directory path %w", err)}
+	}
+	logging.Debug("Uploading configuration", "workspace", workspace, "directory", dirPath)
+
+	configVersion, err := c.cloud.UploadConfig(c.appCtx, cloud.UploadOptions{
+		Workspace:              workspace,
+		Organization:           c.organization,
+		ConfigurationDirectory: dirPath,
+		Speculative:            c.Speculative,
+		Provisional:            c.Provisional,
+		Excludes:               c.uploadExcludes(dirPath),
+	})
+	return &workspaceResult{workspace: workspace, configVersion: configVersion, err: err}
+}
+
+// reads the -workspace-map json object of directory to workspace name, returning the directories by workspace.
+// A workspace may only be mapped once, as concurrent uploads to a workspace would race to become current.
+func (c *UploadConfigurationCommand) readWorkspaceMap() (map[string]string, error) {
+	if c.Workspace != "" || c.Directory != "" || c.Archive != "" || c.ConfigurationVersion != "" {
+		return nil, errors.New("-workspace-map cannot be combined with -workspace, -directory, -archive or -configuration-version")
+	}
+
+	raw, err := os.ReadFile(c.WorkspaceMap)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read -workspace-map: %w", err)
+	}
+	mapping := map[string]string{}
+	if err := json.Unmarshal(raw, &mapping); err != nil {
+		return nil, fmt.Errorf("invalid -workspace-map %q, expected a JSON object of directory to workspace name: %w", c.WorkspaceMap, err)
+	}
+	if len(mapping) == 0 {
+		return nil, fmt.Errorf("invalid -workspace-map %q, no directories are mapped", c.WorkspaceMap)
+	}
+
+	directories := make(map[string]string, len(mapping))
+	for dir, workspace := range mapping {
+		workspace = strings.TrimSpace(workspace)
+		if strings.TrimSpace(dir) == "" || workspace == "" {
+			return nil, fmt.Errorf("invalid -workspace-map %q, directories and workspace names must not be empty", c.WorkspaceMap)
+		}
+		if existing, ok := directories[workspace]; ok {
+			return nil, fmt.Errorf("invalid -workspace-map %q, workspace %q is mapped to both %q and %q", c.WorkspaceMap, workspace, existing, dir)
+		}
+		directories[workspace] = dir
+	}
+	return directories, nil
+}
+
 func (c *UploadConfigurationCommand) addConfigurationDetails(config *tfe.ConfigurationVersion) {
 	if config != nil {
 		// Log to help debug the configuration version details
@@ -88,8 +402,8 @@ func (c *UploadConfigurationCommand) addConfigurationDetails(config *tfe.Configu
 		c.addOutput("configuration_version_status", string(config.Status))
 		
 		// Explicitly log the output values to make troubleshooting easier
-		fmt.Printf("::set-output name=configuration_version_id::%s\n", config.ID)
-		fmt.Printf("::set-output name=configuration_version_status::%s\n", string(config.Status))
+		c.writer.Output(fmt.Sprintf("::set-output name=configuration_version_id::%s", config.ID))
+		c.writer.Output(fmt.Sprintf("::set-output name=configuration_version_status::%s", string(config.Status)))
 	} else {
 		logging.Warn("Configuration version is nil, no outputs will be set")
 	}
@@ -98,6 +412,7 @@ func (c *UploadConfigurationCommand) addConfigurationDetails(config *tfe.Configu
 		stdOut:      false,
 		multiLine:   true,
 		platformOut: true,
+		fields:      c.payloadFields,
 	})
 }
 
@@ -113,17 +428,37 @@ Global Options:
 
 	-token          The token used to authenticate with HCP Terraform. Defaults to reading "TF_API_TOKEN" environment variable.
 
-	-organization   HCP Terraform Organization Name.
+	-organization   HCP Terraform Organization Name. Also accepted as a command option, which overrides the global option for that command.
 
 Options:
 
 	-workspace      The name of the HCP Terraform Workspace to create and upload the terraform configuration version in.
 
-	-directory      Path to the terraform configuration files on disk.
+	-directory      Path to the terraform configuration files on disk. Defaults to the current directory when -archive is not set.
+
+	-workspace-map  Path to a JSON file mapping configuration directories to workspace names, e.g. {"infra/network": "network-prod"}, used instead of -workspace and -directory. Directories are uploaded concurrently, each to a new configuration version in its workspace, and a failed upload does not cancel the others. Outputs are keyed by workspace.
+
+	-archive        Path to a pre-built .tar.gz of the terraform configuration files, uploaded as is instead of packing -directory. The archive must be a gzip compressed tar.
+
+	-configuration-version  ID of a pending configuration version to resume a failed upload into, instead of creating a new configuration version, e.g. the configuration_version_id output of the failed upload. The configuration version must still be pending and belong to -workspace. Cannot be combined with -speculative, -provisional or -create-workspace.
+
+	-exclude        Glob pattern of paths relative to -directory to leave out of the upload, combined with any .terraformignore rules. "*" matches within a path segment, "[...]" and "[!...]" a single character of a class within a path segment and "**" any number of directories, e.g. -exclude='**/*.tfvars' or -exclude='tests/'. Patterns are anchored to -directory. This option accepts multiple instances by providing additional exclude option flags. Cannot be combined with -archive.
 
 	-speculative    When true, this configuration version may only be used to create runs which are speculative, that is, can neither be confirmed nor applied.
 
 	-provisional    When true, this configuration version does not immediately become the workspace's current configuration until a run referencing it is ultimately applied.
+
+	-require-tf-files  Fails before uploading when -directory does not contain any .tf or .tf.json files at its top level, e.g. when it points at the repository root by mistake.
+
+	-create-workspace   Creates the workspace if it does not exist. When the workspace already exists, it is used as is.
+
+	-workspace-project  The project name or ID to create the workspace in. Defaults to the organization's default project. Requires -create-workspace.
+
+	-execution-mode     The execution mode of the created workspace: remote, local or agent. Requires -create-workspace.
+
+	-terraform-version  The Terraform version of the created workspace. Requires -create-workspace.
+
+	-payload-fields     Comma separated list of top-level fields to include in the payload output, e.g. id,status,created-at. Defaults to all fields.
 	`
 	return strings.TrimSpace(helpText)
 }
@@ -5,6 +5,11 @@ package command
 
 import (
 	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
 	"testing"
 
 	"github.com/hashicorp/go-tfe"
@@ -16,12 +21,31 @@ import (
 
 type SuccessfulUploader struct {
 	configurationVersion *tfe.ConfigurationVersion
+	options              *cloud.UploadOptions
+	promotion            *cloud.ConfigurationPromotion
+	ingress              *tfe.IngressAttributes
 }
 
-func (s *SuccessfulUploader) UploadConfig(_ context.Context, _ cloud.UploadOptions) (*tfe.ConfigurationVersion, error) {
+func (s *SuccessfulUploader) UploadConfig(_ context.Context, options cloud.UploadOptions) (*tfe.ConfigurationVersion, error) {
+	s.options = &options
 	return s.configurationVersion, nil
 }
 
+func (s *SuccessfulUploader) GetIngressAttributes(_ context.Context, _ string) (*tfe.IngressAttributes, error) {
+	return s.ingress, nil
+}
+
+func (s *SuccessfulUploader) GetConfigurationVersion(_ context.Context, _ string) (*tfe.ConfigurationVersion, error) {
+	return s.configurationVersion, nil
+}
+
+func (s *SuccessfulUploader) GetConfigurationPromotion(_ context.Context, _ *tfe.Run) (*cloud.ConfigurationPromotion, error) {
+	if s.promotion == nil {
+		return nil, errors.New("configuration version not found")
+	}
+	return s.promotion, nil
+}
+
 func meta(cv *tfe.ConfigurationVersion) *Meta {
 	ctx := context.Background()
 	ui := cli.NewMockUi()
@@ -31,7 +55,7 @@ func meta(cv *tfe.ConfigurationVersion) *Meta {
 		configurationVersion: cv,
 	}
 	env := &environment.CI{}
-	meta := NewMetaOpts(ctx, cloudService, env, WithWriter(writer))
+	meta := NewMetaOpts(ctx, cloudService, env, WithWriter(writer), WithOrg("hashicorp"))
 	return meta
 }
 
@@ -65,6 +89,7 @@ func TestUploadConfigurationCommandRun(t *testing.T) {
 				Speculative: false,
 				Provisional: false,
 			},
+			args: args{args: []string{"-workspace=ws-1", "-directory=dir/"}},
 			want: 0,
 		},
 	}
@@ -84,3 +109,420 @@ func TestUploadConfigurationCommandRun(t *testing.T) {
 		})
 	}
 }
+
+func TestUploadConfigurationCommand_CreateWorkspace(t *testing.T) {
+	testCases := []struct {
+		name          string
+		args          []string
+		err           error
+		expectCreated bool
+		want          int
+	}{
+		{
+			name: "existing-workspace",
+			args: []string{"-workspace=pr-1", "-directory=dir/", "-create-workspace"},
+			want: 0,
+		},
+		{
+			name:          "missing-workspace",
+			args:          []string{"-workspace=pr-1", "-directory=dir/", "-create-workspace", "-workspace-project=previews", "-execution-mode=remote", "-terraform-version=1.9.0"},
+			err:           &cloud.WorkspaceNotFoundError{Organization: "my-org", Workspace: "pr-1"},
+			expectCreated: true,
+			want:          0,
+		},
+		{
+			name: "invalid-execution-mode",
+			args: []string{"-workspace=pr-1", "-directory=dir/", "-create-workspace", "-execution-mode=cloud"},
+			want: 1,
+		},
+		{
+			name: "options-without-create-workspace",
+			args: []string{"-workspace=pr-1", "-directory=dir/", "-execution-mode=remote"},
+			want: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := meta(&tfe.ConfigurationVersion{ID: "cv-1"})
+			reader := &WorkspaceReader{workspace: &tfe.Workspace{ID: "ws-***", Name: "pr-1"}, err: tc.err}
+			m.cloud.WorkspaceService = reader
+			c := &UploadConfigurationCommand{Meta: m}
+
+			if got := c.Run(tc.args); got != tc.want {
+				t.Fatalf("Run() = %v, want %v", got, tc.want)
+			}
+			if (reader.created != nil) != tc.expectCreated {
+				t.Errorf("expected workspace created: %t but received: %v", tc.expectCreated, reader.created)
+			}
+			if tc.expectCreated {
+				expected := &cloud.CreateWorkspaceOptions{Organization: "hashicorp", Name: "pr-1", Project: "previews", ExecutionMode: "remote", TerraformVersion: "1.9.0"}
+				if !reflect.DeepEqual(reader.created, expected) {
+					t.Errorf("expected %v but received %v", expected, reader.created)
+				}
+			}
+			if tc.want == 0 {
+				if workspaceID, _ := c.messages["workspace_id"].Value(); workspaceID != "ws-***" {
+					t.Errorf("expected workspace_id %q but received %q", "ws-***", workspaceID)
+				}
+			}
+		})
+	}
+}
+
+func TestUploadConfigurationCommand_Source(t *testing.T) {
+	archive, err := filepath.Abs("build/config.tar.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cwd, err := filepath.Abs("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name            string
+		args            []string
+		want            int
+		expectArchive   string
+		expectDirectory string
+	}{
+		{
+			name:          "archive",
+			args:          []string{"-workspace=ws-1", "-archive=build/config.tar.gz"},
+			want:          0,
+			expectArchive: archive,
+		},
+		{
+			name: "directory-and-archive",
+			args: []string{"-workspace=ws-1", "-directory=dir/", "-archive=build/config.tar.gz"},
+			want: 1,
+		},
+		{
+			// existing invocations without a source upload the current directory
+			name:            "default-directory",
+			args:            []string{"-workspace=ws-1"},
+			want:            0,
+			expectDirectory: cwd,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := meta(&tfe.ConfigurationVersion{ID: "cv-1"})
+			uploader := m.cloud.ConfigVersionService.(*SuccessfulUploader)
+			c := &UploadConfigurationCommand{Meta: m}
+
+			if got := c.Run(tc.args); got != tc.want {
+				t.Fatalf("Run() = %v, want %v", got, tc.want)
+			}
+			if tc.want != 0 {
+				if uploader.options != nil {
+					t.Errorf("expected no upload but received %v", uploader.options)
+				}
+				return
+			}
+			if uploader.options.ConfigurationArchive != tc.expectArchive {
+				t.Errorf("expected archive %q but received %q", tc.expectArchive, uploader.options.ConfigurationArchive)
+			}
+			if uploader.options.ConfigurationDirectory != tc.expectDirectory {
+				t.Errorf("expected directory %q but received %q", tc.expectDirectory, uploader.options.ConfigurationDirectory)
+			}
+		})
+	}
+}
+
+func TestUploadConfigurationCommand_Exclude(t *testing.T) {
+	testCases := []struct {
+		name     string
+		args     []string
+		want     int
+		expected []string
+	}{
+		{
+			name:     "repeated",
+			args:     []string{"-workspace=ws-1", "-directory=dir/", "-exclude=**/*.tfvars", "-exclude=tests/"},
+			want:     0,
+			expected: []string{"**/*.tfvars", "tests/"},
+		},
+		{
+			name:     "comma",
+			args:     []string{"-workspace=ws-1", "-directory=dir/", "-exclude={a,b}/*"},
+			want:     0,
+			expected: []string{"{a,b}/*"},
+		},
+		{
+			name: "archive",
+			args: []string{"-workspace=ws-1", "-archive=config.tar.gz", "-exclude=tests/"},
+			want: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := meta(&tfe.ConfigurationVersion{ID: "cv-1"})
+			uploader := m.cloud.ConfigVersionService.(*SuccessfulUploader)
+			c := &UploadConfigurationCommand{Meta: m}
+
+			if got := c.Run(tc.args); got != tc.want {
+				t.Fatalf("Run() = %v, want %v", got, tc.want)
+			}
+			if tc.want != 0 {
+				if uploader.options != nil {
+					t.Errorf("expected no upload but received %v", uploader.options)
+				}
+				return
+			}
+			if !reflect.DeepEqual(uploader.options.Excludes, tc.expected) {
+				t.Errorf("expected excludes %v but received %v", tc.expected, uploader.options.Excludes)
+			}
+		})
+	}
+}
+
+func TestUploadConfigurationCommand_ExcludeOutputFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "outputs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name       string
+		outputPath string
+		expected   []string
+	}{
+		{
+			// eg. the Bitbucket dotenv in the clone directory
+			name:       "under-directory",
+			outputPath: filepath.Join(dir, "tfci.env"),
+			expected:   []string{"tests/", "tfci.env"},
+		},
+		{
+			name:       "nested",
+			outputPath: filepath.Join(dir, "outputs", "tfci.env"),
+			expected:   []string{"tests/", "outputs/tfci.env"},
+		},
+		{
+			name:       "outside-directory",
+			outputPath: filepath.Join(t.TempDir(), "tfci.env"),
+			expected:   []string{"tests/"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := meta(&tfe.ConfigurationVersion{ID: "cv-1"})
+			m.env = &environment.CI{Context: &OutputPathContext{path: tc.outputPath}}
+			uploader := m.cloud.ConfigVersionService.(*SuccessfulUploader)
+			c := &UploadConfigurationCommand{Meta: m}
+
+			if got := c.Run([]string{"-workspace=ws-1", "-directory=" + dir, "-exclude=tests/"}); got != 0 {
+				t.Fatalf("Run() = %v, want %v", got, 0)
+			}
+			if !reflect.DeepEqual(uploader.options.Excludes, tc.expected) {
+				t.Errorf("expected excludes %v but received %v", tc.expected, uploader.options.Excludes)
+			}
+		})
+	}
+}
+
+func TestUploadConfigurationCommand_ResumeConfigurationVersion(t *testing.T) {
+	testCases := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{
+			name: "resume",
+			args: []string{"-workspace=ws-1", "-directory=dir/", "-configuration-version=cv-pending"},
+			want: 0,
+		},
+		{
+			name: "speculative",
+			args: []string{"-workspace=ws-1", "-directory=dir/", "-configuration-version=cv-pending", "-speculative"},
+			want: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := meta(&tfe.ConfigurationVersion{ID: "cv-pending"})
+			uploader := m.cloud.ConfigVersionService.(*SuccessfulUploader)
+			c := &UploadConfigurationCommand{Meta: m}
+
+			if got := c.Run(tc.args); got != tc.want {
+				t.Fatalf("Run() = %v, want %v", got, tc.want)
+			}
+			if tc.want != 0 {
+				if uploader.options != nil {
+					t.Errorf("expected no upload but received %v", uploader.options)
+				}
+				return
+			}
+			if uploader.options.ConfigurationVersionID != "cv-pending" {
+				t.Errorf("expected configuration version %q but received %q", "cv-pending", uploader.options.ConfigurationVersionID)
+			}
+		})
+	}
+}
+
+func TestUploadConfigurationCommand_RequireTFFiles(t *testing.T) {
+	withFiles := t.TempDir()
+	if err := os.WriteFile(filepath.Join(withFiles, "main.tf.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// a repository root with the configuration in a subdirectory
+	repoRoot := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repoRoot, "terraform"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoRoot, "terraform", "main.tf"), []byte(""), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{
+			name: "terraform-files-found",
+			args: []string{"-workspace=ws-1", "-directory=" + withFiles, "-require-tf-files"},
+			want: 0,
+		},
+		{
+			name: "no-terraform-files",
+			args: []string{"-workspace=ws-1", "-directory=" + repoRoot, "-require-tf-files"},
+			want: 1,
+		},
+		{
+			name: "missing-directory",
+			args: []string{"-workspace=ws-1", "-directory=" + filepath.Join(repoRoot, "missing"), "-require-tf-files"},
+			want: 1,
+		},
+		{
+			name: "not-required",
+			args: []string{"-workspace=ws-1", "-directory=" + repoRoot},
+			want: 0,
+		},
+		{
+			name: "archive",
+			args: []string{"-workspace=ws-1", "-archive=config.tar.gz", "-require-tf-files"},
+			want: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := meta(&tfe.ConfigurationVersion{ID: "cv-1"})
+			uploader := m.cloud.ConfigVersionService.(*SuccessfulUploader)
+			c := &UploadConfigurationCommand{Meta: m}
+
+			if got := c.Run(tc.args); got != tc.want {
+				t.Fatalf("Run() = %v, want %v", got, tc.want)
+			}
+			if (uploader.options != nil) != (tc.want == 0) {
+				t.Errorf("expected upload: %t but received %v", tc.want == 0, uploader.options)
+			}
+		})
+	}
+}
+
+type MapUploader struct {
+	SuccessfulUploader
+	mu      sync.Mutex
+	uploads map[string]string
+	failing string
+}
+
+func (m *MapUploader) UploadConfig(_ context.Context, options cloud.UploadOptions) (*tfe.ConfigurationVersion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if options.Workspace == m.failing {
+		return nil, errors.New("upload failed")
+	}
+	m.uploads[options.Workspace] = options.ConfigurationDirectory
+	return &tfe.ConfigurationVersion{ID: "cv-" + options.Workspace}, nil
+}
+
+func TestUploadConfigurationCommand_WorkspaceMap(t *testing.T) {
+	dir := t.TempDir()
+	writeMap := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	valid := writeMap("valid.json", `{"infra/network": "network", "infra/compute": "compute"}`)
+
+	testCases := []struct {
+		name     string
+		args     []string
+		failing  string
+		want     int
+		summary  string
+		cvIDs    string
+		uploaded int
+	}{
+		{
+			name:     "all-uploaded",
+			args:     []string{"-workspace-map=" + valid},
+			want:     0,
+			summary:  SummaryAll,
+			cvIDs:    "compute=cv-compute\nnetwork=cv-network",
+			uploaded: 2,
+		},
+		{
+			name:     "partial",
+			args:     []string{"-workspace-map=" + valid},
+			failing:  "compute",
+			want:     1,
+			summary:  SummaryPartial,
+			cvIDs:    "network=cv-network",
+			uploaded: 1,
+		},
+		{
+			name: "combined-with-directory",
+			args: []string{"-workspace-map=" + valid, "-directory=dir/"},
+			want: 1,
+		},
+		{
+			name: "duplicate-workspace",
+			args: []string{"-workspace-map=" + writeMap("duplicate.json", `{"a": "network", "b": "network"}`)},
+			want: 1,
+		},
+		{
+			name: "invalid-json",
+			args: []string{"-workspace-map=" + writeMap("invalid.json", `["a"]`)},
+			want: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := meta(nil)
+			uploader := &MapUploader{uploads: map[string]string{}, failing: tc.failing}
+			m.cloud.ConfigVersionService = uploader
+
+			if got := (&UploadConfigurationCommand{Meta: m}).Run(tc.args); got != tc.want {
+				t.Fatalf("Run() = %v, want %v", got, tc.want)
+			}
+			if len(uploader.uploads) != tc.uploaded {
+				t.Errorf("expected %d uploads but received %v", tc.uploaded, uploader.uploads)
+			}
+			if tc.summary == "" {
+				return
+			}
+			if summary := outputValue(m, "summary_status"); summary != tc.summary {
+				t.Errorf("expected summary_status %q but received %q", tc.summary, summary)
+			}
+			if cvIDs := outputValue(m, "configuration_version_ids"); cvIDs != tc.cvIDs {
+				t.Errorf("expected configuration_version_ids %q but received %q", tc.cvIDs, cvIDs)
+			}
+			if path, _ := filepath.Abs("infra/network"); uploader.uploads["network"] != path {
+				t.Errorf("expected network to be uploaded from %q but received %q", path, uploader.uploads["network"])
+			}
+		})
+	}
+}
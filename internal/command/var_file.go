@@ -4,25 +4,326 @@
 package command
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"maps"
 	"os"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 )
 
 const VarEnvPrefix = "TF_VAR_"
 
-func collectVariables() []*tfe.RunVariable {
+// value types for the -var-type option
+const (
+	// detects HCL literals such as numbers, bools, lists and maps, otherwise treats the value as a string
+	VarTypeAuto = "auto"
+	// the default, as terraform treats -var values of string variables
+	VarTypeString = "string"
+	VarTypeHCL    = "hcl"
+)
+
+var varNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_-]*$`)
+
+// flagVarSlice is a flag.Value implementation which collects repeatable flags such as -var, -replace and -exclude,
+// unlike flagStringSlice values are not split on commas as they may contain HCL lists, resource keys or globs
+type flagVarSlice []string
+
+var _ flag.Value = (*flagVarSlice)(nil)
+
+func (v *flagVarSlice) String() string {
+	return strings.Join(*v, " ")
+}
+
+func (v *flagVarSlice) Set(raw string) error {
+	*v = append(*v, raw)
+	return nil
+}
+
+// collects variables from `TF_VAR_` environment variables, variables from -var-file files take precedence over
+// the environment and variables set with -var take precedence over both, matching terraform
+func collectVariables(fileVars []*tfe.RunVariable, flagVars []*tfe.RunVariable) []*tfe.RunVariable {
 	var tfVars []*tfe.RunVariable
 	// get vars from env
 	tfVarMap := collectEnvVariables()
+	for _, value := range fileVars {
+		tfVarMap[value.Key] = value
+	}
+	for _, value := range flagVars {
+		tfVarMap[value.Key] = value
+	}
 	for _, value := range tfVarMap {
 		tfVars = append(tfVars, value)
 	}
 	return tfVars
 }
 
+// parses `key=value` pairs from -var flags, run variable values must be expressed as HCL literals
+func parseFlagVariables(vars []string, varType string) ([]*tfe.RunVariable, error) {
+	if varType != VarTypeAuto && varType != VarTypeString && varType != VarTypeHCL {
+		return nil, fmt.Errorf("invalid -var-type %q, must be one of: %s, %s, %s", varType, VarTypeAuto, VarTypeString, VarTypeHCL)
+	}
+
+	var runVars []*tfe.RunVariable
+	for _, raw := range vars {
+		key, value, found := strings.Cut(raw, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid -var %q, expected the format key=value", raw)
+		}
+		if !varNamePattern.MatchString(key) {
+			return nil, fmt.Errorf("invalid -var %q, %q is not a valid variable name", raw, key)
+		}
+
+		switch varType {
+		case VarTypeString:
+			value = hclString(value)
+		case VarTypeHCL:
+			if err := validateHCLLiteral(value); err != nil {
+				return nil, fmt.Errorf("invalid -var %q: %w", raw, err)
+			}
+		default:
+			if !isHCLLiteral(value) {
+				value = hclString(value)
+			}
+		}
+
+		log.Printf("[DEBUG] adding variable from flag: '%s'", key)
+		runVars = append(runVars, &tfe.RunVariable{
+			Key:   key,
+			Value: value,
+		})
+	}
+	return runVars, nil
+}
+
+// parses the -var-file files in order, values from later files take precedence
+func parseVarFiles(paths []string) ([]*tfe.RunVariable, error) {
+	varMap := make(map[string]*tfe.RunVariable)
+	var keys []string
+	for _, path := range paths {
+		fileVars, err := parseVarFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range fileVars {
+			if _, ok := varMap[v.Key]; !ok {
+				keys = append(keys, v.Key)
+			}
+			varMap[v.Key] = v
+		}
+	}
+
+	var runVars []*tfe.RunVariable
+	for _, key := range keys {
+		runVars = append(runVars, varMap[key])
+	}
+	return runVars, nil
+}
+
+// parses a .tfvars file, whose values are HCL literals passed through as is, or a .tfvars.json file,
+// whose values are converted to HCL literals
+func parseVarFile(path string) ([]*tfe.RunVariable, error) {
+	var parse func(path string, data []byte) ([]*tfe.RunVariable, error)
+	switch {
+	case strings.HasSuffix(path, ".tfvars.json"):
+		parse = parseJSONVarFile
+	case strings.HasSuffix(path, ".tfvars"):
+		parse = parseHCLVarFile
+	default:
+		return nil, fmt.Errorf("invalid -var-file %q, must be a .tfvars or .tfvars.json file", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read -var-file: %w", err)
+	}
+	runVars, err := parse(path, data)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range runVars {
+		log.Printf("[DEBUG] adding variable from file: '%s', file: '%s'", v.Key, path)
+	}
+	return runVars, nil
+}
+
+func parseJSONVarFile(path string, data []byte) ([]*tfe.RunVariable, error) {
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntaxErr):
+			return nil, fmt.Errorf("%s:%d: invalid JSON: %s", path, lineAt(data, syntaxErr.Offset), syntaxErr)
+		case errors.As(err, &typeErr):
+			return nil, fmt.Errorf("%s:%d: variables must be a JSON object of variable names to values", path, lineAt(data, typeErr.Offset))
+		}
+		return nil, fmt.Errorf("%s: invalid JSON: %w", path, err)
+	}
+
+	var runVars []*tfe.RunVariable
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		if !varNamePattern.MatchString(key) {
+			return nil, fmt.Errorf("%s: %q is not a valid variable name", path, key)
+		}
+		var value any
+		decoder := json.NewDecoder(bytes.NewReader(values[key]))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil {
+			return nil, fmt.Errorf("%s: invalid value of variable %q: %w", path, key, err)
+		}
+		runVars = append(runVars, &tfe.RunVariable{Key: key, Value: hclValue(value)})
+	}
+	return runVars, nil
+}
+
+// the 1-based line of the byte offset
+func lineAt(data []byte, offset int64) int {
+	offset = min(max(offset, 0), int64(len(data)))
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+// converts a decoded JSON value to an HCL literal, JSON strings are never interpolated
+func hclValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case string:
+		return hclString(v)
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, hclValue(item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]any:
+		if len(v) == 0 {
+			return "{}"
+		}
+		items := make([]string, 0, len(v))
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			items = append(items, fmt.Sprintf("%s = %s", hclString(key), hclValue(v[key])))
+		}
+		return "{ " + strings.Join(items, ", ") + " }"
+	}
+	return hclString(fmt.Sprint(value))
+}
+
+// parses the `name = value` attributes of a .tfvars file. Values are not evaluated, the source of each value is
+// passed through as an HCL literal to be validated by HCP Terraform when the run is planned
+func parseHCLVarFile(path string, data []byte) ([]*tfe.RunVariable, error) {
+	file, diags := hclsyntax.ParseConfig(data, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	body := file.Body.(*hclsyntax.Body)
+	if len(body.Blocks) > 0 {
+		block := body.Blocks[0]
+		return nil, fmt.Errorf("%s:%d: unexpected %q block, a variable file only sets variables", path, block.TypeRange.Start.Line, block.Type)
+	}
+
+	// in the order of the file, so the variables are logged as they are defined
+	attrs := slices.SortedFunc(maps.Values(body.Attributes), func(a, b *hclsyntax.Attribute) int {
+		return a.SrcRange.Start.Byte - b.SrcRange.Start.Byte
+	})
+	var runVars []*tfe.RunVariable
+	for _, attr := range attrs {
+		if !varNamePattern.MatchString(attr.Name) {
+			return nil, fmt.Errorf("%s:%d: %q is not a valid variable name", path, attr.NameRange.Start.Line, attr.Name)
+		}
+		value := string(attr.Expr.Range().SliceBytes(data))
+		// the range of a heredoc ends at its closing marker, which must be followed by a newline
+		if strings.HasPrefix(value, "<<") {
+			value += "\n"
+		}
+		runVars = append(runVars, &tfe.RunVariable{Key: attr.Name, Value: value})
+	}
+	return runVars, nil
+}
+
+// quotes the value as an HCL string, escaping template sequences so they are not interpolated. Only the escapes
+// HCL accepts are used, other control characters are written as \uNNNN and invalid UTF-8 is replaced by U+FFFD
+func hclString(value string) string {
+	value = strings.ReplaceAll(value, "${", "$${")
+	value = strings.ReplaceAll(value, "%{", "%%{")
+
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range value {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if unicode.IsControl(r) {
+				fmt.Fprintf(&b, `\u%04x`, r)
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+var hclNumberPattern = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// detects the values of -var-type auto which are sent as HCL literals, a value is only treated as a number when it
+// would be sent unchanged
+func isHCLLiteral(value string) bool {
+	value = strings.TrimSpace(value)
+	switch value {
+	case "true", "false", "null":
+		return true
+	}
+	// only decimal numbers which keep their exact form, eg. "1.10" stays a string as the number would be 1.1,
+	// and "inf", "nan" or hex values which ParseFloat accepts are not HCL numbers
+	if hclNumberPattern.MatchString(value) {
+		f, err := strconv.ParseFloat(value, 64)
+		return err == nil && strconv.FormatFloat(f, 'f', -1, 64) == value
+	}
+	if value == "" || !strings.ContainsAny(value[:1], `"[{`) {
+		return false
+	}
+	return validateHCLLiteral(value) == nil
+}
+
+// checks the value parses as an HCL expression, the value is otherwise validated by HCP Terraform when the run
+// is planned
+func validateHCLLiteral(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("value must not be empty, use \"\" for an empty string")
+	}
+	if _, diags := hclsyntax.ParseExpression([]byte(value), "-var", hcl.InitialPos); diags.HasErrors() {
+		return diags
+	}
+	return nil
+}
+
 func collectEnvVariables() map[string]*tfe.RunVariable {
 	tfRunMap := make(map[string]*tfe.RunVariable)
 
@@ -0,0 +1,322 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFlagVariables(t *testing.T) {
+	testCases := []struct {
+		name      string
+		vars      []string
+		varType   string
+		expected  map[string]string
+		expectErr bool
+	}{
+		{
+			name:    "auto-detects-strings",
+			vars:    []string{"image_tag=v1.2.3", "greeting=hello, ${name}", "empty="},
+			varType: VarTypeAuto,
+			expected: map[string]string{
+				"image_tag": `"v1.2.3"`,
+				"greeting":  `"hello, $${name}"`,
+				"empty":     `""`,
+			},
+		},
+		{
+			name:    "auto-detects-hcl-literals",
+			vars:    []string{"count=3", "enabled=true", `zones=["a", "b"]`, `tags={ env = "dev" }`, `quoted="value"`},
+			varType: VarTypeAuto,
+			expected: map[string]string{
+				"count":   "3",
+				"enabled": "true",
+				"zones":   `["a", "b"]`,
+				"tags":    `{ env = "dev" }`,
+				"quoted":  `"value"`,
+			},
+		},
+		{
+			name:    "auto-keeps-inexact-numbers",
+			vars:    []string{"version=1.10", "build=007", "ratio=inf", "mask=0x1F", "scale=1e3", "offset=-2.5"},
+			varType: VarTypeAuto,
+			expected: map[string]string{
+				"version": `"1.10"`,
+				"build":   `"007"`,
+				"ratio":   `"inf"`,
+				"mask":    `"0x1F"`,
+				"scale":   `"1e3"`,
+				"offset":  "-2.5",
+			},
+		},
+		{
+			name:    "string-type",
+			vars:    []string{"count=3", `zones=["a"]`},
+			varType: VarTypeString,
+			expected: map[string]string{
+				"count": `"3"`,
+				"zones": `"[\"a\"]"`,
+			},
+		},
+		{
+			// strconv.Quote escapes such as \a and \x00 are not valid in HCL
+			name:    "string-control-characters",
+			vars:    []string{"bell=a\ab", "null=a\x00b", "tab=a\tb", `path=C:\tmp "x"`, "invalid=a\xffb"},
+			varType: VarTypeString,
+			expected: map[string]string{
+				"bell":    `"a\u0007b"`,
+				"null":    `"a\u0000b"`,
+				"tab":     `"a\tb"`,
+				"path":    `"C:\\tmp \"x\""`,
+				"invalid": "\"a\uFFFDb\"",
+			},
+		},
+		{
+			name:    "hcl-type",
+			vars:    []string{`zones=["a", "b]"]`},
+			varType: VarTypeHCL,
+			expected: map[string]string{
+				"zones": `["a", "b]"]`,
+			},
+		},
+		{
+			name:      "hcl-type-unbalanced",
+			vars:      []string{`zones=["a", "b"`},
+			varType:   VarTypeHCL,
+			expectErr: true,
+		},
+		{
+			name:      "missing-separator",
+			vars:      []string{"image_tag"},
+			varType:   VarTypeAuto,
+			expectErr: true,
+		},
+		{
+			name:      "invalid-name",
+			vars:      []string{"1tag=v1"},
+			varType:   VarTypeAuto,
+			expectErr: true,
+		},
+		{
+			name:      "invalid-var-type",
+			vars:      []string{"tag=v1"},
+			varType:   "json",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			runVars, err := parseFlagVariables(tc.vars, tc.varType)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error: %t but received: %v", tc.expectErr, err)
+			}
+			if tc.expectErr {
+				return
+			}
+
+			actual := map[string]string{}
+			for _, v := range runVars {
+				actual[v.Key] = v.Value
+			}
+			for key, value := range tc.expected {
+				if actual[key] != value {
+					t.Errorf("expected %s=%s but received %s=%s", key, value, key, actual[key])
+				}
+				// every value is sent as HCL, which the API parses
+				if err := validateHCLLiteral(actual[key]); err != nil {
+					t.Errorf("expected %s=%s to be valid HCL but received: %s", key, actual[key], err)
+				}
+			}
+			if len(actual) != len(tc.expected) {
+				t.Errorf("expected %d variables but received %d", len(tc.expected), len(actual))
+			}
+		})
+	}
+}
+
+func TestParseVarFiles(t *testing.T) {
+	testCases := []struct {
+		name        string
+		file        string
+		content     string
+		expected    map[string]string
+		expectedErr string
+	}{
+		{
+			name: "hcl",
+			file: "prod.tfvars",
+			content: `# deployment settings
+image_tag = "v1.2.3" # trailing comment
+count     = 3
+zones = [
+  "us-east-1a", // primary
+  "us-east-1b",
+]
+/* block
+   comment */
+tags = { env = "prod", owner = "#platform" }
+policy = <<-EOT
+  {"Version": "2012-10-17"}
+  EOT
+`,
+			expected: map[string]string{
+				"image_tag": `"v1.2.3"`,
+				"count":     "3",
+				"zones":     "[\n  \"us-east-1a\", // primary\n  \"us-east-1b\",\n]",
+				"tags":      `{ env = "prod", owner = "#platform" }`,
+				"policy":    "<<-EOT\n  {\"Version\": \"2012-10-17\"}\n  EOT\n",
+			},
+		},
+		{
+			name: "json",
+			file: "prod.tfvars.json",
+			content: `{
+  "image_tag": "v1.2.3",
+  "greeting": "hello ${name}",
+  "count": 3,
+  "enabled": true,
+  "zones": ["us-east-1a", "us-east-1b"],
+  "tags": {"env": "prod"},
+  "none": null
+}`,
+			expected: map[string]string{
+				"image_tag": `"v1.2.3"`,
+				"greeting":  `"hello $${name}"`,
+				"count":     "3",
+				"enabled":   "true",
+				"zones":     `["us-east-1a", "us-east-1b"]`,
+				"tags":      `{ "env" = "prod" }`,
+				"none":      "null",
+			},
+		},
+		{
+			name:        "hcl-unbalanced",
+			file:        "prod.tfvars",
+			content:     "image_tag = \"v1\"\nzones = [\n  \"a\",\n",
+			expectedErr: "prod.tfvars:4,1-1: Missing expression",
+		},
+		{
+			name:        "hcl-unterminated-string",
+			file:        "prod.tfvars",
+			content:     "image_tag = \"v1\n",
+			expectedErr: "prod.tfvars:1,16-2,1: Invalid multi-line string",
+		},
+		{
+			name:        "hcl-duplicate",
+			file:        "prod.tfvars",
+			content:     "image_tag = \"v1\"\n\nimage_tag = \"v2\"\n",
+			expectedErr: "prod.tfvars:3,1-10: Attribute redefined",
+		},
+		{
+			name:        "hcl-missing-equals",
+			file:        "prod.tfvars",
+			content:     "image_tag \"v1\"\n",
+			expectedErr: "prod.tfvars:1,15-2,1: Invalid block definition",
+		},
+		{
+			name:        "hcl-block",
+			file:        "prod.tfvars",
+			content:     "image_tag = \"v1\"\nvariable \"zones\" {}\n",
+			expectedErr: "prod.tfvars:2: unexpected \"variable\" block",
+		},
+		{
+			name:        "json-syntax",
+			file:        "prod.tfvars.json",
+			content:     "{\n  \"image_tag\": \"v1\",\n  \"count\": 3,,\n}",
+			expectedErr: "prod.tfvars.json:3: invalid JSON",
+		},
+		{
+			name:        "json-not-object",
+			file:        "prod.tfvars.json",
+			content:     `["v1"]`,
+			expectedErr: "prod.tfvars.json:1: variables must be a JSON object",
+		},
+		{
+			// only .tfvars.json files are variable files, eg. not a package.json
+			name:        "json-extension",
+			file:        "prod.json",
+			content:     `{"image_tag": "v1"}`,
+			expectedErr: "must be a .tfvars or .tfvars.json file",
+		},
+		{
+			name:        "unknown-extension",
+			file:        "prod.yaml",
+			content:     "image_tag: v1",
+			expectedErr: "must be a .tfvars or .tfvars.json file",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, tc.file)
+			if err := os.WriteFile(path, []byte(tc.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			runVars, err := parseVarFiles([]string{path})
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Fatalf("expected error containing %q but received: %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but received: %s", err)
+			}
+
+			actual := map[string]string{}
+			for _, v := range runVars {
+				actual[v.Key] = v.Value
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %q but received %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCollectVariables_Precedence(t *testing.T) {
+	t.Setenv(VarEnvPrefix+"region", `"us-east-1"`)
+	t.Setenv(VarEnvPrefix+"image_tag", `"env"`)
+
+	dir := t.TempDir()
+	first, second := filepath.Join(dir, "first.tfvars"), filepath.Join(dir, "second.tfvars.json")
+	if err := os.WriteFile(first, []byte("image_tag = \"first\"\ncount = 1\nsize = \"small\"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(second, []byte(`{"count": 2, "size": "large"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	fileVars, err := parseVarFiles([]string{first, second})
+	if err != nil {
+		t.Fatalf("expected no error but received: %s", err)
+	}
+	flagVars, err := parseFlagVariables([]string{"size=medium"}, VarTypeAuto)
+	if err != nil {
+		t.Fatalf("expected no error but received: %s", err)
+	}
+
+	actual := map[string]string{}
+	for _, v := range collectVariables(fileVars, flagVars) {
+		actual[v.Key] = v.Value
+	}
+	expected := map[string]string{
+		"region":    `"us-east-1"`,
+		"image_tag": `"first"`,
+		"count":     "2",
+		"size":      `"medium"`,
+	}
+	for key, value := range expected {
+		if actual[key] != value {
+			t.Errorf("expected %s=%s but received %s=%s", key, value, key, actual[key])
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+)
+
+type SetVariableCommand struct {
+	*Meta
+
+	Workspace string
+	Key       string
+	Value     string
+	Category  string
+	Sensitive flagOptionalBool
+	HCL       bool
+}
+
+func (c *SetVariableCommand) flags() *flag.FlagSet {
+	f := c.flagSet("variable set")
+	f.StringVar(&c.Workspace, "workspace", "", "The name of the HCP Terraform Workspace to set the variable in.")
+	f.StringVar(&c.Key, "key", "", "The name of the variable.")
+	f.StringVar(&c.Value, "value", "", "The value of the variable.")
+	f.StringVar(&c.Category, "category", string(tfe.CategoryTerraform), "The kind of variable: terraform or env.")
+	f.Var(&c.Sensitive, "sensitive", "Marks the variable as sensitive, its value is write only. When not set, an existing variable keeps its sensitivity.")
+	f.BoolVar(&c.HCL, "hcl", false, "Interprets the value as an HCL literal, only applies to terraform variables.")
+
+	return f
+}
+
+func (c *SetVariableCommand) Run(args []string) int {
+	if err := c.setupCmd(args, c.flags(), c.requireOrganization(), requireWorkspace(&c.Workspace)); err != nil {
+		return 1
+	}
+
+	if err := c.validate(); err != nil {
+		c.addOutput("status", string(Error))
+		c.closeOutput()
+		c.writer.ErrorResult(err.Error())
+		return 1
+	}
+
+	variable, created, err := c.cloud.SetVariable(c.appCtx, cloud.SetVariableOptions{
+		Organization: c.organization,
+		Workspace:    c.Workspace,
+		Key:          c.Key,
+		Value:        c.Value,
+		Category:     tfe.CategoryType(c.Category),
+		HCL:          c.HCL,
+		Sensitive:    c.Sensitive.Bool(),
+	})
+	if err != nil {
+		status := c.resolveStatus(err)
+		c.addOutput("status", string(status))
+		c.closeOutput()
+		c.errorResult(status, fmt.Sprintf("error setting variable, '%s' in workspace '%s': %s", c.Key, c.Workspace, err.Error()))
+		return c.exitCode(status)
+	}
+
+	action := "updated"
+	if created {
+		action = "created"
+	}
+	// the value is never echoed, sensitive or not
+	c.writer.Output(fmt.Sprintf("Variable %q %s in workspace %q", c.Key, action, c.Workspace))
+
+	c.addOutput("status", string(Success))
+	c.addOutput("variable_id", variable.ID)
+	c.addOutput("variable_action", action)
+	c.addOutput("variable_sensitive", fmt.Sprint(variable.Sensitive))
+	c.writer.OutputResult(c.closeOutput())
+	return 0
+}
+
+func (c *SetVariableCommand) validate() error {
+	if strings.TrimSpace(c.Key) == "" {
+		return errors.New("setting a variable requires a -key")
+	}
+	switch tfe.CategoryType(c.Category) {
+	case tfe.CategoryTerraform, tfe.CategoryEnv:
+	default:
+		return fmt.Errorf("invalid -category %q, must be one of: %s, %s", c.Category, tfe.CategoryTerraform, tfe.CategoryEnv)
+	}
+	if c.HCL && tfe.CategoryType(c.Category) == tfe.CategoryEnv {
+		return fmt.Errorf("-hcl only applies to terraform variables, not -category=%s", tfe.CategoryEnv)
+	}
+	return nil
+}
+
+func (c *SetVariableCommand) Help() string {
+	helpText := `
+Usage: tfci [global options] variable set [options]
+
+	Creates a workspace variable, or updates its value when a variable with the same key and category exists. The value is never logged.
+
+Global Options:
+
+	-hostname       The hostname of a Terraform Enterprise installation, if using Terraform Enterprise. Defaults to "app.terraform.io".
+
+	-token          The token used to authenticate with HCP Terraform. Defaults to reading "TF_API_TOKEN" environment variable.
+
+	-organization   HCP Terraform Organization Name. Also accepted as a command option, which overrides the global option for that command.
+
+Options:
+
+	-workspace      The name of the HCP Terraform Workspace to set the variable in.
+
+	-key            The name of the variable.
+
+	-value          The value of the variable.
+
+	-category       The kind of variable: terraform or env. Defaults to terraform.
+
+	-sensitive      Marks the variable as sensitive, its value is write only and cannot be read back. A sensitive variable cannot be made non-sensitive. When not set, an existing variable keeps its sensitivity.
+
+	-hcl            Interprets the value as an HCL literal, e.g. a list or map. Only applies to terraform variables.
+	`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *SetVariableCommand) Synopsis() string {
+	return "Creates or updates a workspace variable"
+}
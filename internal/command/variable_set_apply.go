@@ -0,0 +1,87 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/tfci/internal/cloud"
+)
+
+type ApplyVariableSetCommand struct {
+	*Meta
+
+	VariableSet string
+	Workspace   string
+}
+
+func (c *ApplyVariableSetCommand) flags() *flag.FlagSet {
+	f := c.flagSet("variable-set apply")
+	f.StringVar(&c.VariableSet, "variable-set", "", "The name or ID of the HCP Terraform Variable Set to apply.")
+	f.StringVar(&c.Workspace, "workspace", "", "The name of the HCP Terraform Workspace to apply the variable set to.")
+
+	return f
+}
+
+func (c *ApplyVariableSetCommand) Run(args []string) int {
+	if err := c.setupCmd(args, c.flags(), c.requireOrganization(), requireWorkspace(&c.Workspace)); err != nil {
+		return 1
+	}
+
+	if strings.TrimSpace(c.VariableSet) == "" {
+		c.addOutput("status", string(Error))
+		c.closeOutput()
+		c.writer.ErrorResult("applying a variable set requires a -variable-set name or ID")
+		return 1
+	}
+
+	variableSet, err := c.cloud.ApplyVariableSet(c.appCtx, cloud.VariableSetOptions{
+		Organization: c.organization,
+		Workspace:    c.Workspace,
+		VariableSet:  c.VariableSet,
+	})
+	if err != nil {
+		status := c.resolveStatus(err)
+		c.addOutput("status", string(status))
+		c.closeOutput()
+		c.errorResult(status, fmt.Sprintf("error applying variable set, '%s' to workspace '%s': %s", c.VariableSet, c.Workspace, err.Error()))
+		return c.exitCode(status)
+	}
+
+	c.writer.Output(fmt.Sprintf("Variable set %q applied to workspace %q", variableSet.Name, c.Workspace))
+	c.addOutput("status", string(Success))
+	c.addOutput("variable_set_id", variableSet.ID)
+	c.addOutput("variable_set_assignment", "applied")
+	c.writer.OutputResult(c.closeOutput())
+	return 0
+}
+
+func (c *ApplyVariableSetCommand) Help() string {
+	helpText := `
+Usage: tfci [global options] variable-set apply [options]
+
+	Applies a variable set to a workspace, in addition to the workspaces it already applies to. Applying a variable set that already applies to the workspace succeeds. Global variable sets already apply to every workspace and cannot be applied to a single workspace.
+
+Global Options:
+
+	-hostname       The hostname of a Terraform Enterprise installation, if using Terraform Enterprise. Defaults to "app.terraform.io".
+
+	-token          The token used to authenticate with HCP Terraform. Defaults to reading "TF_API_TOKEN" environment variable.
+
+	-organization   HCP Terraform Organization Name. Also accepted as a command option, which overrides the global option for that command.
+
+Options:
+
+	-variable-set   The name or ID of the HCP Terraform Variable Set to apply, e.g. "aws-credentials" or "varset-***".
+
+	-workspace      The name of the HCP Terraform Workspace to apply the variable set to.
+	`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ApplyVariableSetCommand) Synopsis() string {
+	return "Applies a variable set to a workspace"
+}
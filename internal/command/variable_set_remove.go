@@ -0,0 +1,87 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/tfci/internal/cloud"
+)
+
+type RemoveVariableSetCommand struct {
+	*Meta
+
+	VariableSet string
+	Workspace   string
+}
+
+func (c *RemoveVariableSetCommand) flags() *flag.FlagSet {
+	f := c.flagSet("variable-set remove")
+	f.StringVar(&c.VariableSet, "variable-set", "", "The name or ID of the HCP Terraform Variable Set to remove.")
+	f.StringVar(&c.Workspace, "workspace", "", "The name of the HCP Terraform Workspace to remove the variable set from.")
+
+	return f
+}
+
+func (c *RemoveVariableSetCommand) Run(args []string) int {
+	if err := c.setupCmd(args, c.flags(), c.requireOrganization(), requireWorkspace(&c.Workspace)); err != nil {
+		return 1
+	}
+
+	if strings.TrimSpace(c.VariableSet) == "" {
+		c.addOutput("status", string(Error))
+		c.closeOutput()
+		c.writer.ErrorResult("removing a variable set requires a -variable-set name or ID")
+		return 1
+	}
+
+	variableSet, err := c.cloud.RemoveVariableSet(c.appCtx, cloud.VariableSetOptions{
+		Organization: c.organization,
+		Workspace:    c.Workspace,
+		VariableSet:  c.VariableSet,
+	})
+	if err != nil {
+		status := c.resolveStatus(err)
+		c.addOutput("status", string(status))
+		c.closeOutput()
+		c.errorResult(status, fmt.Sprintf("error removing variable set, '%s' from workspace '%s': %s", c.VariableSet, c.Workspace, err.Error()))
+		return c.exitCode(status)
+	}
+
+	c.writer.Output(fmt.Sprintf("Variable set %q removed from workspace %q", variableSet.Name, c.Workspace))
+	c.addOutput("status", string(Success))
+	c.addOutput("variable_set_id", variableSet.ID)
+	c.addOutput("variable_set_assignment", "removed")
+	c.writer.OutputResult(c.closeOutput())
+	return 0
+}
+
+func (c *RemoveVariableSetCommand) Help() string {
+	helpText := `
+Usage: tfci [global options] variable-set remove [options]
+
+	Removes a variable set from a workspace, the variable set keeps applying to its other workspaces. Removing a variable set that does not apply to the workspace succeeds. Global variable sets apply to every workspace and cannot be removed from a single workspace.
+
+Global Options:
+
+	-hostname       The hostname of a Terraform Enterprise installation, if using Terraform Enterprise. Defaults to "app.terraform.io".
+
+	-token          The token used to authenticate with HCP Terraform. Defaults to reading "TF_API_TOKEN" environment variable.
+
+	-organization   HCP Terraform Organization Name. Also accepted as a command option, which overrides the global option for that command.
+
+Options:
+
+	-variable-set   The name or ID of the HCP Terraform Variable Set to remove, e.g. "aws-credentials" or "varset-***".
+
+	-workspace      The name of the HCP Terraform Workspace to remove the variable set from.
+	`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *RemoveVariableSetCommand) Synopsis() string {
+	return "Removes a variable set from a workspace"
+}
@@ -0,0 +1,201 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+	"github.com/hashicorp/tfci/internal/environment"
+	"github.com/hashicorp/tfci/internal/writer"
+	"github.com/mitchellh/cli"
+)
+
+type VariableWriter struct {
+	options *cloud.SetVariableOptions
+	created bool
+}
+
+func (v *VariableWriter) SetVariable(_ context.Context, options cloud.SetVariableOptions) (*tfe.Variable, bool, error) {
+	v.options = &options
+	return &tfe.Variable{ID: "var-123", Key: options.Key, Sensitive: options.Sensitive != nil && *options.Sensitive}, v.created, nil
+}
+
+func (v *VariableWriter) ApplyVariableSet(_ context.Context, options cloud.VariableSetOptions) (*tfe.VariableSet, error) {
+	return &tfe.VariableSet{ID: "varset-123", Name: options.VariableSet}, nil
+}
+
+func (v *VariableWriter) RemoveVariableSet(_ context.Context, options cloud.VariableSetOptions) (*tfe.VariableSet, error) {
+	return &tfe.VariableSet{ID: "varset-123", Name: options.VariableSet}, nil
+}
+
+func TestSetVariableCommand(t *testing.T) {
+	testCases := []struct {
+		name     string
+		args     []string
+		created  bool
+		want     int
+		expected *cloud.SetVariableOptions
+		action   string
+	}{
+		{
+			name:    "created",
+			args:    []string{"-workspace=my-workspace", "-key=ami_id", "-value=ami-123"},
+			created: true,
+			want:    0,
+			expected: &cloud.SetVariableOptions{
+				Organization: "hashicorp",
+				Workspace:    "my-workspace",
+				Key:          "ami_id",
+				Value:        "ami-123",
+				Category:     tfe.CategoryTerraform,
+			},
+			action: "created",
+		},
+		{
+			name: "updated-sensitive-env",
+			args: []string{"-workspace=my-workspace", "-key=API_KEY", "-value=secret", "-category=env", "-sensitive"},
+			want: 0,
+			expected: &cloud.SetVariableOptions{
+				Organization: "hashicorp",
+				Workspace:    "my-workspace",
+				Key:          "API_KEY",
+				Value:        "secret",
+				Category:     tfe.CategoryEnv,
+				Sensitive:    tfe.Bool(true),
+			},
+			action: "updated",
+		},
+		{
+			name: "missing-key",
+			args: []string{"-workspace=my-workspace", "-value=ami-123"},
+			want: 1,
+		},
+		{
+			name: "invalid-category",
+			args: []string{"-workspace=my-workspace", "-key=ami_id", "-category=policy-set"},
+			want: 1,
+		},
+		{
+			name: "hcl-env",
+			args: []string{"-workspace=my-workspace", "-key=ami_id", "-category=env", "-hcl"},
+			want: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			variables := &VariableWriter{created: tc.created}
+			cloudService := cloud.NewCloud(&tfe.Client{}, w)
+			cloudService.VariableService = variables
+			meta := NewMetaOpts(context.Background(), cloudService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))
+
+			if code := (&SetVariableCommand{Meta: meta}).Run(tc.args); code != tc.want {
+				t.Fatalf("expected %d but received %d: %s", tc.want, code, ui.ErrorWriter.String())
+			}
+			if tc.expected == nil {
+				if variables.options != nil {
+					t.Errorf("expected no variable to be set but received %+v", variables.options)
+				}
+				return
+			}
+			if !reflect.DeepEqual(variables.options, tc.expected) {
+				t.Errorf("expected options %+v but received %+v", tc.expected, variables.options)
+			}
+			if id := outputValue(meta, "variable_id"); id != "var-123" {
+				t.Errorf("expected variable_id %q but received %q", "var-123", id)
+			}
+			if action := outputValue(meta, "variable_action"); action != tc.action {
+				t.Errorf("expected variable_action %q but received %q", tc.action, action)
+			}
+			if strings.Contains(ui.OutputWriter.String(), tc.expected.Value) {
+				t.Errorf("expected the value to never be written but received %q", ui.OutputWriter.String())
+			}
+		})
+	}
+}
+
+type GlobalVariableSetWriter struct {
+	VariableWriter
+}
+
+func (v *GlobalVariableSetWriter) ApplyVariableSet(_ context.Context, options cloud.VariableSetOptions) (*tfe.VariableSet, error) {
+	return nil, &cloud.GlobalVariableSetError{VariableSet: options.VariableSet}
+}
+
+func TestApplyVariableSetCommand(t *testing.T) {
+	testCases := []struct {
+		name       string
+		variables  cloud.VariableService
+		args       []string
+		want       int
+		assignment string
+		errorCode  string
+	}{
+		{
+			name:       "applied",
+			variables:  &VariableWriter{},
+			args:       []string{"-workspace=my-workspace", "-variable-set=aws"},
+			want:       0,
+			assignment: "applied",
+		},
+		{
+			name:      "missing-variable-set",
+			variables: &VariableWriter{},
+			args:      []string{"-workspace=my-workspace"},
+			want:      1,
+		},
+		{
+			name:      "global",
+			variables: &GlobalVariableSetWriter{},
+			args:      []string{"-workspace=my-workspace", "-variable-set=shared"},
+			want:      1,
+			errorCode: "global_variable_set",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			cloudService := cloud.NewCloud(&tfe.Client{}, w)
+			cloudService.VariableService = tc.variables
+			meta := NewMetaOpts(context.Background(), cloudService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))
+
+			if code := (&ApplyVariableSetCommand{Meta: meta}).Run(tc.args); code != tc.want {
+				t.Fatalf("expected %d but received %d: %s", tc.want, code, ui.ErrorWriter.String())
+			}
+			if assignment := outputValue(meta, "variable_set_assignment"); assignment != tc.assignment {
+				t.Errorf("expected variable_set_assignment %q but received %q", tc.assignment, assignment)
+			}
+			if errorCode := outputValue(meta, "error_code"); errorCode != tc.errorCode {
+				t.Errorf("expected error_code %q but received %q", tc.errorCode, errorCode)
+			}
+		})
+	}
+}
+
+func TestRemoveVariableSetCommand(t *testing.T) {
+	ui := cli.NewMockUi()
+	w := writer.NewWriter(ui)
+	cloudService := cloud.NewCloud(&tfe.Client{}, w)
+	cloudService.VariableService = &VariableWriter{}
+	meta := NewMetaOpts(context.Background(), cloudService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))
+
+	if code := (&RemoveVariableSetCommand{Meta: meta}).Run([]string{"-workspace=my-workspace", "-variable-set=varset-123"}); code != 0 {
+		t.Fatalf("expected 0 but received %d: %s", code, ui.ErrorWriter.String())
+	}
+	if assignment := outputValue(meta, "variable_set_assignment"); assignment != "removed" {
+		t.Errorf("expected variable_set_assignment %q but received %q", "removed", assignment)
+	}
+	if id := outputValue(meta, "variable_set_id"); id != "varset-123" {
+		t.Errorf("expected variable_set_id %q but received %q", "varset-123", id)
+	}
+}
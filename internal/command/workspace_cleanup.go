@@ -0,0 +1,129 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+type CleanupWorkspaceCommand struct {
+	*Meta
+
+	Tags   []string
+	DryRun bool
+}
+
+func (c *CleanupWorkspaceCommand) flags() *flag.FlagSet {
+	f := c.flagSet("workspace cleanup")
+	f.Var((*flagStringSlice)(&c.Tags), "tag", "Only workspaces having all of the tags are considered for cleanup. You can use this option multiple times.")
+	f.BoolVar(&c.DryRun, "dry-run", false, "Lists the expired workspaces without deleting them.")
+
+	return f
+}
+
+func (c *CleanupWorkspaceCommand) Run(args []string) int {
+	if err := c.setupCmd(args, c.flags(), c.requireOrganization()); err != nil {
+		return 1
+	}
+
+	// require a selector so an organization's workspaces cannot all be considered by accident
+	if len(c.Tags) == 0 {
+		c.addOutput("status", string(Error))
+		c.closeOutput()
+		c.writer.ErrorResult("workspace cleanup requires at least one -tag selector")
+		return 1
+	}
+
+	workspaces, err := c.cloud.ListWorkspacesByTags(c.appCtx, c.organization, c.Tags)
+	if err != nil {
+		status := c.resolveStatus(err)
+		c.addOutput("status", string(status))
+		c.closeOutput()
+		c.errorResult(status, fmt.Sprintf("error listing workspaces in HCP Terraform: %s", err.Error()))
+		return c.exitCode(status)
+	}
+
+	deleted, skipped, failed := []string{}, []string{}, []string{}
+	for _, w := range c.expired(workspaces, time.Now()) {
+		if c.DryRun {
+			c.writer.Output(fmt.Sprintf("Would delete expired workspace: %q", w.Name))
+			deleted = append(deleted, w.Name)
+			continue
+		}
+
+		deleteErr := c.cloud.SafeDeleteWorkspace(c.appCtx, c.organization, w.Name)
+		switch {
+		case deleteErr == nil:
+			c.writer.Output(fmt.Sprintf("Deleted expired workspace: %q", w.Name))
+			deleted = append(deleted, w.Name)
+		// workspaces still managing resources are reported rather than failing the cleanup
+		case errors.Is(deleteErr, tfe.ErrWorkspaceNotSafeToDelete), errors.Is(deleteErr, tfe.ErrWorkspaceStillProcessing):
+			c.writer.Output(fmt.Sprintf("Skipping expired workspace %q, it is still managing resources", w.Name))
+			skipped = append(skipped, w.Name)
+		default:
+			c.writer.Error(fmt.Sprintf("error deleting workspace %q: %s", w.Name, deleteErr.Error()))
+			failed = append(failed, w.Name)
+		}
+	}
+
+	c.addOutput("dry_run", fmt.Sprint(c.DryRun))
+	c.addOutput("deleted_workspaces", strings.Join(deleted, ","))
+	c.addOutput("skipped_workspaces", strings.Join(skipped, ","))
+	if len(failed) > 0 {
+		c.addOutput("status", string(Error))
+		c.addOutput("failed_workspaces", strings.Join(failed, ","))
+		c.writer.ErrorResult(fmt.Sprintf("error deleting workspaces: %s", strings.Join(failed, ", ")))
+		c.writer.OutputResult(c.closeOutput())
+		return 1
+	}
+
+	c.addOutput("status", string(Success))
+	c.writer.OutputResult(c.closeOutput())
+	return 0
+}
+
+// returns the workspaces whose expires-at tag is in the past, workspaces without the tag never expire
+func (c *CleanupWorkspaceCommand) expired(workspaces []*tfe.Workspace, now time.Time) []*tfe.Workspace {
+	expired := []*tfe.Workspace{}
+	for _, w := range workspaces {
+		expiresAt, ok := parseExpiresAt(w.TagNames)
+		if ok && expiresAt.Before(now) {
+			expired = append(expired, w)
+		}
+	}
+	return expired
+}
+
+func (c *CleanupWorkspaceCommand) Help() string {
+	helpText := `
+Usage: tfci [global options] workspace cleanup [options]
+
+	Safely deletes workspaces whose "expires-at" tag, set with "workspace create -ttl", is in the past. Workspaces still managing resources are skipped and reported in the skipped_workspaces output.
+
+Global Options:
+
+	-hostname       The hostname of a Terraform Enterprise installation, if using Terraform Enterprise. Defaults to "app.terraform.io".
+
+	-token          The token used to authenticate with HCP Terraform. Defaults to reading "TF_API_TOKEN" environment variable.
+
+	-organization   HCP Terraform Organization Name. Also accepted as a command option, which overrides the global option for that command.
+
+Options:
+
+	-tag            Only workspaces having all of the tags are considered for cleanup. Required. This option accepts multiple instances by providing additional tag option flags.
+
+	-dry-run        Lists the expired workspaces without deleting them, reporting them in the deleted_workspaces output. Unlike the global -dry-run, which sends no requests and so stops before listing the workspaces, the workspaces are read from HCP Terraform.
+	`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *CleanupWorkspaceCommand) Synopsis() string {
+	return "Safely deletes expired workspaces"
+}
@@ -0,0 +1,132 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+	"github.com/hashicorp/tfci/internal/environment"
+	"github.com/hashicorp/tfci/internal/writer"
+	"github.com/mitchellh/cli"
+)
+
+// lists tagged workspaces and records safe deletes, failing for the configured workspaces
+type WorkspaceCleaner struct {
+	WorkspaceReader
+
+	deleteErrs map[string]error
+	deleted    []string
+}
+
+func (w *WorkspaceCleaner) SafeDeleteWorkspace(_ context.Context, _ string, name string) error {
+	if err := w.deleteErrs[name]; err != nil {
+		return err
+	}
+	w.deleted = append(w.deleted, name)
+	return nil
+}
+
+func TestCleanupWorkspaceCommand(t *testing.T) {
+	expired := expiresAtTag(time.Now().Add(-time.Hour))
+	active := expiresAtTag(time.Now().Add(time.Hour))
+	workspaces := []*tfe.Workspace{
+		{Name: "pr-1", TagNames: []string{"preview", expired}},
+		{Name: "pr-2", TagNames: []string{"preview", active}},
+		{Name: "pr-3", TagNames: []string{"preview", expired}},
+		{Name: "pr-4", TagNames: []string{"preview"}},
+	}
+
+	testCases := []struct {
+		name          string
+		args          []string
+		deleteErrs    map[string]error
+		exitStatus    int
+		expectDeleted []string
+		outputs       map[string]string
+	}{
+		{
+			name:          "deletes-expired",
+			args:          []string{"-tag=preview"},
+			exitStatus:    0,
+			expectDeleted: []string{"pr-1", "pr-3"},
+			outputs: map[string]string{
+				"deleted_workspaces": "pr-1,pr-3",
+				"skipped_workspaces": "",
+			},
+		},
+		{
+			// expired workspaces are listed and reported, but never deleted
+			name:       "dry-run",
+			args:       []string{"-tag=preview", "-dry-run"},
+			exitStatus: 0,
+			outputs: map[string]string{
+				"deleted_workspaces": "pr-1,pr-3",
+				"dry_run":            "true",
+			},
+		},
+		{
+			name:          "still-managing-resources",
+			args:          []string{"-tag=preview"},
+			deleteErrs:    map[string]error{"pr-1": tfe.ErrWorkspaceNotSafeToDelete},
+			exitStatus:    0,
+			expectDeleted: []string{"pr-3"},
+			outputs: map[string]string{
+				"deleted_workspaces": "pr-3",
+				"skipped_workspaces": "pr-1",
+			},
+		},
+		{
+			name:       "missing-tag-selector",
+			args:       []string{},
+			exitStatus: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := writer.NewWriter(ui)
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, w)
+			cleaner := &WorkspaceCleaner{WorkspaceReader: WorkspaceReader{tagged: workspaces}, deleteErrs: tc.deleteErrs}
+			cloudMockService.WorkspaceService = cleaner
+			cmd := &CleanupWorkspaceCommand{Meta: NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))}
+
+			if actual := cmd.Run(tc.args); actual != tc.exitStatus {
+				t.Fatalf("expected %d but received %d", tc.exitStatus, actual)
+			}
+			if len(cleaner.deleted) != len(tc.expectDeleted) {
+				t.Fatalf("expected deleted %v but received %v", tc.expectDeleted, cleaner.deleted)
+			}
+			for i := range cleaner.deleted {
+				if cleaner.deleted[i] != tc.expectDeleted[i] {
+					t.Errorf("expected deleted %v but received %v", tc.expectDeleted, cleaner.deleted)
+				}
+			}
+			for name, expected := range tc.outputs {
+				if actual := outputValue(cmd.Meta, name); actual != expected {
+					t.Errorf("expected %s %q but received %q", name, expected, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestParseExpiresAt(t *testing.T) {
+	expiresAt := time.Unix(1767225600, 0)
+	if tag := expiresAtTag(expiresAt); tag != "expires-at:1767225600" {
+		t.Errorf("expected %q but received %q", "expires-at:1767225600", tag)
+	}
+
+	actual, ok := parseExpiresAt([]string{"preview", "expires-at:invalid", "expires-at:1767225600"})
+	if !ok || !actual.Equal(expiresAt) {
+		t.Errorf("expected %s but received %s", expiresAt, actual)
+	}
+	if _, ok := parseExpiresAt([]string{"preview"}); ok {
+		t.Errorf("expected no expiry without an expires-at tag")
+	}
+}
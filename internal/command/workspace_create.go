@@ -0,0 +1,156 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/tfci/internal/cloud"
+)
+
+// tag prefix recording when an ephemeral workspace expires, as unix seconds, e.g. expires-at:1767225600.
+// Tag names only allow lowercase letters, numbers, colons, hyphens and underscores.
+const expiresAtTagPrefix = "expires-at:"
+
+func expiresAtTag(t time.Time) string {
+	return expiresAtTagPrefix + strconv.FormatInt(t.Unix(), 10)
+}
+
+// returns the expiry recorded in the workspace tags, if any
+func parseExpiresAt(tags []string) (time.Time, bool) {
+	for _, tag := range tags {
+		value, ok := strings.CutPrefix(tag, expiresAtTagPrefix)
+		if !ok {
+			continue
+		}
+		seconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		return time.Unix(seconds, 0), true
+	}
+	return time.Time{}, false
+}
+
+type CreateWorkspaceCommand struct {
+	*Meta
+
+	Workspace        string
+	Project          string
+	ExecutionMode    string
+	TerraformVersion string
+	Tags             []string
+	TTL              time.Duration
+}
+
+func (c *CreateWorkspaceCommand) flags() *flag.FlagSet {
+	f := c.flagSet("workspace create")
+	f.StringVar(&c.Workspace, "workspace", "", "The name of the HCP Terraform Workspace to create.")
+	f.StringVar(&c.Project, "workspace-project", "", "The project name or ID to create the workspace in.")
+	f.StringVar(&c.ExecutionMode, "execution-mode", "", "The execution mode of the workspace: remote, local or agent.")
+	f.StringVar(&c.TerraformVersion, "terraform-version", "", "The Terraform version of the workspace.")
+	f.Var((*flagStringSlice)(&c.Tags), "tag", "A tag to add to the workspace. You can use this option multiple times.")
+	f.DurationVar(&c.TTL, "ttl", 0, "Stamps the workspace with an expires-at tag, after which it is deleted by workspace cleanup. e.g. -ttl=72h")
+
+	return f
+}
+
+func (c *CreateWorkspaceCommand) Run(args []string) int {
+	if err := c.setupCmd(args, c.flags(), c.requireOrganization(), requireWorkspace(&c.Workspace)); err != nil {
+		return 1
+	}
+
+	if c.ExecutionMode != "" && !slices.Contains(executionModes, c.ExecutionMode) {
+		c.addOutput("status", string(Error))
+		c.closeOutput()
+		c.writer.ErrorResult(fmt.Sprintf("invalid -execution-mode %q, must be one of: %s", c.ExecutionMode, strings.Join(executionModes, ", ")))
+		return 1
+	}
+
+	if c.TTL < 0 {
+		c.addOutput("status", string(Error))
+		c.closeOutput()
+		c.writer.ErrorResult("-ttl must be a positive duration")
+		return 1
+	}
+
+	tags := c.Tags
+	if c.TTL > 0 {
+		tags = append(tags, expiresAtTag(time.Now().Add(c.TTL)))
+	}
+
+	workspace, wErr := c.cloud.CreateWorkspace(c.appCtx, cloud.CreateWorkspaceOptions{
+		Organization:     c.organization,
+		Name:             c.Workspace,
+		Project:          c.Project,
+		ExecutionMode:    c.ExecutionMode,
+		TerraformVersion: c.TerraformVersion,
+		Tags:             tags,
+	})
+	if wErr != nil {
+		status := c.resolveStatus(wErr)
+		c.addOutput("status", string(status))
+		c.closeOutput()
+		c.errorResult(status, fmt.Sprintf("error creating workspace, '%s' in HCP Terraform: %s", c.Workspace, wErr.Error()))
+		return c.exitCode(status)
+	}
+
+	c.addOutput("status", string(Success))
+	c.addOutput("workspace_id", workspace.ID)
+	c.addOutput("workspace_name", workspace.Name)
+	// read from the workspace tags, an existing workspace is returned as is without the tag of -ttl
+	if expiresAt, ok := parseExpiresAt(workspace.TagNames); ok {
+		c.addOutput("expires_at", expiresAt.UTC().Format(time.RFC3339))
+	}
+	c.addOutputWithOpts("payload", workspace, &outputOpts{
+		stdOut:      false,
+		multiLine:   true,
+		platformOut: true,
+		fields:      c.payloadFields,
+	})
+	c.writer.OutputResult(c.closeOutput())
+	return 0
+}
+
+func (c *CreateWorkspaceCommand) Help() string {
+	helpText := `
+Usage: tfci [global options] workspace create [options]
+
+	Creates a new workspace, returning the existing workspace as is if one with the same name already exists.
+
+Global Options:
+
+	-hostname       The hostname of a Terraform Enterprise installation, if using Terraform Enterprise. Defaults to "app.terraform.io".
+
+	-token          The token used to authenticate with HCP Terraform. Defaults to reading "TF_API_TOKEN" environment variable.
+
+	-organization   HCP Terraform Organization Name. Also accepted as a command option, which overrides the global option for that command.
+
+Options:
+
+	-workspace          The name of the HCP Terraform Workspace to create.
+
+	-workspace-project  The project name or ID to create the workspace in. Defaults to the organization's default project.
+
+	-execution-mode     The execution mode of the workspace: remote, local or agent.
+
+	-terraform-version  The Terraform version of the workspace.
+
+	-tag                A tag to add to the workspace, e.g. a selector for workspace cleanup. This option accepts multiple instances by providing additional tag option flags.
+
+	-ttl                Stamps the workspace with an "expires-at:<unix seconds>" tag, after which it is deleted by workspace cleanup. e.g. -ttl=72h. The expires_at output is read from the workspace tags, so it is not set when an existing workspace without the tag is returned.
+
+	-payload-fields     Comma separated list of top-level fields to include in the payload output, e.g. id,status,created-at. Defaults to all fields.
+	`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *CreateWorkspaceCommand) Synopsis() string {
+	return "Creates a new workspace"
+}
@@ -0,0 +1,169 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/tfci/internal/cloud"
+	"github.com/hashicorp/tfci/internal/logging"
+)
+
+// status of the workspace's latest health assessment
+const (
+	driftStatusDrifted  = "drifted"
+	driftStatusNoDrift  = "no_drift"
+	driftStatusDisabled = "disabled"
+	driftStatusErrored  = "errored"
+)
+
+type WorkspaceDriftCommand struct {
+	*Meta
+
+	Workspace string
+}
+
+func (c *WorkspaceDriftCommand) flags() *flag.FlagSet {
+	f := c.flagSet("workspace drift")
+	f.StringVar(&c.Workspace, "workspace", "", "The name of the HCP Terraform Workspace.")
+
+	return f
+}
+
+func (c *WorkspaceDriftCommand) Run(args []string) int {
+	if err := c.setupCmd(args, c.flags(), c.requireOrganization(), requireWorkspace(&c.Workspace)); err != nil {
+		return 1
+	}
+
+	assessment, aErr := c.cloud.GetAssessmentResult(c.appCtx, c.organization, c.Workspace)
+	if aErr != nil {
+		status := c.resolveStatus(aErr)
+		c.addOutput("status", string(status))
+		c.closeOutput()
+		c.errorResult(status, fmt.Sprintf("error reading health assessment for workspace, '%s' in HCP Terraform: %s", c.Workspace, aErr.Error()))
+		return c.exitCode(status)
+	}
+
+	drifted := []*cloud.DriftedResource{}
+	driftStatus := assessmentDriftStatus(assessment)
+	switch driftStatus {
+	case driftStatusDisabled:
+		c.writer.Output(fmt.Sprintf("Health assessments are not enabled or have not completed for workspace: %q", c.Workspace))
+	case driftStatusErrored:
+		c.writer.Output(fmt.Sprintf("The latest health assessment for workspace: %q errored: %s", c.Workspace, assessment.ErrorMsg))
+	case driftStatusDrifted:
+		resources, dErr := c.cloud.GetAssessmentDrift(c.appCtx, assessment.ID)
+		if dErr != nil {
+			status := c.resolveStatus(dErr)
+			c.addOutput("status", string(status))
+			c.addOutput("drift_status", driftStatus)
+			c.closeOutput()
+			c.errorResult(status, fmt.Sprintf("error reading drift details for assessment '%s', reading drift details requires admin access to the workspace: %s", assessment.ID, dErr.Error()))
+			return c.exitCode(status)
+		}
+		drifted = resources
+	}
+
+	summary := driftSummaryMarkdown(c.Workspace, driftStatus, drifted)
+	c.addOutput("status", string(Success))
+	c.addOutput("drift_status", driftStatus)
+	if assessment != nil {
+		c.addOutput("assessment_id", assessment.ID)
+		c.addOutput("drifted_resources", fmt.Sprint(assessment.ResourcesDrifted))
+	}
+	c.addOutputWithOpts("drift_payload", drifted, &outputOpts{
+		stdOut:      true,
+		multiLine:   true,
+		platformOut: true,
+	})
+	c.addOutputWithOpts("drift_summary", summary, &outputOpts{
+		stdOut:      false,
+		multiLine:   true,
+		platformOut: true,
+	})
+	c.writeDriftSummary(summary)
+	c.writer.OutputResult(c.closeOutput())
+	return 0
+}
+
+// a missing assessment means health assessments are disabled or none has completed yet
+func assessmentDriftStatus(assessment *cloud.AssessmentResult) string {
+	switch {
+	case assessment == nil:
+		return driftStatusDisabled
+	case !assessment.Succeeded:
+		return driftStatusErrored
+	case assessment.Drifted:
+		return driftStatusDrifted
+	default:
+		return driftStatusNoDrift
+	}
+}
+
+// builds a markdown table of the drifted resources for the platform's job summary page
+func driftSummaryMarkdown(workspace string, driftStatus string, drifted []*cloud.DriftedResource) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "### Drift for workspace %s\n\n", workspace)
+	switch driftStatus {
+	case driftStatusDisabled:
+		b.WriteString("Health assessments are not enabled or have not completed.\n\n")
+		return b.String()
+	case driftStatusErrored:
+		b.WriteString("The latest health assessment errored.\n\n")
+		return b.String()
+	case driftStatusNoDrift:
+		b.WriteString("No drift detected.\n\n")
+		return b.String()
+	}
+
+	b.WriteString("| Resource | Actions | Changed attributes |\n")
+	b.WriteString("| --- | --- | --- |\n")
+	for _, r := range drifted {
+		changed := "-"
+		if len(r.ChangedAttributes) > 0 {
+			changed = "`" + strings.Join(r.ChangedAttributes, "`, `") + "`"
+		}
+		fmt.Fprintf(&b, "| `%s` | %s | %s |\n", r.Address, strings.Join(r.Actions, ", "), changed)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// writes the drift summary to the platform's job summary page, a failure is only a warning
+func (c *WorkspaceDriftCommand) writeDriftSummary(summary string) {
+	if c.env == nil {
+		return
+	}
+	if err := c.env.WriteStepSummary(summary); err != nil {
+		logging.Warn("Failed to write drift summary to the job summary", "workspace", c.Workspace, "error", err)
+	}
+}
+
+func (c *WorkspaceDriftCommand) Help() string {
+	helpText := `
+Usage: tfci [global options] workspace drift [options]
+
+	Returns the drifted resources detected by the workspace's latest health assessment.
+
+Global Options:
+
+	-hostname       The hostname of a Terraform Enterprise installation, if using Terraform Enterprise. Defaults to "app.terraform.io".
+
+	-token          The token used to authenticate with HCP Terraform. Defaults to reading "TF_API_TOKEN" environment variable.
+
+	-organization   HCP Terraform Organization Name. Also accepted as a command option, which overrides the global option for that command.
+
+Options:
+
+	-workspace      Existing HCP Terraform Workspace. Reading drift details requires admin access to the workspace.
+	`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *WorkspaceDriftCommand) Synopsis() string {
+	return "Returns the drifted resources detected by the workspace's latest health assessment"
+}
@@ -0,0 +1,88 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+	"github.com/hashicorp/tfci/internal/environment"
+	"github.com/hashicorp/tfci/internal/writer"
+	"github.com/mitchellh/cli"
+)
+
+func TestWorkspaceDriftCommand(t *testing.T) {
+	drifted := []*cloud.DriftedResource{
+		{Address: "aws_instance.web", Type: "aws_instance", Actions: []string{"update"}, ChangedAttributes: []string{"instance_type", "tags"}},
+	}
+
+	testCases := []struct {
+		name            string
+		assessment      *cloud.AssessmentResult
+		expectedStatus  string
+		expectedPayload string
+		expectedSummary string
+	}{
+		{
+			name:            "drifted",
+			assessment:      &cloud.AssessmentResult{ID: "asmtres-***", Succeeded: true, Drifted: true, ResourcesDrifted: 1},
+			expectedStatus:  "drifted",
+			expectedPayload: `[{"address":"aws_instance.web","type":"aws_instance","actions":["update"],"changed_attributes":["instance_type","tags"]}]`,
+			expectedSummary: "| `aws_instance.web` | update | `instance_type`, `tags` |",
+		},
+		{
+			name:            "no-drift",
+			assessment:      &cloud.AssessmentResult{ID: "asmtres-***", Succeeded: true},
+			expectedStatus:  "no_drift",
+			expectedPayload: `[]`,
+			expectedSummary: "No drift detected.",
+		},
+		{
+			name:            "errored",
+			assessment:      &cloud.AssessmentResult{ID: "asmtres-***", ErrorMsg: "provider error"},
+			expectedStatus:  "errored",
+			expectedPayload: `[]`,
+			expectedSummary: "The latest health assessment errored.",
+		},
+		{
+			name:            "disabled",
+			expectedStatus:  "disabled",
+			expectedPayload: `[]`,
+			expectedSummary: "Health assessments are not enabled or have not completed.",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			writer := writer.NewWriter(ui)
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, writer)
+			cloudMockService.WorkspaceService = &WorkspaceReader{assessment: tc.assessment, drifted: drifted}
+			summaryCtx := &SummaryContext{}
+
+			meta := NewMetaOpts(context.Background(), cloudMockService, &environment.CI{Context: summaryCtx}, WithWriter(writer), WithOrg("hashicorp"))
+			cmd := &WorkspaceDriftCommand{Meta: meta}
+
+			if code := cmd.Run([]string{"-workspace=my-workspace"}); code != 0 {
+				t.Fatalf("expected %d but received %d: %s", 0, code, ui.ErrorWriter.String())
+			}
+
+			if actual := outputValue(meta, "drift_status"); actual != tc.expectedStatus {
+				t.Errorf("expected drift_status %q but received %q", tc.expectedStatus, actual)
+			}
+			if actual := outputValue(meta, "drift_payload"); actual != tc.expectedPayload {
+				t.Errorf("expected drift_payload %s but received %s", tc.expectedPayload, actual)
+			}
+			if !strings.Contains(outputValue(meta, "drift_summary"), tc.expectedSummary) {
+				t.Errorf("expected drift_summary to contain %q but received %q", tc.expectedSummary, outputValue(meta, "drift_summary"))
+			}
+			if !strings.Contains(summaryCtx.summary, tc.expectedSummary) {
+				t.Errorf("expected job summary to contain %q but received %q", tc.expectedSummary, summaryCtx.summary)
+			}
+		})
+	}
+}
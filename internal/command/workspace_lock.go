@@ -0,0 +1,90 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/tfci/internal/cloud"
+)
+
+type LockWorkspaceCommand struct {
+	*Meta
+
+	Workspace string
+	Reason    string
+}
+
+func (c *LockWorkspaceCommand) flags() *flag.FlagSet {
+	f := c.flagSet("workspace lock")
+	f.StringVar(&c.Workspace, "workspace", "", "The name of the HCP Terraform Workspace to lock.")
+	f.StringVar(&c.Reason, "reason", "", "The reason for locking the workspace, shown in HCP Terraform.")
+
+	return f
+}
+
+func (c *LockWorkspaceCommand) Run(args []string) int {
+	if err := c.setupCmd(args, c.flags(), c.requireOrganization(), requireWorkspace(&c.Workspace)); err != nil {
+		return 1
+	}
+
+	workspace, alreadyLocked, err := c.cloud.LockWorkspace(c.appCtx, cloud.LockWorkspaceOptions{
+		Organization: c.organization,
+		Workspace:    c.Workspace,
+		Reason:       c.Reason,
+	})
+	if err != nil {
+		status := c.resolveStatus(err)
+		c.addOutput("status", string(status))
+		c.closeOutput()
+		c.errorResult(status, fmt.Sprintf("error locking workspace, '%s' in HCP Terraform: %s", c.Workspace, err.Error()))
+		return c.exitCode(status)
+	}
+
+	status := Success
+	if alreadyLocked {
+		// the existing lock is held by the current user, it is kept as is
+		status = Noop
+		c.writer.Output(fmt.Sprintf("Workspace %q is already locked by the current user, there is nothing to do", c.Workspace))
+		c.addOutput("lock_reason", "")
+	} else {
+		c.writer.Output(fmt.Sprintf("Locked workspace %q", c.Workspace))
+		c.addOutput("lock_reason", c.Reason)
+	}
+
+	c.addOutput("status", string(status))
+	c.addOutput("workspace_id", workspace.ID)
+	c.addOutput("workspace_locked", "true")
+	c.writer.OutputResult(c.closeOutput())
+	return 0
+}
+
+func (c *LockWorkspaceCommand) Help() string {
+	helpText := `
+Usage: tfci [global options] workspace lock [options]
+
+	Locks a workspace, preventing runs from being applied, e.g. during maintenance. A workspace which is already locked by the same user is left as is and the status is "Noop". A lock held by another user, team or run fails with the "locked" error code. Unlock it with "workspace unlock".
+
+Global Options:
+
+	-hostname       The hostname of a Terraform Enterprise installation, if using Terraform Enterprise. Defaults to "app.terraform.io".
+
+	-token          The token used to authenticate with HCP Terraform. Defaults to reading "TF_API_TOKEN" environment variable.
+
+	-organization   HCP Terraform Organization Name. Also accepted as a command option, which overrides the global option for that command.
+
+Options:
+
+	-workspace      The name of the HCP Terraform Workspace to lock.
+
+	-reason         The reason for locking the workspace, shown in HCP Terraform.
+	`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *LockWorkspaceCommand) Synopsis() string {
+	return "Locks a workspace"
+}
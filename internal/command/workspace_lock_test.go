@@ -0,0 +1,167 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+	"github.com/hashicorp/tfci/internal/environment"
+	"github.com/hashicorp/tfci/internal/writer"
+	"github.com/mitchellh/cli"
+)
+
+func testWorkspaceLockMeta(reader *WorkspaceReader) (*cli.MockUi, *Meta) {
+	ui := cli.NewMockUi()
+	w := writer.NewWriter(ui)
+	cloudService := cloud.NewCloud(&tfe.Client{}, w)
+	cloudService.WorkspaceService = reader
+	return ui, NewMetaOpts(context.Background(), cloudService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))
+}
+
+func TestLockWorkspaceCommand(t *testing.T) {
+	testCases := []struct {
+		name           string
+		workspace      *tfe.Workspace
+		err            error
+		args           []string
+		want           int
+		expectedStatus Status
+		expectedReason string
+		expectedCode   string
+	}{
+		{
+			name:           "locked",
+			workspace:      &tfe.Workspace{ID: "ws-123"},
+			args:           []string{"-workspace=my-workspace", "-reason=maintenance"},
+			want:           0,
+			expectedStatus: Success,
+			expectedReason: "maintenance",
+		},
+		{
+			name:           "already-locked",
+			workspace:      &tfe.Workspace{ID: "ws-123", Locked: true},
+			args:           []string{"-workspace=my-workspace", "-reason=maintenance"},
+			want:           0,
+			expectedStatus: Noop,
+		},
+		{
+			name:           "locked-by-other",
+			workspace:      &tfe.Workspace{ID: "ws-123", Locked: true},
+			err:            &cloud.WorkspaceLockedError{Workspace: "my-workspace", LockedBy: `run "run-123"`},
+			args:           []string{"-workspace=my-workspace", "-reason=maintenance"},
+			want:           1,
+			expectedStatus: Error,
+			expectedCode:   "locked",
+		},
+		{
+			name:           "missing-workspace",
+			workspace:      &tfe.Workspace{ID: "ws-123"},
+			want:           1,
+			expectedStatus: Error,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reader := &WorkspaceReader{workspace: tc.workspace, err: tc.err}
+			ui, meta := testWorkspaceLockMeta(reader)
+
+			if code := (&LockWorkspaceCommand{Meta: meta}).Run(tc.args); code != tc.want {
+				t.Fatalf("expected %d but received %d: %s", tc.want, code, ui.ErrorWriter.String())
+			}
+			if status := outputValue(meta, "status"); status != string(tc.expectedStatus) {
+				t.Errorf("expected status %q but received %q", tc.expectedStatus, status)
+			}
+			if code := outputValue(meta, "error_code"); code != tc.expectedCode {
+				t.Errorf("expected error_code %q but received %q", tc.expectedCode, code)
+			}
+			if tc.want != 0 {
+				return
+			}
+			if reader.locked.Reason != "maintenance" {
+				t.Errorf("expected reason %q but received %q", "maintenance", reader.locked.Reason)
+			}
+			if locked := outputValue(meta, "workspace_locked"); locked != "true" {
+				t.Errorf("expected workspace_locked %q but received %q", "true", locked)
+			}
+			if reason := outputValue(meta, "lock_reason"); reason != tc.expectedReason {
+				t.Errorf("expected lock_reason %q but received %q", tc.expectedReason, reason)
+			}
+		})
+	}
+}
+
+func TestUnlockWorkspaceCommand(t *testing.T) {
+	testCases := []struct {
+		name           string
+		workspace      *tfe.Workspace
+		err            error
+		args           []string
+		want           int
+		expectedStatus Status
+		expectedForce  bool
+		expectedHint   bool
+	}{
+		{
+			name:           "unlocked",
+			workspace:      &tfe.Workspace{ID: "ws-123", Locked: true},
+			args:           []string{"-workspace=my-workspace"},
+			want:           0,
+			expectedStatus: Success,
+		},
+		{
+			name:           "force-unlocked",
+			workspace:      &tfe.Workspace{ID: "ws-123", Locked: true},
+			args:           []string{"-workspace=my-workspace", "-force"},
+			want:           0,
+			expectedStatus: Success,
+			expectedForce:  true,
+		},
+		{
+			name:           "already-unlocked",
+			workspace:      &tfe.Workspace{ID: "ws-123"},
+			args:           []string{"-workspace=my-workspace"},
+			want:           0,
+			expectedStatus: Noop,
+		},
+		{
+			name:           "locked-by-run",
+			err:            fmt.Errorf("failed to unlock workspace: %w", tfe.ErrWorkspaceLockedByRun),
+			args:           []string{"-workspace=my-workspace"},
+			want:           1,
+			expectedStatus: Error,
+			expectedHint:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reader := &WorkspaceReader{workspace: tc.workspace, err: tc.err}
+			ui, meta := testWorkspaceLockMeta(reader)
+
+			if code := (&UnlockWorkspaceCommand{Meta: meta}).Run(tc.args); code != tc.want {
+				t.Fatalf("expected %d but received %d: %s", tc.want, code, ui.ErrorWriter.String())
+			}
+			if status := outputValue(meta, "status"); status != string(tc.expectedStatus) {
+				t.Errorf("expected status %q but received %q", tc.expectedStatus, status)
+			}
+			if reader.unlocked.Force != tc.expectedForce {
+				t.Errorf("expected force %t but received %t", tc.expectedForce, reader.unlocked.Force)
+			}
+			if hint := strings.Contains(ui.ErrorWriter.String(), "-force"); hint != tc.expectedHint {
+				t.Errorf("expected -force hint %t but received %q", tc.expectedHint, ui.ErrorWriter.String())
+			}
+			if tc.want == 0 {
+				if locked := outputValue(meta, "workspace_locked"); locked != "false" {
+					t.Errorf("expected workspace_locked %q but received %q", "false", locked)
+				}
+			}
+		})
+	}
+}
@@ -6,13 +6,19 @@ package command
 import (
 	"flag"
 	"fmt"
+	"sort"
 	"strings"
+
+	"github.com/hashicorp/go-tfe"
 )
 
 type WorkspaceOutputCommand struct {
 	*Meta
 
-	Workspace string
+	Workspace        string
+	WaitForSerial    int64
+	Names            []string
+	IncludeSensitive bool
 }
 
 type WorkspaceOutput struct {
@@ -23,21 +29,31 @@ type WorkspaceOutput struct {
 func (c *WorkspaceOutputCommand) flags() *flag.FlagSet {
 	f := c.flagSet("state output")
 	f.StringVar(&c.Workspace, "workspace", "", "The name of the HCP Terraform Workspace.")
+	f.Int64Var(&c.WaitForSerial, "wait-for-serial", 0, "Waits until the workspace's current state version serial is at least this value before reading outputs.")
+	f.Var((*flagStringSlice)(&c.Names), "name", "Name of an output to return, all outputs are returned by default. You can use this option multiple times.")
+	f.BoolVar(&c.IncludeSensitive, "include-sensitive", false, "Includes sensitive outputs, each sensitive value is masked in GitHub Actions and Azure Pipelines logs.")
 
 	return f
 }
 
 func (c *WorkspaceOutputCommand) Run(args []string) int {
-	if err := c.setupCmd(args, c.flags()); err != nil {
+	if err := c.setupCmd(args, c.flags(), c.requireOrganization(), requireWorkspace(&c.Workspace)); err != nil {
 		return 1
 	}
 
-	// validate workspace name was supplied as argument
-	if c.Workspace == "" {
-		c.addOutput("status", string(Error))
-		c.closeOutput()
-		c.writer.ErrorResult("error workspace output list requires a workspace name")
-		return 1
+	// wait for state from a recent apply to become the current state version
+	if c.WaitForSerial > 0 {
+		sv, svErr := c.cloud.WaitForStateVersion(c.appCtx, c.organization, c.Workspace, c.WaitForSerial)
+		if sv != nil {
+			c.addOutput("state_version_serial", fmt.Sprint(sv.Serial))
+		}
+		if svErr != nil {
+			status := c.resolveStatus(svErr)
+			c.addOutput("status", string(status))
+			c.closeOutput()
+			c.errorResult(status, fmt.Sprintf("error waiting for workspace state version serial %d: %s\n", c.WaitForSerial, svErr.Error()))
+			return c.exitCode(status)
+		}
 	}
 
 	svoList, svoErr := c.cloud.ReadStateOutputs(c.appCtx, c.organization, c.Workspace)
@@ -45,12 +61,26 @@ func (c *WorkspaceOutputCommand) Run(args []string) int {
 		status := c.resolveStatus(svoErr)
 		c.addOutput("status", string(status))
 		c.closeOutput()
-		c.writer.ErrorResult(fmt.Sprintf("error retrieving workspace state version outputs: %s\n", svoErr.Error()))
+		c.errorResult(status, fmt.Sprintf("error retrieving workspace state version outputs: %s\n", svoErr.Error()))
+		return c.exitCode(status)
+	}
+
+	selected, selectErr := c.selectOutputs(svoList.Items)
+	if selectErr != nil {
+		c.addOutput("status", string(Error))
+		c.closeOutput()
+		c.writer.ErrorResult(selectErr.Error())
 		return 1
 	}
 
 	workspaceOutputs := []*WorkspaceOutput{}
-	for _, svo := range svoList.Items {
+	sensitive := false
+	masks := []string{}
+	for _, svo := range selected {
+		if svo.Sensitive {
+			sensitive = true
+			masks = append(masks, maskValues(svo.Value)...)
+		}
 		workspaceOutputs = append(workspaceOutputs, &WorkspaceOutput{
 			Name:  svo.Name,
 			Value: svo.Value,
@@ -61,12 +91,60 @@ func (c *WorkspaceOutputCommand) Run(args []string) int {
 		stdOut:      true,
 		multiLine:   true,
 		platformOut: true,
+		sensitive:   sensitive,
+		masks:       masks,
 	})
 	c.addOutput("status", string(Success))
 	c.writer.OutputResult(c.closeOutput())
 	return 0
 }
 
+// filters the outputs to the requested -name options, in the requested order, and omits
+// sensitive outputs unless -include-sensitive is set
+func (c *WorkspaceOutputCommand) selectOutputs(items []*tfe.StateVersionOutput) ([]*tfe.StateVersionOutput, error) {
+	if len(c.Names) == 0 {
+		selected := []*tfe.StateVersionOutput{}
+		omitted := 0
+		for _, svo := range items {
+			if svo.Sensitive && !c.IncludeSensitive {
+				omitted++
+				continue
+			}
+			selected = append(selected, svo)
+		}
+		if omitted > 0 {
+			c.writer.Output(fmt.Sprintf("Omitting %d sensitive outputs, use -include-sensitive to include them", omitted))
+		}
+		return selected, nil
+	}
+
+	byName := make(map[string]*tfe.StateVersionOutput, len(items))
+	available := make([]string, 0, len(items))
+	for _, svo := range items {
+		byName[svo.Name] = svo
+		available = append(available, svo.Name)
+	}
+	sort.Strings(available)
+
+	selected := []*tfe.StateVersionOutput{}
+	missing := []string{}
+	for _, name := range c.Names {
+		svo, ok := byName[name]
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		if svo.Sensitive && !c.IncludeSensitive {
+			return nil, fmt.Errorf("output %q is sensitive, use -include-sensitive to include it", name)
+		}
+		selected = append(selected, svo)
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("outputs not found in workspace %q: %s. Available outputs: %s", c.Workspace, strings.Join(missing, ", "), strings.Join(available, ", "))
+	}
+	return selected, nil
+}
+
 func (c *WorkspaceOutputCommand) Help() string {
 	helpText := `
 Usage: tfci [global options] workspace outputs [options]
@@ -79,11 +157,17 @@ Global Options:
 
 	-token          The token used to authenticate with HCP Terraform. Defaults to reading "TF_API_TOKEN" environment variable.
 
-	-organization   HCP Terraform Organization Name.
+	-organization   HCP Terraform Organization Name. Also accepted as a command option, which overrides the global option for that command.
 
 Options:
 
 	-workspace            Existing HCP Terraform Workspace.
+
+	-wait-for-serial      Waits until the workspace's current state version serial is at least this value before reading outputs.
+
+	-name                 Name of an output to return, all outputs are returned by default. This option accepts multiple instances by providing additional name option flags.
+
+	-include-sensitive    Includes sensitive outputs, each sensitive value is masked in GitHub Actions and Azure Pipelines logs. Sensitive outputs are omitted by default.
 	`
 	return strings.TrimSpace(helpText)
 }
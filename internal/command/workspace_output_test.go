@@ -6,6 +6,7 @@ package command
 import (
 	"context"
 	"encoding/json"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -20,10 +21,54 @@ type WorkspaceOutputReader struct {
 	svo *tfe.StateVersionOutputsList
 }
 
+func (w *WorkspaceOutputReader) GetWorkspace(_ context.Context, orgName string, wName string) (*tfe.Workspace, error) {
+	return &tfe.Workspace{Name: wName}, nil
+}
+
+func (w *WorkspaceOutputReader) GetAssessmentResult(_ context.Context, _ string, _ string) (*cloud.AssessmentResult, error) {
+	return nil, nil
+}
+
+func (w *WorkspaceOutputReader) GetAssessmentDrift(_ context.Context, _ string) ([]*cloud.DriftedResource, error) {
+	return nil, nil
+}
+
+func (w *WorkspaceOutputReader) CreateWorkspace(_ context.Context, options cloud.CreateWorkspaceOptions) (*tfe.Workspace, error) {
+	return &tfe.Workspace{Name: options.Name}, nil
+}
+
+func (w *WorkspaceOutputReader) ListWorkspacesByTags(_ context.Context, _ string, _ []string) ([]*tfe.Workspace, error) {
+	return nil, nil
+}
+
+func (w *WorkspaceOutputReader) SafeDeleteWorkspace(_ context.Context, _ string, _ string) error {
+	return nil
+}
+
+func (w *WorkspaceOutputReader) ReadCurrentStateVersion(_ context.Context, _ string, _ string) (*tfe.StateVersion, error) {
+	return nil, nil
+}
+
+func (w *WorkspaceOutputReader) DownloadState(_ context.Context, _ *tfe.StateVersion) ([]byte, error) {
+	return nil, nil
+}
+
+func (w *WorkspaceOutputReader) WaitForStateVersion(_ context.Context, _ string, _ string, serial int64) (*tfe.StateVersion, error) {
+	return &tfe.StateVersion{Serial: serial}, nil
+}
+
 func (w *WorkspaceOutputReader) ReadStateOutputs(_ context.Context, orgName string, wName string) (*tfe.StateVersionOutputsList, error) {
 	return w.svo, nil
 }
 
+func (w *WorkspaceOutputReader) LockWorkspace(_ context.Context, _ cloud.LockWorkspaceOptions) (*tfe.Workspace, bool, error) {
+	return &tfe.Workspace{Locked: true}, false, nil
+}
+
+func (w *WorkspaceOutputReader) UnlockWorkspace(_ context.Context, _ cloud.UnlockWorkspaceOptions) (*tfe.Workspace, bool, error) {
+	return &tfe.Workspace{}, false, nil
+}
+
 type testWorkspaceOutputCommandOpts struct {
 	items []*tfe.StateVersionOutput
 }
@@ -50,7 +95,7 @@ func testWorkspaceOutputCommand(t *testing.T, opts *testWorkspaceOutputCommandOp
 		},
 	}
 
-	meta := NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(writer))
+	meta := NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(writer), WithOrg("hashicorp"))
 
 	return ui, &WorkspaceOutputCommand{Meta: meta}
 }
@@ -135,6 +180,11 @@ func TestWorkspaceOutputListCommand_SuccessArgs(t *testing.T) {
 			args:       []string{"--workspace=my-workspace"},
 			exitStatus: 0,
 		},
+		{
+			name:       "valid-args-wait-for-serial",
+			args:       []string{"--workspace=my-workspace", "--wait-for-serial=5"},
+			exitStatus: 0,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -163,7 +213,7 @@ func TestWorkspaceOutputListCommand_ErrorArgs(t *testing.T) {
 		{
 			name:         "no-args",
 			args:         []string{""},
-			errorMessage: "error workspace output list requires a workspace name",
+			errorMessage: "missing required input, set: -workspace",
 		},
 		{
 			name:         "supported-and-unsupported-args",
@@ -187,3 +237,78 @@ func TestWorkspaceOutputListCommand_ErrorArgs(t *testing.T) {
 		})
 	}
 }
+
+func TestWorkspaceOutputListCommand_Filter(t *testing.T) {
+	items := []*tfe.StateVersionOutput{
+		{Name: "image_id", Value: "ami-123456"},
+		{Name: "region", Value: "us-east-1"},
+		{Name: "db_password", Value: "secret", Sensitive: true},
+	}
+
+	testCases := []struct {
+		name            string
+		args            []string
+		exitStatus      int
+		expectNames     []string
+		expectSensitive bool
+		errorMessage    string
+	}{
+		{
+			name:        "sensitive-omitted-by-default",
+			args:        []string{"-workspace=my-workspace"},
+			expectNames: []string{"image_id", "region"},
+		},
+		{
+			name:            "include-sensitive",
+			args:            []string{"-workspace=my-workspace", "-include-sensitive"},
+			expectNames:     []string{"image_id", "region", "db_password"},
+			expectSensitive: true,
+		},
+		{
+			name:        "select-by-name",
+			args:        []string{"-workspace=my-workspace", "-name=region", "-name=image_id"},
+			expectNames: []string{"region", "image_id"},
+		},
+		{
+			name:         "unknown-name",
+			args:         []string{"-workspace=my-workspace", "-name=vpc_id"},
+			exitStatus:   1,
+			errorMessage: `outputs not found in workspace "my-workspace": vpc_id. Available outputs: db_password, image_id, region`,
+		},
+		{
+			name:         "sensitive-name-without-include-sensitive",
+			args:         []string{"-workspace=my-workspace", "-name=db_password"},
+			exitStatus:   1,
+			errorMessage: `output "db_password" is sensitive, use -include-sensitive to include it`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui, cmd := testWorkspaceOutputCommand(t, &testWorkspaceOutputCommandOpts{items: items})
+
+			if actual := cmd.Run(tc.args); actual != tc.exitStatus {
+				t.Fatalf("expected %d but received %d", tc.exitStatus, actual)
+			}
+			if tc.errorMessage != "" {
+				if stderr := strings.TrimSpace(ui.ErrorWriter.String()); stderr != tc.errorMessage {
+					t.Errorf("expected %q but received %q", tc.errorMessage, stderr)
+				}
+				return
+			}
+
+			message := cmd.messages["outputs"]
+			outputs := message.value.([]*WorkspaceOutput)
+			names := []string{}
+			for _, o := range outputs {
+				names = append(names, o.Name)
+			}
+			if !reflect.DeepEqual(names, tc.expectNames) {
+				t.Errorf("expected outputs %v but received %v", tc.expectNames, names)
+			}
+			if message.sensitive != tc.expectSensitive {
+				t.Errorf("expected sensitive %t but received %t", tc.expectSensitive, message.sensitive)
+			}
+		})
+	}
+}
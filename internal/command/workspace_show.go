@@ -0,0 +1,94 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+type ShowWorkspaceCommand struct {
+	*Meta
+
+	Workspace string
+}
+
+func (c *ShowWorkspaceCommand) flags() *flag.FlagSet {
+	f := c.flagSet("workspace show")
+	f.StringVar(&c.Workspace, "workspace", "", "The name of the HCP Terraform Workspace.")
+
+	return f
+}
+
+func (c *ShowWorkspaceCommand) Run(args []string) int {
+	if err := c.setupCmd(args, c.flags(), c.requireOrganization(), requireWorkspace(&c.Workspace)); err != nil {
+		return 1
+	}
+
+	workspace, wErr := c.cloud.GetWorkspace(c.appCtx, c.organization, c.Workspace)
+	if wErr != nil {
+		status := c.resolveStatus(wErr)
+		c.addOutput("status", string(status))
+		c.closeOutput()
+		c.errorResult(status, fmt.Sprintf("error showing workspace, '%s' in HCP Terraform: %s", c.Workspace, wErr.Error()))
+		return c.exitCode(status)
+	}
+
+	c.addOutput("status", string(Success))
+	c.addWorkspaceDetails(workspace)
+	c.writer.OutputResult(c.closeOutput())
+	return 0
+}
+
+func (c *ShowWorkspaceCommand) addWorkspaceDetails(workspace *tfe.Workspace) {
+	if workspace == nil {
+		return
+	}
+	c.addOutput("workspace_id", workspace.ID)
+	c.addOutput("workspace_name", workspace.Name)
+
+	// only VCS-connected workspaces have repository details, API-driven workspaces omit them
+	if workspace.VCSRepo != nil {
+		c.addOutput("vcs_repo_identifier", workspace.VCSRepo.Identifier)
+		c.addOutput("vcs_branch", workspace.VCSRepo.Branch)
+		c.addOutput("vcs_oauth_token_id", workspace.VCSRepo.OAuthTokenID)
+	}
+
+	c.addOutputWithOpts("payload", workspace, &outputOpts{
+		stdOut:      false,
+		multiLine:   true,
+		platformOut: true,
+		fields:      c.payloadFields,
+	})
+}
+
+func (c *ShowWorkspaceCommand) Help() string {
+	helpText := `
+Usage: tfci [global options] workspace show [options]
+
+	Returns workspace details, including VCS repository details for VCS-connected workspaces.
+
+Global Options:
+
+	-hostname       The hostname of a Terraform Enterprise installation, if using Terraform Enterprise. Defaults to "app.terraform.io".
+
+	-token          The token used to authenticate with HCP Terraform. Defaults to reading "TF_API_TOKEN" environment variable.
+
+	-organization   HCP Terraform Organization Name. Also accepted as a command option, which overrides the global option for that command.
+
+Options:
+
+	-workspace      Existing HCP Terraform Workspace.
+
+	-payload-fields Comma separated list of top-level fields to include in the payload output, e.g. id,status,created-at. Defaults to all fields.
+	`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ShowWorkspaceCommand) Synopsis() string {
+	return "Returns workspace details, including VCS repository details for VCS-connected workspaces"
+}
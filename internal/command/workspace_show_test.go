@@ -0,0 +1,226 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+	"github.com/hashicorp/tfci/internal/environment"
+	"github.com/hashicorp/tfci/internal/writer"
+	"github.com/mitchellh/cli"
+)
+
+type WorkspaceReader struct {
+	workspace *tfe.Workspace
+	err       error
+	created   *cloud.CreateWorkspaceOptions
+	tagged    []*tfe.Workspace
+
+	assessment *cloud.AssessmentResult
+	drifted    []*cloud.DriftedResource
+
+	stateVersion *tfe.StateVersion
+	state        []byte
+
+	locked   *cloud.LockWorkspaceOptions
+	unlocked *cloud.UnlockWorkspaceOptions
+}
+
+func (w *WorkspaceReader) GetWorkspace(_ context.Context, _ string, _ string) (*tfe.Workspace, error) {
+	return w.workspace, w.err
+}
+
+func (w *WorkspaceReader) GetAssessmentResult(_ context.Context, _ string, _ string) (*cloud.AssessmentResult, error) {
+	return w.assessment, w.err
+}
+
+func (w *WorkspaceReader) GetAssessmentDrift(_ context.Context, _ string) ([]*cloud.DriftedResource, error) {
+	return w.drifted, nil
+}
+
+func (w *WorkspaceReader) CreateWorkspace(_ context.Context, options cloud.CreateWorkspaceOptions) (*tfe.Workspace, error) {
+	w.created = &options
+	return w.workspace, nil
+}
+
+func (w *WorkspaceReader) ListWorkspacesByTags(_ context.Context, _ string, _ []string) ([]*tfe.Workspace, error) {
+	return w.tagged, w.err
+}
+
+func (w *WorkspaceReader) SafeDeleteWorkspace(_ context.Context, _ string, _ string) error {
+	return nil
+}
+
+func (w *WorkspaceReader) ReadCurrentStateVersion(_ context.Context, _ string, _ string) (*tfe.StateVersion, error) {
+	return w.stateVersion, w.err
+}
+
+func (w *WorkspaceReader) DownloadState(_ context.Context, _ *tfe.StateVersion) ([]byte, error) {
+	return w.state, nil
+}
+
+func (w *WorkspaceReader) WaitForStateVersion(_ context.Context, _ string, _ string, serial int64) (*tfe.StateVersion, error) {
+	return &tfe.StateVersion{Serial: serial}, nil
+}
+
+func (w *WorkspaceReader) ReadStateOutputs(_ context.Context, _ string, _ string) (*tfe.StateVersionOutputsList, error) {
+	return &tfe.StateVersionOutputsList{}, nil
+}
+
+// the workspace's Locked field is the lock before the request
+func (w *WorkspaceReader) LockWorkspace(_ context.Context, options cloud.LockWorkspaceOptions) (*tfe.Workspace, bool, error) {
+	w.locked = &options
+	if w.err != nil {
+		return nil, false, w.err
+	}
+	return w.workspace, w.workspace.Locked, nil
+}
+
+func (w *WorkspaceReader) UnlockWorkspace(_ context.Context, options cloud.UnlockWorkspaceOptions) (*tfe.Workspace, bool, error) {
+	w.unlocked = &options
+	if w.err != nil {
+		return nil, false, w.err
+	}
+	return w.workspace, !w.workspace.Locked, nil
+}
+
+func testShowWorkspaceCommand(t *testing.T, workspace *tfe.Workspace) (*cli.MockUi, *ShowWorkspaceCommand) {
+	t.Helper()
+
+	ui := cli.NewMockUi()
+	writer := writer.NewWriter(ui)
+	cloudMockService := cloud.NewCloud(&tfe.Client{}, writer)
+	cloudMockService.WorkspaceService = &WorkspaceReader{workspace: workspace}
+
+	meta := NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(writer), WithOrg("hashicorp"))
+
+	return ui, &ShowWorkspaceCommand{Meta: meta}
+}
+
+func TestShowWorkspaceCommand_VCSDetails(t *testing.T) {
+	testCases := []struct {
+		name      string
+		workspace *tfe.Workspace
+		expected  map[string]string
+	}{
+		{
+			name: "vcs-workspace",
+			workspace: &tfe.Workspace{
+				ID:   "ws-***",
+				Name: "my-workspace",
+				VCSRepo: &tfe.VCSRepo{
+					Identifier:   "octocat/hello-world",
+					Branch:       "main",
+					OAuthTokenID: "ot-***",
+				},
+			},
+			expected: map[string]string{
+				"vcs_repo_identifier": "octocat/hello-world",
+				"vcs_branch":          "main",
+				"vcs_oauth_token_id":  "ot-***",
+			},
+		},
+		{
+			name: "api-driven-workspace",
+			workspace: &tfe.Workspace{
+				ID:   "ws-***",
+				Name: "my-workspace",
+			},
+			expected: map[string]string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui, cmd := testShowWorkspaceCommand(t, tc.workspace)
+
+			if code := cmd.Run([]string{"--workspace=my-workspace"}); code != 0 {
+				t.Fatalf("expected %d but received %d", 0, code)
+			}
+
+			outputs := map[string]string{}
+			if err := json.Unmarshal([]byte(ui.OutputWriter.String()), &outputs); err != nil {
+				t.Fatalf("error parsing output: %s", err)
+			}
+
+			for _, key := range []string{"vcs_repo_identifier", "vcs_branch", "vcs_oauth_token_id"} {
+				expected, expectExists := tc.expected[key]
+				actual, exists := outputs[key]
+				if expectExists != exists {
+					t.Fatalf("expected %q to exist: %t, but found: %t", key, expectExists, exists)
+				}
+				if expected != actual {
+					t.Errorf("expected %q but received %q", expected, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestShowWorkspaceCommand_ErrorArgs(t *testing.T) {
+	ui, cmd := testShowWorkspaceCommand(t, &tfe.Workspace{})
+
+	if code := cmd.Run([]string{}); code != 1 {
+		t.Fatalf("expected %d but received %d", 1, code)
+	}
+
+	expected := "missing required input, set: -workspace"
+	if output := ui.ErrorWriter.String(); output != expected+"\n" {
+		t.Errorf("expected %q but received %q", expected, output)
+	}
+}
+
+func TestShowWorkspaceCommand_OrganizationOverride(t *testing.T) {
+	testCases := []struct {
+		name         string
+		args         []string
+		exitStatus   int
+		expectOrg    string
+		errorMessage string
+	}{
+		{
+			name:      "global-organization",
+			args:      []string{"-workspace=my-workspace"},
+			expectOrg: "global-org",
+		},
+		{
+			name:      "command-organization-override",
+			args:      []string{"-workspace=my-workspace", "-organization=other-org"},
+			expectOrg: "other-org",
+		},
+		{
+			name:         "empty-organization-override",
+			args:         []string{"-workspace=my-workspace", "-organization="},
+			exitStatus:   1,
+			expectOrg:    "global-org",
+			errorMessage: `invalid value "" for flag -organization: organization cannot be empty`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			writer := writer.NewWriter(ui)
+			cloudMockService := cloud.NewCloud(&tfe.Client{}, writer)
+			cloudMockService.WorkspaceService = &WorkspaceReader{workspace: &tfe.Workspace{ID: "ws-123", Name: "my-workspace"}}
+			meta := NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(writer), WithOrg("global-org"))
+			cmd := &ShowWorkspaceCommand{Meta: meta}
+
+			if code := cmd.Run(tc.args); code != tc.exitStatus {
+				t.Fatalf("expected %d but received %d", tc.exitStatus, code)
+			}
+			if cmd.organization != tc.expectOrg {
+				t.Errorf("expected organization %q but received %q", tc.expectOrg, cmd.organization)
+			}
+			if tc.errorMessage != "" && !strings.Contains(ui.ErrorWriter.String(), tc.errorMessage) {
+				t.Errorf("expected %q but received %q", tc.errorMessage, ui.ErrorWriter.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+// max number of workspaces operated on at once when selecting workspaces by tags
+const bulkConcurrency = 5
+
+// summary_status output values, describing how many of the tagged workspaces succeeded
+const (
+	SummaryAll     = "all"
+	SummaryPartial = "partial"
+	SummaryNone    = "none"
+)
+
+// outcome of an operation on a single workspace selected by tags
+type workspaceResult struct {
+	workspace string
+	runs      []*tfe.Run
+	// set when uploading configuration for the workspace
+	configVersion *tfe.ConfigurationVersion
+	err           error
+}
+
+// parses the comma separated -workspace-tags option, ignoring empty tags
+func parseWorkspaceTags(raw string) []string {
+	tags := []string{}
+	for _, tag := range strings.Split(raw, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// resolves the workspaces having all of the tags, an empty selection is an error
+func (c *Meta) resolveTaggedWorkspaces(tags []string) ([]*tfe.Workspace, error) {
+	workspaces, err := c.cloud.ListWorkspacesByTags(c.appCtx, c.organization, tags)
+	if err != nil {
+		return nil, err
+	}
+	if len(workspaces) == 0 {
+		return nil, fmt.Errorf("no workspaces found with tags: %s", strings.Join(tags, ", "))
+	}
+
+	names := make([]string, 0, len(workspaces))
+	for _, w := range workspaces {
+		names = append(names, w.Name)
+	}
+	c.writer.Output(fmt.Sprintf("Selected %d workspaces with tags %s: %s", len(workspaces), strings.Join(tags, ", "), strings.Join(names, ", ")))
+	return workspaces, nil
+}
+
+// runs fn for each workspace, a failure does not abort the remaining workspaces.
+// Results are returned in the same order as the workspaces.
+func forEachWorkspace(workspaces []*tfe.Workspace, fn func(w *tfe.Workspace) *workspaceResult) []*workspaceResult {
+	results := make([]*workspaceResult, len(workspaces))
+	sem := make(chan struct{}, bulkConcurrency)
+	var wg sync.WaitGroup
+	for i, w := range workspaces {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = fn(w)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// adds the aggregated outputs for the tagged workspaces and returns the overall status,
+// which is only successful when every workspace succeeded
+func (c *Meta) addWorkspaceResults(results []*workspaceResult) Status {
+	status := c.addWorkspaceSummary(results)
+
+	runIDs := []string{}
+	for _, result := range results {
+		ids := make([]string, 0, len(result.runs))
+		for _, run := range result.runs {
+			if run != nil {
+				ids = append(ids, run.ID)
+			}
+		}
+		if len(ids) > 0 {
+			runIDs = append(runIDs, fmt.Sprintf("%s=%s", result.workspace, strings.Join(ids, ",")))
+		}
+	}
+	c.addOutputWithOpts("run_ids", strings.Join(runIDs, "\n"), &outputOpts{
+		stdOut:      true,
+		multiLine:   true,
+		platformOut: true,
+	})
+
+	c.addFailedWorkspaces(results)
+	return status
+}
+
+// adds the status, summary_status and counts for the workspaces, returning the overall status
+func (c *Meta) addWorkspaceSummary(results []*workspaceResult) Status {
+	failed, dryRun := 0, 0
+	for _, result := range results {
+		if isDryRun(result.err) {
+			dryRun++
+			continue
+		}
+		if result.err != nil {
+			c.writer.Error(fmt.Sprintf("workspace %q failed: %s", result.workspace, result.err.Error()))
+			failed++
+		}
+	}
+
+	summary, status := SummaryAll, Success
+	switch failed {
+	case 0:
+		if dryRun > 0 {
+			status = DryRun
+		}
+	case len(results):
+		summary, status = SummaryNone, Error
+	default:
+		summary, status = SummaryPartial, Error
+	}
+
+	c.addOutput("status", string(status))
+	c.addOutput("summary_status", summary)
+	c.addOutput("succeeded_count", fmt.Sprint(len(results)-failed))
+	c.addOutput("failed_count", fmt.Sprint(failed))
+	return status
+}
+
+// adds the failed_workspaces output, a `workspace=error` line per failed workspace
+func (c *Meta) addFailedWorkspaces(results []*workspaceResult) {
+	failures := []string{}
+	for _, result := range results {
+		if result.err != nil && !isDryRun(result.err) {
+			// keep a single line per workspace for the multiline output
+			failures = append(failures, fmt.Sprintf("%s=%s", result.workspace, strings.ReplaceAll(result.err.Error(), "\n", " ")))
+		}
+	}
+	if len(failures) > 0 {
+		c.addOutputWithOpts("failed_workspaces", strings.Join(failures, "\n"), &outputOpts{
+			stdOut:      true,
+			multiLine:   true,
+			platformOut: true,
+		})
+	}
+}
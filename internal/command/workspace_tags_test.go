@@ -0,0 +1,158 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+	"github.com/hashicorp/tfci/internal/environment"
+	"github.com/hashicorp/tfci/internal/writer"
+	"github.com/mitchellh/cli"
+)
+
+// creates or lists a run named after each workspace, failing for the configured workspaces
+type TaggedRunService struct {
+	cloud.RunService
+
+	failures map[string]error
+}
+
+func (r *TaggedRunService) RunLink(_ context.Context, _ string, _ *tfe.Run) (string, error) {
+	return "", nil
+}
+
+func (r *TaggedRunService) CreateRun(_ context.Context, options cloud.CreateRunOptions) (*tfe.Run, error) {
+	if err := r.failures[options.Workspace]; err != nil {
+		return nil, err
+	}
+	return &tfe.Run{ID: "run-" + options.Workspace, Plan: &tfe.Plan{HasChanges: options.Workspace == "api"}}, nil
+}
+
+func (r *TaggedRunService) ListRuns(_ context.Context, options cloud.ListRunsOptions) ([]*tfe.Run, error) {
+	if err := r.failures[options.Workspace]; err != nil {
+		return nil, err
+	}
+	return []*tfe.Run{{ID: "run-" + options.Workspace + "-1"}, {ID: "run-" + options.Workspace + "-2"}}, nil
+}
+
+func testTaggedCommandMeta(t *testing.T, failures map[string]error) (*cli.MockUi, *Meta) {
+	t.Helper()
+
+	ui := cli.NewMockUi()
+	w := writer.NewWriter(ui)
+	cloudMockService := cloud.NewCloud(&tfe.Client{}, w)
+	cloudMockService.RunService = &TaggedRunService{failures: failures}
+	cloudMockService.WorkspaceService = &WorkspaceReader{tagged: []*tfe.Workspace{{Name: "api"}, {Name: "db"}, {Name: "web"}}}
+
+	return ui, NewMetaOpts(context.Background(), cloudMockService, &environment.CI{}, WithWriter(w), WithOrg("hashicorp"))
+}
+
+func outputValue(m *Meta, name string) string {
+	if message, ok := m.messages[name]; ok {
+		value, _ := message.Value()
+		return value
+	}
+	return ""
+}
+
+func TestCreateRunCommand_WorkspaceTags(t *testing.T) {
+	testCases := []struct {
+		name       string
+		args       []string
+		failures   map[string]error
+		exitStatus int
+		outputs    map[string]string
+	}{
+		{
+			name:       "all-succeeded",
+			args:       []string{"-workspace-tags=prod,eu"},
+			exitStatus: 0,
+			outputs: map[string]string{
+				"status":         "Success",
+				"summary_status": SummaryAll,
+				"run_ids":        "api=run-api\ndb=run-db\nweb=run-web",
+			},
+		},
+		{
+			name:       "all-succeeded-with-detailed-exitcode",
+			args:       []string{"-workspace-tags=prod", "-detailed-exitcode"},
+			exitStatus: 2,
+			outputs: map[string]string{
+				"summary_status": SummaryAll,
+			},
+		},
+		{
+			name:       "partial-failure",
+			args:       []string{"-workspace-tags=prod"},
+			failures:   map[string]error{"db": errors.New("run has ended with: 'errored' status")},
+			exitStatus: 1,
+			outputs: map[string]string{
+				"status":            "Error",
+				"summary_status":    SummaryPartial,
+				"succeeded_count":   "2",
+				"failed_count":      "1",
+				"run_ids":           "api=run-api\nweb=run-web",
+				"failed_workspaces": "db=run has ended with: 'errored' status",
+			},
+		},
+		{
+			name:       "combined-with-workspace",
+			args:       []string{"-workspace-tags=prod", "-workspace=api"},
+			exitStatus: 1,
+		},
+		{
+			name:       "combined-with-configuration-version",
+			args:       []string{"-workspace-tags=prod", "-configuration_version=cv-***"},
+			exitStatus: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, meta := testTaggedCommandMeta(t, tc.failures)
+			cmd := &CreateRunCommand{Meta: meta}
+
+			if actual := cmd.Run(tc.args); actual != tc.exitStatus {
+				t.Fatalf("expected %d but received %d", tc.exitStatus, actual)
+			}
+
+			for name, expected := range tc.outputs {
+				if actual := outputValue(cmd.Meta, name); actual != expected {
+					t.Errorf("expected %s %q but received %q", name, expected, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestListRunCommand_WorkspaceTags(t *testing.T) {
+	_, meta := testTaggedCommandMeta(t, map[string]error{
+		"api": errors.New("failed"),
+		"db":  errors.New("failed"),
+		"web": errors.New("failed"),
+	})
+	cmd := &ListRunCommand{Meta: meta}
+
+	if actual := cmd.Run([]string{"-workspace-tags=prod"}); actual != 1 {
+		t.Fatalf("expected %d but received %d", 1, actual)
+	}
+
+	if actual := outputValue(cmd.Meta, "summary_status"); actual != SummaryNone {
+		t.Errorf("expected summary_status %q but received %q", SummaryNone, actual)
+	}
+
+	_, meta = testTaggedCommandMeta(t, nil)
+	cmd = &ListRunCommand{Meta: meta}
+	if actual := cmd.Run([]string{"-workspace-tags=prod"}); actual != 0 {
+		t.Fatalf("expected %d but received %d", 0, actual)
+	}
+	expected := "api=run-api-1,run-api-2\ndb=run-db-1,run-db-2\nweb=run-web-1,run-web-2"
+	if actual := outputValue(cmd.Meta, "run_ids"); actual != expected {
+		t.Errorf("expected run_ids %q but received %q", expected, actual)
+	}
+}
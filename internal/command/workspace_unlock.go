@@ -0,0 +1,103 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-tfe"
+	"github.com/hashicorp/tfci/internal/cloud"
+)
+
+type UnlockWorkspaceCommand struct {
+	*Meta
+
+	Workspace string
+	Force     bool
+}
+
+func (c *UnlockWorkspaceCommand) flags() *flag.FlagSet {
+	f := c.flagSet("workspace unlock")
+	f.StringVar(&c.Workspace, "workspace", "", "The name of the HCP Terraform Workspace to unlock.")
+	f.BoolVar(&c.Force, "force", false, "Force unlocks a lock held by another user, team or run.")
+
+	return f
+}
+
+func (c *UnlockWorkspaceCommand) Run(args []string) int {
+	if err := c.setupCmd(args, c.flags(), c.requireOrganization(), requireWorkspace(&c.Workspace)); err != nil {
+		return 1
+	}
+
+	if c.Force {
+		c.writer.Output(fmt.Sprintf("Warning: force unlocking workspace %q, a run or user holding the lock may still be writing state", c.Workspace))
+	}
+
+	workspace, alreadyUnlocked, err := c.cloud.UnlockWorkspace(c.appCtx, cloud.UnlockWorkspaceOptions{
+		Organization: c.organization,
+		Workspace:    c.Workspace,
+		Force:        c.Force,
+	})
+	if err != nil {
+		status := c.resolveStatus(err)
+		c.addOutput("status", string(status))
+		c.closeOutput()
+		msg := fmt.Sprintf("error unlocking workspace, '%s' in HCP Terraform: %s", c.Workspace, err.Error())
+		if isHeldByOther(err) {
+			msg += ". Use -force to unlock a lock held by another user, team or run"
+		}
+		c.errorResult(status, msg)
+		return c.exitCode(status)
+	}
+
+	status := Success
+	if alreadyUnlocked {
+		status = Noop
+		c.writer.Output(fmt.Sprintf("Workspace %q is not locked, there is nothing to do", c.Workspace))
+	} else {
+		c.writer.Output(fmt.Sprintf("Unlocked workspace %q", c.Workspace))
+	}
+
+	c.addOutput("status", string(status))
+	c.addOutput("workspace_id", workspace.ID)
+	c.addOutput("workspace_locked", "false")
+	c.addOutput("lock_reason", "")
+	c.writer.OutputResult(c.closeOutput())
+	return 0
+}
+
+// the lock is held by someone other than the token, which only a force unlock releases
+func isHeldByOther(err error) bool {
+	return errors.Is(err, tfe.ErrWorkspaceLockedByRun) || errors.Is(err, tfe.ErrWorkspaceLockedByTeam) || errors.Is(err, tfe.ErrWorkspaceLockedByUser)
+}
+
+func (c *UnlockWorkspaceCommand) Help() string {
+	helpText := `
+Usage: tfci [global options] workspace unlock [options]
+
+	Unlocks a workspace locked by "workspace lock" or by the same user or team in HCP Terraform. A workspace which is not locked is left as is and the status is "Noop".
+
+Global Options:
+
+	-hostname       The hostname of a Terraform Enterprise installation, if using Terraform Enterprise. Defaults to "app.terraform.io".
+
+	-token          The token used to authenticate with HCP Terraform. Defaults to reading "TF_API_TOKEN" environment variable.
+
+	-organization   HCP Terraform Organization Name. Also accepted as a command option, which overrides the global option for that command.
+
+Options:
+
+	-workspace      The name of the HCP Terraform Workspace to unlock.
+
+	-force          Force unlocks a lock held by another user, team or run, which requires admin access to the workspace. A run holding the lock may still be writing state, use with care.
+	`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *UnlockWorkspaceCommand) Synopsis() string {
+	return "Unlocks a workspace"
+}
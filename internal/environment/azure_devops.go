@@ -0,0 +1,131 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package environment
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/tfci/internal/logging"
+)
+
+// escapes logging command values, the agent unescapes them when setting the variable
+// https://github.com/microsoft/azure-pipelines-agent/blob/master/docs/design/logging-commands.md
+var azureDevOpsEscaper = strings.NewReplacer(
+	"%", "%AZP25",
+	"\r", "%0D",
+	"\n", "%0A",
+)
+
+// Sourced from: https://learn.microsoft.com/en-us/azure/devops/pipelines/build/variables
+type AzureDevOpsContext struct {
+	// The ID of the record for the completed build.
+	buildId string
+	// The attempt number of the job, starting at 1 and incremented each time the job is retried.
+	jobAttempt string
+	// The latest version control change that is included in this build.
+	sourceVersion string
+	// The person who pushed or checked in the changes, or who queued the build.
+	requestedFor string
+	// A temporary folder that is cleaned after each pipeline job.
+	agentTempDirectory string
+	// data accumulated for output
+	output OutputMap
+	// where logging commands are written, the agent processes commands from stdout and stderr
+	out io.Writer
+	// writes logging commands to stderr, reserving stdout for a structured result
+	quiet bool
+}
+
+func (az *AzureDevOpsContext) ID() string {
+	return fmt.Sprintf("ado-%s-%s", az.buildId, az.jobAttempt)
+}
+
+func (az *AzureDevOpsContext) SHA() string {
+	return az.sourceVersion
+}
+
+func (az *AzureDevOpsContext) SHAShort() string {
+	if len(az.sourceVersion) > 7 {
+		return az.sourceVersion[:7]
+	}
+	return az.sourceVersion
+}
+
+func (az *AzureDevOpsContext) Author() string {
+	return az.requestedFor
+}
+
+func (az *AzureDevOpsContext) WriteDir() string {
+	return az.agentTempDirectory
+}
+
+func (az *AzureDevOpsContext) SetOutput(output OutputMap) {
+	if az.output == nil {
+		az.output = make(map[string]OutputWriter)
+	}
+
+	maps.Copy(az.output, output)
+}
+
+// sets each output as an output variable, available to later steps as $(step.name) and to
+// dependent jobs as dependencies.job.outputs['step.name']
+// https://learn.microsoft.com/en-us/azure/devops/pipelines/scripts/logging-commands#setvariable-initialize-or-modify-the-value-of-a-variable
+func (az *AzureDevOpsContext) CloseOutput() error {
+	out := az.out
+	if az.quiet {
+		out = os.Stderr
+	}
+
+	keys := make([]string, 0, len(az.output))
+	for key := range az.output {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	logging.Debug("Writing outputs as Azure DevOps output variables", "count", len(keys))
+	for _, key := range keys {
+		value := az.output[key]
+		secret := ""
+		if value.Sensitive() {
+			secret = ";issecret=true"
+			// a secret variable only masks its whole value, register the individual values it combines as well
+			if m, ok := value.(masker); ok {
+				for _, mask := range m.Masks() {
+					if _, err := fmt.Fprintf(out, "##vso[task.setsecret]%s\n", azureDevOpsEscaper.Replace(mask)); err != nil {
+						logging.Error("Failed to write secret", "key", key, "error", err)
+						return err
+					}
+				}
+			}
+		}
+		if _, err := fmt.Fprintf(out, "##vso[task.setvariable variable=%s;isOutput=true%s]%s\n", key, secret, azureDevOpsEscaper.Replace(value.String())); err != nil {
+			logging.Error("Failed to write output", "key", key, "error", err)
+			return err
+		}
+	}
+
+	az.output = make(map[string]OutputWriter)
+	return nil
+}
+
+func (az *AzureDevOpsContext) SetQuiet(quiet bool) {
+	az.quiet = quiet
+}
+
+func newAzureDevOpsContext(getenv GetEnv) *AzureDevOpsContext {
+	return &AzureDevOpsContext{
+		buildId:            getenv("BUILD_BUILDID"),
+		jobAttempt:         getenv("SYSTEM_JOBATTEMPT"),
+		sourceVersion:      getenv("BUILD_SOURCEVERSION"),
+		requestedFor:       getenv("BUILD_REQUESTEDFOR"),
+		agentTempDirectory: getenv("AGENT_TEMPDIRECTORY"),
+		output:             make(map[string]OutputWriter),
+		out:                os.Stdout,
+	}
+}
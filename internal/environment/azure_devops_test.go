@@ -0,0 +1,71 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package environment
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_AzureDevOpsContext(t *testing.T) {
+	env := map[string]string{
+		"TF_BUILD":            "True",
+		"BUILD_BUILDID":       "42",
+		"SYSTEM_JOBATTEMPT":   "1",
+		"BUILD_SOURCEVERSION": "0123456789abcdef",
+		"BUILD_REQUESTEDFOR":  "Jane Doe",
+		"AGENT_TEMPDIRECTORY": "/agent/_temp",
+	}
+	ci := &CI{getenv: func(key string) string { return env[key] }}
+	ci.initialize()
+
+	if ci.PlatformType != AzureDevOps {
+		t.Fatalf("expected platform %s but received %s", AzureDevOps, ci.PlatformType)
+	}
+
+	expected := map[string]string{
+		"ID":       "ado-42-1",
+		"SHA":      "0123456789abcdef",
+		"SHAShort": "0123456",
+		"Author":   "Jane Doe",
+		"WriteDir": "/agent/_temp",
+	}
+	actual := map[string]string{
+		"ID":       ci.Context.ID(),
+		"SHA":      ci.Context.SHA(),
+		"SHAShort": ci.Context.SHAShort(),
+		"Author":   ci.Context.Author(),
+		"WriteDir": ci.Context.WriteDir(),
+	}
+	for name, value := range expected {
+		if actual[name] != value {
+			t.Errorf("expected %s %q but received %q", name, value, actual[name])
+		}
+	}
+}
+
+func Test_AzureDevOpsOutput(t *testing.T) {
+	out := &bytes.Buffer{}
+	azure := newAzureDevOpsContext(func(string) string { return "" })
+	azure.out = out
+
+	azure.SetOutput(OutputMap{
+		"run_id":  &testOutput{val: "run-***"},
+		"payload": &testOutput{val: "{\n  \"progress\": \"100%\"\n}", multiLine: true},
+		"token":   &testOutput{val: "hunter2", sensitive: true},
+		"outputs": NewMaskedOutput(`[{"name":"password","value":"hunter2"}]`, false, []string{"hunter2"}),
+	})
+	if err := azure.CloseOutput(); err != nil {
+		t.Fatalf("error closing output: %s", err)
+	}
+
+	expected := "##vso[task.setsecret]hunter2\n" +
+		"##vso[task.setvariable variable=outputs;isOutput=true;issecret=true][{\"name\":\"password\",\"value\":\"hunter2\"}]\n" +
+		"##vso[task.setvariable variable=payload;isOutput=true]{%0A  \"progress\": \"100%AZP25\"%0A}\n" +
+		"##vso[task.setvariable variable=run_id;isOutput=true]run-***\n" +
+		"##vso[task.setvariable variable=token;isOutput=true;issecret=true]hunter2\n"
+	if out.String() != expected {
+		t.Errorf("expected logging commands %q but received %q", expected, out.String())
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package environment
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/hashicorp/tfci/internal/logging"
+)
+
+// file under the clone directory outputs are written to, declare it as an artifact to share outputs with later steps.
+// The upload command leaves it out of the configuration
+const bitbucketOutputFile = "tfci.env"
+
+// Sourced from: https://support.atlassian.com/bitbucket-cloud/docs/variables-and-secrets/#Default-variables
+type BitbucketContext struct {
+	// The unique identifier for a build. It increments with each build.
+	buildNumber string
+	// The commit hash of a commit that kicked off the build.
+	commit string
+	// The UUID of the user who triggered the step, the pipeline's creator for automatically triggered steps.
+	stepTriggererUUID string
+	// The absolute path of the directory that the repository is cloned into within the Docker container.
+	cloneDir string
+	// path to the dotenv file outputs are written to
+	outputPath string
+	// data accumulated for output
+	output OutputMap
+	// destination for the table of outputs
+	stdout io.Writer
+	// skips writing the table of outputs to stdout, outputs are still written to the output path
+	quiet bool
+}
+
+func (bb *BitbucketContext) ID() string {
+	return fmt.Sprintf("bitbucket-%s", bb.buildNumber)
+}
+
+func (bb *BitbucketContext) SHA() string {
+	return bb.commit
+}
+
+func (bb *BitbucketContext) SHAShort() string {
+	if len(bb.commit) > 7 {
+		return bb.commit[:7]
+	}
+	return bb.commit
+}
+
+func (bb *BitbucketContext) Author() string {
+	return bb.stepTriggererUUID
+}
+
+// temp dir under the clone directory, which is kept for the duration of the step
+func (bb *BitbucketContext) WriteDir() string {
+	if bb.cloneDir == "" {
+		return os.TempDir()
+	}
+	return filepath.Join(bb.cloneDir, ".tfci")
+}
+
+func (bb *BitbucketContext) SetOutput(output OutputMap) {
+	if bb.output == nil {
+		bb.output = make(map[string]OutputWriter)
+	}
+
+	maps.Copy(bb.output, output)
+}
+
+// Bitbucket has no outputs between steps, state is shared by artifacts instead. Outputs are appended to a dotenv
+// file under the clone directory, which a later step can load with `set -a; source tfci.env; set +a` once the file
+// is declared as an artifact. Values are single quoted, keeping newlines and disabling any expansion. Sensitive values
+// are never written, as artifacts are stored in plaintext
+// https://support.atlassian.com/bitbucket-cloud/docs/use-artifacts-in-steps/
+func (bb *BitbucketContext) CloseOutput() (retErr error) {
+	keys := slices.Sorted(maps.Keys(bb.output))
+
+	if bb.outputPath == "" {
+		logging.Warn("BITBUCKET_CLONE_DIR environment variable is not set. Outputs will not be available to later steps.")
+	} else {
+		file, err := os.OpenFile(bb.outputPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			logging.Error("Failed to open Bitbucket output file", "path", bb.outputPath, "error", err)
+			return err
+		}
+		defer func() {
+			if err := file.Close(); err != nil {
+				logging.Error("Failed to close Bitbucket output file", "error", err)
+				retErr = err
+			}
+		}()
+
+		logging.Debug("Writing outputs to Bitbucket output file", "count", len(keys), "path", bb.outputPath)
+		for _, key := range keys {
+			if bb.output[key].Sensitive() {
+				logging.Debug("Skipping sensitive output", "key", key)
+				continue
+			}
+			if _, err := fmt.Fprintf(file, "%s=%s%s", key, shellQuote(bb.output[key].String()), EOF); err != nil {
+				logging.Error("Failed to write output", "key", key, "error", err)
+				return err
+			}
+		}
+	}
+
+	if !bb.quiet {
+		if err := writeOutputTable(bb.stdout, bb.output, keys); err != nil {
+			return err
+		}
+	}
+
+	bb.output = make(map[string]OutputWriter)
+	return
+}
+
+func (bb *BitbucketContext) SetQuiet(quiet bool) {
+	bb.quiet = quiet
+}
+
+func (bb *BitbucketContext) OutputPath() string {
+	return bb.outputPath
+}
+
+func newBitbucketContext(getenv GetEnv) *BitbucketContext {
+	cloneDir := getenv("BITBUCKET_CLONE_DIR")
+	// TFCI_OUTPUT_PATH overrides the default file, eg. to collect outputs of parallel steps in separate artifacts
+	outputPath := getenv(EnvOutputPath)
+	if outputPath == "" && cloneDir != "" {
+		outputPath = filepath.Join(cloneDir, bitbucketOutputFile)
+	}
+
+	return &BitbucketContext{
+		buildNumber:       getenv("BITBUCKET_BUILD_NUMBER"),
+		commit:            getenv("BITBUCKET_COMMIT"),
+		stepTriggererUUID: getenv("BITBUCKET_STEP_TRIGGERER_UUID"),
+		cloneDir:          cloneDir,
+		outputPath:        outputPath,
+		output:            make(map[string]OutputWriter),
+		stdout:            os.Stdout,
+	}
+}
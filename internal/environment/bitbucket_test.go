@@ -0,0 +1,103 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package environment
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_BitbucketContext(t *testing.T) {
+	env := map[string]string{
+		"CI":                            "true",
+		"BITBUCKET_BUILD_NUMBER":        "42",
+		"BITBUCKET_COMMIT":              "0123456789abcdef",
+		"BITBUCKET_STEP_TRIGGERER_UUID": "{a1b2c3d4}",
+		"BITBUCKET_CLONE_DIR":           "/opt/atlassian/pipelines/agent/build",
+	}
+	ci := &CI{getenv: func(key string) string { return env[key] }}
+	ci.initialize()
+
+	if ci.PlatformType != Bitbucket {
+		t.Fatalf("expected platform %s but received %s", Bitbucket, ci.PlatformType)
+	}
+
+	expected := map[string]string{
+		"ID":         "bitbucket-42",
+		"SHA":        "0123456789abcdef",
+		"SHAShort":   "0123456",
+		"Author":     "{a1b2c3d4}",
+		"WriteDir":   "/opt/atlassian/pipelines/agent/build/.tfci",
+		"OutputPath": "/opt/atlassian/pipelines/agent/build/tfci.env",
+	}
+	actual := map[string]string{
+		"ID":         ci.Context.ID(),
+		"SHA":        ci.Context.SHA(),
+		"SHAShort":   ci.Context.SHAShort(),
+		"Author":     ci.Context.Author(),
+		"WriteDir":   ci.Context.WriteDir(),
+		"OutputPath": ci.OutputPath(),
+	}
+	for name, value := range expected {
+		if actual[name] != value {
+			t.Errorf("expected %s %q but received %q", name, value, actual[name])
+		}
+	}
+
+	// GitHub takes precedence, eg. a self-hosted runner inheriting Bitbucket variables
+	env["GITHUB_ACTIONS"] = "true"
+	ci.initialize()
+	if ci.PlatformType != GitHub {
+		t.Errorf("expected platform %s but received %s", GitHub, ci.PlatformType)
+	}
+}
+
+func Test_BitbucketOutput(t *testing.T) {
+	cloneDir := t.TempDir()
+	outputPath := filepath.Join(cloneDir, "tfci.env")
+	stdout := &bytes.Buffer{}
+	bitbucket := newBitbucketContext(func(key string) string {
+		if key == "BITBUCKET_CLONE_DIR" {
+			return cloneDir
+		}
+		return ""
+	})
+	bitbucket.stdout = stdout
+
+	bitbucket.SetOutput(OutputMap{
+		"run_id":  &testOutput{val: "run-***"},
+		"payload": &testOutput{val: "{\n  \"name\": \"it's\"\n}", multiLine: true},
+	})
+	if err := bitbucket.CloseOutput(); err != nil {
+		t.Fatalf("error closing output: %s", err)
+	}
+	// a later command in the same step appends to the file
+	bitbucket.SetOutput(OutputMap{"token": &testOutput{val: "hunter2", sensitive: true}})
+	if err := bitbucket.CloseOutput(); err != nil {
+		t.Fatalf("error closing output: %s", err)
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "payload='{\n  \"name\": \"it'\\''s\"\n}'\n" +
+		"run_id='run-***'\n"
+	if string(content) != expected {
+		t.Errorf("expected dotenv %q but received %q", expected, string(content))
+	}
+
+	if info, err := os.Stat(outputPath); err != nil {
+		t.Fatal(err)
+	} else if info.Mode().Perm() != 0600 {
+		t.Errorf("expected dotenv mode %o but received %o", 0600, info.Mode().Perm())
+	}
+
+	if table := stdout.String(); strings.Contains(table, "hunter2") {
+		t.Errorf("expected sensitive value to never be printed but received:\n%s", table)
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package environment
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hashicorp/tfci/internal/logging"
+)
+
+// Sourced from: https://circleci.com/docs/variables/#built-in-environment-variables
+type CircleCIContext struct {
+	// A unique identifier for the workflow instance of the current job.
+	workflowId string
+	// The number of the current job. Job numbers are unique for each job.
+	buildNum string
+	// The SHA1 hash of the last commit of the current build.
+	sha1 string
+	// The GitHub or Bitbucket username of the user who triggered the pipeline.
+	username string
+	// The value of the working_directory key of the current job, may start with ~
+	workingDirectory string
+	// path to the file outputs are exported to, sourced by later steps of the job when it is $BASH_ENV
+	outputPath string
+	// data accumulated for output
+	output OutputMap
+	// destination for the table of outputs
+	stdout io.Writer
+	// skips writing the table of outputs to stdout, outputs are still exported to the output path
+	quiet bool
+}
+
+func (cc *CircleCIContext) ID() string {
+	return fmt.Sprintf("circleci-%s-%s", cc.workflowId, cc.buildNum)
+}
+
+func (cc *CircleCIContext) SHA() string {
+	return cc.sha1
+}
+
+func (cc *CircleCIContext) SHAShort() string {
+	if len(cc.sha1) > 7 {
+		return cc.sha1[:7]
+	}
+	return cc.sha1
+}
+
+func (cc *CircleCIContext) Author() string {
+	return cc.username
+}
+
+// temp dir under the job's working directory, which is kept between the steps of a job
+func (cc *CircleCIContext) WriteDir() string {
+	dir := cc.workingDirectory
+	if dir == "" {
+		return os.TempDir()
+	}
+	if rest, ok := strings.CutPrefix(dir, "~"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return os.TempDir()
+		}
+		dir = filepath.Join(home, rest)
+	}
+	return filepath.Join(dir, ".tfci")
+}
+
+func (cc *CircleCIContext) SetOutput(output OutputMap) {
+	if cc.output == nil {
+		cc.output = make(map[string]OutputWriter)
+	}
+
+	maps.Copy(cc.output, output)
+}
+
+// CircleCI has no outputs between steps, each output is exported as an environment variable to the output path
+// instead. When it is $BASH_ENV, the default, the variables are available to later steps of the job
+// https://circleci.com/docs/set-environment-variable/#set-an-environment-variable-in-a-shell-command
+func (cc *CircleCIContext) CloseOutput() (retErr error) {
+	keys := slices.Sorted(maps.Keys(cc.output))
+
+	if cc.outputPath == "" {
+		logging.Warn("BASH_ENV environment variable is not set. Outputs will not be available to later steps.")
+	} else {
+		file, err := os.OpenFile(cc.outputPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			logging.Error("Failed to open CircleCI output file", "path", cc.outputPath, "error", err)
+			return err
+		}
+		defer func() {
+			if err := file.Close(); err != nil {
+				logging.Error("Failed to close CircleCI output file", "error", err)
+				retErr = err
+			}
+		}()
+
+		logging.Debug("Exporting outputs to CircleCI output file", "count", len(keys), "path", cc.outputPath)
+		for _, key := range keys {
+			if _, err := fmt.Fprintf(file, "export %s=%s%s", key, shellQuote(cc.output[key].String()), EOF); err != nil {
+				logging.Error("Failed to write output", "key", key, "error", err)
+				return err
+			}
+		}
+	}
+
+	if !cc.quiet {
+		if err := writeOutputTable(cc.stdout, cc.output, keys); err != nil {
+			return err
+		}
+	}
+
+	cc.output = make(map[string]OutputWriter)
+	return
+}
+
+// prints the outputs as a readable table, multi-line values are summarized and sensitive values are never printed
+func writeOutputTable(w io.Writer, output OutputMap, keys []string) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(table, "OUTPUT\tVALUE%s", EOF)
+	for _, key := range keys {
+		value := output[key]
+		display := value.String()
+		switch {
+		case value.Sensitive():
+			display = "(sensitive)"
+		case value.MultiLine() || strings.Contains(display, EOF):
+			display = fmt.Sprintf("(%d lines)", strings.Count(strings.TrimRight(display, EOF), EOF)+1)
+		}
+		fmt.Fprintf(table, "%s\t%s%s", key, display, EOF)
+	}
+	return table.Flush()
+}
+
+// quotes the value for a POSIX shell, single quotes keep newlines and disable any expansion
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+func (cc *CircleCIContext) SetQuiet(quiet bool) {
+	cc.quiet = quiet
+}
+
+func (cc *CircleCIContext) OutputPath() string {
+	return cc.outputPath
+}
+
+func newCircleCIContext(getenv GetEnv) *CircleCIContext {
+	// TFCI_OUTPUT_PATH overrides $BASH_ENV, eg. to persist outputs to a workspace for later jobs
+	outputPath := getenv(EnvOutputPath)
+	if outputPath == "" {
+		outputPath = getenv("BASH_ENV")
+	}
+
+	return &CircleCIContext{
+		workflowId:       getenv("CIRCLE_WORKFLOW_ID"),
+		buildNum:         getenv("CIRCLE_BUILD_NUM"),
+		sha1:             getenv("CIRCLE_SHA1"),
+		username:         getenv("CIRCLE_USERNAME"),
+		workingDirectory: getenv("CIRCLE_WORKING_DIRECTORY"),
+		outputPath:       outputPath,
+		output:           make(map[string]OutputWriter),
+		stdout:           os.Stdout,
+	}
+}
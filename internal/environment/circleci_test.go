@@ -0,0 +1,94 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package environment
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_CircleCIContext(t *testing.T) {
+	env := map[string]string{
+		"CIRCLECI":                 "true",
+		"CIRCLE_WORKFLOW_ID":       "6f8c1a2b",
+		"CIRCLE_BUILD_NUM":         "42",
+		"CIRCLE_SHA1":              "0123456789abcdef",
+		"CIRCLE_USERNAME":          "octocat",
+		"CIRCLE_WORKING_DIRECTORY": "/home/circleci/project",
+		"BASH_ENV":                 "/tmp/.bash_env",
+	}
+	ci := &CI{getenv: func(key string) string { return env[key] }}
+	ci.initialize()
+
+	if ci.PlatformType != CircleCI {
+		t.Fatalf("expected platform %s but received %s", CircleCI, ci.PlatformType)
+	}
+
+	expected := map[string]string{
+		"ID":         "circleci-6f8c1a2b-42",
+		"SHA":        "0123456789abcdef",
+		"SHAShort":   "0123456",
+		"Author":     "octocat",
+		"WriteDir":   "/home/circleci/project/.tfci",
+		"OutputPath": "/tmp/.bash_env",
+	}
+	actual := map[string]string{
+		"ID":         ci.Context.ID(),
+		"SHA":        ci.Context.SHA(),
+		"SHAShort":   ci.Context.SHAShort(),
+		"Author":     ci.Context.Author(),
+		"WriteDir":   ci.Context.WriteDir(),
+		"OutputPath": ci.OutputPath(),
+	}
+	for name, value := range expected {
+		if actual[name] != value {
+			t.Errorf("expected %s %q but received %q", name, value, actual[name])
+		}
+	}
+}
+
+func Test_CircleCIOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bash_env")
+	stdout := &bytes.Buffer{}
+	circle := newCircleCIContext(func(key string) string {
+		if key == EnvOutputPath {
+			return path
+		}
+		return ""
+	})
+	circle.stdout = stdout
+
+	circle.SetOutput(OutputMap{
+		"run_id":  &testOutput{val: "run-***"},
+		"payload": &testOutput{val: "{\n  \"name\": \"it's\"\n}", multiLine: true},
+		"token":   &testOutput{val: "hunter2", sensitive: true},
+	})
+	if err := circle.CloseOutput(); err != nil {
+		t.Fatalf("error closing output: %s", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "export payload='{\n  \"name\": \"it'\\''s\"\n}'\n" +
+		"export run_id='run-***'\n" +
+		"export token='hunter2'\n"
+	if string(content) != expected {
+		t.Errorf("expected exports %q but received %q", expected, string(content))
+	}
+
+	table := stdout.String()
+	for _, line := range []string{"payload  (3 lines)", "run_id   run-***", "token    (sensitive)"} {
+		if !strings.Contains(table, line) {
+			t.Errorf("expected table to contain %q but received:\n%s", line, table)
+		}
+	}
+	if strings.Contains(table, "hunter2") {
+		t.Errorf("expected sensitive value to never be printed but received:\n%s", table)
+	}
+}
@@ -6,15 +6,20 @@ package environment
 import (
 	"os"
 	"strconv"
+	"strings"
 	"sync"
 )
 
 type PlatformType string
 
 const (
-	GitLab PlatformType = "GitLab"
-	GitHub PlatformType = "GitHub"
-	Other  PlatformType = "Other"
+	GitLab      PlatformType = "GitLab"
+	GitHub      PlatformType = "GitHub"
+	AzureDevOps PlatformType = "AzureDevOps"
+	CircleCI    PlatformType = "CircleCI"
+	Bitbucket   PlatformType = "Bitbucket"
+	TeamCity    PlatformType = "TeamCity"
+	Other       PlatformType = "Other"
 )
 
 var (
@@ -38,10 +43,27 @@ type OutputWriter interface {
 	MultiLine() bool
 	// resolves string value for the interface{}
 	String() string
+	// determines if value should be masked by platforms that support it
+	Sensitive() bool
 }
 
 type OutputMap map[string]OutputWriter
 
+// implemented by sensitive outputs that combine several values, eg. a collection of outputs where only some are
+// sensitive. Masking the whole serialized value does not mask the individual values where they are printed on their own
+type masker interface {
+	Masks() []string
+}
+
+// returns the values a platform should mask for a sensitive output, the individual sensitive values when the
+// output provides them, otherwise the whole value
+func outputMasks(value OutputWriter) []string {
+	if m, ok := value.(masker); ok && len(m.Masks()) > 0 {
+		return m.Masks()
+	}
+	return []string{value.String()}
+}
+
 // return type map to pass to SetOutput(OutputMap)
 func NewOutputMap() OutputMap {
 	return OutputMap{}
@@ -51,6 +73,8 @@ func NewOutputMap() OutputMap {
 type Output struct {
 	value     string
 	multiLine bool
+	sensitive bool
+	masks     []string
 }
 
 func (o *Output) String() string {
@@ -61,10 +85,29 @@ func (o *Output) MultiLine() bool {
 	return o.multiLine
 }
 
-func NewOutput(val string, multiLine bool) *Output {
+func (o *Output) Sensitive() bool {
+	return o.sensitive
+}
+
+func (o *Output) Masks() []string {
+	return o.masks
+}
+
+func NewOutput(val string, multiLine bool, sensitive bool) *Output {
 	return &Output{
 		value:     val,
 		multiLine: multiLine,
+		sensitive: sensitive,
+	}
+}
+
+// returns a sensitive output where platforms mask each of the given values rather than the whole value
+func NewMaskedOutput(val string, multiLine bool, masks []string) *Output {
+	return &Output{
+		value:     val,
+		multiLine: multiLine,
+		sensitive: true,
+		masks:     masks,
 	}
 }
 
@@ -78,6 +121,45 @@ type Common interface {
 	CloseOutput() error
 }
 
+// implemented by platform contexts that write to stdout, eg. debug echoes of outputs
+type quieter interface {
+	SetQuiet(quiet bool)
+}
+
+// prevents the platform context from writing to stdout, reserving it for a structured result
+func (c *CI) SetQuiet(quiet bool) {
+	if q, ok := c.Context.(quieter); ok {
+		q.SetQuiet(quiet)
+	}
+}
+
+// implemented by platform contexts that write outputs to a file
+type outputPather interface {
+	OutputPath() string
+}
+
+// returns the file outputs are written to, if any
+func (c *CI) OutputPath() string {
+	if p, ok := c.Context.(outputPather); ok {
+		return p.OutputPath()
+	}
+	return ""
+}
+
+// implemented by platform contexts with a job summary page
+type summarizer interface {
+	WriteStepSummary(markdown string) error
+}
+
+// appends markdown to the platform's job summary, a no-op for platforms without one
+func (c *CI) WriteStepSummary(markdown string) error {
+	if s, ok := c.Context.(summarizer); ok {
+		return s.WriteStepSummary(markdown)
+	}
+	return nil
+}
+
+// detects the platform from its environment variables, checked in a fixed order where the first match wins
 func (c *CI) initialize() {
 	ci, _ := strconv.ParseBool(c.getenv("CI"))
 	c.CI = ci
@@ -93,7 +175,36 @@ func (c *CI) initialize() {
 		return
 	}
 
+	// set to "True" by Azure Pipelines agents
+	if strings.EqualFold(c.getenv("TF_BUILD"), "true") {
+		c.PlatformType = AzureDevOps
+		c.Context = newAzureDevOpsContext(c.getenv)
+		return
+	}
+
+	if c.getenv("CIRCLECI") == "true" {
+		c.PlatformType = CircleCI
+		c.Context = newCircleCIContext(c.getenv)
+		return
+	}
+
+	// Bitbucket Pipelines sets no platform flag, the build number is always set
+	if c.getenv("BITBUCKET_BUILD_NUMBER") != "" {
+		c.PlatformType = Bitbucket
+		c.Context = newBitbucketContext(c.getenv)
+		return
+	}
+
+	// set to the server version for every build process by TeamCity agents
+	if c.getenv("TEAMCITY_VERSION") != "" {
+		c.PlatformType = TeamCity
+		c.Context = newTeamCityContext(c.getenv)
+		return
+	}
+
+	// no known CI platform detected, eg. running from a local machine
 	c.PlatformType = Other
+	c.Context = newLocalContext(c.getenv)
 }
 
 func NewCIContext() *CI {
@@ -1,6 +1,7 @@
 package environment
 
 import (
+	"crypto/rand"
 	"fmt"
 	"maps"
 	"os"
@@ -31,10 +32,17 @@ type GitHubContext struct {
 	runnerTemp string
 	// path to output file for GitHub Actions
 	githubOutput string
+	// githubOutput is the GITHUB_ENV file, outputs are exported as environment variables of later steps instead of
+	// step outputs, as GITHUB_OUTPUT is not set
+	envFallback bool
+	// path to the markdown file rendered on the job summary page
+	stepSummary string
 	// data accumulated for output
 	output OutputMap
-	// unique delimiter for multiline outputs
+	// random delimiter for multiline outputs, see delimiterFor
 	fileDelimeter string
+	// skips echoing outputs to stdout, masks are written to stderr instead
+	quiet bool
 }
 
 func (gh *GitHubContext) ID() string {
@@ -69,8 +77,8 @@ func (gh *GitHubContext) SetOutput(output OutputMap) {
 
 func (gh *GitHubContext) CloseOutput() (retErr error) {
 	if gh.githubOutput == "" {
-		logging.Error("GITHUB_OUTPUT environment variable not set")
-		return fmt.Errorf("GITHUB_OUTPUT environment variable not set")
+		logging.Error("GITHUB_OUTPUT and GITHUB_ENV environment variables not set")
+		return fmt.Errorf("GITHUB_OUTPUT and GITHUB_ENV environment variables not set")
 	}
 
 	file, err := os.OpenFile(gh.githubOutput, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
@@ -85,23 +93,40 @@ func (gh *GitHubContext) CloseOutput() (retErr error) {
 		}
 	}()
 
-	logging.Debug("Writing outputs to GitHub output file", "count", len(gh.output))
+	if gh.envFallback {
+		logging.Debug("Exporting outputs as environment variables to GitHub env file", "count", len(gh.output), "path", gh.githubOutput)
+	} else {
+		logging.Debug("Writing outputs to GitHub output file", "count", len(gh.output))
+	}
 
 	for key, value := range gh.output {
 		strValue := value.String()
 
-		// Log each output value for troubleshooting
-		logging.Debug("Setting GitHub output", "key", key, "value", strValue)
+		// mask sensitive values before they can be written anywhere in the workflow logs
+		if value.Sensitive() {
+			for _, mask := range outputMasks(value) {
+				gh.addMask(mask)
+			}
+		}
 
+		// Log each output value for troubleshooting, sensitive values are never logged as the log file is not masked
+		logValue := strValue
+		if value.Sensitive() {
+			logValue = "***"
+		}
+		logging.Debug("Setting GitHub output", "key", key, "value", logValue)
+
+		// GITHUB_ENV shares the key=value and heredoc format of GITHUB_OUTPUT
 		var outputLine string
 		if value.MultiLine() || strings.Contains(strValue, "\n") {
+			delimiter := gh.delimiterFor(strValue)
 			outputLine = fmt.Sprintf("%s<<%s%s%s%s%s%s",
 				key,
-				gh.fileDelimeter,
+				delimiter,
 				EOF,
 				strValue,
 				EOF,
-				gh.fileDelimeter,
+				delimiter,
 				EOF)
 		} else {
 			outputLine = fmt.Sprintf("%s=%s%s", key, strValue, EOF)
@@ -125,14 +150,83 @@ func (gh *GitHubContext) CloseOutput() (retErr error) {
 	}
 
 	// Write to stdout as well for debugging in GitHub Actions logs
-	for key, value := range gh.output {
-		fmt.Printf("::set-output name=%s::%s\n", key, value.String())
+	if !gh.quiet {
+		for key, value := range gh.output {
+			fmt.Printf("::set-output name=%s::%s\n", key, value.String())
+		}
 	}
 
 	gh.output = make(map[string]OutputWriter)
 	return
 }
 
+// returns the delimiter for a multiline value, which is regenerated when the value contains it. Otherwise
+// the value would end the heredoc early, and the remaining lines could set arbitrary outputs
+func (gh *GitHubContext) delimiterFor(value string) string {
+	if gh.fileDelimeter == "" {
+		gh.fileDelimeter = newFileDelimiter()
+	}
+	for strings.Contains(value, gh.fileDelimeter) {
+		logging.Debug("Regenerating GitHub output delimiter, the value contains it")
+		gh.fileDelimeter = newFileDelimiter()
+	}
+	return gh.fileDelimeter
+}
+
+// unpredictable, so an output value cannot contain the delimiter by design
+func newFileDelimiter() string {
+	return fmt.Sprintf("ghadelimiter_%s", rand.Text())
+}
+
+// GitHub masks each line of a registered value individually
+// https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions#masking-a-value-in-a-log
+func (gh *GitHubContext) addMask(value string) {
+	for _, line := range strings.Split(value, EOF) {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		// workflow commands are processed from both stdout and stderr
+		out := os.Stdout
+		if gh.quiet {
+			out = os.Stderr
+		}
+		fmt.Fprintf(out, "::add-mask::%s%s", line, EOF)
+	}
+}
+
+// appends markdown to the job summary page
+// https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions#adding-a-job-summary
+func (gh *GitHubContext) WriteStepSummary(markdown string) (retErr error) {
+	if gh.stepSummary == "" {
+		logging.Warn("GITHUB_STEP_SUMMARY environment variable not set, skipping job summary")
+		return nil
+	}
+
+	file, err := os.OpenFile(gh.stepSummary, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		logging.Error("Failed to open GitHub step summary file", "error", err)
+		return err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			logging.Error("Failed to close GitHub step summary file", "error", err)
+			retErr = err
+		}
+	}()
+
+	_, retErr = file.WriteString(markdown)
+	return
+}
+
+// returns GITHUB_OUTPUT, or GITHUB_ENV on runners predating it
+func (gh *GitHubContext) OutputPath() string {
+	return gh.githubOutput
+}
+
+func (gh *GitHubContext) SetQuiet(quiet bool) {
+	gh.quiet = quiet
+}
+
 func newGitHubContext(getenv GetEnv) *GitHubContext {
 	runId := getenv("GITHUB_RUN_ID")
 	runNumber := getenv("GITHUB_RUN_NUMBER")
@@ -158,20 +252,24 @@ func newGitHubContext(getenv GetEnv) *GitHubContext {
 		refName:      getenv("GITHUB_REF_NAME"),
 		refType:      getenv("GITHUB_REF_TYPE"),
 		githubOutput: githubOutput,
+		stepSummary:  getenv("GITHUB_STEP_SUMMARY"),
 		runnerTemp:   getenv("RUNNER_TEMP"),
 		output:       make(map[string]OutputWriter),
 	}
 
-	ghCtx.fileDelimeter = fmt.Sprintf("GHDELIM_%s_%s_%d", runId, runNumber, os.Getpid())
+	ghCtx.fileDelimeter = newFileDelimiter()
 
 	if ghCtx.githubOutput == "" {
-		logging.Warn("GITHUB_OUTPUT environment variable is not set. Outputs will not be available in GitHub Actions.")
-
-		// Fallback to legacy GITHUB_ENV if available (for older Actions versions)
-		legacyEnv := getenv("GITHUB_ENV")
-		if legacyEnv != "" {
-			logging.Info("Using GITHUB_ENV as fallback for outputs", "path", legacyEnv)
-			ghCtx.githubOutput = legacyEnv
+		// runners predating GITHUB_OUTPUT only provide GITHUB_ENV, which sets environment variables of the later steps
+		// of the job rather than outputs of this step
+		if envFile := getenv("GITHUB_ENV"); envFile != "" {
+			logging.Warn("GITHUB_OUTPUT environment variable is not set, exporting outputs as environment variables to GITHUB_ENV instead. "+
+				"They are not step outputs: read them from the environment of later steps of the same job, e.g. ${{ env.run_id }}, "+
+				"they overwrite existing variables with the same name and are not available to other jobs.", "path", envFile)
+			ghCtx.githubOutput = envFile
+			ghCtx.envFallback = true
+		} else {
+			logging.Warn("GITHUB_OUTPUT environment variable is not set. Outputs will not be available in GitHub Actions.")
 		}
 	}
 
@@ -6,11 +6,14 @@ package environment
 import (
 	"crypto/sha256"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/hashicorp/tfci/internal/logging"
 )
 
 func randomSha(t *testing.T) string {
@@ -55,6 +58,7 @@ func createOutFile(t *testing.T, path string) {
 type testOutput struct {
 	val       string
 	multiLine bool
+	sensitive bool
 }
 
 func (o *testOutput) MultiLine() bool {
@@ -65,6 +69,10 @@ func (o *testOutput) String() string {
 	return o.val
 }
 
+func (o *testOutput) Sensitive() bool {
+	return o.sensitive
+}
+
 func Test_GitHubOutput(t *testing.T) {
 	env := getEnvMock(t)
 	path, _ := filepath.Abs(env["GITHUB_OUTPUT"])
@@ -108,3 +116,237 @@ func Test_GitHubContext(t *testing.T) {
 		t.Errorf("expected %s, but received: %s", sha, actualSHA)
 	}
 }
+
+func Test_GitHubOutputSensitive(t *testing.T) {
+	env := getEnvMock(t)
+	path, _ := filepath.Abs(env["GITHUB_OUTPUT"])
+
+	createOutFile(t, path)
+
+	getenv := func(key string) string {
+		return env[key]
+	}
+	github := newGitHubContext(getenv)
+
+	// eg. the -log-file uploaded as an artifact, which GitHub does not mask
+	t.Setenv(logging.EnvLogLevel, "OFF")
+	logPath := filepath.Join(t.TempDir(), "tfci.log")
+	if err := logging.SetupLogger(&logging.LoggerOptions{LogFile: logPath, LogFileLevel: "DEBUG"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = logging.SetupLogger(nil) })
+
+	github.SetOutput(OutputMap{
+		"secret": &testOutput{val: "hunter2", sensitive: true},
+		"public": &testOutput{val: "visible"},
+	})
+
+	// capture stdout for workflow commands
+	stdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := github.CloseOutput()
+	w.Close()
+	os.Stdout = stdout
+	if err != nil {
+		t.Fatalf("error closing output: %s", err.Error())
+	}
+
+	out, _ := io.ReadAll(r)
+	commands := string(out)
+
+	if !strings.Contains(commands, "::add-mask::hunter2\n") {
+		t.Errorf("expected sensitive value to be masked, but received: %q", commands)
+	}
+	if strings.Contains(commands, "::add-mask::visible") {
+		t.Errorf("expected non-sensitive value to not be masked, but received: %q", commands)
+	}
+	if strings.Index(commands, "::add-mask::hunter2") > strings.Index(commands, "::set-output name=secret") {
+		t.Errorf("expected mask to be emitted before value is written, but received: %q", commands)
+	}
+
+	_ = logging.Sync()
+	logs, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(logs), "hunter2") {
+		t.Errorf("expected sensitive value to never be logged, but received:\n%s", logs)
+	}
+	if !strings.Contains(string(logs), "visible") {
+		t.Errorf("expected non-sensitive value to be logged, but received:\n%s", logs)
+	}
+}
+
+func Test_GitHubOutputMasks(t *testing.T) {
+	env := getEnvMock(t)
+	path, _ := filepath.Abs(env["GITHUB_OUTPUT"])
+
+	createOutFile(t, path)
+
+	github := newGitHubContext(func(key string) string { return env[key] })
+	github.SetQuiet(true)
+
+	github.SetOutput(OutputMap{
+		"outputs": NewMaskedOutput(`[{"name":"password","value":"hunter2"},{"name":"region","value":"us-east-1"}]`, true, []string{"hunter2"}),
+	})
+
+	// masks are written to stderr when quiet
+	stderr := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+	err := github.CloseOutput()
+	w.Close()
+	os.Stderr = stderr
+	if err != nil {
+		t.Fatalf("error closing output: %s", err.Error())
+	}
+
+	out, _ := io.ReadAll(r)
+	if commands := string(out); commands != "::add-mask::hunter2\n" {
+		t.Errorf("expected only the sensitive value to be masked, but received: %q", commands)
+	}
+}
+
+// parses the GITHUB_OUTPUT file format, key=value lines and key<<delimiter heredocs
+func parseGitHubOutput(t *testing.T, content string) map[string]string {
+	t.Helper()
+	outputs := map[string]string{}
+	lines := strings.Split(content, EOF)
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if line == "" {
+			continue
+		}
+		if key, delimiter, ok := strings.Cut(line, "<<"); ok {
+			value := []string{}
+			for i++; i < len(lines) && lines[i] != delimiter; i++ {
+				value = append(value, lines[i])
+			}
+			if i == len(lines) {
+				t.Fatalf("multiline output %q is not terminated by %q", key, delimiter)
+			}
+			outputs[key] = strings.Join(value, EOF)
+			continue
+		}
+		key, value, _ := strings.Cut(line, "=")
+		outputs[key] = value
+	}
+	return outputs
+}
+
+func Test_GitHubOutputDelimiter(t *testing.T) {
+	env := getEnvMock(t)
+	path, _ := filepath.Abs(env["GITHUB_OUTPUT"])
+
+	createOutFile(t, path)
+
+	getenv := func(key string) string {
+		return env[key]
+	}
+	github := newGitHubContext(getenv)
+
+	if !strings.HasPrefix(github.fileDelimeter, "ghadelimiter_") || github.fileDelimeter == newGitHubContext(getenv).fileDelimeter {
+		t.Fatalf("expected a random delimiter but received %q", github.fileDelimeter)
+	}
+
+	// the value ends the heredoc with the candidate delimiter and attempts to inject another output
+	candidate := github.fileDelimeter
+	injected := fmt.Sprintf("line one%s%s%sinjected=true", EOF, candidate, EOF)
+	github.SetOutput(OutputMap{
+		"payload": &testOutput{val: injected, multiLine: true},
+		"status":  &testOutput{val: "Success"},
+	})
+
+	stdout := os.Stdout
+	_, w, _ := os.Pipe()
+	os.Stdout = w
+	err := github.CloseOutput()
+	w.Close()
+	os.Stdout = stdout
+	if err != nil {
+		t.Fatalf("error closing output: %s", err.Error())
+	}
+
+	if github.fileDelimeter == candidate {
+		t.Errorf("expected the delimiter to be regenerated")
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputs := parseGitHubOutput(t, string(content))
+	expected := map[string]string{"payload": injected, "status": "Success"}
+	if len(outputs) != len(expected) {
+		t.Fatalf("expected outputs %v but received %v", expected, outputs)
+	}
+	for key, value := range expected {
+		if outputs[key] != value {
+			t.Errorf("expected output %q to be %q but received %q", key, value, outputs[key])
+		}
+	}
+}
+
+func Test_GitHubOutputEnvFallback(t *testing.T) {
+	testCases := []struct {
+		name        string
+		output      bool
+		envFallback bool
+	}{
+		{
+			name:   "output",
+			output: true,
+		},
+		{
+			name:        "env-fallback",
+			envFallback: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			env := getEnvMock(t)
+			env["GITHUB_OUTPUT"] = ""
+			if tc.output {
+				env["GITHUB_OUTPUT"] = filepath.Join(dir, "github_output")
+			}
+			env["GITHUB_ENV"] = filepath.Join(dir, "github_env")
+			github := newGitHubContext(func(key string) string {
+				return env[key]
+			})
+			github.SetQuiet(true)
+
+			if github.envFallback != tc.envFallback {
+				t.Fatalf("expected envFallback %t but received %t", tc.envFallback, github.envFallback)
+			}
+
+			github.SetOutput(OutputMap{
+				"run_id":  &testOutput{val: "run-***"},
+				"payload": &testOutput{val: "line one\nline two", multiLine: true},
+			})
+			if err := github.CloseOutput(); err != nil {
+				t.Fatalf("error closing output: %s", err.Error())
+			}
+
+			written, unused := env["GITHUB_OUTPUT"], env["GITHUB_ENV"]
+			if tc.envFallback {
+				written, unused = env["GITHUB_ENV"], env["GITHUB_OUTPUT"]
+			}
+			content, err := os.ReadFile(written)
+			if err != nil {
+				t.Fatal(err)
+			}
+			outputs := parseGitHubOutput(t, string(content))
+			if outputs["run_id"] != "run-***" || outputs["payload"] != "line one\nline two" {
+				t.Errorf("expected the outputs to be written to %q but received %v", written, outputs)
+			}
+			if unused != "" {
+				if _, err := os.Stat(unused); !os.IsNotExist(err) {
+					t.Errorf("expected %q to not be written", unused)
+				}
+			}
+		})
+	}
+}
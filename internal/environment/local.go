@@ -0,0 +1,125 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package environment
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/tfci/internal/logging"
+)
+
+// optional file path to write outputs to when running outside of a known CI platform
+const EnvOutputPath = "TFCI_OUTPUT_PATH"
+
+// LocalContext is used when tfci is executed outside of a known CI platform, eg. from a laptop
+type LocalContext struct {
+	// current process id, used to synthesize a stable id for this execution
+	pid int
+	// the name of the user running tfci
+	user string
+	// optional path to output file, when empty outputs are not written. stdout only has the command result, so it
+	// can be piped, eg. to jq
+	outputPath string
+	// data accumulated for output
+	output OutputMap
+	// random delimiter for multiline outputs, see delimiterFor
+	fileDelimiter string
+}
+
+func (l *LocalContext) ID() string {
+	return fmt.Sprintf("local-%d", l.pid)
+}
+
+func (l *LocalContext) SHA() string {
+	return ""
+}
+
+func (l *LocalContext) SHAShort() string {
+	return ""
+}
+
+func (l *LocalContext) Author() string {
+	return l.user
+}
+
+func (l *LocalContext) WriteDir() string {
+	return os.TempDir()
+}
+
+func (l *LocalContext) SetOutput(output OutputMap) {
+	if l.output == nil {
+		l.output = make(map[string]OutputWriter)
+	}
+
+	maps.Copy(l.output, output)
+}
+
+// writes the outputs to TFCI_OUTPUT_PATH as `key=value` lines, multi-line values use the `key<<delimiter` heredoc
+// format of GitHub Actions outputs
+func (l *LocalContext) CloseOutput() (retErr error) {
+	if l.outputPath == "" {
+		logging.Debug("Skipping local outputs, TFCI_OUTPUT_PATH is not set")
+		l.output = make(map[string]OutputWriter)
+		return
+	}
+
+	file, err := os.OpenFile(l.outputPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		logging.Error("Failed to open local output file", "path", l.outputPath, "error", err)
+		return err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			logging.Error("Failed to close local output file", "error", err)
+			retErr = err
+		}
+	}()
+
+	logging.Debug("Writing local outputs", "count", len(l.output), "path", l.outputPath)
+
+	// sort keys so the output is stable between executions
+	for _, key := range slices.Sorted(maps.Keys(l.output)) {
+		value := strings.TrimRight(l.output[key].String(), EOF)
+		line := fmt.Sprintf("%s=%s%s", key, value, EOF)
+		if strings.Contains(value, EOF) {
+			delimiter := l.delimiterFor(value)
+			line = fmt.Sprintf("%s<<%s%s%s%s%s%s", key, delimiter, EOF, value, EOF, delimiter, EOF)
+		}
+		if _, err := file.WriteString(line); err != nil {
+			logging.Error("Failed to write output", "key", key, "error", err)
+			return err
+		}
+	}
+
+	l.output = make(map[string]OutputWriter)
+	return
+}
+
+// returns the delimiter for a multiline value, which is regenerated when the value contains it
+func (l *LocalContext) delimiterFor(value string) string {
+	if l.fileDelimiter == "" {
+		l.fileDelimiter = newFileDelimiter()
+	}
+	for strings.Contains(value, l.fileDelimiter) {
+		l.fileDelimiter = newFileDelimiter()
+	}
+	return l.fileDelimiter
+}
+
+func (l *LocalContext) OutputPath() string {
+	return l.outputPath
+}
+
+func newLocalContext(getenv GetEnv) *LocalContext {
+	return &LocalContext{
+		pid:        os.Getpid(),
+		user:       getenv("USER"),
+		outputPath: getenv(EnvOutputPath),
+		output:     make(map[string]OutputWriter),
+	}
+}
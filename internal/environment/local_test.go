@@ -0,0 +1,92 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package environment
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_LocalContext(t *testing.T) {
+	getenv := func(key string) string {
+		return ""
+	}
+	local := newLocalContext(getenv)
+
+	expectedID := fmt.Sprintf("local-%d", os.Getpid())
+	if actualID := local.ID(); actualID != expectedID {
+		t.Errorf("expected %s, but received: %s", expectedID, actualID)
+	}
+
+	if actualDir := local.WriteDir(); actualDir != os.TempDir() {
+		t.Errorf("expected %s, but received: %s", os.TempDir(), actualDir)
+	}
+}
+
+func Test_LocalOutput(t *testing.T) {
+	// stdout only has the command result, outputs are not written without TFCI_OUTPUT_PATH
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = w
+	t.Cleanup(func() { os.Stdout = stdout })
+
+	getenv := func(key string) string {
+		return ""
+	}
+	local := newLocalContext(getenv)
+	local.SetOutput(OutputMap{
+		"run_id": &testOutput{val: "run-1"},
+	})
+	closeErr := local.CloseOutput()
+	w.Close()
+	os.Stdout = stdout
+	if closeErr != nil {
+		t.Fatalf("error closing output: %s", closeErr.Error())
+	}
+
+	written, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(written) > 0 {
+		t.Errorf("expected no outputs on stdout, but received: %q", string(written))
+	}
+}
+
+func Test_LocalOutputPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outputs")
+	getenv := func(key string) string {
+		if key == EnvOutputPath {
+			return path
+		}
+		return ""
+	}
+	local := newLocalContext(getenv)
+
+	local.SetOutput(OutputMap{
+		"payload": &testOutput{val: "{\"pk\": \"pv\"}\n", multiLine: true},
+		"plan":    &testOutput{val: "line 1\nline 2\n", multiLine: true},
+	})
+
+	if err := local.CloseOutput(); err != nil {
+		t.Fatalf("error closing output: %s", err.Error())
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("file read error: %v", err)
+	}
+
+	delimiter := local.fileDelimiter
+	expected := "payload={\"pk\": \"pv\"}\nplan<<" + delimiter + "\nline 1\nline 2\n" + delimiter + "\n"
+	if delimiter == "" || string(contents) != expected {
+		t.Errorf("expected %q, but received: %q", expected, string(contents))
+	}
+}
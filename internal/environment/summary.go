@@ -0,0 +1,69 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package environment
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/tfci/internal/logging"
+)
+
+const (
+	// default number of trailing log lines appended to the job summary
+	SummaryLogMaxLines = 500
+	// GitHub limits each step summary to 1MiB, leaving room for other steps writing to the summary
+	summaryLogMaxBytes = 256 * 1024
+)
+
+// terminal color codes are not rendered in markdown
+var ansiEscapePattern = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+// LogSummary appends the trailing lines of run logs to the job summary in collapsible code blocks
+type LogSummary struct {
+	ci       *CI
+	maxLines int
+}
+
+func (s *LogSummary) MaxLines() int {
+	return s.maxLines
+}
+
+func (s *LogSummary) TeeLog(label string, lines []string, omitted int) {
+	// keep the end of the log, which is where failures are reported
+	size := 0
+	for i := len(lines) - 1; i >= 0; i-- {
+		lines[i] = ansiEscapePattern.ReplaceAllString(lines[i], "")
+		size += len(lines[i]) + 1
+		if size > summaryLogMaxBytes {
+			omitted += i + 1
+			lines = lines[i+1:]
+			break
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<details><summary>%s</summary>\n\n", label)
+	if omitted > 0 {
+		fmt.Fprintf(&b, "_%d earlier lines omitted_\n\n", omitted)
+	}
+	b.WriteString("```text\n")
+	for _, line := range lines {
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	b.WriteString("```\n\n</details>\n\n")
+
+	if err := s.ci.WriteStepSummary(b.String()); err != nil {
+		logging.Warn("Failed to append logs to the job summary", "label", label, "error", err)
+	}
+}
+
+func NewLogSummary(ci *CI, maxLines int) *LogSummary {
+	return &LogSummary{
+		ci:       ci,
+		maxLines: maxLines,
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package environment
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_LogSummary(t *testing.T) {
+	summaryPath := filepath.Join(t.TempDir(), "step_summary")
+	getenv := func(key string) string {
+		if key == "GITHUB_STEP_SUMMARY" {
+			return summaryPath
+		}
+		return getEnvMock(t)[key]
+	}
+	ci := &CI{PlatformType: GitHub, Context: newGitHubContext(getenv)}
+
+	summary := NewLogSummary(ci, 2)
+	summary.TeeLog("Plan Log", []string{"\x1b[31mError:\x1b[0m invalid value", "exit status 1"}, 40)
+
+	content, err := os.ReadFile(summaryPath)
+	if err != nil {
+		t.Fatalf("error reading step summary: %s", err)
+	}
+
+	expected := "<details><summary>Plan Log</summary>\n\n_40 earlier lines omitted_\n\n```text\nError: invalid value\nexit status 1\n```\n\n</details>\n\n"
+	if actual := string(content); actual != expected {
+		t.Errorf("expected %q, but received: %q", expected, actual)
+	}
+}
+
+func Test_LogSummary_NoSummaryPlatform(t *testing.T) {
+	getenv := func(key string) string {
+		return ""
+	}
+	ci := &CI{PlatformType: Other, Context: newLocalContext(getenv)}
+
+	if err := ci.WriteStepSummary("summary"); err != nil {
+		t.Errorf("expected %v but received: %s", nil, err)
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package environment
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/tfci/internal/logging"
+)
+
+// escapes service message attribute values, the agent unescapes them when setting the parameter
+// https://www.jetbrains.com/help/teamcity/service-messages.html#Escaped+Values
+var teamCityEscaper = strings.NewReplacer(
+	"|", "||",
+	"'", "|'",
+	"\n", "|n",
+	"\r", "|r",
+	"[", "|[",
+	"]", "|]",
+	"\u0085", "|x",
+	"\u2028", "|l",
+	"\u2029", "|p",
+)
+
+// Sourced from: https://www.jetbrains.com/help/teamcity/predefined-build-parameters.html
+type TeamCityContext struct {
+	// The build number assigned by the build configuration's build number format.
+	buildNumber string
+	// The latest VCS revision included in the build, only set for build configurations with a single VCS root.
+	vcsNumber string
+	// The user who triggered the build, TeamCity only provides it as the teamcity.build.triggeredBy.username
+	// parameter, which must be mapped to the TEAMCITY_TRIGGERED_BY environment variable
+	triggeredBy string
+	// data accumulated for output
+	output OutputMap
+	// where service messages are written, the agent processes messages from stdout
+	out io.Writer
+	// writes service messages to stderr, reserving stdout for a structured result
+	quiet bool
+}
+
+func (tc *TeamCityContext) ID() string {
+	return fmt.Sprintf("teamcity-%s", tc.buildNumber)
+}
+
+func (tc *TeamCityContext) SHA() string {
+	return tc.vcsNumber
+}
+
+func (tc *TeamCityContext) SHAShort() string {
+	if len(tc.vcsNumber) > 7 {
+		return tc.vcsNumber[:7]
+	}
+	return tc.vcsNumber
+}
+
+func (tc *TeamCityContext) Author() string {
+	return tc.triggeredBy
+}
+
+// the agent points TMPDIR at the build's temp directory, which is cleaned after each build
+func (tc *TeamCityContext) WriteDir() string {
+	return os.TempDir()
+}
+
+func (tc *TeamCityContext) SetOutput(output OutputMap) {
+	if tc.output == nil {
+		tc.output = make(map[string]OutputWriter)
+	}
+
+	maps.Copy(tc.output, output)
+}
+
+// sets each output as a build configuration parameter, available to later steps as %name% and to dependent builds
+// as %dep.<build configuration id>.name%. Service messages cannot set password parameters, so sensitive outputs are
+// skipped rather than shown on the build's parameters tab
+// https://www.jetbrains.com/help/teamcity/service-messages.html#Adding+or+Changing+a+Build+Parameter
+func (tc *TeamCityContext) CloseOutput() error {
+	out := tc.out
+	if tc.quiet {
+		out = os.Stderr
+	}
+
+	keys := slices.Sorted(maps.Keys(tc.output))
+
+	logging.Debug("Writing outputs as TeamCity build parameters", "count", len(keys))
+	for _, key := range keys {
+		value := tc.output[key]
+		if value.Sensitive() {
+			logging.Warn("Skipping sensitive output, TeamCity build parameters are not masked", "key", key)
+			continue
+		}
+		if _, err := fmt.Fprintf(out, "##teamcity[setParameter name='%s' value='%s']\n", teamCityEscaper.Replace(key), teamCityEscaper.Replace(value.String())); err != nil {
+			logging.Error("Failed to write output", "key", key, "error", err)
+			return err
+		}
+	}
+
+	tc.output = make(map[string]OutputWriter)
+	return nil
+}
+
+func (tc *TeamCityContext) SetQuiet(quiet bool) {
+	tc.quiet = quiet
+}
+
+func newTeamCityContext(getenv GetEnv) *TeamCityContext {
+	return &TeamCityContext{
+		buildNumber: getenv("BUILD_NUMBER"),
+		vcsNumber:   getenv("BUILD_VCS_NUMBER"),
+		triggeredBy: getenv("TEAMCITY_TRIGGERED_BY"),
+		output:      make(map[string]OutputWriter),
+		out:         os.Stdout,
+	}
+}
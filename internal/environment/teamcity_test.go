@@ -0,0 +1,93 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package environment
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func Test_TeamCityContext(t *testing.T) {
+	env := map[string]string{
+		"TEAMCITY_VERSION":      "2024.12 (build 174331)",
+		"BUILD_NUMBER":          "42",
+		"BUILD_VCS_NUMBER":      "0123456789abcdef",
+		"TEAMCITY_TRIGGERED_BY": "jdoe",
+	}
+	ci := &CI{getenv: func(key string) string { return env[key] }}
+	ci.initialize()
+
+	if ci.PlatformType != TeamCity {
+		t.Fatalf("expected platform %s but received %s", TeamCity, ci.PlatformType)
+	}
+
+	expected := map[string]string{
+		"ID":       "teamcity-42",
+		"SHA":      "0123456789abcdef",
+		"SHAShort": "0123456",
+		"Author":   "jdoe",
+		"WriteDir": os.TempDir(),
+	}
+	actual := map[string]string{
+		"ID":       ci.Context.ID(),
+		"SHA":      ci.Context.SHA(),
+		"SHAShort": ci.Context.SHAShort(),
+		"Author":   ci.Context.Author(),
+		"WriteDir": ci.Context.WriteDir(),
+	}
+	for name, value := range expected {
+		if actual[name] != value {
+			t.Errorf("expected %s %q but received %q", name, value, actual[name])
+		}
+	}
+}
+
+func Test_TeamCityEscaper(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{name: "plain", value: "run-***", expected: "run-***"},
+		{name: "pipe", value: "a|b", expected: "a||b"},
+		{name: "apostrophe", value: "it's", expected: "it|'s"},
+		{name: "brackets", value: "[\"a\", \"b\"]", expected: "|[\"a\", \"b\"|]"},
+		{name: "newlines", value: "line one\r\nline two", expected: "line one|r|nline two"},
+		{name: "unicode-separators", value: "a\u0085b\u2028c\u2029d", expected: "a|xb|lc|pd"},
+		// a literal |n stays distinguishable from an escaped newline
+		{name: "escaped", value: "|n", expected: "||n"},
+		// a value cannot end the service message early
+		{name: "injection", value: "x']\n##teamcity[setParameter name='status' value='Success']", expected: "x|'|]|n##teamcity|[setParameter name=|'status|' value=|'Success|'|]"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if escaped := teamCityEscaper.Replace(tc.value); escaped != tc.expected {
+				t.Errorf("expected %q but received %q", tc.expected, escaped)
+			}
+		})
+	}
+}
+
+func Test_TeamCityOutput(t *testing.T) {
+	out := &bytes.Buffer{}
+	teamcity := newTeamCityContext(func(string) string { return "" })
+	teamcity.out = out
+
+	teamcity.SetOutput(OutputMap{
+		"run_id":  &testOutput{val: "run-***"},
+		"payload": &testOutput{val: "{\n  \"tags\": [\"it's\"]\n}", multiLine: true},
+		"token":   &testOutput{val: "hunter2", sensitive: true},
+	})
+	if err := teamcity.CloseOutput(); err != nil {
+		t.Fatalf("error closing output: %s", err)
+	}
+
+	expected := "##teamcity[setParameter name='payload' value='{|n  \"tags\": |[\"it|'s\"|]|n}']\n" +
+		"##teamcity[setParameter name='run_id' value='run-***']\n"
+	if out.String() != expected {
+		t.Errorf("expected service messages %q but received %q", expected, out.String())
+	}
+}
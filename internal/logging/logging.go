@@ -4,6 +4,8 @@
 package logging
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"os"
 	"strings"
@@ -19,25 +21,38 @@ const (
 	EnvLogFormat = "TF_LOG_FORMAT"
 )
 
+// TraceLevel is below DEBUG, eg. for every HCP Terraform API request. It is opt-in only, with TF_LOG=TRACE
+const TraceLevel = zapcore.DebugLevel - 1
+
 var (
 	// Valid log levels
-	ValidLevels = []string{"DEBUG", "INFO", "WARN", "ERROR", "OFF"}
+	ValidLevels = []string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR", "OFF"}
 	// Valid log formats
 	ValidFormats = []string{"JSON", "CONSOLE"}
 	// Global logger instance
 	logger *zap.Logger
 	// Sugar logger for convenience methods
 	sugar *zap.SugaredLogger
+	// Optional log file, closed when the logger is reinitialized
+	logFile *os.File
 )
 
 // LoggerOptions holds configuration for the logger
 type LoggerOptions struct {
 	PlatformType string
+	// Optional path to additionally write logs to
+	LogFile string
+	// Log level for the log file, independent of the stderr log level
+	LogFileLevel string
+	// Disables the colored level of console logs, eg. with -no-color or NO_COLOR
+	NoColor bool
 }
 
 // parseLogLevel converts string level to zapcore.Level
 func parseLogLevel(level string) zapcore.Level {
 	switch strings.ToUpper(level) {
+	case "TRACE":
+		return TraceLevel
 	case "DEBUG":
 		return zapcore.DebugLevel
 	case "INFO":
@@ -53,8 +68,39 @@ func parseLogLevel(level string) zapcore.Level {
 	}
 }
 
+// newEncoder configures encoder based on format, color is omitted for non-terminal sinks
+func newEncoder(logFormat string, color bool) zapcore.Encoder {
+	if logFormat == "JSON" {
+		encoderConfig := zap.NewProductionEncoderConfig()
+		encoderConfig.TimeKey = "timestamp"
+		encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+		encoderConfig.EncodeLevel = traceLevelEncoder("trace", encoderConfig.EncodeLevel)
+		return zapcore.NewJSONEncoder(encoderConfig)
+	}
+
+	encoderConfig := zap.NewDevelopmentEncoderConfig()
+	encoderConfig.EncodeLevel = traceLevelEncoder("TRACE", zapcore.CapitalLevelEncoder)
+	if color {
+		encoderConfig.EncodeLevel = traceLevelEncoder("TRACE", zapcore.CapitalColorLevelEncoder)
+	}
+	encoderConfig.EncodeTime = zapcore.TimeEncoderOfLayout("15:04:05")
+	encoderConfig.ConsoleSeparator = " "
+	return zapcore.NewConsoleEncoder(encoderConfig)
+}
+
+// names the custom trace level, which zap would otherwise encode as "LEVEL(-2)"
+func traceLevelEncoder(name string, next zapcore.LevelEncoder) zapcore.LevelEncoder {
+	return func(level zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+		if level == TraceLevel {
+			enc.AppendString(name)
+			return
+		}
+		next(level, enc)
+	}
+}
+
 // SetupLogger initializes the global logger
-func SetupLogger(options *LoggerOptions) {
+func SetupLogger(options *LoggerOptions) error {
 	if options == nil {
 		options = &LoggerOptions{}
 	}
@@ -73,27 +119,39 @@ func SetupLogger(options *LoggerOptions) {
 	}
 	logFormat = strings.ToUpper(logFormat)
 
-	// Configure encoder based on format
-	var encoder zapcore.Encoder
-	if logFormat == "JSON" {
-		encoderConfig := zap.NewProductionEncoderConfig()
-		encoderConfig.TimeKey = "timestamp"
-		encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
-		encoder = zapcore.NewJSONEncoder(encoderConfig)
-	} else {
-		encoderConfig := zap.NewDevelopmentEncoderConfig()
-		encoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
-		encoderConfig.EncodeTime = zapcore.TimeEncoderOfLayout("15:04:05")
-		encoderConfig.ConsoleSeparator = " "
-		encoder = zapcore.NewConsoleEncoder(encoderConfig)
-	}
+	// Scrub the API token and any configured patterns from every entry, eg. a token echoed by an error
+	Redact(os.Getenv("TF_API_TOKEN"))
+	invalidPatterns := redactions.setPatterns(os.Getenv(EnvRedactPatterns))
 
-	// Create core
-	core := zapcore.NewCore(
-		encoder,
+	// Create core, each sink is wrapped on its own so the redacted entry is only written at the sink's level
+	core := newRedactCore(zapcore.NewCore(
+		newEncoder(logFormat, !options.NoColor),
 		zapcore.AddSync(os.Stderr),
 		logLevel,
-	)
+	), redactions)
+
+	// Additionally write logs to file, with an independent level
+	if options.LogFile != "" {
+		file, err := os.OpenFile(options.LogFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("error opening log file %s: %w", options.LogFile, err)
+		}
+		if logFile != nil {
+			logFile.Close()
+		}
+		logFile = file
+
+		logFileLevelStr := options.LogFileLevel
+		if logFileLevelStr == "" {
+			logFileLevelStr = "DEBUG" // Default to capturing everything
+		}
+
+		core = zapcore.NewTee(core, newRedactCore(zapcore.NewCore(
+			newEncoder(logFormat, false),
+			zapcore.AddSync(file),
+			parseLogLevel(logFileLevelStr),
+		), redactions))
+	}
 
 	// Create logger with platform field
 	logger = zap.New(core, 
@@ -108,14 +166,21 @@ func SetupLogger(options *LoggerOptions) {
 	// Redirect standard library's logger to zap
 	zap.RedirectStdLog(logger)
 
+	for _, p := range invalidPatterns {
+		sugar.Warnw("Ignoring invalid redact pattern, it is not a valid regular expression", "env", EnvRedactPatterns, "pattern", p)
+	}
+
 	// Log initialization
 	if logLevel <= zapcore.DebugLevel {
 		sugar.Debugw("Logger initialized",
 			"level", logLevelStr,
 			"format", logFormat,
 			"platform", options.PlatformType,
+			"log_file", options.LogFile,
 		)
 	}
+
+	return nil
 }
 
 // GetLogger returns the Zap logger
@@ -128,6 +193,19 @@ func GetSugaredLogger() *zap.SugaredLogger {
 	return sugar
 }
 
+// TraceEnabled reports whether trace logs are written anywhere, eg. to skip expensive tracing
+func TraceEnabled() bool {
+	return logger != nil && logger.Core().Enabled(TraceLevel)
+}
+
+// Trace logs a message at trace level, with alternating keys and values
+func Trace(msg string, args ...interface{}) {
+	if logger == nil {
+		return
+	}
+	sugar.Logw(TraceLevel, msg, args...)
+}
+
 // Debug logs a message at debug level
 func Debug(msg string, args ...interface{}) {
 	if logger == nil {
@@ -192,6 +270,17 @@ func Error(msg string, args ...interface{}) {
 	}
 }
 
+// ErrorTypes returns the concrete type of the error and each error it wraps, eg. [*url.Error *net.OpError]
+// useful to distinguish transport errors from API errors when the flattened message is ambiguous
+func ErrorTypes(err error) []string {
+	var types []string
+	for err != nil {
+		types = append(types, fmt.Sprintf("%T", err))
+		err = errors.Unwrap(err)
+	}
+	return types
+}
+
 // Sync flushes any buffered log entries
 func Sync() error {
 	if logger == nil {
@@ -0,0 +1,175 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSetupLogger_LogFile(t *testing.T) {
+	testCases := []struct {
+		name         string
+		logFormat    string
+		logFileLevel string
+		expected     []string
+		unexpected   []string
+	}{
+		{
+			name:       "default-level",
+			expected:   []string{"DEBUG", "debug message", "INFO", "info message", "WARN", "warn message"},
+			unexpected: []string{"trace message"},
+		},
+		{
+			name:         "warn-level",
+			logFileLevel: "WARN",
+			expected:     []string{"WARN", "warn message"},
+			unexpected:   []string{"debug message", "info message"},
+		},
+		{
+			name:         "trace-level",
+			logFileLevel: "TRACE",
+			expected:     []string{"TRACE", "trace message", "debug message"},
+		},
+		{
+			name:       "json-format",
+			logFormat:  "JSON",
+			expected:   []string{`"level":"debug"`, `"msg":"debug message"`, `"run_id":"run-123"`, `"platform":"local"`},
+			unexpected: []string{"\x1b["},
+		},
+		{
+			name:       "console-format-without-color",
+			expected:   []string{"run_id"},
+			unexpected: []string{"\x1b["},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// stderr logs are independent of the log file level
+			t.Setenv(EnvLogLevel, "OFF")
+			t.Setenv(EnvLogFormat, tc.logFormat)
+
+			path := filepath.Join(t.TempDir(), "tfci.log")
+			if err := SetupLogger(&LoggerOptions{PlatformType: "local", LogFile: path, LogFileLevel: tc.logFileLevel}); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(resetLogger)
+
+			Trace("trace message")
+			Debug("debug message", "run_id", "run-123")
+			Info("info message")
+			Warn("warn message")
+			_ = Sync()
+
+			raw, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			content := string(raw)
+			for _, s := range tc.expected {
+				if !strings.Contains(content, s) {
+					t.Errorf("expected log file to contain %q, received:\n%s", s, content)
+				}
+			}
+			for _, s := range tc.unexpected {
+				if strings.Contains(content, s) {
+					t.Errorf("expected log file not to contain %q, received:\n%s", s, content)
+				}
+			}
+		})
+	}
+}
+
+func TestSetupLogger_LogFileAppends(t *testing.T) {
+	t.Setenv(EnvLogLevel, "OFF")
+	path := filepath.Join(t.TempDir(), "tfci.log")
+	t.Cleanup(resetLogger)
+
+	// eg. a log file shared by every tfci command of a job
+	for _, msg := range []string{"first command", "second command"} {
+		if err := SetupLogger(&LoggerOptions{LogFile: path}); err != nil {
+			t.Fatal(err)
+		}
+		Info(msg)
+		_ = Sync()
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, second := strings.Index(string(raw), "first command"), strings.Index(string(raw), "second command")
+	if first < 0 || second < first {
+		t.Errorf("expected both commands to be logged in order, received:\n%s", raw)
+	}
+}
+
+func TestSetupLogger_LogFileError(t *testing.T) {
+	t.Setenv(EnvLogLevel, "OFF")
+	path := filepath.Join(t.TempDir(), "missing", "tfci.log")
+
+	err := SetupLogger(&LoggerOptions{LogFile: path})
+	if err == nil {
+		resetLogger()
+		t.Fatal("expected an error opening a log file in a missing directory")
+	}
+	if !strings.Contains(err.Error(), "error opening log file "+path) {
+		t.Errorf("expected the log file path in the error, received: %s", err)
+	}
+}
+
+// restores the package state between tests, closing the log file
+func resetLogger() {
+	if logFile != nil {
+		logFile.Close()
+		logFile = nil
+	}
+	logger, sugar = nil, nil
+}
+
+func TestErrorTypes(t *testing.T) {
+	urlErr := &url.Error{Op: "Get", URL: "https://app.terraform.io/api/v2/ping", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}
+
+	testCases := []struct {
+		name     string
+		err      error
+		expected []string
+	}{
+		{
+			name: "nil",
+			err:  nil,
+		},
+		{
+			name:     "plain",
+			err:      errors.New("invalid value"),
+			expected: []string{"*errors.errorString"},
+		},
+		{
+			name:     "transport",
+			err:      fmt.Errorf("failed to read run: %w", urlErr),
+			expected: []string{"*fmt.wrapError", "*url.Error", "*net.OpError", "*errors.errorString"},
+		},
+		{
+			name:     "joined",
+			err:      errors.Join(errors.New("first"), errors.New("second")),
+			expected: []string{"*errors.joinError"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := ErrorTypes(tc.err); !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %v but received %v", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logging
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// Environment variable of additional regex patterns to redact from logs, one per line
+	EnvRedactPatterns = "TFCI_REDACT_PATTERNS"
+	// replaces each redacted value
+	redactedValue = "***"
+	// shorter secret values are ignored, as they would redact unrelated words in every log entry
+	minRedactLength = 8
+)
+
+// secrets scrubbed from every log entry, shared by each logger SetupLogger creates
+var redactions = &redactor{}
+
+type redactor struct {
+	mu       sync.RWMutex
+	patterns []*regexp.Regexp
+	values   []string
+}
+
+// Redact scrubs the secret value from every later log entry, eg. the API token once it is resolved
+func Redact(value string) {
+	if len(value) < minRedactLength {
+		return
+	}
+	redactions.mu.Lock()
+	defer redactions.mu.Unlock()
+	for _, v := range redactions.values {
+		if v == value {
+			return
+		}
+	}
+	redactions.values = append(redactions.values, value)
+}
+
+// compiles the patterns, one per line as a comma is common within a pattern, eg. `[0-9]{8,}`. Returns the
+// patterns which are not valid regular expressions
+func (r *redactor) setPatterns(patterns string) []string {
+	compiled := []*regexp.Regexp{}
+	invalid := []string{}
+	for _, p := range strings.Split(patterns, "\n") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		re, err := regexp.Compile(p)
+		if err != nil {
+			invalid = append(invalid, p)
+			continue
+		}
+		compiled = append(compiled, re)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.patterns = compiled
+	return invalid
+}
+
+func (r *redactor) redact(s string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, v := range r.values {
+		s = strings.ReplaceAll(s, v, redactedValue)
+	}
+	for _, re := range r.patterns {
+		s = re.ReplaceAllString(s, redactedValue)
+	}
+	return s
+}
+
+// scrubs the message and fields of each entry before the wrapped core encodes them
+type redactCore struct {
+	zapcore.Core
+	redactor *redactor
+}
+
+func newRedactCore(core zapcore.Core, r *redactor) zapcore.Core {
+	return &redactCore{Core: core, redactor: r}
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(c.redactFields(fields)), redactor: c.redactor}
+}
+
+func (c *redactCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *redactCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = c.redactor.redact(entry.Message)
+	return c.Core.Write(entry, c.redactFields(fields))
+}
+
+// string like fields are redacted in place. Errors, stringers and reflected values are replaced by their redacted
+// string only when they contain a secret, so their encoding is otherwise unchanged
+func (c *redactCore) redactFields(fields []zapcore.Field) []zapcore.Field {
+	redacted := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		redacted[i] = f
+		switch f.Type {
+		case zapcore.StringType:
+			redacted[i].String = c.redactor.redact(f.String)
+		case zapcore.ByteStringType:
+			if b, ok := f.Interface.([]byte); ok {
+				if s := c.redactor.redact(string(b)); s != string(b) {
+					redacted[i] = zap.String(f.Key, s)
+				}
+			}
+		case zapcore.ErrorType, zapcore.StringerType, zapcore.ReflectType:
+			if f.Interface == nil {
+				continue
+			}
+			original := fmt.Sprint(f.Interface)
+			if s := c.redactor.redact(original); s != original {
+				redacted[i] = zap.String(f.Key, s)
+			}
+		}
+	}
+	return redacted
+}
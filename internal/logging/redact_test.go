@@ -0,0 +1,63 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetupLogger_Redact(t *testing.T) {
+	token := "abc123.atlasv1.secrettokenvalue"
+	t.Setenv(EnvLogLevel, "OFF")
+	t.Setenv("TF_API_TOKEN", token)
+	t.Setenv(EnvRedactPatterns, "ghp_[A-Za-z0-9]{8,}\n[invalid")
+
+	path := filepath.Join(t.TempDir(), "tfci.log")
+	if err := SetupLogger(&LoggerOptions{LogFile: path, LogFileLevel: "TRACE"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { logger, sugar = nil, nil })
+
+	err := fmt.Errorf("failed to read run: %w", errors.New("unauthorized token "+token))
+	Trace("trace "+token, "token", token)
+	Debug("debug "+token, "error", err)
+	Info("info", "header", "Bearer "+token)
+	Warn("warn %s", token)
+	Error("error", "payload", map[string]string{"token": token})
+	log.Printf("[DEBUG] standard library log %s", token)
+	Info("github token", "value", "ghp_abcdefgh12345678")
+	if err := Sync(); err != nil && !strings.Contains(err.Error(), "sync") {
+		t.Fatal(err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logs := string(raw)
+	if strings.Contains(logs, token) || strings.Contains(logs, "ghp_abcdefgh12345678") {
+		t.Fatalf("expected every secret to be redacted but received:\n%s", logs)
+	}
+	for _, expected := range []string{"trace ***", "debug ***", "Bearer ***", "warn ***", "standard library log ***", "unauthorized token ***", `"value": "***"`} {
+		if !strings.Contains(logs, expected) {
+			t.Errorf("expected %q in the logs but received:\n%s", expected, logs)
+		}
+	}
+	if !strings.Contains(logs, `"pattern": "[invalid"`) {
+		t.Errorf("expected a warning for the invalid pattern but received:\n%s", logs)
+	}
+}
+
+func TestRedact_ShortValues(t *testing.T) {
+	Redact("short")
+	if actual := redactions.redact("a short message"); actual != "a short message" {
+		t.Errorf("expected short values to never be redacted but received %q", actual)
+	}
+}
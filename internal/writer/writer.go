@@ -4,30 +4,117 @@
 package writer
 
 import (
+	"encoding/json"
+	"fmt"
 	"log"
+	"strings"
 
 	"github.com/mitchellh/cli"
 )
 
+type OutputFormat string
+
+const (
+	FormatText OutputFormat = "text"
+	FormatJSON OutputFormat = "json"
+	// compact single line summary of scalar outputs, eg. for piping to `jq`
+	FormatOneLineJSON OutputFormat = "oneline-json"
+)
+
+// resolves the output format from the `-output-format` flag value
+func ParseOutputFormat(value string) (OutputFormat, error) {
+	switch format := OutputFormat(value); format {
+	case FormatText, FormatJSON:
+		return format, nil
+	default:
+		return "", fmt.Errorf("invalid output format %q, must be one of: %s, %s", value, FormatText, FormatJSON)
+	}
+}
+
+// structured result emitted to stdout when using the json output format
+type result struct {
+	Status  string                 `json:"status"`
+	Outputs map[string]interface{} `json:"outputs"`
+	Error   string                 `json:"error,omitempty"`
+}
+
+// flattens the result to its single line scalar outputs, eg. status, ids and counts
+func (r *result) summary() map[string]interface{} {
+	summary := map[string]interface{}{}
+	for name, value := range r.Outputs {
+		switch v := value.(type) {
+		case string:
+			if strings.Contains(v, "\n") {
+				continue
+			}
+		case bool, int, int64, float64:
+		default:
+			continue
+		}
+		summary[name] = value
+	}
+	summary["status"] = r.Status
+	if r.Error != "" {
+		summary["error"] = r.Error
+	}
+	return summary
+}
+
 type Writer struct {
-	json bool
-	ui   cli.Ui
+	json   bool
+	format OutputFormat
+	ui     cli.Ui
+	// accumulated command result, written by Flush() when using the json output format
+	result *result
+	// suppresses diagnostic messages, errors and the command result are still written
+	quiet bool
+}
+
+func WithOutputFormat(format OutputFormat) func(*Writer) {
+	return func(w *Writer) {
+		w.format = format
+	}
 }
 
-func NewWriter(ui cli.Ui) *Writer {
-	return &Writer{
-		ui: ui,
+func WithQuiet(quiet bool) func(*Writer) {
+	return func(w *Writer) {
+		w.quiet = quiet
 	}
 }
 
+func NewWriter(ui cli.Ui, setters ...func(*Writer)) *Writer {
+	w := &Writer{
+		ui:     ui,
+		format: FormatText,
+	}
+
+	for _, setter := range setters {
+		setter(w)
+	}
+
+	// stdout is reserved for the structured result
+	w.json = w.Structured()
+	return w
+}
+
+// reports whether stdout is reserved for a structured result written by Flush()
+func (w *Writer) Structured() bool {
+	return w.format == FormatJSON || w.format == FormatOneLineJSON
+}
+
 func (w *Writer) UseJson(json bool) {
 	log.Printf("[DEBUG] Writer using json: %t", json)
-	w.json = json
+	w.json = json || w.Structured()
 }
 
 // In-Progress diagnostic information
 // if *json is set to true, will send log formatting to stderr
+// with -quiet the message is only logged at the debug level
 func (w *Writer) Output(message string) {
+	if w.quiet {
+		log.Printf("[DEBUG] %s", message)
+		return
+	}
 	if w.json {
 		log.Printf("[INFO] %s", message)
 		return
@@ -47,14 +134,59 @@ func (w *Writer) Error(message string) {
 	w.ui.Error(message)
 }
 
+// Records the command outputs, included in the structured result when using the json output format
+func (w *Writer) SetResult(outputs map[string]interface{}) {
+	w.ensureResult()
+	w.result.Outputs = outputs
+	if status, ok := outputs["status"].(string); ok {
+		w.result.Status = status
+	}
+}
+
 // Final message sent to stdout stream
 // regardless of `json` field we will output the message to stdout stream
 // requires the message string is formatted prior to passing to this method receiver
+// with the json output format, the outputs are instead written by Flush()
 func (w *Writer) OutputResult(message string) {
+	if w.Structured() {
+		return
+	}
 	w.ui.Output(message)
 }
 
 // Final message sent to stderr stream
+// with the json output format, the error is instead included in the result written by Flush()
 func (w *Writer) ErrorResult(message string) {
+	if w.Structured() {
+		w.ensureResult()
+		w.result.Error = message
+		return
+	}
 	w.ui.Error(message)
 }
+
+// Writes the structured result as a single json object to stdout when using the json output formats
+func (w *Writer) Flush() {
+	if !w.Structured() || w.result == nil {
+		return
+	}
+
+	var value interface{} = w.result
+	if w.format == FormatOneLineJSON {
+		value = w.result.summary()
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		w.ui.Error(fmt.Sprintf("error marshalling result: %s", err))
+		return
+	}
+	w.ui.Output(string(b))
+	w.result = nil
+}
+
+func (w *Writer) ensureResult() {
+	if w.result == nil {
+		w.result = &result{Outputs: map[string]interface{}{}}
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package writer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestWriter_Quiet(t *testing.T) {
+	testCases := []struct {
+		name           string
+		quiet          bool
+		expectedStdout string
+	}{
+		{
+			name:           "default",
+			expectedStdout: "Created Run ID: \"run-***\"\n{\"status\": \"Success\"}\n",
+		},
+		{
+			name:           "quiet",
+			quiet:          true,
+			expectedStdout: "{\"status\": \"Success\"}\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			w := NewWriter(ui, WithQuiet(tc.quiet))
+
+			w.Output(`Created Run ID: "run-***"`)
+			w.OutputResult(`{"status": "Success"}`)
+			w.ErrorResult("error reading run")
+
+			if stdout := ui.OutputWriter.String(); stdout != tc.expectedStdout {
+				t.Errorf("expected stdout %q but received %q", tc.expectedStdout, stdout)
+			}
+			// errors are always written
+			if stderr := ui.ErrorWriter.String(); !strings.Contains(stderr, "error reading run") {
+				t.Errorf("expected the error on stderr but received %q", stderr)
+			}
+		})
+	}
+}
@@ -7,11 +7,17 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"runtime"
+	"runtime/debug"
+	"syscall"
 
 	"github.com/hashicorp/tfci/internal/environment"
 	"github.com/hashicorp/tfci/internal/logging"
+	"github.com/hashicorp/tfci/internal/writer"
 	"github.com/hashicorp/tfci/version"
+
+	cmd "github.com/hashicorp/tfci/internal/command"
 	"github.com/mitchellh/cli"
 )
 
@@ -19,16 +25,31 @@ var (
 	Ui     cli.Ui
 	appCtx context.Context
 	env    *environment.CI
+	// command result writer, flushed once the command completes
+	resultWriter *writer.Writer
+	// releases the -timeout deadline of appCtx
+	stopTimeout context.CancelFunc = func() {}
+	// shared by every command, holds the outputs to flush if the command panics
+	commandMeta *cmd.Meta
 )
 
 func main() {
+	os.Exit(run())
+}
+
+// sets up the process and runs the command, returning the exit code once the deferred cleanup has run, as os.Exit
+// skips deferred calls
+func run() int {
 	// load env
 	env = environment.NewCIContext()
 
-	// setup logging
-	logging.SetupLogger(&logging.LoggerOptions{
+	// setup logging, -no-color is applied once flags are parsed
+	if err := logging.SetupLogger(&logging.LoggerOptions{
 		PlatformType: string(env.PlatformType),
-	})
+		NoColor:      noColorEnv(),
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to setup logger: %v\n", err)
+	}
 
 	// Ensure logs are flushed on exit
 	defer func() {
@@ -39,19 +60,36 @@ func main() {
 	}()
 
 	// Ui settings
-	Ui = &cli.ColoredUi{
+	Ui = newUi(noColorEnv())
+
+	// stop waiting on runs when interrupted, eg. Ctrl-C or runner cancellation
+	var stop context.CancelFunc
+	appCtx, stop = signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return realMain()
+}
+
+// reports whether the NO_COLOR environment variable is set, see https://no-color.org
+func noColorEnv() bool {
+	return os.Getenv("NO_COLOR") != ""
+}
+
+// returns a plain Ui when color is disabled, as escape codes are garbled by some CI log viewers
+func newUi(noColor bool) cli.Ui {
+	basicUi := &cli.BasicUi{
+		Writer:      os.Stdout,
+		ErrorWriter: os.Stderr,
+		Reader:      os.Stdin,
+	}
+	if noColor {
+		return basicUi
+	}
+	return &cli.ColoredUi{
 		ErrorColor: cli.UiColorRed,
 		WarnColor:  cli.UiColorYellow,
-		Ui: &cli.BasicUi{
-			Writer:      os.Stdout,
-			ErrorWriter: os.Stderr,
-			Reader:      os.Stdin,
-		},
+		Ui:         basicUi,
 	}
-
-	appCtx = context.Background()
-
-	os.Exit(realMain())
 }
 
 func realMain() int {
@@ -63,12 +101,15 @@ func realMain() int {
 	cliRunner, runError := newCliRunner()
 	if runError != nil {
 		logging.Error("Failed to create CLI runner", "error", runError)
+		logging.Debug("CLI runner error details", "error_types", logging.ErrorTypes(runError))
 		Ui.Error(runError.Error())
 		return 1
 	}
+	defer stopTimeout()
 
 	logging.Debug("Running command")
-	exitCode, err := cliRunner.Run()
+	exitCode, err := runCommand(cliRunner)
+	resultWriter.Flush()
 	if err != nil {
 		logging.Error("Command execution failed", "error", err)
 		Ui.Error(err.Error())
@@ -77,3 +118,28 @@ func realMain() int {
 
 	return exitCode
 }
+
+// runs the command, recovering a panic to flush the outputs accumulated so far with the Error status. Panics in
+// goroutines started by the command, eg. concurrent workspace runs, cannot be recovered
+func runCommand(cliRunner *cli.CLI) (exitCode int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logging.Error("Command panicked", "panic", r, "stack", string(debug.Stack()))
+			flushOutputOnPanic()
+			exitCode, err = 1, fmt.Errorf("tfci failed unexpectedly: %v", r)
+		}
+	}()
+	return cliRunner.Run()
+}
+
+// best effort, the outputs may be in the state that caused the panic
+func flushOutputOnPanic() {
+	defer func() {
+		if r := recover(); r != nil {
+			logging.Error("Failed to flush outputs after panic", "panic", r)
+		}
+	}()
+	if commandMeta != nil {
+		commandMeta.FlushOutputOnPanic()
+	}
+}
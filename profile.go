@@ -0,0 +1,122 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/tfci/internal/logging"
+)
+
+const envProfileFile = "TFCI_PROFILE_FILE"
+
+// the profile file, eg. ~/.tfci.json, keyed by profile name:
+//
+//	{"profiles": {"staging": {"hostname": "tfe.example.com", "organization": "my-org", "token": "..."}}}
+type profileFile struct {
+	Profiles map[string]*profile `json:"profiles"`
+}
+
+// the host, organization and token selected by -profile, any of which may be empty
+type profile struct {
+	Hostname     string `json:"hostname"`
+	Organization string `json:"organization"`
+	Token        string `json:"token"`
+}
+
+// the settings of the global options, which flags and environment variables set before a profile
+type connection struct {
+	hostname     string
+	organization string
+	token        string
+	tokenFile    string
+}
+
+// path of the profile file from TFCI_PROFILE_FILE, otherwise ~/.tfci.json
+func profileFilePath(getenv func(string) string) (string, error) {
+	if path := getenv(envProfileFile); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to locate the profile file, set %s: %w", envProfileFile, err)
+	}
+	return filepath.Join(home, ".tfci.json"), nil
+}
+
+// reads the named profile, failing with the available profile names when it is not in the file
+func loadProfile(path string, name string) (*profile, error) {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("profile file %q does not exist, create it or set %s", path, envProfileFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read profile file %q: %w", path, err)
+	}
+
+	file := &profileFile{}
+	if err := json.Unmarshal(raw, file); err != nil {
+		return nil, fmt.Errorf("invalid profile file %q: %w", path, err)
+	}
+	p, ok := file.Profiles[name]
+	if !ok || p == nil {
+		available := slices.Sorted(maps.Keys(file.Profiles))
+		return nil, fmt.Errorf("profile %q not found in %q, available profiles: %s", name, path, strings.Join(available, ", "))
+	}
+
+	// the file holds tokens, like the Terraform CLI credentials file it should only be readable by its owner
+	if info, err := os.Stat(path); err == nil && info.Mode().Perm()&0o077 != 0 && p.Token != "" {
+		logging.Warn("Profile file with a token is readable by other users, restrict it with chmod 600", "path", path)
+	}
+	return p, nil
+}
+
+// fills in the settings of the connection which no flag or environment variable sets from the profile. A token
+// from any source, including a token file, takes precedence over the profile's token
+func mergeProfile(conn connection, p *profile, getenv func(string) string) connection {
+	if conn.hostname == "" && getenv("TF_HOSTNAME") == "" {
+		conn.hostname = p.Hostname
+	}
+	if conn.organization == "" && getenv("TF_CLOUD_ORGANIZATION") == "" {
+		conn.organization = p.Organization
+	}
+	if conn.token == "" && conn.tokenFile == "" && getenv("TF_API_TOKEN_FILE") == "" && getenv("TF_API_TOKEN") == "" {
+		conn.token = p.Token
+	}
+	return conn
+}
+
+// applies the -profile to the global options, the token is never logged
+func applyProfile(name string) error {
+	path, err := profileFilePath(os.Getenv)
+	if err != nil {
+		return err
+	}
+	p, err := loadProfile(path, name)
+	if err != nil {
+		return err
+	}
+
+	conn := mergeProfile(connection{
+		hostname:     *hostnameFlag,
+		organization: *organizationFlag,
+		token:        *tokenFlag,
+		tokenFile:    *tokenFileFlag,
+	}, p, os.Getenv)
+	logging.Debug("Using profile", "profile", name, "path", path,
+		"profile_hostname", conn.hostname != *hostnameFlag,
+		"profile_organization", conn.organization != *organizationFlag,
+		"profile_token", conn.token != *tokenFlag)
+
+	*hostnameFlag, *organizationFlag, *tokenFlag = conn.hostname, conn.organization, conn.token
+	return nil
+}
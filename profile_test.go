@@ -0,0 +1,110 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".tfci.json")
+	contents := `{"profiles":{
+		"staging":{"hostname":"tfe.example.com","organization":"staging-org","token":"staging-token"},
+		"production":{"organization":"prod-org"}
+	}}`
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := loadProfile(path, "staging")
+	if err != nil {
+		t.Fatalf("expected no error but received %s", err)
+	}
+	expected := profile{Hostname: "tfe.example.com", Organization: "staging-org", Token: "staging-token"}
+	if *p != expected {
+		t.Errorf("expected profile %+v but received %+v", expected, *p)
+	}
+
+	_, err = loadProfile(path, "dev")
+	if err == nil || !strings.Contains(err.Error(), "available profiles: production, staging") {
+		t.Errorf("expected an error listing the available profiles but received %v", err)
+	}
+
+	if _, err := loadProfile(filepath.Join(t.TempDir(), "missing.json"), "staging"); err == nil {
+		t.Error("expected an error for a missing profile file")
+	}
+}
+
+func TestMergeProfile(t *testing.T) {
+	p := &profile{Hostname: "tfe.example.com", Organization: "staging-org", Token: "staging-token"}
+
+	testCases := []struct {
+		name     string
+		conn     connection
+		env      map[string]string
+		expected connection
+	}{
+		{
+			name:     "profile-only",
+			expected: connection{hostname: "tfe.example.com", organization: "staging-org", token: "staging-token"},
+		},
+		{
+			name:     "flags-override",
+			conn:     connection{hostname: "app.terraform.io", organization: "my-org", token: "flag-token"},
+			expected: connection{hostname: "app.terraform.io", organization: "my-org", token: "flag-token"},
+		},
+		{
+			// env vars are resolved later, the profile only fills in what they leave unset
+			name: "env-overrides",
+			env: map[string]string{
+				"TF_HOSTNAME":           "app.terraform.io",
+				"TF_CLOUD_ORGANIZATION": "my-org",
+				"TF_API_TOKEN":          "env-token",
+			},
+			expected: connection{},
+		},
+		{
+			name:     "token-file-overrides",
+			conn:     connection{tokenFile: "/run/secrets/token"},
+			expected: connection{hostname: "tfe.example.com", organization: "staging-org", tokenFile: "/run/secrets/token"},
+		},
+		{
+			name:     "token-file-env-overrides",
+			env:      map[string]string{"TF_API_TOKEN_FILE": "/run/secrets/token"},
+			expected: connection{hostname: "tfe.example.com", organization: "staging-org"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			getenv := func(key string) string { return tc.env[key] }
+			if actual := mergeProfile(tc.conn, p, getenv); actual != tc.expected {
+				t.Errorf("expected %+v but received %+v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestProfileFilePath(t *testing.T) {
+	getenv := func(key string) string {
+		if key == envProfileFile {
+			return "/etc/tfci/profiles.json"
+		}
+		return ""
+	}
+	if path, err := profileFilePath(getenv); err != nil || path != "/etc/tfci/profiles.json" {
+		t.Errorf("expected %q but received %q, %v", "/etc/tfci/profiles.json", path, err)
+	}
+
+	path, err := profileFilePath(func(string) string { return "" })
+	if err != nil {
+		t.Fatalf("expected no error but received %s", err)
+	}
+	if filepath.Base(path) != ".tfci.json" {
+		t.Errorf("expected the default profile file but received %q", path)
+	}
+}